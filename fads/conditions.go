@@ -0,0 +1,67 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fads
+
+import (
+	"math"
+
+	"go-hep.org/x/hep/fwk"
+)
+
+// EtaBins holds conditions data (e.g. calibration constants) binned in
+// |eta|: Params[i] holds the parameters for Edges[i] <= |eta| < Edges[i+1].
+//
+// EtaBins is the payload, in JSON or rio, of the conditions tags used by
+// EnergyScale and EnergySmearing.
+type EtaBins struct {
+	Edges  []float64   `json:"edges"`
+	Params [][]float64 `json:"params"`
+}
+
+// At returns the parameters for eta, or nil if eta is outside the bins.
+func (bins *EtaBins) At(eta float64) []float64 {
+	eta = math.Abs(eta)
+	for i := 0; i+1 < len(bins.Edges) && i < len(bins.Params); i++ {
+		if bins.Edges[i] <= eta && eta < bins.Edges[i+1] {
+			return bins.Params[i]
+		}
+	}
+	return nil
+}
+
+// getEtaBins retrieves from the conditions service svc the payload of tag
+// valid for run.
+func getEtaBins(ctx fwk.Context, svc, tag string, run int64, nparams int) (*EtaBins, error) {
+	s, err := ctx.Svc(svc)
+	if err != nil {
+		return nil, err
+	}
+	csvc, ok := s.(fwk.CondSvc)
+	if !ok {
+		return nil, fwk.Errorf("fads: service [%s] is not a fwk.CondSvc", svc)
+	}
+
+	var bins EtaBins
+	iov, err := csvc.Get(tag, fwk.IOVTime{Run: run}, &bins)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bins.Params) != len(bins.Edges)-1 {
+		return nil, fwk.Errorf("fads: tag [%s] %v: %d bins with %d edges",
+			tag, iov, len(bins.Params), len(bins.Edges),
+		)
+	}
+	for i, p := range bins.Params {
+		if len(p) != nparams {
+			return nil, fwk.Errorf("fads: tag [%s] %v: %d parameters in bin %d (want %d)",
+				tag, iov, len(p), i, nparams,
+			)
+		}
+	}
+
+	ctx.Msg().Debugf("run %d: conditions [%s] %v\n", run, tag, iov)
+	return &bins, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fads
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-hep.org/x/hep/fwk"
+	_ "go-hep.org/x/hep/fwk/condsvc"
+	"go-hep.org/x/hep/fwk/job"
+)
+
+// context is a minimal fwk.Context providing a conditions service.
+type context struct {
+	msg  fwk.MsgStream
+	csvc fwk.Svc
+}
+
+func (ctx context) ID() int64          { return 0 }
+func (ctx context) Slot() int          { return 0 }
+func (ctx context) Store() fwk.Store   { return nil }
+func (ctx context) Msg() fwk.MsgStream { return ctx.msg }
+func (ctx context) Svc(n string) (fwk.Svc, error) {
+	if n != ctx.csvc.Name() {
+		return nil, fmt.Errorf("no service [%s]", n)
+	}
+	return ctx.csvc, nil
+}
+
+func writeEtaBins(t *testing.T, dir, tag string, run int64, params string) {
+	fname := filepath.Join(dir, tag, "v1", fmt.Sprintf("%d_0.json", run))
+	err := os.MkdirAll(filepath.Dir(fname), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(fname, []byte(`{"edges": [0, 2.5], "params": [`+params+`]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConditionsRefresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fads-conditions-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeEtaBins(t, dir, "scale", 1, "[1.5]")
+	writeEtaBins(t, dir, "scale", 2, "[2.5]")
+	writeEtaBins(t, dir, "resolution", 1, "[0, 0, 0.1]")
+	writeEtaBins(t, dir, "resolution", 2, "[0, 0, 0.2]")
+
+	app := job.NewJob(nil, job.P{"MsgLevel": job.MsgLevel("ERROR")})
+	svc := app.Create(job.C{
+		Type:  "go-hep.org/x/hep/fwk/condsvc.csvc",
+		Name:  "conditions",
+		Props: job.P{"Source": dir},
+	}).(fwk.Svc)
+	scale := app.Create(job.C{
+		Type: "go-hep.org/x/hep/fads.EnergyScale",
+		Name: "scale",
+		Props: job.P{
+			"CondSvc":  "conditions",
+			"ScaleTag": "scale",
+		},
+	}).(*EnergyScale)
+	smear := app.Create(job.C{
+		Type: "go-hep.org/x/hep/fads.EnergySmearing",
+		Name: "smear",
+		Props: job.P{
+			"CondSvc":       "conditions",
+			"ResolutionTag": "resolution",
+		},
+	}).(*EnergySmearing)
+
+	ctx := context{
+		msg:  fwk.NewMsgStream("fads", fwk.LvlError, nil),
+		csvc: svc,
+	}
+	err = svc.(fwk.Configurer).Configure(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = svc.StartSvc(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svc.StopSvc(ctx)
+
+	for _, tc := range []struct {
+		run   int64
+		scale float64
+		sigma float64
+	}{
+		{run: 1, scale: 1.5, sigma: 10},
+		{run: 2, scale: 2.5, sigma: 20},
+		{run: 1, scale: 1.5, sigma: 10},
+	} {
+		err = scale.BeginRun(ctx, tc.run)
+		if err != nil {
+			t.Fatalf("run %d: %v", tc.run, err)
+		}
+		err = smear.BeginRun(ctx, tc.run)
+		if err != nil {
+			t.Fatalf("run %d: %v", tc.run, err)
+		}
+
+		if got := scale.scale(10, 1); got != tc.scale {
+			t.Errorf("run %d: got scale=%v. want %v", tc.run, got, tc.scale)
+		}
+		if got := scale.scale(10, 3); got != 0 {
+			t.Errorf("run %d: got scale=%v outside the bins. want 0", tc.run, got)
+		}
+		if got := smear.smear(-1, 100); math.Abs(got-tc.sigma) > 1e-9 {
+			t.Errorf("run %d: got sigma=%v. want %v", tc.run, got, tc.sigma)
+		}
+	}
+}
@@ -18,6 +18,9 @@ type EnergyScale struct {
 	output string
 
 	scale func(pt, eta float64) float64
+
+	csvc string // name of the conditions service
+	tag  string // conditions tag of the scale, if any
 }
 
 func (tsk *EnergyScale) Configure(ctx fwk.Context) error {
@@ -48,6 +51,34 @@ func (tsk *EnergyScale) StopTask(ctx fwk.Context) error {
 	return err
 }
 
+// BeginRun retrieves the scale valid for run from the conditions service,
+// when the 'ScaleTag' property is set.
+// The payload of the tag is an EtaBins with one parameter per bin: the
+// scale.
+func (tsk *EnergyScale) BeginRun(ctx fwk.Context, run int64) error {
+	if tsk.tag == "" {
+		return nil
+	}
+
+	bins, err := getEtaBins(ctx, tsk.csvc, tsk.tag, run, 1)
+	if err != nil {
+		return err
+	}
+
+	tsk.scale = func(pt, eta float64) float64 {
+		p := bins.At(eta)
+		if p == nil {
+			return 0
+		}
+		return p[0]
+	}
+	return nil
+}
+
+func (tsk *EnergyScale) EndRun(ctx fwk.Context, run int64) error {
+	return nil
+}
+
 func (tsk *EnergyScale) Process(ctx fwk.Context) error {
 	var err error
 
@@ -96,6 +127,7 @@ func newEnergyScale(typ, name string, mgr fwk.App) (fwk.Component, error) {
 		input:    "InputParticles",
 		output:   "OutputParticles",
 		scale:    func(pt, eta float64) float64 { return 0.0 },
+		csvc:     "condsvc",
 	}
 
 	err = tsk.DeclProp("Input", &tsk.input)
@@ -113,9 +145,21 @@ func newEnergyScale(typ, name string, mgr fwk.App) (fwk.Component, error) {
 		return nil, err
 	}
 
+	err = tsk.DeclProp("CondSvc", &tsk.csvc)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tsk.DeclProp("ScaleTag", &tsk.tag)
+	if err != nil {
+		return nil, err
+	}
+
 	return tsk, err
 }
 
 func init() {
 	fwk.Register(reflect.TypeOf(EnergyScale{}), newEnergyScale)
 }
+
+var _ fwk.RunHandler = (*EnergyScale)(nil)
@@ -22,6 +22,8 @@ type EnergySmearing struct {
 	output string
 
 	smear func(eta, ene float64) float64
+	csvc  string // name of the conditions service
+	tag   string // conditions tag of the resolution, if any
 	seed  int64
 	src   *rand.Rand
 	srcmu sync.Mutex
@@ -55,6 +57,37 @@ func (tsk *EnergySmearing) StopTask(ctx fwk.Context) error {
 	return err
 }
 
+// BeginRun retrieves the energy resolution valid for run from the
+// conditions service, when the 'ResolutionTag' property is set.
+// The payload of the tag is an EtaBins with three parameters per bin, the
+// noise N, stochastic S and constant C terms of the resolution:
+//
+//	sigma(E) = sqrt(N^2 + S^2*E + C^2*E^2)
+func (tsk *EnergySmearing) BeginRun(ctx fwk.Context, run int64) error {
+	if tsk.tag == "" {
+		return nil
+	}
+
+	bins, err := getEtaBins(ctx, tsk.csvc, tsk.tag, run, 3)
+	if err != nil {
+		return err
+	}
+
+	tsk.smear = func(eta, ene float64) float64 {
+		p := bins.At(eta)
+		if p == nil {
+			return 0
+		}
+		n, s, c := p[0], p[1], p[2]
+		return math.Sqrt(n*n + s*s*ene + c*c*ene*ene)
+	}
+	return nil
+}
+
+func (tsk *EnergySmearing) EndRun(ctx fwk.Context, run int64) error {
+	return nil
+}
+
 func (tsk *EnergySmearing) Process(ctx fwk.Context) error {
 	var err error
 	store := ctx.Store()
@@ -119,6 +152,7 @@ func init() {
 				input:    "InputParticles",
 				output:   "OutputParticles",
 				smear:    func(x, y float64) float64 { return 0 },
+				csvc:     "condsvc",
 				seed:     1234,
 			}
 
@@ -137,6 +171,16 @@ func init() {
 				return nil, err
 			}
 
+			err = tsk.DeclProp("CondSvc", &tsk.csvc)
+			if err != nil {
+				return nil, err
+			}
+
+			err = tsk.DeclProp("ResolutionTag", &tsk.tag)
+			if err != nil {
+				return nil, err
+			}
+
 			err = tsk.DeclProp("Seed", &tsk.seed)
 			if err != nil {
 				return nil, err
@@ -146,3 +190,5 @@ func init() {
 		},
 	)
 }
+
+var _ fwk.RunHandler = (*EnergySmearing)(nil)
@@ -0,0 +1,415 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fit
+
+import (
+	"math"
+	"sync"
+
+	"go-hep.org/x/hep/hbook"
+)
+
+// Model describes a probability density function of one variable,
+// parametrized by a slice of parameters.
+type Model interface {
+	// NumParams returns the number of parameters of the model.
+	NumParams() int
+
+	// PDF returns the value of the normalised density at x.
+	PDF(x float64, ps []float64) float64
+
+	// Integral returns the integral of the normalised density
+	// over [lo, hi].
+	Integral(lo, hi float64, ps []float64) float64
+}
+
+// Extended is a model which also predicts the number of events
+// it describes.
+type Extended interface {
+	Model
+
+	// Yield returns the expected number of events.
+	Yield(ps []float64) float64
+}
+
+// Sum is the weighted sum of a set of normalised models.
+//
+// The parameters of a Sum are the parameters of each of its
+// models, in order, followed by the len(Models)-1 fractions
+// of the first models.
+// The fraction of the last model is one minus the sum of the others.
+type Sum struct {
+	Models []Model
+}
+
+// NumParams implements the Model interface.
+func (m Sum) NumParams() int {
+	return numParams(m.Models) + len(m.Models) - 1
+}
+
+// PDF implements the Model interface.
+func (m Sum) PDF(x float64, ps []float64) float64 {
+	fs := m.fracs(ps)
+	sum := 0.0
+	for i, sub := range m.Models {
+		var pars []float64
+		pars, ps = ps[:sub.NumParams()], ps[sub.NumParams():]
+		sum += fs[i] * sub.PDF(x, pars)
+	}
+	return sum
+}
+
+// Integral implements the Model interface.
+func (m Sum) Integral(lo, hi float64, ps []float64) float64 {
+	fs := m.fracs(ps)
+	sum := 0.0
+	for i, sub := range m.Models {
+		var pars []float64
+		pars, ps = ps[:sub.NumParams()], ps[sub.NumParams():]
+		sum += fs[i] * sub.Integral(lo, hi, pars)
+	}
+	return sum
+}
+
+func (m Sum) fracs(ps []float64) []float64 {
+	n := len(m.Models)
+	fs := make([]float64, n)
+	copy(fs, ps[numParams(m.Models):])
+	fs[n-1] = 1
+	for _, f := range fs[:n-1] {
+		fs[n-1] -= f
+	}
+	return fs
+}
+
+// ExtSum is the sum of a set of normalised models, each weighted
+// by its own number of events.
+//
+// The parameters of an ExtSum are the parameters of each of its
+// models, in order, followed by the yield of each model.
+type ExtSum struct {
+	Models []Model
+}
+
+// NumParams implements the Model interface.
+func (m ExtSum) NumParams() int {
+	return numParams(m.Models) + len(m.Models)
+}
+
+// PDF implements the Model interface.
+func (m ExtSum) PDF(x float64, ps []float64) float64 {
+	ys := ps[numParams(m.Models):]
+	sum := 0.0
+	for i, sub := range m.Models {
+		var pars []float64
+		pars, ps = ps[:sub.NumParams()], ps[sub.NumParams():]
+		sum += ys[i] * sub.PDF(x, pars)
+	}
+	return sum / m.Yield(ys)
+}
+
+// Integral implements the Model interface.
+func (m ExtSum) Integral(lo, hi float64, ps []float64) float64 {
+	ys := ps[numParams(m.Models):]
+	sum := 0.0
+	for i, sub := range m.Models {
+		var pars []float64
+		pars, ps = ps[:sub.NumParams()], ps[sub.NumParams():]
+		sum += ys[i] * sub.Integral(lo, hi, pars)
+	}
+	return sum / m.Yield(ys)
+}
+
+// Yield implements the Extended interface.
+func (m ExtSum) Yield(ps []float64) float64 {
+	ys := ps[len(ps)-len(m.Models):]
+	sum := 0.0
+	for _, y := range ys {
+		sum += y
+	}
+	return sum
+}
+
+// Product is the product of a set of models of the same variable,
+// normalised over [Min, Max].
+//
+// The parameters of a Product are the parameters of each of its
+// models, in order.
+// The normalisation of a Product is computed numerically.
+type Product struct {
+	Min, Max float64
+	Models   []Model
+
+	norm normCache
+}
+
+// NumParams implements the Model interface.
+func (m *Product) NumParams() int {
+	return numParams(m.Models)
+}
+
+// PDF implements the Model interface.
+func (m *Product) PDF(x float64, ps []float64) float64 {
+	if x < m.Min || x > m.Max {
+		return 0
+	}
+	return m.eval(x, ps) / m.norm.get(ps, func() float64 {
+		return integrate(func(x float64) float64 { return m.eval(x, ps) }, m.Min, m.Max)
+	})
+}
+
+// Integral implements the Model interface.
+func (m *Product) Integral(lo, hi float64, ps []float64) float64 {
+	lo, hi, ok := clip(lo, hi, m.Min, m.Max)
+	if !ok {
+		return 0
+	}
+	return integrate(func(x float64) float64 { return m.PDF(x, ps) }, lo, hi)
+}
+
+func (m *Product) eval(x float64, ps []float64) float64 {
+	v := 1.0
+	for _, sub := range m.Models {
+		var pars []float64
+		pars, ps = ps[:sub.NumParams()], ps[sub.NumParams():]
+		v *= sub.PDF(x, pars)
+	}
+	return v
+}
+
+// Conv is the convolution of a model with a resolution model,
+// computed on a regular grid of N points over [Min, Max].
+//
+// The parameters of a Conv are the parameters of Model followed by
+// the parameters of Resolution.
+// The resolution model is evaluated at the difference x-x' and should
+// thus be centred on zero.
+type Conv struct {
+	Min, Max   float64
+	N          int // number of grid points (default: 1000)
+	Model      Model
+	Resolution Model
+
+	mu   sync.Mutex
+	ps   []float64
+	grid []float64 // normalised convolution values at the grid points
+}
+
+// NumParams implements the Model interface.
+func (m *Conv) NumParams() int {
+	return m.Model.NumParams() + m.Resolution.NumParams()
+}
+
+// PDF implements the Model interface.
+func (m *Conv) PDF(x float64, ps []float64) float64 {
+	if x < m.Min || x > m.Max {
+		return 0
+	}
+	grid := m.values(ps)
+	dx := (m.Max - m.Min) / float64(len(grid)-1)
+	i := int((x - m.Min) / dx)
+	if i >= len(grid)-1 {
+		return grid[len(grid)-1]
+	}
+	f := (x - m.Min - float64(i)*dx) / dx
+	return (1-f)*grid[i] + f*grid[i+1]
+}
+
+// Integral implements the Model interface.
+func (m *Conv) Integral(lo, hi float64, ps []float64) float64 {
+	lo, hi, ok := clip(lo, hi, m.Min, m.Max)
+	if !ok {
+		return 0
+	}
+	return integrate(func(x float64) float64 { return m.PDF(x, ps) }, lo, hi)
+}
+
+// values returns the normalised convolution on the grid, recomputing it
+// when the parameters changed since the last call.
+func (m *Conv) values(ps []float64) []float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.grid != nil && equal(m.ps, ps) {
+		return m.grid
+	}
+
+	n := m.N
+	if n <= 1 {
+		n = 1000
+	}
+	var (
+		np   = m.Model.NumParams()
+		mps  = ps[:np]
+		rps  = ps[np:]
+		dx   = (m.Max - m.Min) / float64(n-1)
+		fs   = make([]float64, n)
+		grid = make([]float64, n)
+	)
+	for i := range fs {
+		fs[i] = m.Model.PDF(m.Min+float64(i)*dx, mps)
+	}
+	for i := range grid {
+		x := m.Min + float64(i)*dx
+		sum := 0.0
+		for j, f := range fs {
+			sum += f * m.Resolution.PDF(x-(m.Min+float64(j)*dx), rps)
+		}
+		grid[i] = sum * dx
+	}
+
+	// normalise with the trapezoidal rule, consistent with the linear
+	// interpolation used in PDF.
+	norm := 0.5 * (grid[0] + grid[n-1])
+	for _, v := range grid[1 : n-1] {
+		norm += v
+	}
+	norm *= dx
+	if norm > 0 {
+		for i := range grid {
+			grid[i] /= norm
+		}
+	}
+
+	m.ps = append(m.ps[:0], ps...)
+	m.grid = grid
+	return grid
+}
+
+// FuncH1D returns a Func1D describing the bin contents of the histogram h
+// with the model m, suitable for use with the H1D function.
+//
+// The expected content of a bin is computed from the integral of the model
+// over that bin.
+// If m is an Extended model, its parameters are used as is.
+// Otherwise, an additional parameter for the number of events is appended to
+// the parameters of the model and initialised to the sum of weights of h.
+func FuncH1D(h *hbook.H1D, m Model, ps []float64) Func1D {
+	bins := hbook.Bin1Ds(h.Binning().Bins())
+	edges := func(x float64) (float64, float64) {
+		i := bins.IndexOf(x)
+		if i < 0 || i >= len(bins) {
+			return x, x
+		}
+		return bins[i].XMin(), bins[i].XMax()
+	}
+
+	if ext, ok := m.(Extended); ok {
+		return Func1D{
+			F: func(x float64, ps []float64) float64 {
+				lo, hi := edges(x)
+				return ext.Yield(ps) * m.Integral(lo, hi, ps)
+			},
+			Ps: append([]float64(nil), ps...),
+		}
+	}
+
+	n := m.NumParams()
+	return Func1D{
+		F: func(x float64, ps []float64) float64 {
+			lo, hi := edges(x)
+			return ps[n] * m.Integral(lo, hi, ps[:n])
+		},
+		Ps: append(append([]float64(nil), ps...), h.SumW()),
+	}
+}
+
+func numParams(ms []Model) int {
+	n := 0
+	for _, m := range ms {
+		n += m.NumParams()
+	}
+	return n
+}
+
+// normCache caches the normalisation of a model for the last set of
+// parameters it was computed with.
+type normCache struct {
+	mu sync.Mutex
+	ps []float64
+	v  float64
+}
+
+func (c *normCache) get(ps []float64, f func() float64) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ps != nil && equal(c.ps, ps) {
+		return c.v
+	}
+	c.v = f()
+	c.ps = append(c.ps[:0], ps...)
+	return c.v
+}
+
+func equal(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// clip restricts [lo, hi] to [min, max] and reports whether the
+// resulting interval is not empty.
+func clip(lo, hi, min, max float64) (float64, float64, bool) {
+	lo = math.Max(lo, min)
+	hi = math.Min(hi, max)
+	return lo, hi, lo < hi
+}
+
+// integrate returns the integral of f over [lo, hi], using an adaptive
+// Simpson quadrature on a set of initial panels.
+func integrate(f func(x float64) float64, lo, hi float64) float64 {
+	const (
+		panels = 64
+		eps    = 1e-10
+		depth  = 30
+	)
+	if lo == hi {
+		return 0
+	}
+	var (
+		sum = 0.0
+		dx  = (hi - lo) / panels
+		a   = lo
+		fa  = f(a)
+	)
+	for i := 1; i <= panels; i++ {
+		b := lo + float64(i)*dx
+		if i == panels {
+			b = hi
+		}
+		var (
+			fb = f(b)
+			m  = 0.5 * (a + b)
+			fm = f(m)
+			s  = (b - a) / 6 * (fa + 4*fm + fb)
+		)
+		sum += simpson(f, a, b, fa, fm, fb, s, eps/panels, depth)
+		a, fa = b, fb
+	}
+	return sum
+}
+
+func simpson(f func(float64) float64, a, b, fa, fm, fb, whole, eps float64, depth int) float64 {
+	var (
+		m   = 0.5 * (a + b)
+		lm  = 0.5 * (a + m)
+		rm  = 0.5 * (m + b)
+		flm = f(lm)
+		frm = f(rm)
+		l   = (m - a) / 6 * (fa + 4*flm + fm)
+		r   = (b - m) / 6 * (fm + 4*frm + fb)
+		d   = l + r - whole
+	)
+	if depth <= 0 || math.Abs(d) <= 15*eps {
+		return l + r + d/15
+	}
+	return simpson(f, a, m, fa, flm, fm, l, 0.5*eps, depth-1) +
+		simpson(f, m, b, fm, frm, fb, r, 0.5*eps, depth-1)
+}
@@ -0,0 +1,169 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fit_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"go-hep.org/x/hep/fit"
+	"go-hep.org/x/hep/hbook"
+	"gonum.org/v1/gonum/optimize"
+)
+
+func TestModelNormalisation(t *testing.T) {
+	var (
+		sig = fit.Gaussian{Min: 0, Max: 10}
+		bkg = fit.Exponential{Min: 0, Max: 10}
+	)
+	for _, tc := range []struct {
+		name  string
+		model fit.Model
+		ps    []float64
+	}{
+		{
+			name:  "sum",
+			model: fit.Sum{Models: []fit.Model{sig, bkg}},
+			ps:    []float64{5, 1, -0.3, 0.2},
+		},
+		{
+			name:  "ext-sum",
+			model: fit.ExtSum{Models: []fit.Model{sig, bkg}},
+			ps:    []float64{5, 1, -0.3, 200, 800},
+		},
+		{
+			name:  "product",
+			model: &fit.Product{Min: 0, Max: 10, Models: []fit.Model{sig, bkg}},
+			ps:    []float64{5, 1, -0.3},
+		},
+		{
+			name: "conv",
+			model: &fit.Conv{
+				Min: 0, Max: 10, N: 500,
+				Model:      fit.BreitWigner{Min: 0, Max: 10},
+				Resolution: fit.Gaussian{Min: -10, Max: 10},
+			},
+			ps: []float64{5, 0.5, 0, 0.3},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.model.Integral(0, 10, tc.ps); math.Abs(got-1) > 1e-6 {
+				t.Fatalf("invalid normalisation: got=%v", got)
+			}
+			pdf := func(x float64) float64 { return tc.model.PDF(x, tc.ps) }
+			if got := trapz(pdf, 0, 10, 100000); math.Abs(got-1) > 1e-4 {
+				t.Fatalf("invalid PDF normalisation: got=%v", got)
+			}
+		})
+	}
+}
+
+func TestConvGaussians(t *testing.T) {
+	// the convolution of two gaussians is a gaussian.
+	var (
+		conv = &fit.Conv{
+			Min: -20, Max: 20, N: 2001,
+			Model:      fit.Gaussian{Min: -20, Max: 20},
+			Resolution: fit.Gaussian{Min: -40, Max: 40},
+		}
+		want = fit.Gaussian{Min: -20, Max: 20}
+	)
+	for _, x := range []float64{-3, -1, 0, 0.5, 2} {
+		got := conv.PDF(x, []float64{1, 1.5, 0, 2})
+		exp := want.PDF(x, []float64{1, 2.5})
+		if math.Abs(got-exp) > 1e-4 {
+			t.Fatalf("x=%v: got=%v, want=%v", x, got, exp)
+		}
+	}
+}
+
+func genSigBkg(rnd *rand.Rand, nsig, nbkg int) []float64 {
+	data := make([]float64, 0, nsig+nbkg)
+	for len(data) < nsig {
+		v := 5 + 0.5*rnd.NormFloat64()
+		if v < 0 || v > 10 {
+			continue
+		}
+		data = append(data, v)
+	}
+	for len(data) < nsig+nbkg {
+		v := rnd.ExpFloat64() / 0.3
+		if v > 10 {
+			continue
+		}
+		data = append(data, v)
+	}
+	return data
+}
+
+func TestFuncH1D(t *testing.T) {
+	var (
+		rnd  = rand.New(rand.NewSource(1234))
+		data = genSigBkg(rnd, 2000, 8000)
+		h    = hbook.NewH1D(50, 0, 10)
+	)
+	for _, v := range data {
+		h.Fill(v, 1)
+	}
+
+	model := fit.ExtSum{Models: []fit.Model{
+		fit.Gaussian{Min: 0, Max: 10},
+		fit.Exponential{Min: 0, Max: 10},
+	}}
+
+	res, err := fit.H1D(h, fit.FuncH1D(h, model, []float64{4.5, 1, -0.1, 1000, 9000}), nil, &optimize.NelderMead{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.Status.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []float64{5, 0.5, -0.3, 2000, 8000}
+	tols := []float64{0.05, 0.05, 0.02, 150, 150}
+	for i := range want {
+		if math.Abs(res.X[i]-want[i]) > tols[i] {
+			t.Fatalf("par[%d]: got=%v, want=%v", i, res.X[i], want[i])
+		}
+	}
+
+	// non-extended model: the normalisation is appended to the parameters.
+	f := fit.FuncH1D(h, fit.Gaussian{Min: 0, Max: 10}, []float64{5, 1})
+	if got, want := len(f.Ps), 3; got != want {
+		t.Fatalf("invalid number of parameters: got=%d, want=%d", got, want)
+	}
+	if got, want := f.Ps[2], h.SumW(); got != want {
+		t.Fatalf("invalid initial normalisation: got=%v, want=%v", got, want)
+	}
+}
+
+func TestUnbinned(t *testing.T) {
+	var (
+		rnd  = rand.New(rand.NewSource(1234))
+		data = genSigBkg(rnd, 500, 1500)
+	)
+
+	model := fit.Sum{Models: []fit.Model{
+		fit.Gaussian{Min: 0, Max: 10},
+		fit.Exponential{Min: 0, Max: 10},
+	}}
+
+	res, err := fit.Unbinned(data, model, []float64{4.5, 1, -0.1, 0.5}, nil, &optimize.NelderMead{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.Status.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []float64{5, 0.5, -0.3, 0.25}
+	tols := []float64{0.1, 0.1, 0.05, 0.03}
+	for i := range want {
+		if math.Abs(res.X[i]-want[i]) > tols[i] {
+			t.Fatalf("par[%d]: got=%v, want=%v", i, res.X[i], want[i])
+		}
+	}
+}
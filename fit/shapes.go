@@ -0,0 +1,420 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fit
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// shape is a non-normalised function of one variable, with a primitive.
+type shape interface {
+	eval(x float64, ps []float64) float64
+	prim(x float64, ps []float64) float64
+}
+
+// pdf returns the value at x of the shape s, normalised over [min, max].
+func pdf(s shape, min, max, x float64, ps []float64) float64 {
+	if x < min || x > max {
+		return 0
+	}
+	return s.eval(x, ps) / (s.prim(max, ps) - s.prim(min, ps))
+}
+
+// integral returns the integral over [lo, hi] of the shape s, normalised
+// over [min, max].
+func integral(s shape, min, max, lo, hi float64, ps []float64) float64 {
+	lo, hi, ok := clip(lo, hi, min, max)
+	if !ok {
+		return 0
+	}
+	return (s.prim(hi, ps) - s.prim(lo, ps)) / (s.prim(max, ps) - s.prim(min, ps))
+}
+
+// Gaussian is a normal distribution, normalised over [Min, Max].
+//
+// Its parameters are the mean and the standard deviation.
+type Gaussian struct {
+	Min, Max float64
+}
+
+// NumParams implements the Model interface.
+func (Gaussian) NumParams() int { return 2 }
+
+// PDF implements the Model interface.
+func (m Gaussian) PDF(x float64, ps []float64) float64 {
+	return pdf(m, m.Min, m.Max, x, ps)
+}
+
+// Integral implements the Model interface.
+func (m Gaussian) Integral(lo, hi float64, ps []float64) float64 {
+	return integral(m, m.Min, m.Max, lo, hi, ps)
+}
+
+func (Gaussian) eval(x float64, ps []float64) float64 {
+	mu, sigma := ps[0], ps[1]
+	v := (x - mu) / sigma
+	return math.Exp(-0.5 * v * v)
+}
+
+func (Gaussian) prim(x float64, ps []float64) float64 {
+	mu, sigma := ps[0], ps[1]
+	return sigma * math.Sqrt(math.Pi/2) * math.Erf((x-mu)/(sigma*math.Sqrt2))
+}
+
+// CrystalBall is a Gaussian core with a power-law tail, normalised over
+// [Min, Max].
+//
+// Its parameters are the mean and standard deviation of the core,
+// alpha, the distance (in standard deviations) from the mean where the
+// tail starts, and n, the exponent of the tail.
+// The tail is on the low side for a positive alpha, on the high side
+// otherwise.
+type CrystalBall struct {
+	Min, Max float64
+}
+
+// NumParams implements the Model interface.
+func (CrystalBall) NumParams() int { return 4 }
+
+// PDF implements the Model interface.
+func (m CrystalBall) PDF(x float64, ps []float64) float64 {
+	return pdf(m, m.Min, m.Max, x, ps)
+}
+
+// Integral implements the Model interface.
+func (m CrystalBall) Integral(lo, hi float64, ps []float64) float64 {
+	return integral(m, m.Min, m.Max, lo, hi, ps)
+}
+
+func (CrystalBall) eval(x float64, ps []float64) float64 {
+	var (
+		mu, sigma = ps[0], ps[1]
+		alpha, n  = ps[2], ps[3]
+		t         = (x - mu) / sigma
+		a         = math.Abs(alpha)
+	)
+	if alpha < 0 {
+		t = -t
+	}
+	if t >= -a {
+		return math.Exp(-0.5 * t * t)
+	}
+	A := math.Pow(n/a, n) * math.Exp(-0.5*a*a)
+	B := n/a - a
+	return A * math.Pow(B-t, -n)
+}
+
+func (CrystalBall) prim(x float64, ps []float64) float64 {
+	var (
+		mu, sigma = ps[0], ps[1]
+		alpha, n  = ps[2], ps[3]
+		t         = (x - mu) / sigma
+		a         = math.Abs(alpha)
+		sign      = 1.0
+	)
+	if alpha < 0 {
+		t = -t
+		sign = -1
+	}
+
+	core := func(t float64) float64 {
+		return math.Sqrt(math.Pi/2) * math.Erf(t/math.Sqrt2)
+	}
+	if t >= -a {
+		return sign * sigma * core(t)
+	}
+
+	A := math.Pow(n/a, n) * math.Exp(-0.5*a*a)
+	B := n/a - a
+	tail := func(t float64) float64 {
+		if n == 1 {
+			return -A * math.Log(B-t)
+		}
+		return A * math.Pow(B-t, 1-n) / (n - 1)
+	}
+	return sign * sigma * (core(-a) + tail(t) - tail(-a))
+}
+
+// BreitWigner is a non-relativistic Breit-Wigner (Cauchy) distribution,
+// normalised over [Min, Max].
+//
+// Its parameters are the mass and the full width at half maximum.
+type BreitWigner struct {
+	Min, Max float64
+}
+
+// NumParams implements the Model interface.
+func (BreitWigner) NumParams() int { return 2 }
+
+// PDF implements the Model interface.
+func (m BreitWigner) PDF(x float64, ps []float64) float64 {
+	return pdf(m, m.Min, m.Max, x, ps)
+}
+
+// Integral implements the Model interface.
+func (m BreitWigner) Integral(lo, hi float64, ps []float64) float64 {
+	return integral(m, m.Min, m.Max, lo, hi, ps)
+}
+
+func (BreitWigner) eval(x float64, ps []float64) float64 {
+	m, g := ps[0], 0.5*ps[1]
+	return g / math.Pi / ((x-m)*(x-m) + g*g)
+}
+
+func (BreitWigner) prim(x float64, ps []float64) float64 {
+	m, g := ps[0], 0.5*ps[1]
+	return math.Atan((x-m)/g) / math.Pi
+}
+
+// Exponential is an exponential distribution exp(lambda*x), normalised over
+// [Min, Max].
+//
+// Its only parameter is the slope lambda.
+type Exponential struct {
+	Min, Max float64
+}
+
+// NumParams implements the Model interface.
+func (Exponential) NumParams() int { return 1 }
+
+// PDF implements the Model interface.
+func (m Exponential) PDF(x float64, ps []float64) float64 {
+	return pdf(m, m.Min, m.Max, x, ps)
+}
+
+// Integral implements the Model interface.
+func (m Exponential) Integral(lo, hi float64, ps []float64) float64 {
+	return integral(m, m.Min, m.Max, lo, hi, ps)
+}
+
+func (m Exponential) eval(x float64, ps []float64) float64 {
+	// shift by Min to keep the exponential in range.
+	return math.Exp(ps[0] * (x - m.Min))
+}
+
+func (m Exponential) prim(x float64, ps []float64) float64 {
+	lambda := ps[0]
+	if lambda == 0 {
+		return x
+	}
+	return math.Expm1(lambda*(x-m.Min)) / lambda
+}
+
+// Polynomial is the polynomial 1 + c1*x + c2*x^2 + ... + cN*x^N, normalised
+// over [Min, Max].
+//
+// Its parameters are the Degree coefficients c1, ..., cN.
+// The constant term is fixed to 1 as it is absorbed by the normalisation.
+type Polynomial struct {
+	Min, Max float64
+	Degree   int
+}
+
+// NumParams implements the Model interface.
+func (m Polynomial) NumParams() int { return m.Degree }
+
+// PDF implements the Model interface.
+func (m Polynomial) PDF(x float64, ps []float64) float64 {
+	return pdf(m, m.Min, m.Max, x, ps)
+}
+
+// Integral implements the Model interface.
+func (m Polynomial) Integral(lo, hi float64, ps []float64) float64 {
+	return integral(m, m.Min, m.Max, lo, hi, ps)
+}
+
+func (Polynomial) eval(x float64, ps []float64) float64 {
+	v := 0.0
+	for i := len(ps) - 1; i >= 0; i-- {
+		v = (v + ps[i]) * x
+	}
+	return 1 + v
+}
+
+func (Polynomial) prim(x float64, ps []float64) float64 {
+	v := 0.0
+	for i := len(ps) - 1; i >= 0; i-- {
+		v = (v + ps[i]/float64(i+2)) * x
+	}
+	return x + v*x
+}
+
+// Voigtian is the convolution of a Breit-Wigner with a Gaussian,
+// normalised over [Min, Max].
+//
+// Its parameters are the mass, the full width at half maximum of the
+// Breit-Wigner and the standard deviation of the Gaussian.
+// The Voigt profile has no closed-form primitive: its integrals are
+// computed numerically.
+type Voigtian struct {
+	Min, Max float64
+
+	norm normCache
+}
+
+// NumParams implements the Model interface.
+func (*Voigtian) NumParams() int { return 3 }
+
+// PDF implements the Model interface.
+func (m *Voigtian) PDF(x float64, ps []float64) float64 {
+	if x < m.Min || x > m.Max {
+		return 0
+	}
+	return voigt(x, ps) / m.norm.get(ps, func() float64 {
+		return integrate(func(x float64) float64 { return voigt(x, ps) }, m.Min, m.Max)
+	})
+}
+
+// Integral implements the Model interface.
+func (m *Voigtian) Integral(lo, hi float64, ps []float64) float64 {
+	lo, hi, ok := clip(lo, hi, m.Min, m.Max)
+	if !ok {
+		return 0
+	}
+	return integrate(func(x float64) float64 { return m.PDF(x, ps) }, lo, hi)
+}
+
+// voigt returns the Voigt profile, normalised over the real line.
+func voigt(x float64, ps []float64) float64 {
+	var (
+		m     = ps[0]
+		gamma = 0.5 * ps[1]
+		sigma = ps[2]
+	)
+	switch {
+	case sigma == 0:
+		return BreitWigner{}.eval(x, ps)
+	case gamma == 0:
+		v := (x - m) / sigma
+		return math.Exp(-0.5*v*v) / (sigma * math.Sqrt(2*math.Pi))
+	}
+	z := complex(x-m, gamma) / complex(sigma*math.Sqrt2, 0)
+	return real(faddeeva(z)) / (sigma * math.Sqrt(2*math.Pi))
+}
+
+// faddeeva returns the Faddeeva function w(z) = exp(-z^2) erfc(-iz),
+// for Im(z) >= 0, using the rational approximations of J. Humlicek,
+// J. Quant. Spectrosc. Radiat. Transfer 27 (1982) 437.
+func faddeeva(z complex128) complex128 {
+	var (
+		x = real(z)
+		y = imag(z)
+		t = complex(y, -x)
+		s = math.Abs(x) + y
+	)
+	switch {
+	case s >= 15:
+		return t * 0.5641896 / (0.5 + t*t)
+	case s >= 5.5:
+		u := t * t
+		return t * (1.410474 + u*0.5641896) / (0.75 + u*(3+u))
+	case y >= 0.195*math.Abs(x)-0.176:
+		return (16.4955 + t*(20.20933+t*(11.96482+t*(3.778987+t*0.5642236)))) /
+			(16.4955 + t*(38.82363+t*(39.27121+t*(21.69274+t*(6.699398+t)))))
+	}
+	u := t * t
+	return cmplx.Exp(u) - t*(36183.31-u*(3321.9905-u*(1540.787-u*(219.0313-u*(35.76683-u*(1.320522-u*0.56419))))))/
+		(32066.6-u*(24322.84-u*(9022.228-u*(2186.181-u*(364.2191-u*(61.57037-u*(1.841439-u)))))))
+}
+
+// Landau is the Landau distribution, normalised over [Min, Max].
+//
+// Its parameters are the location and the scale of the distribution.
+// The most probable value is located at about location-0.22278*scale.
+// The Landau distribution has no closed-form primitive: its integrals are
+// computed numerically.
+type Landau struct {
+	Min, Max float64
+
+	norm normCache
+}
+
+// NumParams implements the Model interface.
+func (*Landau) NumParams() int { return 2 }
+
+// PDF implements the Model interface.
+func (m *Landau) PDF(x float64, ps []float64) float64 {
+	if x < m.Min || x > m.Max {
+		return 0
+	}
+	return landau(x, ps) / m.norm.get(ps, func() float64 {
+		return integrate(func(x float64) float64 { return landau(x, ps) }, m.Min, m.Max)
+	})
+}
+
+// Integral implements the Model interface.
+func (m *Landau) Integral(lo, hi float64, ps []float64) float64 {
+	lo, hi, ok := clip(lo, hi, m.Min, m.Max)
+	if !ok {
+		return 0
+	}
+	return integrate(func(x float64) float64 { return m.PDF(x, ps) }, lo, hi)
+}
+
+// landau returns the Landau density, normalised over the real line,
+// using the algorithm of K.S. Kölbig and B. Schorr,
+// Comput. Phys. Commun. 31 (1984) 97 (CERNLIB G110 DENLAN).
+func landau(x float64, ps []float64) float64 {
+	var (
+		p1 = [5]float64{0.4259894875, -0.1249762550, 0.03984243700, -0.006298287635, 0.001511162253}
+		q1 = [5]float64{1.0, -0.3388260629, 0.09594393323, -0.01608042283, 0.003778942063}
+		p2 = [5]float64{0.1788541609, 0.1173957403, 0.01488850518, -0.001394989411, 0.0001283617211}
+		q2 = [5]float64{1.0, 0.7428795082, 0.3153932961, 0.06694219548, 0.008790609714}
+		p3 = [5]float64{0.1788544503, 0.09359161662, 0.006325387654, 0.00006611667319, -0.000002031049101}
+		q3 = [5]float64{1.0, 0.6097809921, 0.2560616665, 0.04746722384, 0.006957301675}
+		p4 = [5]float64{0.9874054407, 118.6723273, 849.2794360, -743.7792444, 427.0262186}
+		q4 = [5]float64{1.0, 106.8615961, 337.6496214, 2016.712389, 1597.063511}
+		p5 = [5]float64{1.003675074, 167.5702434, 4789.711289, 21217.86767, -22324.94910}
+		q5 = [5]float64{1.0, 156.9424537, 3745.310488, 9834.698876, 66924.28357}
+		p6 = [5]float64{1.000827619, 664.9143136, 62972.92665, 475554.6998, -5743609.109}
+		q6 = [5]float64{1.0, 651.4101098, 56974.73333, 165917.4725, -2815759.939}
+		a1 = [3]float64{0.04166666667, -0.01996527778, 0.02709538966}
+		a2 = [2]float64{-1.845568670, -4.284640743}
+	)
+
+	scale := ps[1]
+	if scale <= 0 {
+		return 0
+	}
+	v := (x - ps[0]) / scale
+
+	ratio := func(p, q [5]float64, u float64) float64 {
+		return (p[0] + (p[1]+(p[2]+(p[3]+p[4]*u)*u)*u)*u) /
+			(q[0] + (q[1]+(q[2]+(q[3]+q[4]*u)*u)*u)*u)
+	}
+
+	var d float64
+	switch {
+	case v < -5.5:
+		u := math.Exp(v + 1)
+		if u < 1e-10 {
+			return 0
+		}
+		ue := math.Exp(-1 / u)
+		us := math.Sqrt(u)
+		d = 0.3989422803 * (ue / us) * (1 + (a1[0]+(a1[1]+a1[2]*u)*u)*u)
+	case v < -1:
+		u := math.Exp(-v - 1)
+		d = math.Exp(-u) * math.Sqrt(u) * ratio(p1, q1, v)
+	case v < 1:
+		d = ratio(p2, q2, v)
+	case v < 5:
+		d = ratio(p3, q3, v)
+	case v < 12:
+		u := 1 / v
+		d = u * u * ratio(p4, q4, u)
+	case v < 50:
+		u := 1 / v
+		d = u * u * ratio(p5, q5, u)
+	case v < 300:
+		u := 1 / v
+		d = u * u * ratio(p6, q6, u)
+	default:
+		u := 1 / (v - v*math.Log(v)/(v+1))
+		d = u * u * (1 + (a2[0]+a2[1]*u)*u)
+	}
+	return d / scale
+}
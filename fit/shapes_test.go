@@ -0,0 +1,120 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fit_test
+
+import (
+	"math"
+	"testing"
+
+	"go-hep.org/x/hep/fit"
+)
+
+// trapz returns the integral of f over [lo, hi] with the trapezoidal rule.
+func trapz(f func(x float64) float64, lo, hi float64, n int) float64 {
+	dx := (hi - lo) / float64(n)
+	sum := 0.5 * (f(lo) + f(hi))
+	for i := 1; i < n; i++ {
+		sum += f(lo + float64(i)*dx)
+	}
+	return sum * dx
+}
+
+func TestShapes(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		model fit.Model
+		ps    []float64
+	}{
+		{"gauss", fit.Gaussian{Min: -10, Max: 10}, []float64{1, 2}},
+		{"gauss-trunc", fit.Gaussian{Min: 0, Max: 3}, []float64{1, 2}},
+		{"cb", fit.CrystalBall{Min: -10, Max: 10}, []float64{0.5, 1, 1.5, 3}},
+		{"cb-n1", fit.CrystalBall{Min: -10, Max: 10}, []float64{0.5, 1, 1.5, 1}},
+		{"cb-high", fit.CrystalBall{Min: -10, Max: 10}, []float64{0.5, 1, -1.5, 3}},
+		{"bw", fit.BreitWigner{Min: 80, Max: 100}, []float64{91.2, 2.5}},
+		{"exp", fit.Exponential{Min: 0, Max: 5}, []float64{-0.7}},
+		{"exp-flat", fit.Exponential{Min: 0, Max: 5}, []float64{0}},
+		{"poly", fit.Polynomial{Min: -1, Max: 2, Degree: 2}, []float64{0.5, 0.2}},
+		{"voigt", &fit.Voigtian{Min: 80, Max: 100}, []float64{91.2, 2.5, 1.5}},
+		{"landau", &fit.Landau{Min: -5, Max: 50}, []float64{2, 1.5}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, want := tc.model.NumParams(), len(tc.ps); got != want {
+				t.Fatalf("invalid number of parameters: got=%d, want=%d", got, want)
+			}
+			pdf := func(x float64) float64 { return tc.model.PDF(x, tc.ps) }
+
+			lo, hi := -1000.0, 1000.0
+			if got := tc.model.Integral(lo, hi, tc.ps); math.Abs(got-1) > 1e-6 {
+				t.Fatalf("invalid normalisation: got=%v", got)
+			}
+
+			lo, hi = 0.5, 2.5
+			switch tc.name {
+			case "bw", "voigt":
+				lo, hi = 90, 92.5
+			case "poly":
+				lo, hi = -0.5, 1.5
+			}
+			got := tc.model.Integral(lo, hi, tc.ps)
+			want := trapz(pdf, lo, hi, 100000)
+			if math.Abs(got-want) > 1e-6 {
+				t.Fatalf("invalid integral: got=%v, want=%v", got, want)
+			}
+
+			if got := tc.model.PDF(-2000, tc.ps); got != 0 {
+				t.Fatalf("invalid PDF outside range: got=%v", got)
+			}
+		})
+	}
+}
+
+func TestCrystalBallCore(t *testing.T) {
+	var (
+		cb = fit.CrystalBall{Min: -10, Max: 10}
+		g  = fit.Gaussian{Min: -10, Max: 10}
+		ps = []float64{0, 1, 20, 2}
+	)
+	// with the tail far away, the Crystal Ball is a Gaussian.
+	for _, x := range []float64{-3, -1, 0, 0.5, 2} {
+		got := cb.PDF(x, ps)
+		want := g.PDF(x, ps[:2])
+		if math.Abs(got-want) > 1e-12 {
+			t.Fatalf("x=%v: got=%v, want=%v", x, got, want)
+		}
+	}
+}
+
+func TestVoigtianLimits(t *testing.T) {
+	var (
+		v  = &fit.Voigtian{Min: -50, Max: 50}
+		g  = fit.Gaussian{Min: -50, Max: 50}
+		bw = fit.BreitWigner{Min: -50, Max: 50}
+	)
+	for _, x := range []float64{-3, -1, 0, 0.5, 2} {
+		if got, want := v.PDF(x, []float64{0, 0, 1.5}), g.PDF(x, []float64{0, 1.5}); math.Abs(got-want) > 1e-12 {
+			t.Fatalf("gauss-limit x=%v: got=%v, want=%v", x, got, want)
+		}
+		if got, want := v.PDF(x, []float64{0, 2, 0}), bw.PDF(x, []float64{0, 2}); math.Abs(got-want) > 1e-12 {
+			t.Fatalf("bw-limit x=%v: got=%v, want=%v", x, got, want)
+		}
+	}
+}
+
+func TestLandauPeak(t *testing.T) {
+	l := &fit.Landau{Min: -10, Max: 1000}
+	ps := []float64{0, 1}
+	var (
+		xmax = 0.0
+		vmax = 0.0
+	)
+	for x := -2.0; x < 2; x += 1e-4 {
+		if v := l.PDF(x, ps); v > vmax {
+			xmax, vmax = x, v
+		}
+	}
+	if want := -0.22278; math.Abs(xmax-want) > 1e-3 {
+		t.Fatalf("invalid most probable value: got=%v, want=%v", xmax, want)
+	}
+}
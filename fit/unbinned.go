@@ -0,0 +1,63 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fit
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/diff/fd"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/optimize"
+)
+
+// Unbinned returns the result of an unbinned maximum likelihood fit of
+// the model to the data with method m, starting from the parameters ps.
+//
+// If model is an Extended model, the extended likelihood is used.
+// In case settings is nil, the optimize.DefaultSettings is used.
+// In case m is nil, the same default optimization method than for Curve1D is used.
+func Unbinned(data []float64, model Model, ps []float64, settings *optimize.Settings, m optimize.Method) (*optimize.Result, error) {
+	if len(ps) != model.NumParams() {
+		panic("fit: invalid number of initial parameters")
+	}
+
+	ext, extended := model.(Extended)
+	nll := func(ps []float64) float64 {
+		sum := 0.0
+		for _, x := range data {
+			v := model.PDF(x, ps)
+			if v <= 0 {
+				return math.Inf(+1)
+			}
+			sum -= math.Log(v)
+		}
+		if extended {
+			nu := ext.Yield(ps)
+			if nu <= 0 {
+				return math.Inf(+1)
+			}
+			sum += nu - float64(len(data))*math.Log(nu)
+		}
+		return sum
+	}
+
+	p := optimize.Problem{
+		Func: nll,
+		Grad: func(grad, ps []float64) {
+			fd.Gradient(grad, nll, ps, nil)
+		},
+		Hess: func(hess mat.MutableSymmetric, x []float64) {
+			fd.Hessian(hess.(*mat.SymDense), nll, x, nil)
+		},
+	}
+
+	if m == nil {
+		m = &optimize.NelderMead{}
+	}
+
+	p0 := make([]float64, len(ps))
+	copy(p0, ps)
+	return optimize.Local(p, p0, settings, m)
+}
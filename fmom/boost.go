@@ -0,0 +1,121 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fmom
+
+import (
+	"fmt"
+	"math"
+)
+
+// BoostVector returns the velocity vector (px/E, py/E, pz/E) of p,
+// ie: the boost vector to go from the rest frame of p to the
+// frame where p is defined.
+func BoostVector(p P4) Vec3 {
+	e := p.E()
+	if e == 0 {
+		return Vec3{}
+	}
+	return Vec3{p.Px() / e, p.Py() / e, p.Pz() / e}
+}
+
+// Boost returns a copy of p, boosted by the velocity vector beta.
+// The returned P4 has the same concrete type than p.
+func Boost(p P4, beta Vec3) P4 {
+	var (
+		bx, by, bz = beta[0], beta[1], beta[2]
+		b2         = bx*bx + by*by + bz*bz
+		px, py, pz = p.Px(), p.Py(), p.Pz()
+		e          = p.E()
+	)
+	if b2 >= 1 {
+		panic(fmt.Errorf("fmom: invalid boost vector (|beta|=%v >= 1)", math.Sqrt(b2)))
+	}
+
+	var (
+		gamma  = 1 / math.Sqrt(1-b2)
+		bp     = bx*px + by*py + bz*pz
+		gamma2 = 0.0
+	)
+	if b2 > 0 {
+		gamma2 = (gamma - 1) / b2
+	}
+
+	return newLike(p, NewPxPyPzE(
+		px+gamma2*bp*bx+gamma*bx*e,
+		py+gamma2*bp*by+gamma*by*e,
+		pz+gamma2*bp*bz+gamma*bz*e,
+		gamma*(e+bp),
+	))
+}
+
+// RestFrame returns a copy of p, expressed in the rest frame of ref.
+// The returned P4 has the same concrete type than p.
+func RestFrame(p, ref P4) P4 {
+	b := BoostVector(ref)
+	return Boost(p, Vec3{-b[0], -b[1], -b[2]})
+}
+
+// RotateX returns a copy of p, rotated by angle (in radians) around the x-axis.
+// The returned P4 has the same concrete type than p.
+func RotateX(p P4, angle float64) P4 {
+	return newLikeVec(p, Vect(p).RotateX(angle))
+}
+
+// RotateY returns a copy of p, rotated by angle (in radians) around the y-axis.
+// The returned P4 has the same concrete type than p.
+func RotateY(p P4, angle float64) P4 {
+	return newLikeVec(p, Vect(p).RotateY(angle))
+}
+
+// RotateZ returns a copy of p, rotated by angle (in radians) around the z-axis.
+// The returned P4 has the same concrete type than p.
+func RotateZ(p P4, angle float64) P4 {
+	return newLikeVec(p, Vect(p).RotateZ(angle))
+}
+
+// Rotate returns a copy of p, rotated by angle (in radians) around the
+// axis vector.
+// The returned P4 has the same concrete type than p.
+func Rotate(p P4, angle float64, axis Vec3) P4 {
+	return newLikeVec(p, Vect(p).Rotate(angle, axis))
+}
+
+// newLikeVec returns a new P4 with the 3-momentum vec and the energy of ref,
+// with the same concrete type than ref.
+func newLikeVec(ref P4, vec Vec3) P4 {
+	return newLike(ref, NewPxPyPzE(vec[0], vec[1], vec[2], ref.E()))
+}
+
+// newLike returns a new P4 holding the 4-momentum p, with the same
+// concrete type than ref.
+func newLike(ref P4, p PxPyPzE) P4 {
+	switch ref.(type) {
+	case *PxPyPzE:
+		return &p
+
+	case *EEtaPhiM:
+		var pp EEtaPhiM
+		pp.Set(&p)
+		return &pp
+
+	case *EtEtaPhiM:
+		var pp EtEtaPhiM
+		pp.Set(&p)
+		return &pp
+
+	case *PtEtaPhiM:
+		var pp PtEtaPhiM
+		pp.Set(&p)
+		return &pp
+
+	case *IPtCotThPhiM:
+		var pp IPtCotThPhiM
+		pp.Set(&p)
+		return &pp
+
+	default:
+		panic(fmt.Errorf("fmom: invalid P4 concrete value: %#v", ref))
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fmom
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestBoost(t *testing.T) {
+	for _, p := range []P4{
+		newPxPyPzE(NewPxPyPzE(10, 20, 30, 100)),
+		newEEtaPhiM(NewPxPyPzE(10, 20, 30, 100)),
+		newEtEtaPhiM(NewPxPyPzE(10, 20, 30, 100)),
+		newPtEtaPhiM(NewPxPyPzE(10, 20, 30, 100)),
+		newIPtCotThPhiM(NewPxPyPzE(10, 20, 30, 100)),
+	} {
+		ref := newPxPyPzE(NewPxPyPzE(-5, 10, 50, 80))
+		rest := RestFrame(p, ref)
+		if got, want := reflect.TypeOf(rest), reflect.TypeOf(p); got != want {
+			t.Fatalf("invalid concrete type: got=%v, want=%v", got, want)
+		}
+		if !cmpeq(rest.M(), p.M(), epsilon_test) {
+			t.Fatalf("boost modified mass: got=%v, want=%v", rest.M(), p.M())
+		}
+
+		back := Boost(rest, BoostVector(ref))
+		if !p4equal(back, p, epsilon_test) {
+			t.Fatalf("invalid boost:\ngot= %v\nwant=%v", back, p)
+		}
+	}
+
+	p := newPxPyPzE(NewPxPyPzE(10, 20, 30, 100))
+	rest := RestFrame(p, p)
+	want := newPxPyPzE(NewPxPyPzE(0, 0, 0, p.M()))
+	if !p4equal(rest, want, epsilon_test) {
+		t.Fatalf("invalid rest frame:\ngot= %v\nwant=%v", rest, want)
+	}
+
+	// boost along z
+	p = newPxPyPzE(NewPxPyPzE(0, 0, 0, 1))
+	got := Boost(p, Vec3{0, 0, 0.6})
+	want = newPxPyPzE(NewPxPyPzE(0, 0, 0.75, 1.25))
+	if !p4equal(got, want, epsilon_test) {
+		t.Fatalf("invalid boost:\ngot= %v\nwant=%v", got, want)
+	}
+}
+
+func TestBoostPanics(t *testing.T) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Fatalf("expected a panic")
+		}
+	}()
+	p := newPxPyPzE(NewPxPyPzE(0, 0, 0, 1))
+	Boost(p, Vec3{0, 0, 1})
+}
+
+func TestRotate(t *testing.T) {
+	p := newPtEtaPhiM(NewPxPyPzE(1, 2, 3, 10))
+	for _, tc := range []struct {
+		name string
+		got  P4
+		want P4
+	}{
+		{
+			name: "rotx",
+			got:  RotateX(p, math.Pi/2),
+			want: newPxPyPzE(NewPxPyPzE(1, -3, 2, 10)),
+		},
+		{
+			name: "roty",
+			got:  RotateY(p, math.Pi/2),
+			want: newPxPyPzE(NewPxPyPzE(3, 2, -1, 10)),
+		},
+		{
+			name: "rotz",
+			got:  RotateZ(p, math.Pi/2),
+			want: newPxPyPzE(NewPxPyPzE(-2, 1, 3, 10)),
+		},
+		{
+			name: "rot-x",
+			got:  Rotate(p, math.Pi/2, Vec3{2, 0, 0}),
+			want: RotateX(p, math.Pi/2),
+		},
+		{
+			name: "rot-y",
+			got:  Rotate(p, math.Pi/2, Vec3{0, 3, 0}),
+			want: RotateY(p, math.Pi/2),
+		},
+		{
+			name: "rot-z",
+			got:  Rotate(p, 0.3, Vec3{0, 0, 1}),
+			want: RotateZ(p, 0.3),
+		},
+		{
+			name: "rot-diag",
+			got:  Rotate(p, 2*math.Pi/3, Vec3{1, 1, 1}),
+			want: newPxPyPzE(NewPxPyPzE(3, 1, 2, 10)),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, want := reflect.TypeOf(tc.got), reflect.TypeOf(p); got != want {
+				t.Fatalf("invalid concrete type: got=%v, want=%v", got, want)
+			}
+			if !p4equal(tc.got, tc.want, epsilon_test) {
+				t.Fatalf("invalid rotation:\ngot= %v\nwant=%v", tc.got, tc.want)
+			}
+			if !cmpeq(tc.got.M(), p.M(), epsilon_test) {
+				t.Fatalf("rotation modified mass: got=%v, want=%v", tc.got.M(), p.M())
+			}
+		})
+	}
+}
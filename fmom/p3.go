@@ -4,17 +4,149 @@
 
 package fmom
 
+import (
+	"math"
+)
+
 // Vec3 is a 3-dim vector.
 type Vec3 [3]float64
 
-func (vec *Vec3) X() float64 {
+func (vec Vec3) X() float64 {
 	return vec[0]
 }
 
-func (vec *Vec3) Y() float64 {
+func (vec Vec3) Y() float64 {
 	return vec[1]
 }
 
-func (vec *Vec3) Z() float64 {
+func (vec Vec3) Z() float64 {
 	return vec[2]
 }
+
+// Add returns vec+o.
+func (vec Vec3) Add(o Vec3) Vec3 {
+	return Vec3{vec[0] + o[0], vec[1] + o[1], vec[2] + o[2]}
+}
+
+// Sub returns vec-o.
+func (vec Vec3) Sub(o Vec3) Vec3 {
+	return Vec3{vec[0] - o[0], vec[1] - o[1], vec[2] - o[2]}
+}
+
+// Scale returns a*vec.
+func (vec Vec3) Scale(a float64) Vec3 {
+	return Vec3{a * vec[0], a * vec[1], a * vec[2]}
+}
+
+// Dot returns the dot product vec.o.
+func (vec Vec3) Dot(o Vec3) float64 {
+	return vec[0]*o[0] + vec[1]*o[1] + vec[2]*o[2]
+}
+
+// Cross returns the cross product vec x o.
+func (vec Vec3) Cross(o Vec3) Vec3 {
+	return Vec3{
+		vec[1]*o[2] - vec[2]*o[1],
+		vec[2]*o[0] - vec[0]*o[2],
+		vec[0]*o[1] - vec[1]*o[0],
+	}
+}
+
+// Mag2 returns the squared magnitude of vec.
+func (vec Vec3) Mag2() float64 {
+	return vec.Dot(vec)
+}
+
+// Mag returns the magnitude of vec.
+func (vec Vec3) Mag() float64 {
+	return math.Sqrt(vec.Mag2())
+}
+
+// Perp2 returns the squared transverse component of vec.
+func (vec Vec3) Perp2() float64 {
+	return vec[0]*vec[0] + vec[1]*vec[1]
+}
+
+// Perp returns the transverse component of vec.
+func (vec Vec3) Perp() float64 {
+	return math.Sqrt(vec.Perp2())
+}
+
+// Unit returns the unit vector along vec.
+// Unit returns vec unmodified if vec is the null vector.
+func (vec Vec3) Unit() Vec3 {
+	mag := vec.Mag()
+	if mag == 0 {
+		return vec
+	}
+	return vec.Scale(1 / mag)
+}
+
+// Phi returns the azimuthal angle of vec in [-pi,pi].
+func (vec Vec3) Phi() float64 {
+	if vec[0] == 0 && vec[1] == 0 {
+		return 0
+	}
+	return math.Atan2(vec[1], vec[0])
+}
+
+// Theta returns the polar angle of vec in [0,pi].
+func (vec Vec3) Theta() float64 {
+	if vec[0] == 0 && vec[1] == 0 && vec[2] == 0 {
+		return 0
+	}
+	return math.Atan2(vec.Perp(), vec[2])
+}
+
+// Angle returns the angle between vec and o, in [0,pi].
+func (vec Vec3) Angle(o Vec3) float64 {
+	mag2 := vec.Mag2() * o.Mag2()
+	if mag2 <= 0 {
+		return 0
+	}
+	cos := vec.Dot(o) / math.Sqrt(mag2)
+	switch {
+	case cos > +1:
+		cos = +1
+	case cos < -1:
+		cos = -1
+	}
+	return math.Acos(cos)
+}
+
+// RotateX returns vec rotated by angle (in radians) around the x-axis.
+func (vec Vec3) RotateX(angle float64) Vec3 {
+	sin, cos := math.Sincos(angle)
+	return Vec3{vec[0], cos*vec[1] - sin*vec[2], sin*vec[1] + cos*vec[2]}
+}
+
+// RotateY returns vec rotated by angle (in radians) around the y-axis.
+func (vec Vec3) RotateY(angle float64) Vec3 {
+	sin, cos := math.Sincos(angle)
+	return Vec3{cos*vec[0] + sin*vec[2], vec[1], -sin*vec[0] + cos*vec[2]}
+}
+
+// RotateZ returns vec rotated by angle (in radians) around the z-axis.
+func (vec Vec3) RotateZ(angle float64) Vec3 {
+	sin, cos := math.Sincos(angle)
+	return Vec3{cos*vec[0] - sin*vec[1], sin*vec[0] + cos*vec[1], vec[2]}
+}
+
+// Rotate returns vec rotated by angle (in radians) around the axis vector.
+func (vec Vec3) Rotate(angle float64, axis Vec3) Vec3 {
+	if axis.Mag2() == 0 {
+		panic("fmom: invalid rotation axis (null vector)")
+	}
+
+	// Rodrigues' rotation formula.
+	var (
+		k        = axis.Unit()
+		sin, cos = math.Sincos(angle)
+	)
+	return vec.Scale(cos).Add(k.Cross(vec).Scale(sin)).Add(k.Scale(k.Dot(vec) * (1 - cos)))
+}
+
+// Vect returns the 3-momentum of p.
+func Vect(p P4) Vec3 {
+	return Vec3{p.Px(), p.Py(), p.Pz()}
+}
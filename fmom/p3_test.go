@@ -0,0 +1,95 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fmom
+
+import (
+	"math"
+	"testing"
+)
+
+func vec3equal(v1, v2 Vec3, epsilon float64) bool {
+	return cmpeq(v1[0], v2[0], epsilon) &&
+		cmpeq(v1[1], v2[1], epsilon) &&
+		cmpeq(v1[2], v2[2], epsilon)
+}
+
+func TestVec3(t *testing.T) {
+	var (
+		u = Vec3{1, 2, 3}
+		v = Vec3{-2, 0, 4}
+	)
+
+	if got, want := u.Add(v), (Vec3{-1, 2, 7}); got != want {
+		t.Fatalf("invalid add: got=%v, want=%v", got, want)
+	}
+	if got, want := u.Sub(v), (Vec3{3, 2, -1}); got != want {
+		t.Fatalf("invalid sub: got=%v, want=%v", got, want)
+	}
+	if got, want := u.Scale(2), (Vec3{2, 4, 6}); got != want {
+		t.Fatalf("invalid scale: got=%v, want=%v", got, want)
+	}
+	if got, want := u.Dot(v), 10.0; got != want {
+		t.Fatalf("invalid dot: got=%v, want=%v", got, want)
+	}
+	if got, want := u.Cross(v), (Vec3{8, -10, 4}); got != want {
+		t.Fatalf("invalid cross: got=%v, want=%v", got, want)
+	}
+	if got, want := u.Cross(v).Dot(u), 0.0; got != want {
+		t.Fatalf("cross product not orthogonal: got=%v, want=%v", got, want)
+	}
+	if got, want := u.Mag2(), 14.0; got != want {
+		t.Fatalf("invalid mag2: got=%v, want=%v", got, want)
+	}
+	if got, want := u.Mag(), math.Sqrt(14); got != want {
+		t.Fatalf("invalid mag: got=%v, want=%v", got, want)
+	}
+	if got, want := u.Perp(), math.Sqrt(5); got != want {
+		t.Fatalf("invalid perp: got=%v, want=%v", got, want)
+	}
+	if got, want := u.Unit().Mag(), 1.0; !cmpeq(got, want, epsilon_test) {
+		t.Fatalf("invalid unit: got=%v, want=%v", got, want)
+	}
+	if got, want := (Vec3{}).Unit(), (Vec3{}); got != want {
+		t.Fatalf("invalid null unit: got=%v, want=%v", got, want)
+	}
+	if got, want := (Vec3{0, 1, 0}).Phi(), math.Pi/2; !cmpeq(got, want, epsilon_test) {
+		t.Fatalf("invalid phi: got=%v, want=%v", got, want)
+	}
+	if got, want := (Vec3{1, 0, -1}).Theta(), 3*math.Pi/4; !cmpeq(got, want, epsilon_test) {
+		t.Fatalf("invalid theta: got=%v, want=%v", got, want)
+	}
+	if got, want := (Vec3{1, 0, 0}).Angle(Vec3{0, 0, 3}), math.Pi/2; !cmpeq(got, want, epsilon_test) {
+		t.Fatalf("invalid angle: got=%v, want=%v", got, want)
+	}
+	if got, want := u.Angle(u.Scale(-2)), math.Pi; !cmpeq(got, want, epsilon_test) {
+		t.Fatalf("invalid angle: got=%v, want=%v", got, want)
+	}
+}
+
+func TestVec3Rotate(t *testing.T) {
+	u := Vec3{1, 2, 3}
+	for _, tc := range []struct {
+		name string
+		got  Vec3
+		want Vec3
+	}{
+		{"rotx", u.RotateX(math.Pi / 2), Vec3{1, -3, 2}},
+		{"roty", u.RotateY(math.Pi / 2), Vec3{3, 2, -1}},
+		{"rotz", u.RotateZ(math.Pi / 2), Vec3{-2, 1, 3}},
+		{"rot-x", u.Rotate(0.3, Vec3{2, 0, 0}), u.RotateX(0.3)},
+		{"rot-y", u.Rotate(0.3, Vec3{0, 2, 0}), u.RotateY(0.3)},
+		{"rot-z", u.Rotate(0.3, Vec3{0, 0, 2}), u.RotateZ(0.3)},
+		{"rot-diag", u.Rotate(2*math.Pi/3, Vec3{1, 1, 1}), Vec3{3, 1, 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if !vec3equal(tc.got, tc.want, epsilon_test) {
+				t.Fatalf("got=%v, want=%v", tc.got, tc.want)
+			}
+			if !cmpeq(tc.got.Mag(), u.Mag(), epsilon_test) {
+				t.Fatalf("rotation modified magnitude: got=%v, want=%v", tc.got.Mag(), u.Mag())
+			}
+		})
+	}
+}
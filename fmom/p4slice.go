@@ -0,0 +1,216 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fmom
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// P4Slice is a collection of 4-vectors stored as a structure of arrays.
+//
+// P4Slice provides batched operations over many 4-vectors, without going
+// through the P4 interface for each of them.
+type P4Slice struct {
+	Px []float64
+	Py []float64
+	Pz []float64
+	E  []float64
+}
+
+// NewP4Slice returns a new P4Slice of n null 4-vectors.
+func NewP4Slice(n int) *P4Slice {
+	return &P4Slice{
+		Px: make([]float64, n),
+		Py: make([]float64, n),
+		Pz: make([]float64, n),
+		E:  make([]float64, n),
+	}
+}
+
+// NewP4SliceFrom returns a new P4Slice holding the given 4-vectors.
+func NewP4SliceFrom(ps []P4) *P4Slice {
+	out := NewP4Slice(len(ps))
+	for i, p := range ps {
+		out.Set(i, p)
+	}
+	return out
+}
+
+// Len returns the number of 4-vectors in the slice.
+func (ps *P4Slice) Len() int {
+	return len(ps.E)
+}
+
+// At returns the i-th 4-vector of the slice.
+func (ps *P4Slice) At(i int) PxPyPzE {
+	return NewPxPyPzE(ps.Px[i], ps.Py[i], ps.Pz[i], ps.E[i])
+}
+
+// Set sets the i-th 4-vector of the slice to p.
+func (ps *P4Slice) Set(i int, p P4) {
+	ps.Px[i] = p.Px()
+	ps.Py[i] = p.Py()
+	ps.Pz[i] = p.Pz()
+	ps.E[i] = p.E()
+}
+
+// Append appends the 4-vector p to the slice.
+func (ps *P4Slice) Append(p P4) {
+	ps.Px = append(ps.Px, p.Px())
+	ps.Py = append(ps.Py, p.Py())
+	ps.Pz = append(ps.Pz, p.Pz())
+	ps.E = append(ps.E, p.E())
+}
+
+// Add adds element-wise the 4-vectors of o to the ones of ps.
+// Add panics if ps and o do not have the same length.
+func (ps *P4Slice) Add(o *P4Slice) {
+	if ps.Len() != o.Len() {
+		panic(fmt.Errorf("fmom: length mismatch (%d != %d)", ps.Len(), o.Len()))
+	}
+	add(ps.Px, o.Px)
+	add(ps.Py, o.Py)
+	add(ps.Pz, o.Pz)
+	add(ps.E, o.E)
+}
+
+func add(dst, src []float64) {
+	src = src[:len(dst)]
+	for i, v := range src {
+		dst[i] += v
+	}
+}
+
+// Sum returns the sum of all the 4-vectors of the slice.
+func (ps *P4Slice) Sum() PxPyPzE {
+	var sum PxPyPzE
+	for i := range ps.E {
+		sum[0] += ps.Px[i]
+		sum[1] += ps.Py[i]
+		sum[2] += ps.Pz[i]
+		sum[3] += ps.E[i]
+	}
+	return sum
+}
+
+// Mass stores the masses of the 4-vectors of the slice into dst and
+// returns it.
+// If dst is too small, a new slice is allocated.
+func (ps *P4Slice) Mass(dst []float64) []float64 {
+	dst = resize(dst, ps.Len())
+	for i := range dst {
+		p := ps.At(i)
+		dst[i] = p.M()
+	}
+	return dst
+}
+
+// Pt stores the transverse momenta of the 4-vectors of the slice into dst
+// and returns it.
+// If dst is too small, a new slice is allocated.
+func (ps *P4Slice) Pt(dst []float64) []float64 {
+	dst = resize(dst, ps.Len())
+	for i := range dst {
+		p := ps.At(i)
+		dst[i] = p.Pt()
+	}
+	return dst
+}
+
+// Eta stores the pseudo-rapidities of the 4-vectors of the slice into dst
+// and returns it.
+// If dst is too small, a new slice is allocated.
+func (ps *P4Slice) Eta(dst []float64) []float64 {
+	dst = resize(dst, ps.Len())
+	for i := range dst {
+		p := ps.At(i)
+		dst[i] = p.Eta()
+	}
+	return dst
+}
+
+// Phi stores the azimuthal angles of the 4-vectors of the slice into dst
+// and returns it.
+// If dst is too small, a new slice is allocated.
+func (ps *P4Slice) Phi(dst []float64) []float64 {
+	dst = resize(dst, ps.Len())
+	for i := range dst {
+		p := ps.At(i)
+		dst[i] = p.Phi()
+	}
+	return dst
+}
+
+// DeltaR stores into dst the matrix of the delta R between the 4-vectors
+// of ps and the ones of o, and returns it.
+// The matrix is stored in row-major order: the delta R between ps.At(i)
+// and o.At(j) is dst[i*o.Len()+j].
+// If dst is too small, a new slice is allocated.
+func (ps *P4Slice) DeltaR(dst []float64, o *P4Slice) []float64 {
+	var (
+		n    = ps.Len()
+		m    = o.Len()
+		eta1 = ps.Eta(nil)
+		phi1 = ps.Phi(nil)
+		eta2 = eta1
+		phi2 = phi1
+	)
+	if o != ps {
+		eta2 = o.Eta(nil)
+		phi2 = o.Phi(nil)
+	}
+	dst = resize(dst, n*m)
+	for i := 0; i < n; i++ {
+		row := dst[i*m : (i+1)*m]
+		for j := range row {
+			deta := eta1[i] - eta2[j]
+			dphi := math.Remainder(phi1[i]-phi2[j], twopi)
+			row[j] = math.Sqrt(deta*deta + dphi*dphi)
+		}
+	}
+	return dst
+}
+
+// Swap swaps the i-th and j-th 4-vectors of the slice.
+func (ps *P4Slice) Swap(i, j int) {
+	ps.Px[i], ps.Px[j] = ps.Px[j], ps.Px[i]
+	ps.Py[i], ps.Py[j] = ps.Py[j], ps.Py[i]
+	ps.Pz[i], ps.Pz[j] = ps.Pz[j], ps.Pz[i]
+	ps.E[i], ps.E[j] = ps.E[j], ps.E[i]
+}
+
+// SortByPt sorts the 4-vectors of the slice by decreasing transverse momentum.
+func (ps *P4Slice) SortByPt() {
+	sort.Sort(byKey{ps: ps, key: ps.Pt(nil)})
+}
+
+// SortByE sorts the 4-vectors of the slice by decreasing energy.
+func (ps *P4Slice) SortByE() {
+	key := make([]float64, ps.Len())
+	copy(key, ps.E)
+	sort.Sort(byKey{ps: ps, key: key})
+}
+
+// byKey sorts a P4Slice by decreasing values of a pre-computed key.
+type byKey struct {
+	ps  *P4Slice
+	key []float64
+}
+
+func (p byKey) Len() int           { return len(p.key) }
+func (p byKey) Less(i, j int) bool { return p.key[i] > p.key[j] }
+func (p byKey) Swap(i, j int) {
+	p.key[i], p.key[j] = p.key[j], p.key[i]
+	p.ps.Swap(i, j)
+}
+
+func resize(v []float64, n int) []float64 {
+	if cap(v) < n {
+		return make([]float64, n)
+	}
+	return v[:n]
+}
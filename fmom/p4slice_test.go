@@ -0,0 +1,171 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fmom
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func genP4s(rnd *rand.Rand, n int) []P4 {
+	ps := make([]P4, n)
+	for i := range ps {
+		var (
+			pt  = 1 + 100*rnd.Float64()
+			eta = -4 + 8*rnd.Float64()
+			phi = -3 + 6*rnd.Float64()
+			m   = 10 * rnd.Float64()
+		)
+		ps[i] = &PtEtaPhiM{pt, eta, phi, m}
+	}
+	return ps
+}
+
+func TestP4Slice(t *testing.T) {
+	var (
+		rnd = rand.New(rand.NewSource(1234))
+		p1  = genP4s(rnd, 50)
+		p2  = genP4s(rnd, 20)
+		s1  = NewP4SliceFrom(p1)
+		s2  = NewP4SliceFrom(p2)
+	)
+
+	if got, want := s1.Len(), len(p1); got != want {
+		t.Fatalf("invalid length: got=%d, want=%d", got, want)
+	}
+
+	var (
+		ms   = s1.Mass(nil)
+		pts  = s1.Pt(make([]float64, 0, 100))
+		etas = s1.Eta(nil)
+		phis = s1.Phi(nil)
+		sum  PxPyPzE
+	)
+	for i, p := range p1 {
+		if got, want := s1.At(i), p; !p4equal(&got, want, epsilon_test) {
+			t.Fatalf("invalid p4[%d]: got=%v, want=%v", i, got, want)
+		}
+		if got, want := ms[i], p.M(); !cmpeq(got, want, epsilon_test) {
+			t.Fatalf("invalid mass[%d]: got=%v, want=%v", i, got, want)
+		}
+		if got, want := pts[i], p.Pt(); !cmpeq(got, want, epsilon_test) {
+			t.Fatalf("invalid pt[%d]: got=%v, want=%v", i, got, want)
+		}
+		if got, want := etas[i], p.Eta(); !cmpeq(got, want, epsilon_test) {
+			t.Fatalf("invalid eta[%d]: got=%v, want=%v", i, got, want)
+		}
+		if got, want := phis[i], p.Phi(); !cmpeq(got, want, epsilon_test) {
+			t.Fatalf("invalid phi[%d]: got=%v, want=%v", i, got, want)
+		}
+		IAdd(&sum, p)
+	}
+	if got, want := s1.Sum(), sum; !p4equal(&got, &want, epsilon_test) {
+		t.Fatalf("invalid sum: got=%v, want=%v", got, want)
+	}
+
+	drs := s1.DeltaR(nil, s2)
+	if got, want := len(drs), s1.Len()*s2.Len(); got != want {
+		t.Fatalf("invalid delta-R matrix size: got=%d, want=%d", got, want)
+	}
+	for i := range p1 {
+		for j := range p2 {
+			if got, want := drs[i*len(p2)+j], DeltaR(p1[i], p2[j]); !cmpeq(got, want, epsilon_test) {
+				t.Fatalf("invalid delta-R[%d,%d]: got=%v, want=%v", i, j, got, want)
+			}
+		}
+	}
+
+	self := s2.DeltaR(nil, s2)
+	for i := range p2 {
+		if got := self[i*len(p2)+i]; got != 0 {
+			t.Fatalf("invalid delta-R[%d,%d]: got=%v, want=0", i, i, got)
+		}
+	}
+}
+
+func TestP4SliceAdd(t *testing.T) {
+	var (
+		rnd = rand.New(rand.NewSource(1234))
+		p1  = genP4s(rnd, 10)
+		p2  = genP4s(rnd, 10)
+		s1  = NewP4SliceFrom(p1)
+		s2  = NewP4SliceFrom(p2)
+	)
+	s1.Add(s2)
+	for i := range p1 {
+		got := s1.At(i)
+		want := Add(p1[i], p2[i])
+		if !p4equal(&got, want, epsilon_test) {
+			t.Fatalf("invalid sum[%d]: got=%v, want=%v", i, got, want)
+		}
+	}
+
+	func() {
+		defer func() {
+			if e := recover(); e == nil {
+				t.Fatalf("expected a panic")
+			}
+		}()
+		s1.Add(NewP4Slice(2))
+	}()
+
+	var s3 P4Slice
+	for _, p := range p1 {
+		s3.Append(p)
+	}
+	if got, want := s3.Len(), len(p1); got != want {
+		t.Fatalf("invalid length: got=%d, want=%d", got, want)
+	}
+}
+
+func TestP4SliceSort(t *testing.T) {
+	var (
+		rnd = rand.New(rand.NewSource(1234))
+		ps  = NewP4SliceFrom(genP4s(rnd, 100))
+		sum = ps.Sum()
+	)
+
+	ps.SortByPt()
+	pts := ps.Pt(nil)
+	for i := 1; i < len(pts); i++ {
+		if pts[i-1] < pts[i] {
+			t.Fatalf("slice not sorted by pt: pt[%d]=%v < pt[%d]=%v", i-1, pts[i-1], i, pts[i])
+		}
+	}
+	if got := ps.Sum(); !p4equal(&got, &sum, epsilon_test) {
+		t.Fatalf("sort modified the content of the slice")
+	}
+
+	ps.SortByE()
+	for i := 1; i < ps.Len(); i++ {
+		if ps.E[i-1] < ps.E[i] {
+			t.Fatalf("slice not sorted by energy: e[%d]=%v < e[%d]=%v", i-1, ps.E[i-1], i, ps.E[i])
+		}
+	}
+}
+
+func BenchmarkDeltaRP4(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1234))
+	ps := genP4s(rnd, 1000)
+	drs := make([]float64, len(ps)*len(ps))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, p1 := range ps {
+			for k, p2 := range ps {
+				drs[j*len(ps)+k] = DeltaR(p1, p2)
+			}
+		}
+	}
+}
+
+func BenchmarkDeltaRP4Slice(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1234))
+	ps := NewP4SliceFrom(genP4s(rnd, 1000))
+	drs := make([]float64, ps.Len()*ps.Len())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		drs = ps.DeltaR(drs, ps)
+	}
+}
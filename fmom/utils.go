@@ -46,3 +46,91 @@ func CosTheta(p1, p2 P4) float64 {
 	cosTh := dot / (mag1 * mag2)
 	return cosTh
 }
+
+// DeltaRapidity returns the delta rapidity between two P4
+func DeltaRapidity(p1, p2 P4) float64 {
+	return p1.Rapidity() - p2.Rapidity()
+}
+
+// InvMass returns the invariant mass of the sum of the given 4-vectors.
+func InvMass(ps ...P4) float64 {
+	var sum PxPyPzE
+	for _, p := range ps {
+		sum[0] += p.Px()
+		sum[1] += p.Py()
+		sum[2] += p.Pz()
+		sum[3] += p.E()
+	}
+	return sum.M()
+}
+
+// TransverseMass returns the transverse mass of the system made of
+// the given 4-vectors, mT^2 = (sum_i Et_i)^2 - |sum_i pT_i|^2,
+// where Et_i = sqrt(m_i^2 + pT_i^2).
+//
+// For a lepton and a missing transverse momentum, this is the usual
+// W transverse mass.
+func TransverseMass(ps ...P4) float64 {
+	var et, px, py float64
+	for _, p := range ps {
+		pt2 := p.Px()*p.Px() + p.Py()*p.Py()
+		et += math.Sqrt(math.Max(p.M2(), 0) + pt2)
+		px += p.Px()
+		py += p.Py()
+	}
+	mt2 := et*et - px*px - py*py
+	if mt2 < 0 {
+		return -math.Sqrt(-mt2)
+	}
+	return math.Sqrt(mt2)
+}
+
+// CosThetaHelicity returns the cosine of the helicity angle of the
+// daughter d of the parent particle p.
+//
+// The helicity angle is the angle between the momentum of d in the rest
+// frame of p and the direction of flight of p.
+func CosThetaHelicity(p, d P4) float64 {
+	rd := RestFrame(d, p)
+	return CosTheta(rd, p)
+}
+
+// CollinsSoper returns the cosine of the polar angle and the azimuthal
+// angle of the particle l1 in the Collins-Soper frame of the di-particle
+// system l1+l2.
+//
+// The Collins-Soper frame is the rest frame of l1+l2 where the z-axis
+// bisects the angle between the momentum of the first beam and the
+// opposite of the momentum of the second beam, the beams being along
+// the +z and -z directions of the laboratory frame.
+// The x-axis lies in the plane of the beams, along the transverse momentum
+// of l1+l2.
+// The direction of the z-axis is flipped when the longitudinal momentum of
+// the di-particle system is negative.
+func CollinsSoper(l1, l2 P4) (cosTheta, phi float64) {
+	var (
+		q  = NewPxPyPzE(l1.Px()+l2.Px(), l1.Py()+l2.Py(), l1.Pz()+l2.Pz(), l1.E()+l2.E())
+		b1 = NewPxPyPzE(0, 0, +1, 1)
+		b2 = NewPxPyPzE(0, 0, -1, 1)
+	)
+	if q.Pz() < 0 {
+		b1, b2 = b2, b1
+	}
+
+	var (
+		u1 = Vect(RestFrame(&b1, &q)).Unit()
+		u2 = Vect(RestFrame(&b2, &q)).Unit()
+		z  = u1.Sub(u2).Unit()
+		x  = u1.Add(u2).Scale(-1).Unit()
+		y  = z.Cross(x)
+		l  = Vect(RestFrame(l1, &q)).Unit()
+	)
+
+	cosTheta = l.Dot(z)
+	if q.Px() == 0 && q.Py() == 0 {
+		// no transverse momentum: the x-axis is ill-defined.
+		return cosTheta, 0
+	}
+	phi = math.Atan2(l.Dot(y), l.Dot(x))
+	return cosTheta, phi
+}
@@ -323,3 +323,106 @@ func TestCosTheta(t *testing.T) {
 		}
 	}
 }
+
+func TestInvMass(t *testing.T) {
+	var (
+		p1 = newPxPyPzE(NewPxPyPzE(+30, 0, +10, math.Sqrt(1000)))
+		p2 = newPtEtaPhiM(NewPxPyPzE(-30, 0, +10, math.Sqrt(1000)))
+	)
+	if got, want := InvMass(p1, p2), Add(p1, p2).M(); !cmpeq(got, want, epsilon_test) {
+		t.Fatalf("invalid inv-mass: got=%v, want=%v", got, want)
+	}
+	if got, want := InvMass(p1, p2), 60.0; !cmpeq(got, want, epsilon_test) {
+		t.Fatalf("invalid inv-mass: got=%v, want=%v", got, want)
+	}
+	if got, want := InvMass(p1), p1.M(); !cmpeq(got, want, epsilon_test) {
+		t.Fatalf("invalid inv-mass: got=%v, want=%v", got, want)
+	}
+}
+
+func TestTransverseMass(t *testing.T) {
+	var (
+		lep = newPxPyPzE(NewPxPyPzE(40, 0, 25, math.Sqrt(40*40+25*25)))
+		met = newPxPyPzE(NewPxPyPzE(-40, 0, 0, 40))
+	)
+	if got, want := TransverseMass(lep, met), 80.0; !cmpeq(got, want, epsilon_test) {
+		t.Fatalf("invalid mT: got=%v, want=%v", got, want)
+	}
+
+	met = newPxPyPzE(NewPxPyPzE(0, 40, 0, 40))
+	if got, want := TransverseMass(lep, met), math.Sqrt(2*40*40); !cmpeq(got, want, epsilon_test) {
+		t.Fatalf("invalid mT: got=%v, want=%v", got, want)
+	}
+}
+
+func TestCosThetaHelicity(t *testing.T) {
+	// a particle at rest decaying into two back-to-back daughters
+	// along the x-axis, then boosted along the x-axis.
+	var (
+		d1 = newPxPyPzE(NewPxPyPzE(+3, 0, 0, 5))
+		d2 = newPxPyPzE(NewPxPyPzE(-3, 0, 0, 5))
+		b  = Vec3{0.5, 0, 0}
+		p  = Add(Boost(d1, b), Boost(d2, b))
+	)
+	if got, want := CosThetaHelicity(p, Boost(d1, b)), +1.0; !cmpeq(got, want, epsilon_test) {
+		t.Fatalf("invalid cos-theta: got=%v, want=%v", got, want)
+	}
+	if got, want := CosThetaHelicity(p, Boost(d2, b)), -1.0; !cmpeq(got, want, epsilon_test) {
+		t.Fatalf("invalid cos-theta: got=%v, want=%v", got, want)
+	}
+}
+
+func TestCollinsSoper(t *testing.T) {
+	// analytic formula for cos(theta_CS).
+	cosCS := func(l1, l2 P4) float64 {
+		var (
+			sq2  = math.Sqrt2
+			p1p  = (l1.E() + l1.Pz()) / sq2
+			p1m  = (l1.E() - l1.Pz()) / sq2
+			p2p  = (l2.E() + l2.Pz()) / sq2
+			p2m  = (l2.E() - l2.Pz()) / sq2
+			q    = Add(l1, l2)
+			m2   = q.M2()
+			qt2  = q.Px()*q.Px() + q.Py()*q.Py()
+			sign = 1.0
+		)
+		if q.Pz() < 0 {
+			sign = -1
+		}
+		return sign * 2 * (p1p*p2m - p1m*p2p) / math.Sqrt(m2*(m2+qt2))
+	}
+
+	for _, tc := range []struct {
+		l1, l2 P4
+	}{
+		{
+			l1: newPxPyPzE(NewPxPyPzE(10, 20, 30, math.Sqrt(100+400+900))),
+			l2: newPxPyPzE(NewPxPyPzE(-15, 5, -40, math.Sqrt(225+25+1600))),
+		},
+		{
+			l1: newPxPyPzE(NewPxPyPzE(30, -20, -50, math.Sqrt(900+400+2500))),
+			l2: newPxPyPzE(NewPxPyPzE(-5, 25, -10, math.Sqrt(25+625+100))),
+		},
+		{
+			l1: newPxPyPzE(NewPxPyPzE(0, 40, 0, 40)),
+			l2: newPxPyPzE(NewPxPyPzE(0, -40, 0, 40)),
+		},
+	} {
+		cos, phi := CollinsSoper(tc.l1, tc.l2)
+		if got, want := cos, cosCS(tc.l1, tc.l2); !cmpeq(got, want, epsilon_test) {
+			t.Fatalf("invalid cos-theta-CS: got=%v, want=%v", got, want)
+		}
+		if phi < -math.Pi || phi > math.Pi {
+			t.Fatalf("invalid phi-CS: %v", phi)
+		}
+
+		// the angles of the second particle are opposite.
+		cos2, phi2 := CollinsSoper(tc.l2, tc.l1)
+		if !cmpeq(cos2, -cos, epsilon_test) {
+			t.Fatalf("invalid cos-theta-CS for l2: got=%v, want=%v", cos2, -cos)
+		}
+		if dphi := math.Abs(math.Remainder(phi2-phi, twopi)); !cmpeq(dphi, math.Pi, epsilon_test) && tc.l1.Px()+tc.l2.Px() != 0 {
+			t.Fatalf("invalid phi-CS for l2: got=%v, phi=%v", phi2, phi)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build go1.16
+
+package fwk
+
+import (
+	"runtime/metrics"
+)
+
+// readAllocs returns the cumulative number of heap objects and bytes
+// allocated by the process.
+func readAllocs() (objs, bytes uint64) {
+	samples := []metrics.Sample{
+		{Name: "/gc/heap/allocs:objects"},
+		{Name: "/gc/heap/allocs:bytes"},
+	}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		objs = samples[0].Value.Uint64()
+	}
+	if samples[1].Value.Kind() == metrics.KindUint64 {
+		bytes = samples[1].Value.Uint64()
+	}
+	return objs, bytes
+}
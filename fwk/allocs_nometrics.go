@@ -0,0 +1,19 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !go1.16
+
+package fwk
+
+import (
+	"runtime"
+)
+
+// readAllocs returns the cumulative number of heap objects and bytes
+// allocated by the process.
+func readAllocs() (objs, bytes uint64) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return mem.Mallocs, mem.TotalAlloc
+}
@@ -5,8 +5,10 @@
 package fwk
 
 import (
+	"fmt"
 	"io"
 	"math"
+	"os"
 	"reflect"
 	"runtime"
 	"sort"
@@ -27,10 +29,30 @@ type appmgr struct {
 
 	evtmax int64
 	nprocs int
+	ntasks int
+	paths  []Path
+
+	ckpt      string        // name of the checkpoint file
+	ckptEvery int64         // number of input events between checkpoints
+	resume    bool          // whether to resume from the checkpoint file
+	ckptr     *checkpointer // saves and restores the checkpoints
+	skip      int64         // number of input events processed by a previous job
+
+	workers int      // number of local worker processes
+	hosts   []string // addresses of the remote worker agents
+	workdir string   // directory of the worker processes
+	wcmd    []string // command line of the worker processes
+	first   int64    // index of the first input event processed by this worker
+
+	msgLevels map[string]Level // message levels, by component name
+	msgFormat string           // format of the messages ("text" or "json")
+	msgFile   string           // name of the log file
+	msgLimit  int              // maximum number of times a message is displayed
 
 	comps   map[string]Component
 	tsks    []Task
 	svcs    []Svc
+	clones  map[string][]Task // clones of the non re-entrant tasks, for the event slots 1, 2, ...
 	istream Task
 	ctxs    [2][]context
 }
@@ -56,11 +78,15 @@ func NewApp() App {
 			//LvlError,
 			nil,
 		),
-		evtmax: -1,
-		nprocs: -1,
-		comps:  make(map[string]Component),
-		tsks:   make([]Task, 0),
-		svcs:   make([]Svc, 0),
+		evtmax:    -1,
+		nprocs:    -1,
+		ntasks:    -1,
+		ckptEvery: 1000,
+		workdir:   "fwk-workers",
+		comps:     make(map[string]Component),
+		tsks:      make([]Task, 0),
+		svcs:      make([]Svc, 0),
+		clones:    make(map[string][]Task),
 	}
 
 	svc, err := app.New("go-hep.org/x/hep/fwk.datastore", "evtstore")
@@ -70,34 +96,76 @@ func NewApp() App {
 	}
 	app.store = svc.(*datastore)
 
-	err = app.AddSvc(app.store)
+	svc, err = app.New("go-hep.org/x/hep/fwk.dflowsvc", "dataflow")
 	if err != nil {
-		app.msg.Errorf("fwk.NewApp: could not create evtstore: %v\n", err)
+		app.msg.Errorf("fwk.NewApp: could not create dataflow svc: %v\n", err)
 		return nil
 	}
+	app.dflow = svc.(*dflowsvc)
 
-	svc, err = app.New("go-hep.org/x/hep/fwk.dflowsvc", "dataflow")
+	err = app.DeclProp(app, "EvtMax", &app.evtmax)
 	if err != nil {
-		app.msg.Errorf("fwk.NewApp: could not create dataflow svc: %v\n", err)
+		app.msg.Errorf("fwk.NewApp: could not declare property 'EvtMax': %v\n", err)
 		return nil
 	}
-	app.dflow = svc.(*dflowsvc)
 
-	err = app.AddSvc(app.dflow)
+	err = app.DeclProp(app, "NProcs", &app.nprocs)
 	if err != nil {
-		app.msg.Errorf("fwk.NewApp: could not create dataflow svc: %v\n", err)
+		app.msg.Errorf("fwk.NewApp: could not declare property 'NProcs': %v\n", err)
 		return nil
 	}
 
-	err = app.DeclProp(app, "EvtMax", &app.evtmax)
+	err = app.DeclProp(app, "NTasks", &app.ntasks)
 	if err != nil {
-		app.msg.Errorf("fwk.NewApp: could not declare property 'EvtMax': %v\n", err)
+		app.msg.Errorf("fwk.NewApp: could not declare property 'NTasks': %v\n", err)
 		return nil
 	}
 
-	err = app.DeclProp(app, "NProcs", &app.nprocs)
+	err = app.DeclProp(app, "Paths", &app.paths)
 	if err != nil {
-		app.msg.Errorf("fwk.NewApp: could not declare property 'NProcs': %v\n", err)
+		app.msg.Errorf("fwk.NewApp: could not declare property 'Paths': %v\n", err)
+		return nil
+	}
+
+	err = app.DeclProp(app, "Checkpoint", &app.ckpt)
+	if err != nil {
+		app.msg.Errorf("fwk.NewApp: could not declare property 'Checkpoint': %v\n", err)
+		return nil
+	}
+
+	err = app.DeclProp(app, "CheckpointEvery", &app.ckptEvery)
+	if err != nil {
+		app.msg.Errorf("fwk.NewApp: could not declare property 'CheckpointEvery': %v\n", err)
+		return nil
+	}
+
+	err = app.DeclProp(app, "Resume", &app.resume)
+	if err != nil {
+		app.msg.Errorf("fwk.NewApp: could not declare property 'Resume': %v\n", err)
+		return nil
+	}
+
+	err = app.DeclProp(app, "Workers", &app.workers)
+	if err != nil {
+		app.msg.Errorf("fwk.NewApp: could not declare property 'Workers': %v\n", err)
+		return nil
+	}
+
+	err = app.DeclProp(app, "Hosts", &app.hosts)
+	if err != nil {
+		app.msg.Errorf("fwk.NewApp: could not declare property 'Hosts': %v\n", err)
+		return nil
+	}
+
+	err = app.DeclProp(app, "WorkDir", &app.workdir)
+	if err != nil {
+		app.msg.Errorf("fwk.NewApp: could not declare property 'WorkDir': %v\n", err)
+		return nil
+	}
+
+	err = app.DeclProp(app, "WorkerCmd", &app.wcmd)
+	if err != nil {
+		app.msg.Errorf("fwk.NewApp: could not declare property 'WorkerCmd': %v\n", err)
 		return nil
 	}
 
@@ -107,6 +175,30 @@ func NewApp() App {
 		return nil
 	}
 
+	err = app.DeclProp(app, "MsgLevels", &app.msgLevels)
+	if err != nil {
+		app.msg.Errorf("fwk.NewApp: could not declare property 'MsgLevels': %v\n", err)
+		return nil
+	}
+
+	err = app.DeclProp(app, "MsgFormat", &app.msgFormat)
+	if err != nil {
+		app.msg.Errorf("fwk.NewApp: could not declare property 'MsgFormat': %v\n", err)
+		return nil
+	}
+
+	err = app.DeclProp(app, "MsgFile", &app.msgFile)
+	if err != nil {
+		app.msg.Errorf("fwk.NewApp: could not declare property 'MsgFile': %v\n", err)
+		return nil
+	}
+
+	err = app.DeclProp(app, "MsgRateLimit", &app.msgLimit)
+	if err != nil {
+		app.msg.Errorf("fwk.NewApp: could not declare property 'MsgRateLimit': %v\n", err)
+		return nil
+	}
+
 	return app
 }
 
@@ -345,9 +437,10 @@ func (app *appmgr) Run() error {
 		id:    0,
 		slot:  0,
 		store: nil,
-		msg:   newMsgStream("<root>", app.msg.lvl, nil),
+		msg:   app.msgstream("<root>"),
 		mgr:   app,
 	}
+	defer app.msg.log.close(app.msg)
 
 	start := time.Now()
 	var mstart runtime.MemStats
@@ -418,6 +511,18 @@ func (app *appmgr) configure(ctx Context) error {
 	app.msg.Debugf("configure...\n")
 	app.state = fsm.Configuring
 
+	if env := os.Getenv(workerEnv); env != "" {
+		err = app.setupWorker(env)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = app.setupMsg()
+	if err != nil {
+		return err
+	}
+
 	if app.evtmax == -1 {
 		app.evtmax = math.MaxInt64
 	}
@@ -426,13 +531,21 @@ func (app *appmgr) configure(ctx Context) error {
 		app.nprocs = runtime.NumCPU()
 	}
 
+	if app.ntasks <= 0 {
+		app.ntasks = runtime.NumCPU()
+	}
+
+	if app.resume && app.ckpt == "" {
+		return Errorf("fwk: can not resume without a 'Checkpoint' file")
+	}
+
 	tsks := make([]context, len(app.tsks))
 	for j, tsk := range app.tsks {
 		tsks[j] = context{
 			id:    -1,
 			slot:  0,
 			store: app.store,
-			msg:   newMsgStream(tsk.Name(), app.msg.lvl, nil),
+			msg:   app.msgstream(tsk.Name()),
 			mgr:   app,
 		}
 	}
@@ -443,7 +556,7 @@ func (app *appmgr) configure(ctx Context) error {
 			id:    -1,
 			slot:  0,
 			store: app.store,
-			msg:   newMsgStream(svc.Name(), app.msg.lvl, nil),
+			msg:   app.msgstream(svc.Name()),
 			mgr:   app,
 		}
 	}
@@ -504,15 +617,75 @@ func (app *appmgr) start(ctx Context) error {
 		}
 	}
 
+	err = app.startClones()
+	if err != nil {
+		return err
+	}
+
+	app.ckptr = newCheckpointer(app)
+	app.skip = app.first
+	if app.resume {
+		n, err := app.ckptr.restore()
+		if err != nil {
+			return err
+		}
+		if n > app.skip {
+			app.skip = n
+		}
+	}
+
 	app.state = fsm.Started
 	return err
 }
 
+// startClones clones and starts the non re-entrant tasks implementing
+// TaskCloner, once for each additional event slot.
+func (app *appmgr) startClones() error {
+	for _, tsk := range app.tsks {
+		if r, ok := tsk.(Reentrant); !ok || r.Reentrant() {
+			continue
+		}
+		cloner, ok := tsk.(TaskCloner)
+		if !ok {
+			continue
+		}
+		clones := make([]Task, 0, app.nprocs)
+		for slot := 1; slot < app.nprocs; slot++ {
+			app.msg.Debugf("cloning [%s] for slot %d...\n", tsk.Name(), slot)
+			clone, err := cloner.CloneTask(slot)
+			if err != nil {
+				return err
+			}
+			err = clone.StartTask(app.cloneContext(clone, slot))
+			if err != nil {
+				return err
+			}
+			clones = append(clones, clone)
+		}
+		app.clones[tsk.Name()] = clones
+	}
+	return nil
+}
+
+func (app *appmgr) cloneContext(clone Task, slot int) context {
+	return context{
+		id:    -1,
+		slot:  slot,
+		store: app.store,
+		msg:   app.msgstream(clone.Name()),
+		mgr:   app,
+	}
+}
+
 func (app *appmgr) run(ctx Context) error {
 	var err error
 	defer app.msg.flush()
 	app.state = fsm.Running
 
+	if app.distributed() {
+		return app.runDistributed(ctx)
+	}
+
 	maxprocs := runtime.GOMAXPROCS(app.nprocs)
 
 	switch app.nprocs {
@@ -533,72 +706,102 @@ func (app *appmgr) runSequential(ctx Context) error {
 	runctx, runCancel := nctx.WithCancel(nctx.Background())
 	defer runCancel()
 
+	ictrl, err := app.startInputStream()
+	if err != nil {
+		return err
+	}
+	defer close(ictrl.Quit)
+
+	// the input stream is not part of the tasks anymore.
 	keys := app.dflow.keys()
-	ctxs := make([]context, len(app.tsks))
 	store := *app.store
+	ictx := context{
+		id:    -1,
+		slot:  0,
+		store: &store,
+		msg:   app.msgstream(app.istream.Name()),
+		mgr:   app,
+	}
+	ctxs := make([]context, len(app.tsks))
 	for j, tsk := range app.tsks {
 		ctxs[j] = context{
 			id:    -1,
 			slot:  0,
 			store: &store,
-			msg:   newMsgStream(tsk.Name(), app.msg.lvl, nil),
+			msg:   app.msgstream(tsk.Name()),
 			mgr:   app,
 		}
 	}
 
-	ictrl, err := app.startInputStream()
+	octrl, err := app.startOutputStreams()
 	if err != nil {
 		return err
 	}
-	defer close(ictrl.Quit)
 
-	octrl, err := app.startOutputStreams()
+	defer close(octrl.Quit)
+
+	sched, err := newScheduler(app, 1, app.ntasks)
 	if err != nil {
 		return err
 	}
+	tr := newTransitions(app)
 
-	defer close(octrl.Quit)
+	ievt, err := app.seek()
+	if err != nil {
+		return err
+	}
+	for ; ievt < app.evtmax; ievt++ {
+		err = func() error {
+			evtctx, evtCancel := nctx.WithCancel(runctx)
+			defer evtCancel()
 
-	for ievt := int64(0); ievt < app.evtmax; ievt++ {
-		evtctx, evtCancel := nctx.WithCancel(runctx)
+			app.msg.Infof(">>> running evt=%d...\n", ievt)
+			err := store.reset(keys)
+			if err != nil {
+				return err
+			}
+			defer store.close()
 
-		app.msg.Infof(">>> running evt=%d...\n", ievt)
-		err = store.reset(keys)
-		if err != nil {
-			return err
-		}
-		err = app.istream.Process(ctxs[0])
-		if err != nil {
-			store.close()
-			app.msg.flush()
-			return err
-		}
-		run := taskrunner{
-			ievt:   ievt,
-			errc:   make(chan error, len(app.tsks)),
-			evtctx: evtctx,
-		}
-		for i, tsk := range app.tsks {
-			go run.run(i, ctxs[i], tsk)
-		}
-		ndone := 0
-	errloop:
-		for err = range run.errc {
-			ndone++
+			err = app.istream.Process(ictx)
 			if err != nil {
-				evtCancel()
-				store.close()
 				app.msg.flush()
+				if err == io.EOF {
+					if e := tr.end(); e != nil {
+						return e
+					}
+					if e := app.ckptr.save(ievt); e != nil {
+						return e
+					}
+				}
 				return err
 			}
-			if ndone == len(app.tsks) {
-				break errloop
+			if ievt < app.skip {
+				// already processed by the checkpointed job.
+				return nil
+			}
+			defer app.msg.flush()
+			if app.ckptr.due(ievt) {
+				err = app.ckptr.save(ievt)
+				if err != nil {
+					return err
+				}
+			}
+			err = tr.next(tr.eventID())
+			if err != nil {
+				return err
 			}
+			return sched.run(evtctx, 0, ievt, ctxs, &store)
+		}()
+		if err != nil {
+			return err
 		}
-		store.close()
-		app.msg.flush()
 	}
 
+	err = tr.end()
+	if err != nil {
+		return err
+	}
+	err = app.ckptr.save(ievt)
 	return err
 }
 
@@ -611,8 +814,10 @@ func (app *appmgr) runConcurrent(ctx Context) error {
 	ctrl := workercontrol{
 		evts:   make(chan context, 2*app.nprocs),
 		done:   make(chan struct{}),
-		errc:   make(chan error),
+		fed:    make(chan struct{}),
+		errc:   make(chan error, app.nprocs+1), // one error per worker, one for the input
 		runctx: runctx,
+		cancel: runCancel,
 	}
 
 	istream, err := app.startInputStream()
@@ -627,75 +832,173 @@ func (app *appmgr) runConcurrent(ctx Context) error {
 	}
 	defer close(ostream.Quit)
 
+	ctrl.sched, err = newScheduler(app, app.nprocs, app.ntasks)
+	if err != nil {
+		return err
+	}
+
 	workers := make([]worker, app.nprocs)
 	for i := 0; i < app.nprocs; i++ {
 		workers[i] = *newWorker(i, app, &ctrl)
 	}
 
 	go func() {
-		keys := app.dflow.keys()
-		msg := newMsgStream(app.istream.Name(), app.msg.lvl, nil)
-		for ievt := int64(0); ievt < app.evtmax; ievt++ {
-			evtctx, evtCancel := nctx.WithCancel(runctx)
-			store := *app.store
-			store.store = make(map[string]achan, len(keys))
-			err := store.reset(keys)
+		err := app.feed(&ctrl)
+		if err != nil {
+			ctrl.fail(err)
+		}
+		close(ctrl.evts)
+		close(ctrl.fed)
+	}()
+
+	for ndone := 1; ndone <= len(workers); ndone++ {
+		<-ctrl.done
+		app.msg.Infof("workers done: %d/%d\n", ndone, app.nprocs)
+	}
+
+	// the input stream must not be used anymore once we return.
+	<-ctrl.fed
+
+	close(ctrl.errc)
+	for eworker := range ctrl.errc {
+		if err == nil {
+			// only record first error.
+			// FIXME(sbinet) record all of them (errstack)
+			err = eworker
+		}
+	}
+
+	return err
+}
+
+// feed reads the events from the input stream and sends them to the workers,
+// until the end of the input or the first error.
+func (app *appmgr) feed(ctrl *workercontrol) error {
+	keys := app.dflow.keys()
+	msg := app.msgstream(app.istream.Name())
+	tr := newTransitions(app)
+
+	// wait waits for the events sent to the workers and reports whether
+	// the event loop may go on.
+	wait := func() bool {
+		ctrl.inflight.Wait()
+		return !ctrl.stopped()
+	}
+
+	ievt, err := app.seek()
+	if err != nil {
+		return err
+	}
+	for ; ievt < app.evtmax; ievt++ {
+		if ctrl.stopped() {
+			return nil
+		}
+		evtctx, evtCancel := nctx.WithCancel(ctrl.runctx)
+		store := *app.store
+		store.store = make(map[string]achan, len(keys))
+		err := store.reset(keys)
+		if err != nil {
+			evtCancel()
+			return err
+		}
+		ctx := context{
+			id:    ievt,
+			slot:  0,
+			store: &store,
+			msg:   msg,
+			mgr:   nil, // nobody's supposed to access mgr's state during event-loop
+			ctx:   evtctx,
+		}
+
+		err = app.istream.Process(ctx)
+		if err != nil {
+			evtCancel()
+			if err != io.EOF {
+				return err
+			}
+			break
+		}
+		if ievt < app.skip {
+			// already processed by the checkpointed job.
+			evtCancel()
+			err = store.reset(keys)
 			if err != nil {
-				evtCancel()
-				close(ctrl.evts)
-				ctrl.errc <- err
-				return
+				return err
 			}
-			ctx := context{
-				id:    ievt,
-				slot:  0,
-				store: &store,
-				msg:   msg,
-				mgr:   nil, // nobody's supposed to access mgr's state during event-loop
-				ctx:   evtctx,
+			continue
+		}
+		if app.ckptr.due(ievt) {
+			if !wait() {
+				evtCancel()
+				return nil
 			}
-
-			err = app.istream.Process(ctx)
+			err = app.ckptr.save(ievt)
 			if err != nil {
-				if err != io.EOF {
-					evtCancel()
-					ctrl.errc <- err
-				}
-				close(ctrl.evts)
-				return
+				evtCancel()
+				return err
 			}
-			ctrl.evts <- ctx
 		}
-		close(ctrl.evts)
-	}()
 
-	ndone := 0
-ctrl:
-	for {
-		select {
-		case eworker, ok := <-ctrl.errc:
-			if !ok {
-				continue
-			}
-			if eworker != nil && err == nil {
-				// only record first error.
-				// FIXME(sbinet) record all of them (errstack)
-				err = eworker
+		// events of the previous run or luminosity block
+		// must be processed before the transition.
+		if id := tr.eventID(); tr.changes(id) {
+			if !wait() {
+				evtCancel()
+				return nil
 			}
-
-		case <-runctx.Done():
-			return runctx.Err()
-
-		case <-ctrl.done:
-			ndone++
-			app.msg.Infof("workers done: %d/%d\n", ndone, app.nprocs)
-			if ndone == len(workers) {
-				break ctrl
+			err = tr.next(id)
+			if err != nil {
+				evtCancel()
+				return err
 			}
 		}
+		if mon := ctrl.sched.mon; mon != nil {
+			mon.MonQueue(len(ctrl.evts), cap(ctrl.evts))
+		}
+		ctrl.inflight.Add(1)
+		select {
+		case ctrl.evts <- ctx:
+		case <-ctrl.runctx.Done():
+			ctrl.inflight.Done()
+			evtCancel()
+			store.close()
+			return nil
+		}
 	}
 
-	return err
+	if !wait() {
+		return nil
+	}
+	err = tr.end()
+	if err != nil {
+		return err
+	}
+	return app.ckptr.save(ievt)
+}
+
+// seek skips the input events processed by a previous job or by the other
+// workers of a distributed job and returns the index of the next input
+// event.
+// The events are skipped without being read when the input streamer is an
+// InputSeeker. Otherwise, they are read and discarded by the event loop.
+func (app *appmgr) seek() (int64, error) {
+	if app.skip <= 0 {
+		return 0, nil
+	}
+	in, ok := app.istream.(*InputStream)
+	if !ok {
+		return 0, nil
+	}
+	seeker, ok := in.streamer.(InputSeeker)
+	if !ok {
+		return 0, nil
+	}
+	app.msg.Debugf("seeking input event %d...\n", app.skip)
+	err := seeker.SeekEvent(app.skip)
+	if err != nil {
+		return 0, Errorf("fwk: could not seek input event %d: %v", app.skip, err)
+	}
+	return app.skip, nil
 }
 
 func (app *appmgr) startInputStream() (StreamControl, error) {
@@ -728,6 +1031,10 @@ func (app *appmgr) startInputStream() (StreamControl, error) {
 	case 1:
 		app.istream = inputs[0]
 		app.tsks = append(app.tsks[:idx], app.tsks[idx+1:]...)
+		if len(app.ctxs[0]) > idx {
+			// keep the contexts of the tasks in sync with app.tsks.
+			app.ctxs[0] = append(app.ctxs[0][:idx:idx], app.ctxs[0][idx+1:]...)
+		}
 		err := inputs[0].connect(ctrl)
 		if err != nil {
 			return ctrl, err
@@ -755,7 +1062,13 @@ func (app *appmgr) startOutputStreams() (StreamControl, error) {
 		if !ok {
 			continue
 		}
-		err = in.connect(ctrl)
+		// each output stream gets its own channels, so its events are
+		// written by its own streamer.
+		err = in.connect(StreamControl{
+			Ctx:  make(chan Context),
+			Err:  make(chan error),
+			Quit: ctrl.Quit,
+		})
 		if err != nil {
 			return ctrl, err
 		}
@@ -781,6 +1094,12 @@ func (app *appmgr) stop(ctx Context) error {
 		if err != nil {
 			return err
 		}
+		for j, clone := range app.clones[tsk.Name()] {
+			err = clone.StopTask(app.cloneContext(clone, j+1))
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	for i, svc := range app.svcs {
@@ -813,6 +1132,35 @@ func (app *appmgr) Msg() MsgStream {
 	return app.msg
 }
 
+// msgstream returns a message stream for the component named name, with the
+// message level of that component.
+func (app *appmgr) msgstream(name string) msgstream {
+	lvl, ok := app.msgLevels[name]
+	if !ok {
+		lvl = app.msg.lvl
+	}
+	return msgstream{
+		lvl:  lvl,
+		name: name,
+		n:    fmt.Sprintf("%-20s ", name),
+		log:  app.msg.log,
+	}
+}
+
+// setupMsg configures the backend of the message streams of the application.
+func (app *appmgr) setupMsg() error {
+	err := app.msg.log.setup(app.msgFormat, app.msgLimit, app.msgFile)
+	if err != nil {
+		return err
+	}
+	for name := range app.msgLevels {
+		if name != app.name && !app.HasComponent(name) {
+			app.msg.Warnf("'MsgLevels': no component named [%s]\n", name)
+		}
+	}
+	return nil
+}
+
 func (app *appmgr) printDataFlow() error {
 	var err error
 
@@ -839,6 +1187,11 @@ func init() {
 		reflect.TypeOf(appmgr{}),
 		func(t, name string, mgr App) (Component, error) {
 			app := NewApp().(*appmgr)
+			// properties are declared under the name of the component.
+			app.props[name] = app.props[app.name]
+			if name != app.name {
+				delete(app.props, app.name)
+			}
 			app.name = name
 			return app, nil
 		},
@@ -0,0 +1,237 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fwk
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checkpoint is the content of a checkpoint file.
+type checkpoint struct {
+	Events  int64             // number of input events fully processed
+	States  map[string][]byte // saved states of the Checkpointers, by component name
+	Outputs []string          // absolute names of the files written by the output streams of the chain of resumed jobs
+}
+
+// checkpointer saves and restores the state of the components of an
+// application implementing Checkpointer.
+//
+// Clones of tasks are saved under the name "<task>[<slot>]".
+//
+// checkpointer is not concurrent-safe: it must be used from the goroutine
+// feeding events to the event loop, while no event is being processed.
+type checkpointer struct {
+	app   *appmgr
+	comps map[string]Checkpointer
+	names []string // names of comps, in the order of the application
+	last  int64    // number of input events at the last checkpoint
+	olds  []string // output files written by the jobs this one resumed
+}
+
+// newCheckpointer collects the services, tasks and task clones of app
+// implementing Checkpointer.
+func newCheckpointer(app *appmgr) *checkpointer {
+	cp := &checkpointer{
+		app:   app,
+		comps: make(map[string]Checkpointer),
+	}
+	add := func(name string, c Component) {
+		if ckpt, ok := c.(Checkpointer); ok {
+			cp.comps[name] = ckpt
+			cp.names = append(cp.names, name)
+		}
+	}
+	for _, svc := range app.svcs {
+		add(svc.Name(), svc)
+	}
+	for _, tsk := range app.tsks {
+		add(tsk.Name(), tsk)
+		for i, clone := range app.clones[tsk.Name()] {
+			add(fmt.Sprintf("%s[%d]", tsk.Name(), i+1), clone)
+		}
+	}
+	return cp
+}
+
+// due returns whether a checkpoint must be saved before processing the
+// input event ievt.
+func (cp *checkpointer) due(ievt int64) bool {
+	every := cp.app.ckptEvery
+	if cp.app.ckpt == "" || every <= 0 || ievt <= cp.last {
+		return false
+	}
+	return ievt%every == 0
+}
+
+// save saves the state of the components, after nevts input events have
+// been processed, into the checkpoint file of the application.
+//
+// The previous checkpoint file is only replaced once the new one has been
+// completely written.
+func (cp *checkpointer) save(nevts int64) error {
+	fname := cp.app.ckpt
+	if fname == "" {
+		return nil
+	}
+
+	ckpt := checkpoint{
+		Events:  nevts,
+		States:  make(map[string][]byte, len(cp.comps)),
+		Outputs: append(append([]string(nil), cp.olds...), cp.outputs()...),
+	}
+	for _, name := range cp.names {
+		buf := new(bytes.Buffer)
+		err := cp.comps[name].SaveState(buf)
+		if err != nil {
+			return Errorf("fwk: could not save state of [%s]: %v", name, err)
+		}
+		ckpt.States[name] = buf.Bytes()
+	}
+
+	tmp := fname + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return Errorf("fwk: could not create checkpoint file: %v", err)
+	}
+	defer f.Close()
+
+	err = gob.NewEncoder(f).Encode(ckpt)
+	if err != nil {
+		return Errorf("fwk: could not write checkpoint file: %v", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return Errorf("fwk: could not close checkpoint file: %v", err)
+	}
+
+	err = os.Rename(tmp, fname)
+	if err != nil {
+		return Errorf("fwk: could not rename checkpoint file: %v", err)
+	}
+
+	cp.last = nevts
+	cp.app.msg.Debugf("checkpoint saved after %d events\n", nevts)
+	return nil
+}
+
+// restore restores the state of the components from the checkpoint file
+// of the application and returns the number of input events already
+// processed.
+// restore returns 0 if the checkpoint file does not exist.
+func (cp *checkpointer) restore() (int64, error) {
+	fname := cp.app.ckpt
+	_, err := os.Stat(fname)
+	if os.IsNotExist(err) {
+		cp.app.msg.Infof("no checkpoint [%s] to resume from\n", fname)
+		return 0, nil
+	}
+
+	ckpt, err := cp.load(fname)
+	if err != nil {
+		return 0, err
+	}
+
+	err = cp.checkOutputs(ckpt.Outputs)
+	if err != nil {
+		return 0, err
+	}
+
+	n := ckpt.Events
+	cp.last = n
+	cp.olds = ckpt.Outputs
+	cp.app.msg.Infof("resuming after %d events from checkpoint [%s]\n", n, fname)
+	return n, nil
+}
+
+// load merges the states saved in the checkpoint file fname into the
+// components and returns the content of the checkpoint file.
+//
+// The state of a clone is loaded into the task itself when the task has
+// no clone for that slot.
+func (cp *checkpointer) load(fname string) (checkpoint, error) {
+	var ckpt checkpoint
+	f, err := os.Open(fname)
+	if err != nil {
+		return ckpt, Errorf("fwk: could not open checkpoint file: %v", err)
+	}
+	defer f.Close()
+
+	err = gob.NewDecoder(f).Decode(&ckpt)
+	if err != nil {
+		return ckpt, Errorf("fwk: could not read checkpoint file [%s]: %v", fname, err)
+	}
+
+	for name, state := range ckpt.States {
+		c, ok := cp.comps[name]
+		if !ok {
+			if i := strings.LastIndex(name, "["); i > 0 && strings.HasSuffix(name, "]") {
+				c, ok = cp.comps[name[:i]]
+			}
+		}
+		if !ok {
+			return ckpt, Errorf("fwk: checkpoint [%s]: no component [%s] to restore", fname, name)
+		}
+		err = c.LoadState(bytes.NewReader(state))
+		if err != nil {
+			return ckpt, Errorf("fwk: could not restore state of [%s]: %v", name, err)
+		}
+	}
+
+	return ckpt, nil
+}
+
+// outputs returns the absolute names of the files written by the output
+// streams of the application.
+func (cp *checkpointer) outputs() []string {
+	var names []string
+	for _, tsk := range cp.app.tsks {
+		out, ok := tsk.(*OutputStream)
+		if !ok {
+			continue
+		}
+		f, ok := out.streamer.(OutputFiler)
+		if !ok {
+			continue
+		}
+		for _, name := range f.OutputFiles() {
+			if abs, err := filepath.Abs(name); err == nil {
+				name = abs
+			}
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// checkOutputs checks the output streams of the application do not
+// overwrite the files, named olds, written by the previous jobs of the chain
+// of resumed jobs.
+func (cp *checkpointer) checkOutputs(olds []string) error {
+	written := make(map[string]bool, len(olds))
+	for _, name := range olds {
+		written[name] = true
+	}
+	for _, tsk := range cp.app.tsks {
+		out, ok := tsk.(*OutputStream)
+		if !ok {
+			continue
+		}
+		if _, ok := out.streamer.(OutputFiler); !ok {
+			return Errorf("fwk: can not resume output stream [%s]: streamer %T does not name its output files", out.Name(), out.streamer)
+		}
+	}
+	for _, name := range cp.outputs() {
+		if written[name] {
+			return Errorf("fwk: can not resume: output file [%s] holds the events processed before the checkpoint (name a new output file)", name)
+		}
+	}
+	return nil
+}
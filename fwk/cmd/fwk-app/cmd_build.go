@@ -15,7 +15,7 @@ import (
 func fwk_make_cmd_build() *commander.Command {
 	cmd := &commander.Command{
 		Run:       fwk_run_cmd_build,
-		UsageLine: "build [options] <config.go> [<config2.go> [...]]",
+		UsageLine: "build [options] <config.go|job.toml> [<config2.go> [...]]",
 		Short:     "build a fwk job",
 		Long: `
 build builds a fwk-based job and produces a binary.
@@ -24,6 +24,7 @@ ex:
  $ fwk-app build config.go
  $ fwk-app build config1.go config2.go
  $ fwk-app build ./some-dir
+ $ fwk-app build job.toml
  $ fwk-app build -o=my-binary config.go
 `,
 		Flag: *flag.NewFlagSet("fwk-app-build", flag.ExitOnError),
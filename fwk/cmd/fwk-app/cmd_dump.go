@@ -0,0 +1,84 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/gonuts/commander"
+	"github.com/gonuts/flag"
+	"go-hep.org/x/hep/fwk/utils/builder"
+)
+
+func fwk_make_cmd_dump() *commander.Command {
+	cmd := &commander.Command{
+		Run:       fwk_run_cmd_dump,
+		UsageLine: "dump [options] <config.go|job.toml> [<config2.go> [...]]",
+		Short:     "dump the TOML description of a fwk job",
+		Long: `
+dump builds a fwk-based job and writes its TOML description, once all its
+setup functions and job descriptions have been applied, without running it.
+
+The resulting description can be edited and run with 'fwk-app run job.toml'.
+
+ex:
+ $ fwk-app dump config.go
+ $ fwk-app dump -o=job.toml config1.go config2.go
+ $ fwk-app dump -o=job.toml config.go -- -evtmax=1000 -nprocs=4
+`,
+		Flag: *flag.NewFlagSet("fwk-app-dump", flag.ExitOnError),
+	}
+	cmd.Flag.String("o", "job.toml", "name of the TOML job description to write")
+	return cmd
+}
+
+func fwk_run_cmd_dump(cmd *commander.Command, args []string) error {
+	var err error
+	n := "fwk-app-" + cmd.Name()
+
+	out, err := filepath.Abs(cmd.Flag.Lookup("o").Value.Get().(string))
+	if err != nil {
+		return err
+	}
+
+	subargs := []string{"-dump=" + out}
+	fnames := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "" || arg == "--" {
+			continue
+		}
+		if arg[0] == '-' {
+			subargs = append(subargs, arg)
+			continue
+		}
+		fnames = append(fnames, arg)
+	}
+
+	if len(fnames) <= 0 {
+		return fmt.Errorf("%s: you need to give a list of files or a directory", n)
+	}
+
+	bldr, err := builder.NewBuilder(fnames...)
+	if err != nil {
+		return err
+	}
+	bldr.Name = fmt.Sprintf(".fwk-app-dump-%d", os.Getpid())
+
+	err = bldr.Build()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(bldr.Name)
+
+	sub := exec.Command("./"+bldr.Name, subargs...)
+	sub.Stdout = os.Stdout
+	sub.Stderr = os.Stderr
+	sub.Stdin = os.Stdin
+
+	return sub.Run()
+}
@@ -0,0 +1,133 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/gonuts/commander"
+	"github.com/gonuts/flag"
+	"go-hep.org/x/hep/fwk/utils/builder"
+)
+
+func fwk_make_cmd_graph() *commander.Command {
+	cmd := &commander.Command{
+		Run:       fwk_run_cmd_graph,
+		UsageLine: "graph [options] <config.go|job.toml> [<config2.go> [...]]",
+		Short:     "render the data-flow graph of a fwk job",
+		Long: `
+graph builds a fwk-based job and writes the data-flow graph of its tasks, once
+all its setup functions and job descriptions have been applied.
+
+Tasks are drawn as components and data ports as ellipses, the edges being
+labeled with the type of the data.
+Inputs with no producer, ports with several producers and cycles are drawn in
+red and reported, with the full path of each cycle.
+
+The graph is written in the DOT language, or rendered with the 'dot' command
+of GraphViz when the output file has another extension (e.g. .svg or .png).
+
+With -http, the job is then run while a live view of its data-flow, with the
+timing of each task, is served at the given address.
+
+ex:
+ $ fwk-app graph config.go
+ $ fwk-app graph -o=dataflow.svg config.go job.toml
+ $ fwk-app graph -http=127.0.0.1:8080 config.go -- -evtmax=100000 -nprocs=4
+`,
+		Flag: *flag.NewFlagSet("fwk-app-graph", flag.ExitOnError),
+	}
+	cmd.Flag.String("o", "dataflow.dot", "name of the file where to write the graph")
+	cmd.Flag.String("http", "", "address where to serve the live view of the data-flow while running the job (e.g. 127.0.0.1:8080; the view is not authenticated: use a loopback address)")
+	return cmd
+}
+
+func fwk_run_cmd_graph(cmd *commander.Command, args []string) error {
+	var err error
+	n := "fwk-app-" + cmd.Name()
+
+	out, err := filepath.Abs(cmd.Flag.Lookup("o").Value.Get().(string))
+	if err != nil {
+		return err
+	}
+	addr := cmd.Flag.Lookup("http").Value.Get().(string)
+
+	var subargs []string
+	fnames := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "" || arg == "--" {
+			continue
+		}
+		if arg[0] == '-' {
+			subargs = append(subargs, arg)
+			continue
+		}
+		fnames = append(fnames, arg)
+	}
+
+	if len(fnames) <= 0 {
+		return fmt.Errorf("%s: you need to give a list of files or a directory", n)
+	}
+
+	// the graph is written in the DOT language, then rendered if needed.
+	dot := out
+	format := filepath.Ext(out)
+	render := format != ".dot" && format != ".gv"
+	if render {
+		f, err := ioutil.TempFile("", "fwk-app-graph-")
+		if err != nil {
+			return err
+		}
+		f.Close()
+		dot = f.Name()
+		defer os.Remove(dot)
+	}
+
+	bldr, err := builder.NewBuilder(fnames...)
+	if err != nil {
+		return err
+	}
+	bldr.Name = fmt.Sprintf(".fwk-app-graph-%d", os.Getpid())
+
+	err = bldr.Build()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(bldr.Name)
+
+	run := func(args ...string) error {
+		sub := exec.Command("./"+bldr.Name, append(args, subargs...)...)
+		sub.Stdout = os.Stdout
+		sub.Stderr = os.Stderr
+		sub.Stdin = os.Stdin
+		return sub.Run()
+	}
+
+	// the graph is written, with its inconsistencies, even if it is invalid.
+	errg := run("-graph=" + dot)
+	if fi, err := os.Stat(dot); err != nil || fi.Size() == 0 {
+		return errg
+	}
+
+	if render {
+		err = exec.Command("dot", "-T"+format[1:], "-o", out, dot).Run()
+		if err != nil {
+			return fmt.Errorf("%s: could not render %s with GraphViz: %v", n, out, err)
+		}
+	}
+
+	if errg != nil {
+		return fmt.Errorf("%s: invalid data-flow graph", n)
+	}
+
+	if addr == "" {
+		return nil
+	}
+	return run("-dflow-http=" + addr)
+}
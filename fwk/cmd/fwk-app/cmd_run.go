@@ -18,7 +18,7 @@ import (
 func fwk_make_cmd_run() *commander.Command {
 	cmd := &commander.Command{
 		Run:       fwk_run_cmd_run,
-		UsageLine: "run [options] <config.go> [<config2.go> [...]]",
+		UsageLine: "run [options] <config.go|job.toml> [<config2.go> [...]]",
 		Short:     "run a fwk job",
 		Long: `
 run runs a fwk-based job.
@@ -27,7 +27,15 @@ ex:
  $ fwk-app run config.go
  $ fwk-app run config1.go config2.go
  $ fwk-app run ./some-dir
+ $ fwk-app run job.toml
+ $ fwk-app run config.go job.toml
  $ fwk-app run -l=INFO -nprocs=4 -evtmax=-1 config.go
+ $ fwk-app run -checkpoint=job.ckpt config.go
+ $ fwk-app run -checkpoint=job.ckpt -resume config.go
+ $ fwk-app run -evtmax=100000 -workers=4 config.go
+ $ FWK_DIST_SECRET=xxx fwk-app run -evtmax=100000 -hosts=host1:7070,host2:7070 config.go
+ $ fwk-app run -log-file=job.log -log-format=json config.go
+ $ fwk-app run -dflow-http=127.0.0.1:8080 config.go
 `,
 		Flag: *flag.NewFlagSet("fwk-app-run", flag.ExitOnError),
 	}
@@ -40,6 +48,14 @@ ex:
 	cmd.Flag.Int("evtmax", -1, "number of events to process")
 	cmd.Flag.Int("nprocs", 0, "number of concurrent events to process")
 	cmd.Flag.Bool("cpu-prof", false, "enable CPU profiling")
+	cmd.Flag.String("checkpoint", "", "file where to save checkpoints")
+	cmd.Flag.Int("checkpoint-every", 1000, "number of events between checkpoints")
+	cmd.Flag.Bool("resume", false, "resume from the checkpoint file (events processed after the last checkpoint are written again to the new output files)")
+	cmd.Flag.Int("workers", 0, "number of local worker processes")
+	cmd.Flag.String("hosts", "", "comma-separated list of worker agents (host:port)")
+	cmd.Flag.String("log-file", "", "file where to write all messages (the console only shows warnings, errors and a summary)")
+	cmd.Flag.String("log-format", "text", "format of the messages (text|json)")
+	cmd.Flag.String("dflow-http", "", "address where to serve a live view of the data-flow (e.g. 127.0.0.1:8080; the view is not authenticated: use a loopback address)")
 	return cmd
 }
 
@@ -49,7 +65,7 @@ func fwk_run_cmd_run(cmd *commander.Command, args []string) error {
 	n := "fwk-app-" + cmd.Name()
 
 	subargs := make([]string, 0, len(args))
-	for _, nn := range []string{"l", "evtmax", "nprocs", "cpu-prof"} {
+	for _, nn := range []string{"l", "evtmax", "nprocs", "cpu-prof", "checkpoint", "checkpoint-every", "resume", "workers", "hosts", "log-file", "log-format", "dflow-http"} {
 		val := cmd.Flag.Lookup(nn)
 		if val == nil {
 			continue
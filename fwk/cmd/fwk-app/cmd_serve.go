@@ -0,0 +1,60 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/gonuts/commander"
+	"github.com/gonuts/flag"
+	"go-hep.org/x/hep/fwk/utils/dist"
+)
+
+func fwk_make_cmd_serve() *commander.Command {
+	cmd := &commander.Command{
+		Run:       fwk_run_cmd_serve,
+		UsageLine: "serve [options]",
+		Short:     "serve the workers of distributed fwk jobs",
+		Long: `
+serve runs an agent executing the worker processes of distributed fwk jobs,
+sent by fwk-app run -hosts=... coordinators.
+
+The agent runs, as the user running fwk-app serve, any executable sent by a
+coordinator knowing its secret: the secret, read from the FWK_DIST_SECRET
+environment variable of both the agent and the coordinators, must only be
+shared with trusted users.
+Requests, executables and output files are sent unencrypted: on untrusted
+networks, keep the default loopback address and reach the agent through
+an SSH tunnel.
+
+ex:
+ $ FWK_DIST_SECRET=xxx fwk-app serve
+ $ FWK_DIST_SECRET=xxx fwk-app serve -addr=:7070
+`,
+		Flag: *flag.NewFlagSet("fwk-app-serve", flag.ExitOnError),
+	}
+	cmd.Flag.String("addr", "127.0.0.1:7070", "address to listen on")
+	return cmd
+}
+
+func fwk_run_cmd_serve(cmd *commander.Command, args []string) error {
+	n := "fwk-app-" + cmd.Name()
+	addr := cmd.Flag.Lookup("addr").Value.Get().(string)
+	secret := os.Getenv(dist.SecretEnv)
+	if secret == "" {
+		return fmt.Errorf("%s: no secret in $%s", n, dist.SecretEnv)
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("%s: %v", n, err)
+	}
+	defer l.Close()
+
+	fmt.Printf("%s: listening on %s...\n", n, l.Addr())
+	return dist.Serve(l, secret)
+}
@@ -28,6 +28,9 @@ func init() {
 		Subcommands: []*commander.Command{
 			fwk_make_cmd_run(),
 			fwk_make_cmd_build(),
+			fwk_make_cmd_dump(),
+			fwk_make_cmd_graph(),
+			fwk_make_cmd_serve(),
 		},
 		Flag: *flag.NewFlagSet("fwk-app", flag.ExitOnError),
 	}
@@ -0,0 +1,70 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fwk
+
+import (
+	"fmt"
+	"math"
+)
+
+// IOVTime is a point in the (run, event) space of the intervals of
+// validity of conditions data.
+type IOVTime struct {
+	Run   int64 // run number
+	Event int64 // event number
+}
+
+// Before returns whether t comes before u.
+func (t IOVTime) Before(u IOVTime) bool {
+	return t.Run < u.Run || (t.Run == u.Run && t.Event < u.Event)
+}
+
+func (t IOVTime) String() string {
+	switch {
+	case t == IOVMax:
+		return "inf"
+	case t.Event == 0:
+		return fmt.Sprintf("%d", t.Run)
+	default:
+		return fmt.Sprintf("%d:%d", t.Run, t.Event)
+	}
+}
+
+// IOVMax is the end of open-ended intervals of validity.
+var IOVMax = IOVTime{Run: math.MaxInt64, Event: math.MaxInt64}
+
+// IOV is an interval of validity of conditions data: from Since (included)
+// until Until (excluded).
+type IOV struct {
+	Since IOVTime
+	Until IOVTime
+}
+
+// Contains returns whether t is in the interval of validity.
+func (iov IOV) Contains(t IOVTime) bool {
+	return !t.Before(iov.Since) && t.Before(iov.Until)
+}
+
+func (iov IOV) String() string {
+	return fmt.Sprintf("[%v, %v)", iov.Since, iov.Until)
+}
+
+// CondSvc is the interface of services providing conditions data (e.g.
+// calibration constants.)
+//
+// Conditions data are versioned payloads, identified by a tag (e.g.
+// "ecal/scale"), each valid for an interval of validity.
+// Get may be called concurrently.
+//
+// The fwk/condsvc package provides a CondSvc reading payloads, in JSON or
+// rio, from a directory or a SQLite file.
+// Tasks usually retrieve the payloads they need in their BeginRun.
+type CondSvc interface {
+	Svc
+
+	// Get decodes into ptr the payload of tag valid at t and returns
+	// the interval of validity of that payload.
+	Get(tag string, t IOVTime, ptr interface{}) (IOV, error)
+}
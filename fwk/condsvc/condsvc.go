@@ -0,0 +1,389 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package condsvc provides a fwk.CondSvc serving conditions data (e.g.
+// calibration constants) from a local database: a directory or a SQLite
+// file.
+//
+// Payloads are identified by a tag (e.g. "ecal/scale") and a version.
+// A payload is valid from the (run, event) starting its interval of
+// validity until the start of the next payload of the same tag and
+// version.
+// Payloads are JSON documents or rio streams holding a single record (as
+// written by rio.Writer.WriteValue.)
+//
+// In a directory, the payloads of the version V of a tag are the files:
+//
+//	<dir>/<tag>/v<V>/<run>_<event>.json
+//	<dir>/<tag>/v<V>/<run>_<event>.rio
+//
+// In a SQLite file, they are the rows of the table:
+//
+//	CREATE TABLE conditions (
+//		tag     TEXT    NOT NULL,
+//		version INTEGER NOT NULL,
+//		run     INTEGER NOT NULL, -- start of the interval of validity
+//		event   INTEGER NOT NULL,
+//		format  TEXT    NOT NULL, -- "json" or "rio"
+//		payload BLOB    NOT NULL
+//	);
+//
+// The database is accessed through database/sql: the application must
+// register the driver selected by the 'Driver' property (e.g. "sqlite3",
+// by importing github.com/mattn/go-sqlite3.)
+//
+// The service uses the latest version of each tag, unless the 'Versions'
+// property selects another one.
+// Payloads are immutable: the service keeps the last 'CacheSize' payloads
+// it read in a cache and, at the beginning of each run, refreshes its index
+// of the payloads so payloads added to the database while a job runs are
+// picked up.
+package condsvc // import "go-hep.org/x/hep/fwk/condsvc"
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/rio"
+)
+
+// Payload formats.
+const (
+	fmtJSON = "json"
+	fmtRIO  = "rio"
+)
+
+// payload describes a payload of a tag.
+type payload struct {
+	since  fwk.IOVTime // start of the interval of validity
+	format string      // fmtJSON or fmtRIO
+	ref    string      // location of the payload in the database
+}
+
+// backend is a database of conditions data.
+type backend interface {
+	// versions returns the versions of a tag, sorted.
+	versions(tag string) ([]int64, error)
+
+	// index returns the payloads of a version of a tag, sorted by start
+	// of their interval of validity.
+	index(tag string, version int64) ([]payload, error)
+
+	// load returns the content of a payload.
+	load(tag string, version int64, p payload) ([]byte, error)
+
+	close() error
+}
+
+// folder is the index of the payloads of a tag, for the version in use.
+type folder struct {
+	version  int64
+	payloads []payload
+}
+
+// key identifies a payload in the cache.
+type key struct {
+	tag     string
+	version int64
+	since   fwk.IOVTime
+}
+
+type entry struct {
+	key  key
+	data []byte
+}
+
+type csvc struct {
+	fwk.SvcBase
+
+	source   string           // directory or database file
+	driver   string           // database/sql driver of database files
+	versions map[string]int64 // versions of the tags (default: latest version)
+	csize    int              // maximum number of payloads in the cache
+
+	mu    sync.Mutex
+	db    backend
+	tags  map[string]*folder // index of the tags used so far
+	lru   *list.List         // cached payloads, most recently used first
+	cache map[key]*list.Element
+	nload int // number of payloads loaded from the database
+	nhit  int // number of payloads found in the cache
+}
+
+func (svc *csvc) Configure(ctx fwk.Context) error {
+	var err error
+
+	if svc.source == "" {
+		return fwk.Errorf("%s: no conditions database ('Source' property)", svc.Name())
+	}
+
+	if svc.csize < 1 {
+		return fwk.Errorf("%s: invalid cache size (%d)", svc.Name(), svc.csize)
+	}
+
+	return err
+}
+
+func (svc *csvc) StartSvc(ctx fwk.Context) error {
+	var err error
+
+	fi, err := os.Stat(svc.source)
+	if err != nil {
+		return fwk.Errorf("%s: could not open conditions database: %v", svc.Name(), err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	switch {
+	case fi.IsDir():
+		svc.db = newDirDB(svc.source)
+	default:
+		svc.db, err = newSQLDB(svc.driver, svc.source)
+		if err != nil {
+			return fwk.Errorf("%s: could not open conditions database: %v", svc.Name(), err)
+		}
+	}
+
+	svc.tags = make(map[string]*folder)
+	svc.lru = list.New()
+	svc.cache = make(map[key]*list.Element)
+	svc.nload = 0
+	svc.nhit = 0
+
+	return err
+}
+
+func (svc *csvc) StopSvc(ctx fwk.Context) error {
+	var err error
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	ctx.Msg().Debugf("tags: %d, payloads loaded: %d, cache hits: %d\n",
+		len(svc.tags), svc.nload, svc.nhit,
+	)
+
+	if svc.db != nil {
+		err = svc.db.close()
+		svc.db = nil
+	}
+
+	return err
+}
+
+// BeginRun refreshes the index of the tags used so far.
+func (svc *csvc) BeginRun(ctx fwk.Context, run int64) error {
+	svc.mu.Lock()
+	db := svc.db
+	tags := make([]string, 0, len(svc.tags))
+	for tag := range svc.tags {
+		tags = append(tags, tag)
+	}
+	svc.mu.Unlock()
+
+	for _, tag := range tags {
+		f, err := svc.index(db, tag)
+		if err != nil {
+			return err
+		}
+		svc.mu.Lock()
+		svc.tags[tag] = f
+		svc.mu.Unlock()
+	}
+	return nil
+}
+
+func (svc *csvc) EndRun(ctx fwk.Context, run int64) error {
+	return nil
+}
+
+// Get decodes into ptr the payload of tag valid at t.
+//
+// The database is read without holding the lock of the service, so
+// concurrent calls to Get only wait for each other to update the index of
+// the tags and the cache.
+func (svc *csvc) Get(tag string, t fwk.IOVTime, ptr interface{}) (fwk.IOV, error) {
+	svc.mu.Lock()
+	db := svc.db
+	f, ok := svc.tags[tag]
+	svc.mu.Unlock()
+
+	if db == nil {
+		return fwk.IOV{}, fwk.Errorf("%s: service not started", svc.Name())
+	}
+
+	if !ok {
+		var err error
+		f, err = svc.index(db, tag)
+		if err != nil {
+			return fwk.IOV{}, err
+		}
+		svc.mu.Lock()
+		if cur, dup := svc.tags[tag]; dup {
+			// indexed concurrently.
+			f = cur
+		} else {
+			svc.tags[tag] = f
+		}
+		svc.mu.Unlock()
+	}
+
+	i := sort.Search(len(f.payloads), func(i int) bool {
+		return t.Before(f.payloads[i].since)
+	}) - 1
+	if i < 0 {
+		return fwk.IOV{}, fwk.Errorf("%s: no payload for tag [%s] (version %d) at %v",
+			svc.Name(), tag, f.version, t,
+		)
+	}
+
+	p := f.payloads[i]
+	iov := fwk.IOV{Since: p.since, Until: fwk.IOVMax}
+	if i+1 < len(f.payloads) {
+		iov.Until = f.payloads[i+1].since
+	}
+
+	data, err := svc.load(db, key{tag, f.version, p.since}, p)
+	if err != nil {
+		return iov, err
+	}
+
+	err = decode(p.format, data, ptr)
+	if err != nil {
+		return iov, fwk.Errorf("%s: could not decode payload of tag [%s] (version %d) for %v: %v",
+			svc.Name(), tag, f.version, iov, err,
+		)
+	}
+	return iov, nil
+}
+
+// index indexes the payloads of the version in use of tag, from db.
+func (svc *csvc) index(db backend, tag string) (*folder, error) {
+	version, ok := svc.versions[tag]
+	if !ok {
+		vs, err := db.versions(tag)
+		if err != nil {
+			return nil, fwk.Errorf("%s: %v", svc.Name(), err)
+		}
+		if len(vs) == 0 {
+			return nil, fwk.Errorf("%s: no tag [%s]", svc.Name(), tag)
+		}
+		version = vs[len(vs)-1]
+	}
+
+	payloads, err := db.index(tag, version)
+	if err != nil {
+		return nil, fwk.Errorf("%s: %v", svc.Name(), err)
+	}
+	if len(payloads) == 0 {
+		return nil, fwk.Errorf("%s: no payload for tag [%s] (version %d)", svc.Name(), tag, version)
+	}
+	return &folder{version: version, payloads: payloads}, nil
+}
+
+// load returns the content of a payload, from the cache if possible or
+// from db.
+func (svc *csvc) load(db backend, k key, p payload) ([]byte, error) {
+	svc.mu.Lock()
+	if elmt, ok := svc.cache[k]; ok {
+		svc.nhit++
+		svc.lru.MoveToFront(elmt)
+		svc.mu.Unlock()
+		return elmt.Value.(*entry).data, nil
+	}
+	svc.mu.Unlock()
+
+	data, err := db.load(k.tag, k.version, p)
+	if err != nil {
+		return nil, fwk.Errorf("%s: %v", svc.Name(), err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	svc.nload++
+	if elmt, ok := svc.cache[k]; ok {
+		// loaded concurrently.
+		svc.lru.MoveToFront(elmt)
+		return elmt.Value.(*entry).data, nil
+	}
+
+	svc.cache[k] = svc.lru.PushFront(&entry{key: k, data: data})
+	for svc.lru.Len() > svc.csize {
+		last := svc.lru.Back()
+		svc.lru.Remove(last)
+		delete(svc.cache, last.Value.(*entry).key)
+	}
+	return data, nil
+}
+
+// decode decodes a payload into ptr.
+func decode(format string, data []byte, ptr interface{}) error {
+	switch format {
+	case fmtJSON:
+		return json.Unmarshal(data, ptr)
+	case fmtRIO:
+		f, err := rio.Open(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		keys := f.Keys()
+		if len(keys) != 1 {
+			return fmt.Errorf("rio payload with %d records (want 1)", len(keys))
+		}
+		return f.Get(keys[0].Name, ptr)
+	default:
+		return fmt.Errorf("invalid payload format %q (want %q or %q)", format, fmtJSON, fmtRIO)
+	}
+}
+
+func newcsvc(typ, name string, mgr fwk.App) (fwk.Component, error) {
+	var err error
+	svc := &csvc{
+		SvcBase:  fwk.NewSvc(typ, name, mgr),
+		driver:   "sqlite3",
+		versions: make(map[string]int64),
+		csize:    16,
+	}
+
+	err = svc.DeclProp("Source", &svc.source)
+	if err != nil {
+		return nil, err
+	}
+
+	err = svc.DeclProp("Driver", &svc.driver)
+	if err != nil {
+		return nil, err
+	}
+
+	err = svc.DeclProp("Versions", &svc.versions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = svc.DeclProp("CacheSize", &svc.csize)
+	if err != nil {
+		return nil, err
+	}
+
+	return svc, err
+}
+
+func init() {
+	fwk.Register(reflect.TypeOf(csvc{}), newcsvc)
+}
+
+var (
+	_ fwk.CondSvc    = (*csvc)(nil)
+	_ fwk.RunHandler = (*csvc)(nil)
+)
@@ -0,0 +1,503 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package condsvc
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/fwk/job"
+	"go-hep.org/x/hep/fwk/utils/errstack"
+	"go-hep.org/x/hep/rio"
+)
+
+type calib struct {
+	Scale float64
+	Bins  []float64
+}
+
+// context is a minimal fwk.Context to drive the service.
+type context struct {
+	msg fwk.MsgStream
+}
+
+func (ctx context) ID() int64                     { return 0 }
+func (ctx context) Slot() int                     { return 0 }
+func (ctx context) Store() fwk.Store              { return nil }
+func (ctx context) Msg() fwk.MsgStream            { return ctx.msg }
+func (ctx context) Svc(n string) (fwk.Svc, error) { return nil, fmt.Errorf("no service [%s]", n) }
+
+func newctx() fwk.Context {
+	return context{msg: fwk.NewMsgStream("condsvc", fwk.LvlError, nil)}
+}
+
+// newsvc creates and starts a conditions service reading source.
+func newsvc(t *testing.T, props job.P) *csvc {
+	app := job.NewJob(nil, job.P{"MsgLevel": job.MsgLevel("ERROR")})
+	svc := app.Create(job.C{
+		Type:  "go-hep.org/x/hep/fwk/condsvc.csvc",
+		Name:  "condsvc",
+		Props: props,
+	}).(*csvc)
+
+	ctx := newctx()
+	err := svc.Configure(ctx)
+	if err != nil {
+		t.Fatalf("could not configure service: %v", err)
+	}
+	err = svc.StartSvc(ctx)
+	if err != nil {
+		t.Fatalf("could not start service: %v", err)
+	}
+	return svc
+}
+
+func writeJSON(t *testing.T, dir, tag string, version int64, run, evt int64, v calib) {
+	fname := filepath.Join(dir, tag, fmt.Sprintf("v%d", version), fmt.Sprintf("%d_%d.json", run, evt))
+	err := os.MkdirAll(filepath.Dir(fname), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(fname, []byte(fmt.Sprintf(`{"Scale": %v, "Bins": [1, 2]}`, v.Scale)), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeRIO(t *testing.T, dir, tag string, version int64, run, evt int64, v calib) {
+	fname := filepath.Join(dir, tag, fmt.Sprintf("v%d", version), fmt.Sprintf("%d_%d.rio", run, evt))
+	err := os.MkdirAll(filepath.Dir(fname), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w, err := rio.NewWriter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.WriteValue("calib", &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fwk-condsvc-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeJSON(t, dir, "ecal/scale", 1, 1, 0, calib{Scale: 1.1})
+	writeJSON(t, dir, "ecal/scale", 1, 5, 0, calib{Scale: 1.5})
+	writeRIO(t, dir, "ecal/scale", 1, 5, 100, calib{Scale: 1.6, Bins: []float64{1, 2}})
+	writeJSON(t, dir, "ecal/scale", 2, 1, 0, calib{Scale: 2.1})
+	err = ioutil.WriteFile(filepath.Join(dir, "ecal/scale/v1/.README"), nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		version int64 // 0: latest
+		t       fwk.IOVTime
+		want    float64
+		iov     string
+	}{
+		{t: fwk.IOVTime{Run: 1}, want: 2.1, iov: "[1, inf)"},
+		{t: fwk.IOVTime{Run: 42, Event: 3}, want: 2.1, iov: "[1, inf)"},
+		{version: 1, t: fwk.IOVTime{Run: 1}, want: 1.1, iov: "[1, 5)"},
+		{version: 1, t: fwk.IOVTime{Run: 4, Event: 1000}, want: 1.1, iov: "[1, 5)"},
+		{version: 1, t: fwk.IOVTime{Run: 5}, want: 1.5, iov: "[5, 5:100)"},
+		{version: 1, t: fwk.IOVTime{Run: 5, Event: 99}, want: 1.5, iov: "[5, 5:100)"},
+		{version: 1, t: fwk.IOVTime{Run: 5, Event: 100}, want: 1.6, iov: "[5:100, inf)"},
+		{version: 1, t: fwk.IOVTime{Run: 7}, want: 1.6, iov: "[5:100, inf)"},
+	} {
+		props := job.P{"Source": dir}
+		if test.version != 0 {
+			props["Versions"] = map[string]int64{"ecal/scale": test.version}
+		}
+		svc := newsvc(t, props)
+
+		var v calib
+		iov, err := svc.Get("ecal/scale", test.t, &v)
+		if err != nil {
+			t.Fatalf("v%d %v: %v", test.version, test.t, err)
+		}
+		if v.Scale != test.want {
+			t.Errorf("v%d %v: got scale=%v. want %v", test.version, test.t, v.Scale, test.want)
+		}
+		if !reflect.DeepEqual(v.Bins, []float64{1, 2}) {
+			t.Errorf("v%d %v: got bins=%v. want [1 2]", test.version, test.t, v.Bins)
+		}
+		if got := iov.String(); got != test.iov {
+			t.Errorf("v%d %v: got iov=%s. want %s", test.version, test.t, got, test.iov)
+		}
+		if !iov.Contains(test.t) {
+			t.Errorf("v%d %v: iov %v does not contain the requested time", test.version, test.t, iov)
+		}
+
+		err = svc.StopSvc(newctx())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fwk-condsvc-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for run := int64(1); run <= 3; run++ {
+		writeJSON(t, dir, "scale", 1, run, 0, calib{Scale: float64(run)})
+	}
+
+	svc := newsvc(t, job.P{"Source": dir, "CacheSize": 2})
+	defer svc.StopSvc(newctx())
+
+	for i, run := range []int64{1, 1, 2, 1, 3, 2, 1} {
+		var v calib
+		_, err := svc.Get("scale", fwk.IOVTime{Run: run}, &v)
+		if err != nil {
+			t.Fatalf("get #%d: %v", i, err)
+		}
+		if v.Scale != float64(run) {
+			t.Fatalf("get #%d: got scale=%v. want %v", i, v.Scale, run)
+		}
+	}
+
+	// loads: 1, 2, 3 (evicts 2), 2 (evicts 1), 1 (evicts 3). hits: 1, 1.
+	if svc.nload != 5 || svc.nhit != 2 {
+		t.Fatalf("got %d loads and %d cache hits. want 5 and 2", svc.nload, svc.nhit)
+	}
+	if n := svc.lru.Len(); n != 2 {
+		t.Fatalf("got %d payloads in cache. want 2", n)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fwk-condsvc-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeJSON(t, dir, "scale", 1, 1, 0, calib{Scale: 1})
+
+	ctx := newctx()
+	svc := newsvc(t, job.P{"Source": dir})
+	defer svc.StopSvc(ctx)
+
+	get := func(run int64) (float64, fwk.IOV) {
+		var v calib
+		iov, err := svc.Get("scale", fwk.IOVTime{Run: run}, &v)
+		if err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		return v.Scale, iov
+	}
+
+	err = svc.BeginRun(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := get(1); v != 1 {
+		t.Fatalf("run 1: got scale=%v. want 1", v)
+	}
+
+	// a new payload, for the next run.
+	writeJSON(t, dir, "scale", 1, 2, 0, calib{Scale: 2})
+
+	if v, iov := get(2); v != 1 || iov.Until != fwk.IOVMax {
+		t.Fatalf("run 2 (before refresh): got scale=%v iov=%v. want 1 [1, inf)", v, iov)
+	}
+
+	err = svc.BeginRun(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, iov := get(2); v != 2 || iov.String() != "[2, inf)" {
+		t.Fatalf("run 2: got scale=%v iov=%v. want 2 [2, inf)", v, iov)
+	}
+	if v, iov := get(1); v != 1 || iov.String() != "[1, 2)" {
+		t.Fatalf("run 1: got scale=%v iov=%v. want 1 [1, 2)", v, iov)
+	}
+
+	// a new version of the tag.
+	writeJSON(t, dir, "scale", 2, 1, 0, calib{Scale: 10})
+	err = svc.BeginRun(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := get(3); v != 10 {
+		t.Fatalf("run 3: got scale=%v. want 10", v)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fwk-condsvc-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeJSON(t, dir, "scale", 1, 10, 0, calib{Scale: 1})
+	err = os.MkdirAll(filepath.Join(dir, "bad", "v1"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "bad", "v1", "1.json"), nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := newsvc(t, job.P{"Source": dir})
+	defer svc.StopSvc(newctx())
+
+	for _, test := range []struct {
+		tag  string
+		t    fwk.IOVTime
+		want string
+	}{
+		{"scale", fwk.IOVTime{Run: 9}, "condsvc: no payload for tag [scale] (version 1) at 9"},
+		{"nope", fwk.IOVTime{Run: 10}, "condsvc: no tag [nope]"},
+		{"../scale", fwk.IOVTime{Run: 10}, "condsvc: invalid tag name [../scale]"},
+		{"bad", fwk.IOVTime{Run: 10}, `condsvc: tag [bad] (version 1): invalid payload file name "1.json"`},
+	} {
+		var v calib
+		_, err := svc.Get(test.tag, test.t, &v)
+		if err == nil {
+			t.Errorf("%s: expected an error", test.tag)
+			continue
+		}
+		if got := errmsg(err); !strings.HasPrefix(got, test.want) {
+			t.Errorf("%s: got error %q. want %q", test.tag, got, test.want)
+		}
+	}
+
+	var v struct{ Scale string }
+	_, err = svc.Get("scale", fwk.IOVTime{Run: 10}, &v)
+	if err == nil || !strings.Contains(errmsg(err), "could not decode payload of tag [scale] (version 1) for [10, inf)") {
+		t.Errorf("got error %v. want a decoding error", err)
+	}
+}
+
+func TestNoDriver(t *testing.T) {
+	f, err := ioutil.TempFile("", "fwk-condsvc-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Close()
+
+	app := job.NewJob(nil, job.P{"MsgLevel": job.MsgLevel("ERROR")})
+	svc := app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk/condsvc.csvc",
+		Name: "condsvc",
+		Props: job.P{
+			"Source": f.Name(),
+			"Driver": "no-such-driver",
+		},
+	}).(*csvc)
+
+	err = svc.StartSvc(newctx())
+	if err == nil || !strings.Contains(errmsg(err), `no database/sql driver "no-such-driver" registered`) {
+		t.Fatalf("got error %v. want a missing driver error", err)
+	}
+}
+
+func TestSQLDB(t *testing.T) {
+	f, err := ioutil.TempFile("", "fwk-condsvc-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Close()
+
+	fakedb.set(f.Name(), []fakerow{
+		{"ecal/scale", 1, 1, 0, fmtJSON, []byte(`{"Scale": 1.1}`)},
+		{"ecal/scale", 1, 5, 0, fmtJSON, []byte(`{"Scale": 1.5}`)},
+		{"ecal/scale", 2, 1, 0, fmtJSON, []byte(`{"Scale": 2.1}`)},
+		{"hcal/scale", 1, 1, 0, fmtJSON, []byte(`{"Scale": 3.1}`)},
+	})
+
+	svc := newsvc(t, job.P{
+		"Source":   f.Name(),
+		"Driver":   "condsvc-fake",
+		"Versions": map[string]int64{"ecal/scale": 1},
+	})
+	defer svc.StopSvc(newctx())
+
+	for _, test := range []struct {
+		tag  string
+		run  int64
+		want float64
+		iov  string
+	}{
+		{"ecal/scale", 1, 1.1, "[1, 5)"},
+		{"ecal/scale", 6, 1.5, "[5, inf)"},
+		{"hcal/scale", 6, 3.1, "[1, inf)"},
+	} {
+		var v calib
+		iov, err := svc.Get(test.tag, fwk.IOVTime{Run: test.run}, &v)
+		if err != nil {
+			t.Fatalf("%s %d: %v", test.tag, test.run, err)
+		}
+		if v.Scale != test.want {
+			t.Errorf("%s %d: got scale=%v. want %v", test.tag, test.run, v.Scale, test.want)
+		}
+		if got := iov.String(); got != test.iov {
+			t.Errorf("%s %d: got iov=%s. want %s", test.tag, test.run, got, test.iov)
+		}
+	}
+}
+
+func errmsg(err error) string {
+	if e, ok := err.(*errstack.Error); ok {
+		return e.Err.Error()
+	}
+	return err.Error()
+}
+
+// fakerow is a row of the conditions table of the fake database/sql driver.
+type fakerow struct {
+	tag     string
+	version int64
+	run     int64
+	event   int64
+	format  string
+	payload []byte
+}
+
+// fakedriver is a database/sql driver serving the queries of sqldb from
+// in-memory tables.
+type fakedriver struct {
+	mu  sync.Mutex
+	dbs map[string][]fakerow
+}
+
+var fakedb = &fakedriver{dbs: make(map[string][]fakerow)}
+
+func init() {
+	sql.Register("condsvc-fake", fakedb)
+}
+
+func (drv *fakedriver) set(dsn string, rows []fakerow) {
+	drv.mu.Lock()
+	drv.dbs[dsn] = rows
+	drv.mu.Unlock()
+}
+
+func (drv *fakedriver) Open(dsn string) (driver.Conn, error) {
+	drv.mu.Lock()
+	defer drv.mu.Unlock()
+	rows, ok := drv.dbs[dsn]
+	if !ok {
+		return nil, fmt.Errorf("no database %q", dsn)
+	}
+	return &fakeconn{rows: rows}, nil
+}
+
+type fakeconn struct {
+	rows []fakerow
+}
+
+func (c *fakeconn) Prepare(query string) (driver.Stmt, error) {
+	return &fakestmt{c: c, query: query}, nil
+}
+
+func (c *fakeconn) Close() error              { return nil }
+func (c *fakeconn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("no transactions") }
+
+type fakestmt struct {
+	c     *fakeconn
+	query string
+}
+
+func (s *fakestmt) Close() error  { return nil }
+func (s *fakestmt) NumInput() int { return strings.Count(s.query, "?") }
+
+func (s *fakestmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("read-only database")
+}
+
+func (s *fakestmt) Query(args []driver.Value) (driver.Rows, error) {
+	match := func(row fakerow) bool {
+		want := []driver.Value{row.tag, row.version, row.run, row.event}
+		for i, arg := range args {
+			if arg != want[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	rows := &fakerows{}
+	seen := make(map[int64]bool)
+	for _, row := range s.c.rows {
+		if !match(row) {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(s.query, "SELECT DISTINCT version "):
+			rows.cols = []string{"version"}
+			if !seen[row.version] {
+				seen[row.version] = true
+				rows.vs = append(rows.vs, []driver.Value{row.version})
+			}
+		case strings.HasPrefix(s.query, "SELECT run, event, format "):
+			rows.cols = []string{"run", "event", "format"}
+			rows.vs = append(rows.vs, []driver.Value{row.run, row.event, row.format})
+		case strings.HasPrefix(s.query, "SELECT payload "):
+			rows.cols = []string{"payload"}
+			rows.vs = append(rows.vs, []driver.Value{row.payload})
+		default:
+			return nil, fmt.Errorf("unknown query %q", s.query)
+		}
+	}
+	return rows, nil
+}
+
+type fakerows struct {
+	cols []string
+	vs   [][]driver.Value
+}
+
+func (r *fakerows) Columns() []string { return r.cols }
+func (r *fakerows) Close() error      { return nil }
+
+func (r *fakerows) Next(dest []driver.Value) error {
+	if len(r.vs) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.vs[0])
+	r.vs = r.vs[1:]
+	return nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package condsvc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dirdb is a conditions database stored in a directory.
+type dirdb struct {
+	root string
+}
+
+func newDirDB(root string) *dirdb {
+	return &dirdb{root: root}
+}
+
+// dir returns the directory holding the versions of tag.
+func (db *dirdb) dir(tag string) (string, error) {
+	if tag == "" || path.IsAbs(tag) || path.Clean(tag) != tag || strings.HasPrefix(tag, "..") {
+		return "", fmt.Errorf("invalid tag name [%s]", tag)
+	}
+	return filepath.Join(db.root, filepath.FromSlash(tag)), nil
+}
+
+func (db *dirdb) versions(tag string) ([]int64, error) {
+	dir, err := db.dir(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var vs []int64
+	for _, fi := range fis {
+		if !fi.IsDir() || !strings.HasPrefix(fi.Name(), "v") {
+			continue
+		}
+		v, err := strconv.ParseInt(fi.Name()[1:], 10, 64)
+		if err != nil {
+			continue
+		}
+		vs = append(vs, v)
+	}
+	sort.Sort(int64s(vs))
+	return vs, nil
+}
+
+func (db *dirdb) index(tag string, version int64) ([]payload, error) {
+	dir, err := db.dir(tag)
+	if err != nil {
+		return nil, err
+	}
+	dir = filepath.Join(dir, fmt.Sprintf("v%d", version))
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ps []payload
+	for _, fi := range fis {
+		name := fi.Name()
+		if fi.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		p, err := parsePayloadName(name)
+		if err != nil {
+			return nil, fmt.Errorf("tag [%s] (version %d): %v", tag, version, err)
+		}
+		ps = append(ps, p)
+	}
+	sort.Sort(bySince(ps))
+
+	for i := 1; i < len(ps); i++ {
+		if ps[i].since == ps[i-1].since {
+			return nil, fmt.Errorf("tag [%s] (version %d): payloads %s and %s start at the same IOV",
+				tag, version, ps[i-1].ref, ps[i].ref,
+			)
+		}
+	}
+	return ps, nil
+}
+
+// parsePayloadName parses the name of a payload file: <run>_<event>.<format>.
+func parsePayloadName(name string) (payload, error) {
+	p := payload{ref: name}
+	invalid := fmt.Errorf("invalid payload file name %q (want <run>_<event>.json or <run>_<event>.rio)", name)
+
+	ext := filepath.Ext(name)
+	switch ext {
+	case ".json":
+		p.format = fmtJSON
+	case ".rio":
+		p.format = fmtRIO
+	default:
+		return p, invalid
+	}
+
+	toks := strings.Split(strings.TrimSuffix(name, ext), "_")
+	if len(toks) != 2 {
+		return p, invalid
+	}
+	var err error
+	p.since.Run, err = strconv.ParseInt(toks[0], 10, 64)
+	if err != nil {
+		return p, invalid
+	}
+	p.since.Event, err = strconv.ParseInt(toks[1], 10, 64)
+	if err != nil {
+		return p, invalid
+	}
+	return p, nil
+}
+
+func (db *dirdb) load(tag string, version int64, p payload) ([]byte, error) {
+	dir, err := db.dir(tag)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filepath.Join(dir, fmt.Sprintf("v%d", version), p.ref))
+}
+
+func (db *dirdb) close() error {
+	return nil
+}
+
+type int64s []int64
+
+func (p int64s) Len() int           { return len(p) }
+func (p int64s) Less(i, j int) bool { return p[i] < p[j] }
+func (p int64s) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+type bySince []payload
+
+func (p bySince) Len() int           { return len(p) }
+func (p bySince) Less(i, j int) bool { return p[i].since.Before(p[j].since) }
+func (p bySince) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+var _ backend = (*dirdb)(nil)
@@ -0,0 +1,109 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package condsvc
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// sqldb is a conditions database stored in a SQL database (e.g. a SQLite
+// file.)
+type sqldb struct {
+	db *sql.DB
+}
+
+func newSQLDB(driver, dsn string) (*sqldb, error) {
+	found := false
+	for _, name := range sql.Drivers() {
+		if name == driver {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no database/sql driver %q registered (the application must import one, e.g. github.com/mattn/go-sqlite3)", driver)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqldb{db: db}, nil
+}
+
+func (db *sqldb) versions(tag string) ([]int64, error) {
+	rows, err := db.db.Query(
+		"SELECT DISTINCT version FROM conditions WHERE tag = ? ORDER BY version",
+		tag,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vs []int64
+	for rows.Next() {
+		var v int64
+		err = rows.Scan(&v)
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+	return vs, rows.Err()
+}
+
+func (db *sqldb) index(tag string, version int64) ([]payload, error) {
+	rows, err := db.db.Query(
+		"SELECT run, event, format FROM conditions WHERE tag = ? AND version = ? ORDER BY run, event",
+		tag, version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ps []payload
+	for rows.Next() {
+		var p payload
+		err = rows.Scan(&p.since.Run, &p.since.Event, &p.format)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(ps); n > 0 && ps[n-1].since == p.since {
+			return nil, fmt.Errorf("tag [%s] (version %d): several payloads start at %v", tag, version, p.since)
+		}
+		ps = append(ps, p)
+	}
+	return ps, rows.Err()
+}
+
+func (db *sqldb) load(tag string, version int64, p payload) ([]byte, error) {
+	var data []byte
+	err := db.db.QueryRow(
+		"SELECT payload FROM conditions WHERE tag = ? AND version = ? AND run = ? AND event = ?",
+		tag, version, p.since.Run, p.since.Event,
+	).Scan(&data)
+	if err != nil {
+		return nil, fmt.Errorf("could not load payload of tag [%s] (version %d) at %v: %v",
+			tag, version, p.since, err,
+		)
+	}
+	return data, nil
+}
+
+func (db *sqldb) close() error {
+	return db.db.Close()
+}
+
+var _ backend = (*sqldb)(nil)
@@ -6,6 +6,7 @@ package fwk
 
 import (
 	"fmt"
+	"io"
 	"reflect"
 
 	"go-hep.org/x/hep/fwk/fsm"
@@ -48,6 +49,15 @@ type ComponentMgr interface {
 
 // Task is a component processing event-level data.
 // Task.Process is called for every component and for every input event.
+//
+// The input and output ports of the tasks drive their scheduling: during
+// the processing of an event, a task is only run once all the tasks
+// producing its inputs have been run, and independent tasks run
+// concurrently. Tasks must thus declare all the data they get from the
+// store as inputs: getting an undeclared port from the store fails.
+// The application property 'NProcs' bounds the number of events processed
+// concurrently, the property 'NTasks' the number of tasks running
+// concurrently, across all these events.
 type Task interface {
 	Component
 
@@ -56,6 +66,82 @@ type Task interface {
 	StopTask(ctx Context) error
 }
 
+// Reentrant is the interface implemented by tasks which declare whether
+// their Process method may be called concurrently, for different events.
+// Tasks not implementing Reentrant are considered re-entrant.
+//
+// The events processed by a non re-entrant task are serialised, unless
+// the task also implements TaskCloner.
+type Reentrant interface {
+	Reentrant() bool
+}
+
+// TaskCloner is the interface implemented by non re-entrant tasks which
+// can be cloned, so that each event slot processes its events with its
+// own clone of the task.
+//
+// CloneTask is called once for each additional event slot (1, 2, ...),
+// after the task has been configured.
+// Clones are started, stopped and notified of run and luminosity-block
+// transitions alongside the original task, which processes the events of
+// the slot 0.
+type TaskCloner interface {
+	CloneTask(slot int) (Task, error)
+}
+
+// RunHandler is the interface implemented by tasks and services which
+// need to be notified of run boundaries (e.g. to book per-run histograms
+// or to reload conditions data.)
+//
+// BeginRun is called before the first event of a run is processed.
+// EndRun is called after the last event of a run has been processed.
+// Both are called sequentially, while no event is being processed.
+// Services are notified before tasks for BeginRun, after tasks for EndRun.
+type RunHandler interface {
+	BeginRun(ctx Context, run int64) error
+	EndRun(ctx Context, run int64) error
+}
+
+// LumiBlockHandler is the interface implemented by tasks and services
+// which need to be notified of luminosity-block boundaries.
+//
+// BeginLumiBlock is called before the first event of a luminosity block
+// is processed, after the BeginRun of its run.
+// EndLumiBlock is called after the last event of a luminosity block has
+// been processed, before the EndRun of its run.
+// Both are called sequentially, while no event is being processed: all
+// the events of a luminosity block are processed after its BeginLumiBlock
+// and before its EndLumiBlock, even during a concurrent event loop.
+type LumiBlockHandler interface {
+	BeginLumiBlock(ctx Context, run, lumi int64) error
+	EndLumiBlock(ctx Context, run, lumi int64) error
+}
+
+// Checkpointer is the interface implemented by tasks and services whose
+// state is saved in the checkpoints of the application, so a job can be
+// resumed after the last fully processed input event.
+//
+// SaveState is called while no event is being processed.
+// LoadState is called when a job is resumed, after the component has been
+// started, and merges the saved state into the state of the component.
+// LoadState may be called more than once for a task, when it is resumed
+// with fewer event slots than the clones it was checkpointed with.
+//
+// Checkpoints are written to the file named by the 'Checkpoint' property
+// of the application, every 'CheckpointEvery' input events and at the end
+// of the event loop.
+// A job run with the 'Resume' property restores the saved states and skips
+// the input events already processed.
+// Output streams are not checkpointed: a resumed job must write new output
+// files (see OutputFiler.)
+// The events processed after the last checkpoint and before the job was
+// stopped are already in the output files of the stopped job: the resumed
+// job writes them again, in its own output files.
+type Checkpointer interface {
+	SaveState(w io.Writer) error
+	LoadState(r io.Reader) error
+}
+
 // TaskMgr manages tasks.
 type TaskMgr interface {
 	AddTask(tsk Task) error
@@ -216,6 +302,16 @@ func (lvl Level) String() string {
 }
 
 // MsgStream provides access to verbosity-defined formated messages, a la fmt.Printf.
+//
+// The messages emitted by the components of an application through the
+// MsgStream of their context are discarded below the 'MsgLevel' property of
+// the application, unless 'MsgLevels' overrides that level for the emitting
+// component.
+// When 'MsgFile' is set, all messages are written to that file, in the
+// 'MsgFormat' format ("text" or "json"), while the console only shows the
+// warnings, the errors and a summary of the messages at the end of the job.
+// 'MsgRateLimit' bounds the number of times a given message (same
+// component, level and format) is displayed, e.g. across events.
 type MsgStream interface {
 	Debugf(format string, a ...interface{}) (int, error)
 	Infof(format string, a ...interface{}) (int, error)
@@ -225,6 +321,16 @@ type MsgStream interface {
 	Msg(lvl Level, format string, a ...interface{}) (int, error)
 }
 
+// StructuredLogger is the interface implemented by MsgStreams which can
+// display structured messages, such as the message streams of the
+// components of an application (see Log.)
+//
+// Log displays a message with level lvl: the message text followed by a
+// list of alternating keys and values.
+type StructuredLogger interface {
+	Log(lvl Level, text string, kvs ...interface{}) error
+}
+
 // Deleter prepares values to be GC-reclaimed
 type Deleter interface {
 	Delete() error
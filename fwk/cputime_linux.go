@@ -0,0 +1,24 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fwk
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const clockThreadCPUTimeID = 3 // CLOCK_THREAD_CPUTIME_ID
+
+// threadCPUTime returns the CPU time consumed by the current OS thread.
+// The calling goroutine should be locked to its thread.
+func threadCPUTime() time.Duration {
+	var ts syscall.Timespec
+	_, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockThreadCPUTimeID, uintptr(unsafe.Pointer(&ts)), 0)
+	if errno != 0 {
+		return 0
+	}
+	return time.Duration(ts.Nano())
+}
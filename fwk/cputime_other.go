@@ -0,0 +1,17 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !linux
+
+package fwk
+
+import (
+	"time"
+)
+
+// threadCPUTime returns the CPU time consumed by the current OS thread.
+// It is not available on this platform.
+func threadCPUTime() time.Duration {
+	return 0
+}
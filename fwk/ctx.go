@@ -15,7 +15,8 @@ type context struct {
 	msg   msgstream
 	mgr   App
 
-	ctx nctx.Context
+	ctx    nctx.Context
+	accept *bool // filter decision of the task for the event
 }
 
 func (ctx context) ID() int64 {
@@ -7,11 +7,18 @@ package fwk
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/awalterschulze/gographviz"
+	"go-hep.org/x/hep/fwk/fsm"
 	"go-hep.org/x/hep/fwk/utils/tarjan"
 )
 
@@ -29,12 +36,28 @@ func newNode() *node {
 
 // dflowsvc models and describes the runtime data-flow and (data) dependencies between
 // components as declared during configuration.
+//
+// dflowsvc checks, before the event loop, that each input port has exactly
+// one producer and that the tasks do not depend on each other in a cycle:
+// all the inconsistencies are reported at once, with the full path of each
+// cycle.
+// When its 'HTTP' property is set, dflowsvc serves a live view of the
+// data-flow graph (see DumpDataFlow), with the timing of each task, while
+// the job runs.
+// The view is not authenticated: it should be served on a loopback address
+// (e.g. "127.0.0.1:8080".)
 type dflowsvc struct {
 	SvcBase
 	nodes map[string]*node
 	edges map[string]reflect.Type
 
 	dotfile string // path to a DOT file where to dump the data dependency graph.
+	http    string // address where to serve the live view of the data-flow.
+
+	mu    sync.Mutex
+	srv   net.Listener         // listener of the live view, if any
+	beg   time.Time            // start of the live view
+	stats map[string]*nodestat // timing of the tasks, for the live view
 }
 
 func (svc *dflowsvc) Configure(ctx Context) error {
@@ -44,89 +67,23 @@ func (svc *dflowsvc) Configure(ctx Context) error {
 func (svc *dflowsvc) StartSvc(ctx Context) error {
 	var err error
 
-	// sort node-names for reproducibility
-	nodenames := make([]string, 0, len(svc.nodes))
-	for n := range svc.nodes {
-		nodenames = append(nodenames, n)
-	}
-	sort.Strings(nodenames)
-
-	// - make sure all input keys of components are available
-	//   as output keys of a task
-	// - also detect whether a key is labeled as an out-port
-	//   by 2 different components
-	out := make(map[string]string) // outport-name -> producer-name
-	for _, tsk := range nodenames {
-		node := svc.nodes[tsk]
-		for k := range node.out {
-			n, dup := out[k]
-			if dup {
-				return Errorf("%s: component [%s] already declared port [%s] as its output (current=%s)",
-					svc.Name(), n, k, tsk,
-				)
-			}
-			out[k] = tsk
-		}
-	}
+	g := svc.graph()
 
-	for _, tsk := range nodenames {
-		node := svc.nodes[tsk]
-		for k := range node.in {
-			_, ok := out[k]
-			if !ok {
-				return Errorf("%s: component [%s] declared port [%s] as input but NO KNOWN producer",
-					svc.Name(), tsk, k,
-				)
-			}
-		}
-	}
-
-	// detect cycles.
-	graph := make(map[interface{}][]interface{})
-	for _, n := range nodenames {
-		node := svc.nodes[n]
-		graph[n] = []interface{}{}
-		for in := range node.in {
-			for _, o := range nodenames {
-				if o == n {
-					continue
-				}
-				onode := svc.nodes[o]
-				connected := false
-				for out := range onode.out {
-					if in == out {
-						connected = true
-						break
-					}
-				}
-				if connected {
-					graph[n] = append(graph[n], o)
-				}
-			}
+	// dump the graph first, so its inconsistencies can be inspected.
+	if svc.dotfile != "" {
+		err = svc.dumpgraph(g)
+		if err != nil {
+			return err
 		}
 	}
 
-	cycles := tarjan.Connections(graph)
-	if len(cycles) > 0 {
-		msg := ctx.Msg()
-		ncycles := 0
-		for _, cycle := range cycles {
-			if len(cycle) > 1 {
-				ncycles++
-				msg.Errorf("cycle detected: %v\n", cycle)
-			}
-		}
-		s := ""
-		if ncycles > 1 {
-			s = "s"
-		}
-		if ncycles > 0 {
-			return Errorf("%s: cycle%s detected: %d", svc.Name(), s, ncycles)
-		}
+	err = svc.check(g)
+	if err != nil {
+		return err
 	}
 
-	if svc.dotfile != "" {
-		err = svc.dumpgraph()
+	if svc.http != "" {
+		err = svc.serve(ctx, g)
 		if err != nil {
 			return err
 		}
@@ -134,8 +91,29 @@ func (svc *dflowsvc) StartSvc(ctx Context) error {
 	return err
 }
 
+// check returns an error describing the inconsistencies of the data-flow
+// graph g, if any.
+func (svc *dflowsvc) check(g *dflowgraph) error {
+	errs := g.problems()
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return Errorf("%s: %s", svc.Name(), errs[0])
+	default:
+		return Errorf("%s: %d inconsistencies in the data-flow graph:\n%s",
+			svc.Name(), len(errs), strings.Join(errs, "\n"),
+		)
+	}
+}
+
 func (svc *dflowsvc) StopSvc(ctx Context) error {
-	return nil
+	var err error
+	if svc.srv != nil {
+		err = svc.srv.Close()
+		svc.srv = nil
+	}
+	return err
 }
 
 func (svc *dflowsvc) keys() []string {
@@ -234,81 +212,359 @@ func (svc *dflowsvc) addOutNode(tsk string, name string, t reflect.Type) error {
 
 	node.out[name] = t
 
-	edgetyp, dup := svc.edges[name]
-	if dup {
-		// edge already exists
-		// loop over nodes, find out who already defined that edge
-		nodenames := make([]string, 0, len(svc.nodes))
-		for tskname := range svc.nodes {
-			nodenames = append(nodenames, tskname)
+	// components producing the same port are reported when the service starts,
+	// once all the ports have been declared.
+	svc.edges[name] = t
+	return nil
+}
+
+func (svc *dflowsvc) dumpgraph(g *dflowgraph) error {
+	dot, err := g.dot()
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(svc.dotfile, []byte(dot), 0644)
+	if err != nil {
+		return Error(err)
+	}
+
+	return err
+}
+
+// dflowgraph is the data-flow graph of the tasks of an application:
+// tasks are connected through the ports they produce and consume.
+type dflowgraph struct {
+	nodes     map[string]*node
+	tasks     []string            // names of the tasks, sorted
+	ports     []string            // names of the ports, sorted
+	producers map[string][]string // port name -> names of the tasks producing it
+	consumers map[string][]string // port name -> names of the tasks consuming it
+	cycles    [][]string          // cycles of tasks, as closed paths
+}
+
+// graph builds the data-flow graph of the tasks declared so far.
+func (svc *dflowsvc) graph() *dflowgraph {
+	g := &dflowgraph{
+		nodes:     svc.nodes,
+		tasks:     make([]string, 0, len(svc.nodes)),
+		producers: make(map[string][]string),
+		consumers: make(map[string][]string),
+	}
+
+	for n := range svc.nodes {
+		g.tasks = append(g.tasks, n)
+	}
+	sort.Strings(g.tasks)
+
+	for _, tsk := range g.tasks {
+		node := svc.nodes[tsk]
+		for _, port := range portNames(node.out) {
+			g.producers[port] = append(g.producers[port], tsk)
 		}
-		sort.Strings(nodenames)
-		for _, duptsk := range nodenames {
-			dupnode := svc.nodes[duptsk]
-			if duptsk == tsk {
-				continue
-			}
-			for out := range dupnode.out {
-				if out == name {
-					return Errorf(
-						"fwk.DeclOutPort: component [%s] already declared out-port with name [%s (type=%v)].\nfwk.DeclOutPort: component [%s] is trying to add a duplicate out-port [%s (type=%v)]",
-						duptsk,
-						name,
-						edgetyp,
-						tsk,
-						name,
-						t,
-					)
+		for _, port := range portNames(node.in) {
+			g.consumers[port] = append(g.consumers[port], tsk)
+		}
+	}
+
+	for port := range g.producers {
+		g.ports = append(g.ports, port)
+	}
+	for port := range g.consumers {
+		if _, ok := g.producers[port]; !ok {
+			g.ports = append(g.ports, port)
+		}
+	}
+	sort.Strings(g.ports)
+
+	// task -> tasks consuming its outputs.
+	succ := make(map[string][]string, len(g.tasks))
+	for _, tsk := range g.tasks {
+		for _, port := range portNames(svc.nodes[tsk].out) {
+			for _, user := range g.consumers[port] {
+				if user != tsk {
+					succ[tsk] = append(succ[tsk], user)
 				}
 			}
 		}
 	}
-	svc.edges[name] = t
-	return nil
+	g.cycles = findCycles(succ)
+
+	return g
 }
 
-func (svc *dflowsvc) dumpgraph() error {
+// problems describes the inconsistencies of the graph: ports produced by
+// several tasks, inputs with no producer and cycles.
+func (g *dflowgraph) problems() []string {
+	var errs []string
+	for _, port := range g.ports {
+		tsks := g.producers[port]
+		if len(tsks) < 2 {
+			continue
+		}
+		descr := make([]string, len(tsks))
+		for i, tsk := range tsks {
+			descr[i] = fmt.Sprintf("[%s] (type=%v)", tsk, g.nodes[tsk].out[port])
+		}
+		errs = append(errs, fmt.Sprintf("port [%s] declared as output by %d components: %s",
+			port, len(tsks), strings.Join(descr, ", "),
+		))
+	}
+
+	for _, tsk := range g.tasks {
+		for _, port := range portNames(g.nodes[tsk].in) {
+			if len(g.producers[port]) == 0 {
+				errs = append(errs, fmt.Sprintf("component [%s] declared port [%s] as input but NO KNOWN producer",
+					tsk, port,
+				))
+			}
+		}
+	}
+
+	for _, cycle := range g.cycles {
+		errs = append(errs, "cycle detected: "+g.path(cycle))
+	}
+	return errs
+}
+
+// links returns the names of the ports produced by the task src and
+// consumed by the task dst.
+func (g *dflowgraph) links(src, dst string) []string {
+	var ports []string
+	in := g.nodes[dst].in
+	for _, port := range portNames(g.nodes[src].out) {
+		if _, ok := in[port]; ok {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// path formats a path of tasks, with the ports connecting them.
+func (g *dflowgraph) path(tsks []string) string {
+	var o bytes.Buffer
+	o.WriteString(tsks[0])
+	for i := 1; i < len(tsks); i++ {
+		fmt.Fprintf(&o, " -[%s]-> %s", strings.Join(g.links(tsks[i-1], tsks[i]), ","), tsks[i])
+	}
+	return o.String()
+}
+
+// dot describes the graph in the DOT language.
+//
+// Tasks are drawn as components and ports as ellipses, the edges being
+// labeled with the type of the data.
+// Ports with no producer or with several producers are drawn in red, as
+// well as the edges of the cycles.
+func (g *dflowgraph) dot() (string, error) {
 	var err error
-	g := gographviz.NewGraph()
-	gname := "dataflow"
-	g.SetName(gname)
-	g.SetDir(true)
+	const gname = "dataflow"
+
+	gv := gographviz.NewGraph()
+	err = gv.SetName(gname)
+	if err != nil {
+		return "", Error(err)
+	}
+	err = gv.SetDir(true)
+	if err != nil {
+		return "", Error(err)
+	}
+
+	// node identifiers, as tasks and ports may share names.
+	task := func(name string) string { return strconv.Quote("task:" + name) }
+	port := func(name string) string { return strconv.Quote("port:" + name) }
+
+	// edges (task->port and port->task) belonging to a cycle.
+	incycle := make(map[[2]string]bool)
+	for _, cycle := range g.cycles {
+		for i := 1; i < len(cycle); i++ {
+			for _, p := range g.links(cycle[i-1], cycle[i]) {
+				incycle[[2]string{task(cycle[i-1]), port(p)}] = true
+				incycle[[2]string{port(p), task(cycle[i])}] = true
+			}
+		}
+	}
+
+	for _, name := range g.tasks {
+		err = gv.AddNode(gname, task(name), map[string]string{
+			"label": strconv.Quote(name),
+			"shape": "component",
+		})
+		if err != nil {
+			return "", Error(err)
+		}
+	}
 
-	quote := func(s string) string {
-		return fmt.Sprintf("%q", s)
+	for _, name := range g.ports {
+		attrs := map[string]string{
+			"label": strconv.Quote(name),
+			"shape": "ellipse",
+		}
+		switch n := len(g.producers[name]); {
+		case n == 0:
+			attrs["color"] = "red"
+			attrs["style"] = "dashed"
+			attrs["tooltip"] = strconv.Quote("no producer")
+		case n > 1:
+			attrs["color"] = "red"
+			attrs["style"] = "bold"
+			attrs["tooltip"] = strconv.Quote(fmt.Sprintf("%d producers", n))
+		}
+		err = gv.AddNode(gname, port(name), attrs)
+		if err != nil {
+			return "", Error(err)
+		}
+	}
+
+	edge := func(src, dst string, typ reflect.Type) error {
+		attrs := map[string]string{"label": strconv.Quote(typ.String())}
+		if incycle[[2]string{src, dst}] {
+			attrs["color"] = "red"
+			attrs["penwidth"] = "2"
+		}
+		return gv.AddEdge(src, dst, true, attrs)
 	}
 
-	for name, typ := range svc.edges {
-		typename := typ.String()
-		attrdata := map[string]string{
-			`"node"`: `"data"`,
-			`"type"`: quote(typename),
+	for _, name := range g.tasks {
+		node := g.nodes[name]
+		for _, p := range portNames(node.in) {
+			err = edge(port(p), task(name), node.in[p])
+			if err != nil {
+				return "", Error(err)
+			}
+		}
+		for _, p := range portNames(node.out) {
+			err = edge(task(name), port(p), node.out[p])
+			if err != nil {
+				return "", Error(err)
+			}
 		}
-		g.AddNode(gname, quote(name), attrdata)
 	}
 
-	attrtask := map[string]string{
-		`"node"`:  `"task"`,
-		`"shape"`: `"component"`,
+	return gv.String(), err
+}
+
+// portNames returns the sorted names of the ports.
+func portNames(ports map[string]reflect.Type) []string {
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// findCycles returns a cycle of the directed graph g for each of its
+// strongly connected components of more than one vertex.
+// g associates each vertex with its successors.
+// Each cycle is a shortest path from the smallest vertex of its component
+// back to that vertex.
+func findCycles(g map[string][]string) [][]string {
+	graph := make(map[interface{}][]interface{}, len(g))
+	for v, succ := range g {
+		edges := make([]interface{}, len(succ))
+		for i, w := range succ {
+			edges[i] = w
+		}
+		graph[v] = edges
 	}
-	for name, node := range svc.nodes {
-		g.AddNode(gname, quote(name), attrtask)
 
-		for in := range node.in {
-			g.AddEdge(quote(in), quote(name), true, nil)
+	var cycles [][]string
+	for _, scc := range tarjan.Connections(graph) {
+		if len(scc) < 2 {
+			continue
+		}
+		comp := make(map[string]bool, len(scc))
+		start := scc[0].(string)
+		for _, v := range scc {
+			comp[v.(string)] = true
+			if v.(string) < start {
+				start = v.(string)
+			}
+		}
+
+		// breadth-first search of the way back to start.
+		prev := make(map[string]string)
+		queue := []string{start}
+	search:
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			succ := append([]string(nil), g[v]...)
+			sort.Strings(succ)
+			for _, w := range succ {
+				switch {
+				case !comp[w]:
+					continue
+				case w == start:
+					cycle := []string{start}
+					for ; v != start; v = prev[v] {
+						cycle = append(cycle, v)
+					}
+					cycle = append(cycle, start)
+					for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+						cycle[i], cycle[j] = cycle[j], cycle[i]
+					}
+					cycles = append(cycles, cycle)
+					break search
+				}
+				if _, seen := prev[w]; seen {
+					continue
+				}
+				prev[w] = v
+				queue = append(queue, w)
+			}
 		}
+	}
+
+	sort.Sort(cyclesByName(cycles))
+	return cycles
+}
 
-		for out := range node.out {
-			g.AddEdge(quote(name), quote(out), true, nil)
+type cyclesByName [][]string
+
+func (p cyclesByName) Len() int           { return len(p) }
+func (p cyclesByName) Less(i, j int) bool { return p[i][0] < p[j][0] }
+func (p cyclesByName) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// DumpDataFlow configures app, if it was not configured yet, and writes
+// the data-flow graph of its tasks to w, in the DOT language.
+//
+// Tasks are drawn as components and ports as ellipses, the edges being
+// labeled with the type of the data.
+// Ports with no producer or with several producers are drawn in red, as
+// well as the edges of the cycles.
+// Once the graph is written, DumpDataFlow returns an error describing these
+// inconsistencies, if any.
+//
+// 'fwk-app graph' writes the data-flow graph of a job with DumpDataFlow.
+func DumpDataFlow(w io.Writer, app App) error {
+	mgr, ok := app.(*appmgr)
+	if !ok {
+		return Errorf("fwk.DumpDataFlow: invalid application type %T", app)
+	}
+
+	if mgr.state == fsm.Undefined {
+		err := mgr.Scripter().Configure()
+		if err != nil {
+			return err
 		}
 	}
+	if mgr.dflow == nil {
+		return Errorf("fwk.DumpDataFlow: application [%s] already shut down", mgr.Name())
+	}
 
-	err = ioutil.WriteFile(svc.dotfile, []byte(g.String()), 0644)
+	g := mgr.dflow.graph()
+	dot, err := g.dot()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, dot)
 	if err != nil {
 		return Error(err)
 	}
 
-	return err
+	return mgr.dflow.check(g)
 }
 
 func newDataFlowSvc(typ, name string, mgr App) (Component, error) {
@@ -318,6 +574,7 @@ func newDataFlowSvc(typ, name string, mgr App) (Component, error) {
 		nodes:   make(map[string]*node),
 		edges:   make(map[string]reflect.Type),
 		dotfile: "", // empty: no dump
+		http:    "", // empty: no live view
 	}
 
 	err = svc.DeclProp("DotFile", &svc.dotfile)
@@ -325,6 +582,11 @@ func newDataFlowSvc(typ, name string, mgr App) (Component, error) {
 		return nil, err
 	}
 
+	err = svc.DeclProp("HTTP", &svc.http)
+	if err != nil {
+		return nil, err
+	}
+
 	return svc, err
 }
 
@@ -0,0 +1,238 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fwk
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// nodestat holds the timing of the events processed by a task.
+type nodestat struct {
+	n    int64         // number of events processed
+	wall time.Duration // total wall-clock time
+	max  time.Duration // longest wall-clock time for an event
+}
+
+// record records the wall-clock time taken by the task tsk to process an
+// event, for the live view.
+func (svc *dflowsvc) record(tsk string, wall time.Duration) {
+	svc.mu.Lock()
+	st, ok := svc.stats[tsk]
+	if !ok {
+		st = new(nodestat)
+		svc.stats[tsk] = st
+	}
+	st.n++
+	st.wall += wall
+	if wall > st.max {
+		st.max = wall
+	}
+	svc.mu.Unlock()
+}
+
+// serve serves the live view of the data-flow graph g: the graph (rendered
+// with the GraphViz 'dot' command, when available) and the timing of the
+// tasks, refreshed while the events are processed.
+func (svc *dflowsvc) serve(ctx Context, g *dflowgraph) error {
+	dot, err := g.dot()
+	if err != nil {
+		return err
+	}
+
+	type port struct {
+		Name string
+		Type string
+	}
+	type task struct {
+		Name string
+		In   []port
+		Out  []port
+	}
+	page := struct {
+		SVG   template.HTML
+		Tasks []task
+	}{
+		SVG: renderSVG(dot),
+	}
+	for _, name := range g.tasks {
+		node := g.nodes[name]
+		tsk := task{Name: name}
+		for _, p := range portNames(node.in) {
+			tsk.In = append(tsk.In, port{p, node.in[p].String()})
+		}
+		for _, p := range portNames(node.out) {
+			tsk.Out = append(tsk.Out, port{p, node.out[p].String()})
+		}
+		page.Tasks = append(page.Tasks, tsk)
+	}
+
+	html := new(bytes.Buffer)
+	err = dflowPage.Execute(html, page)
+	if err != nil {
+		return Error(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(html.Bytes())
+	})
+	mux.HandleFunc("/dataflow.dot", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.Write([]byte(dot))
+	})
+	mux.HandleFunc("/stats", svc.serveStats)
+
+	l, err := net.Listen("tcp", svc.http)
+	if err != nil {
+		return Errorf("%s: could not serve the data-flow view: %v", svc.Name(), err)
+	}
+
+	svc.mu.Lock()
+	svc.beg = time.Now()
+	svc.stats = make(map[string]*nodestat, len(g.tasks))
+	svc.mu.Unlock()
+
+	// no keep-alive: the view is closed with its listener.
+	srv := &http.Server{Handler: mux}
+	srv.SetKeepAlivesEnabled(false)
+
+	svc.srv = l
+	go srv.Serve(l)
+
+	ctx.Msg().Infof("data-flow view served at http://%s/\n", l.Addr())
+	return err
+}
+
+// serveStats serves the timing of the tasks, in JSON.
+func (svc *dflowsvc) serveStats(w http.ResponseWriter, r *http.Request) {
+	type taskstat struct {
+		Task string        `json:"task"`
+		N    int64         `json:"n"`
+		Wall time.Duration `json:"wall"` // in nanoseconds
+		Max  time.Duration `json:"max"`  // in nanoseconds
+	}
+	var stats struct {
+		Elapsed time.Duration `json:"elapsed"` // in nanoseconds
+		Tasks   []taskstat    `json:"tasks"`
+	}
+
+	svc.mu.Lock()
+	stats.Elapsed = time.Since(svc.beg)
+	stats.Tasks = make([]taskstat, 0, len(svc.stats))
+	for name, st := range svc.stats {
+		stats.Tasks = append(stats.Tasks, taskstat{name, st.n, st.wall, st.max})
+	}
+	svc.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(stats)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// renderSVG renders a DOT graph in SVG, with the GraphViz 'dot' command.
+// renderSVG returns an empty string if the graph could not be rendered.
+func renderSVG(dot string) template.HTML {
+	exe, err := exec.LookPath("dot")
+	if err != nil {
+		return ""
+	}
+	cmd := exec.Command(exe, "-Tsvg")
+	cmd.Stdin = strings.NewReader(dot)
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	// drop the XML prolog, to embed the SVG in the page.
+	svg := string(out)
+	if i := strings.Index(svg, "<svg"); i >= 0 {
+		svg = svg[i:]
+	}
+	return template.HTML(svg)
+}
+
+var dflowPage = template.Must(template.New("dataflow").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>fwk data-flow</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; vertical-align: top; }
+td.num { text-align: right; }
+div.bar { background: #4a90c0; height: 1em; width: 0; }
+</style>
+</head>
+<body>
+<h1>data-flow</h1>
+<div>
+{{if .SVG}}{{.SVG}}{{else}}<p>GraphViz is not available to render the graph: see <a href="dataflow.dot">dataflow.dot</a>.</p>{{end}}
+</div>
+<h2>tasks</h2>
+<p>elapsed: <span id="elapsed">-</span></p>
+<table>
+<tr><th>task</th><th>inputs</th><th>outputs</th><th>events</th><th>total</th><th>mean</th><th>max</th><th>share</th></tr>
+{{range .Tasks}}<tr id="task-{{.Name}}">
+<td>{{.Name}}</td>
+<td>{{range .In}}{{.Name}} <i>({{.Type}})</i><br>{{end}}</td>
+<td>{{range .Out}}{{.Name}} <i>({{.Type}})</i><br>{{end}}</td>
+<td class="num">0</td><td class="num">-</td><td class="num">-</td><td class="num">-</td>
+<td><div class="bar"></div></td>
+</tr>
+{{end}}
+</table>
+<script>
+function duration(ns) {
+	if (ns >= 1e9) { return (ns/1e9).toFixed(2) + "s"; }
+	if (ns >= 1e6) { return (ns/1e6).toFixed(2) + "ms"; }
+	return (ns/1e3).toFixed(1) + "µs";
+}
+
+function refresh() {
+	var req = new XMLHttpRequest();
+	req.onload = function() {
+		var stats = JSON.parse(req.responseText);
+		document.getElementById("elapsed").textContent = duration(stats.elapsed);
+		var total = 0;
+		stats.tasks.forEach(function(t) { total += t.wall; });
+		stats.tasks.forEach(function(t) {
+			var row = document.getElementById("task-" + t.task);
+			if (!row) {
+				return;
+			}
+			row.cells[3].textContent = t.n;
+			row.cells[4].textContent = duration(t.wall);
+			row.cells[5].textContent = t.n > 0 ? duration(t.wall / t.n) : "-";
+			row.cells[6].textContent = duration(t.max);
+			row.cells[7].firstChild.style.width = (total > 0 ? 200 * t.wall / total : 0) + "px";
+		});
+		setTimeout(refresh, 1000);
+	};
+	req.onerror = function() {
+		document.getElementById("elapsed").textContent += " (job done)";
+	};
+	req.open("GET", "stats");
+	req.send();
+}
+
+refresh();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,146 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fwk
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"go-hep.org/x/hep/fwk/utils/dist"
+	"go-hep.org/x/hep/fwk/utils/parallel"
+)
+
+const (
+	// workerEnv is the environment variable holding the range of input
+	// events, "<first>:<last>", a worker process of a distributed job
+	// must process.
+	workerEnv = "FWK_WORKER"
+
+	// workerState is the name of the checkpoint file where a worker saves
+	// the state of its components, in its directory.
+	workerState = "fwk-worker.ckpt"
+)
+
+// distributed returns whether the application coordinates the worker
+// processes of a distributed job.
+func (app *appmgr) distributed() bool {
+	return app.workers > 0 || len(app.hosts) > 0
+}
+
+// setupWorker configures the application as the worker process of a
+// distributed job, processing the range of input events described by env.
+func (app *appmgr) setupWorker(env string) error {
+	var first, last int64
+	_, err := fmt.Sscanf(env, "%d:%d", &first, &last)
+	if err != nil || first < 0 || last < first {
+		return Errorf("fwk: invalid worker range %s=%q", workerEnv, env)
+	}
+
+	app.workers = 0
+	app.hosts = nil
+	app.first = first
+	app.evtmax = last
+	app.ckpt = workerState
+	app.ckptEvery = 0
+	app.resume = false
+	return nil
+}
+
+// runDistributed partitions the input events in ranges processed by worker
+// processes running the same job, and merges their results: the states of
+// the Checkpointers and the outputs of the OutputMergers.
+func (app *appmgr) runDistributed(ctx Context) error {
+	if app.evtmax == math.MaxInt64 {
+		return Errorf("fwk: distributed jobs need a bounded 'EvtMax'")
+	}
+
+	var runners []dist.Runner
+	for i := 0; i < app.workers; i++ {
+		runners = append(runners, dist.Local{})
+	}
+	if len(app.hosts) > 0 && os.Getenv(dist.SecretEnv) == "" {
+		return Errorf("fwk: remote workers need the secret of their agents in $%s", dist.SecretEnv)
+	}
+	for _, host := range app.hosts {
+		runners = append(runners, dist.Remote{Addr: host, Secret: os.Getenv(dist.SecretEnv)})
+	}
+
+	cmd := app.wcmd
+	if len(cmd) == 0 {
+		cmd = os.Args
+	}
+	exe, err := exec.LookPath(cmd[0])
+	if err != nil {
+		return Errorf("fwk: could not find worker executable: %v", err)
+	}
+	exe, err = filepath.Abs(exe)
+	if err != nil {
+		return Error(err)
+	}
+
+	workdir, err := filepath.Abs(app.workdir)
+	if err != nil {
+		return Error(err)
+	}
+
+	n := int64(len(runners))
+	dirs := make([]string, len(runners))
+	run := parallel.NewRun(len(runners))
+	for i := range runners {
+		first := app.evtmax / n * int64(i)
+		last := app.evtmax / n * int64(i+1)
+		if i == len(runners)-1 {
+			last = app.evtmax
+		}
+		dirs[i] = filepath.Join(workdir, fmt.Sprintf("worker-%03d", i))
+		err = os.RemoveAll(dirs[i])
+		if err != nil {
+			return Error(err)
+		}
+
+		job := dist.Job{
+			Exe:  exe,
+			Args: cmd[1:],
+			Env:  []string{fmt.Sprintf("%s=%d:%d", workerEnv, first, last)},
+			Dir:  dirs[i],
+		}
+		runner := runners[i]
+		app.msg.Infof("starting worker #%d on events [%d, %d) in [%s]...\n", i, first, last, job.Dir)
+		run.Do(func() error {
+			return runner.Run(job)
+		})
+	}
+
+	err = run.Wait()
+	if err != nil {
+		return Errorf("fwk: distributed job failed: %v", err)
+	}
+
+	for _, dir := range dirs {
+		_, err = app.ckptr.load(filepath.Join(dir, workerState))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, tsk := range app.tsks {
+		out, ok := tsk.(*OutputStream)
+		if !ok {
+			continue
+		}
+		merged, err := out.merge(dirs)
+		if err != nil {
+			return Errorf("fwk: could not merge outputs of [%s]: %v", out.Name(), err)
+		}
+		if !merged {
+			app.msg.Warnf("outputs of [%s] not merged: left in [%s]\n", out.Name(), workdir)
+		}
+	}
+
+	return nil
+}
@@ -6,16 +6,25 @@ package fwk_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"go-hep.org/x/hep/fwk"
 	"go-hep.org/x/hep/fwk/job"
+	frio "go-hep.org/x/hep/fwk/rio"
 	"go-hep.org/x/hep/fwk/testdata"
+	"go-hep.org/x/hep/fwk/utils/dist"
 	"go-hep.org/x/hep/fwk/utils/errstack"
+	"go-hep.org/x/hep/rio"
 )
 
 func newapp(evtmax int64, nprocs int) *job.Job {
@@ -99,6 +108,53 @@ func TestSimpleConcApp(t *testing.T) {
 	}
 }
 
+func TestConcAppFailure(t *testing.T) {
+	const max = 100000
+
+	for _, nprocs := range []int{1, 2, 4} {
+		app := job.NewJob(nil, job.P{
+			"EvtMax":   int64(-1),
+			"NProcs":   nprocs,
+			"MsgLevel": job.MsgLevel("ERROR"),
+		})
+
+		r := newTestReader(max).(*bytes.Buffer)
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk.InputStream",
+			Name: "input",
+			Props: job.P{
+				"Ports": []fwk.Port{
+					{Name: "ints", Type: reflect.TypeOf(int64(1))},
+				},
+				"Streamer": &testdata.InputStream{R: r},
+			},
+		})
+
+		sum := new(testdata.Sum)
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk/testdata.accum",
+			Name: "accum",
+			Props: job.P{
+				"Input": "ints",
+				"Fail":  int64(10),
+				"Sum":   sum,
+			},
+		})
+
+		err := app.App().Run()
+		if err == nil {
+			t.Fatalf("nprocs=%d: expected an error", nprocs)
+		}
+
+		// the surviving workers must not process the rest of the input.
+		if sum.N >= max-1 || r.Len() == 0 {
+			t.Fatalf("nprocs=%d: input read to the end after a failure (%d events processed)",
+				nprocs, sum.N,
+			)
+		}
+	}
+}
+
 func TestDuplicateOutputPort(t *testing.T) {
 	app := newapp(1, 1)
 	app.Create(job.C{
@@ -122,8 +178,9 @@ func TestDuplicateOutputPort(t *testing.T) {
 	if err == nil {
 		t.Fatalf("expected an error\n")
 	}
-	exp := fmt.Errorf(`fwk.DeclOutPort: component [t0] already declared out-port with name [t0-ints1 (type=int64)].
-fwk.DeclOutPort: component [t1] is trying to add a duplicate out-port [t0-ints1 (type=int64)]`)
+	exp := fmt.Errorf(`dataflow: 2 inconsistencies in the data-flow graph:
+port [t0-ints1] declared as output by 2 components: [t0] (type=int64), [t1] (type=int64)
+port [t0-ints2] declared as output by 2 components: [t0] (type=int64), [t1] (type=int64)`)
 	errs := err.(*errstack.Error)
 	if !reflect.DeepEqual(errs.Err, exp) {
 		t.Fatalf("invalid error.\nexp=%v (type=%[1]T)\ngot=%v (type=%[2]T)\n", exp, errs.Err)
@@ -240,13 +297,170 @@ func TestPortsCycles(t *testing.T) {
 	if err == nil {
 		t.Fatalf("expected an error\n")
 	}
-	exp := fmt.Errorf("dataflow: cycle detected: 1")
+	exp := fmt.Errorf("dataflow: cycle detected: t1-cycle -[data-1]-> t2 -[data-2]-> t3 -[input]-> t1-cycle")
 	errs := err.(*errstack.Error)
 	if !reflect.DeepEqual(errs.Err, exp) {
 		t.Fatalf("invalid error.\nexp=%v (type=%[1]T)\ngot=%v (type=%[2]T)\n", exp, errs.Err)
 	}
 }
 
+func TestDumpDataFlow(t *testing.T) {
+	app := newapp(1, 0)
+	for _, c := range []job.C{
+		{
+			Type:  "go-hep.org/x/hep/fwk/testdata.task1",
+			Name:  "t0",
+			Props: job.P{"Ints1": "ints1", "Ints2": "ints2"},
+		},
+		{
+			Type:  "go-hep.org/x/hep/fwk/testdata.task1",
+			Name:  "t1",
+			Props: job.P{"Ints1": "ints1", "Ints2": "t1-ints2"},
+		},
+		{
+			Type:  "go-hep.org/x/hep/fwk/testdata.task2",
+			Name:  "t2",
+			Props: job.P{"Input": "missing", "Output": "t2-ints"},
+		},
+		{
+			Type:  "go-hep.org/x/hep/fwk/testdata.task2",
+			Name:  "c1",
+			Props: job.P{"Input": "c2-ints", "Output": "c1-ints"},
+		},
+		{
+			Type:  "go-hep.org/x/hep/fwk/testdata.task2",
+			Name:  "c2",
+			Props: job.P{"Input": "c1-ints", "Output": "c2-ints"},
+		},
+	} {
+		app.Create(c)
+	}
+
+	buf := new(bytes.Buffer)
+	err := fwk.DumpDataFlow(buf, app.App())
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	exp := fmt.Errorf(`dataflow: 3 inconsistencies in the data-flow graph:
+port [ints1] declared as output by 2 components: [t0] (type=int64), [t1] (type=int64)
+component [t2] declared port [missing] as input but NO KNOWN producer
+cycle detected: c1 -[c1-ints]-> c2 -[c2-ints]-> c1`)
+	if got := err.(*errstack.Error).Err; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("invalid error.\nexp=%v\ngot=%v\n", exp, got)
+	}
+
+	dot := buf.String()
+	for _, want := range []string{
+		`"task:t0"->"port:ints1"[ label="int64" ];`,
+		`"port:c1-ints"->"task:c2"[ color=red, label="int64", penwidth=2 ];`,
+		`"task:t0" [ label="t0", shape=component ];`,
+		`"port:ints1" [ color=red, label="ints1", shape=ellipse, style=bold, tooltip="2 producers" ];`,
+		`"port:missing" [ color=red, label="missing", shape=ellipse, style=dashed, tooltip="no producer" ];`,
+		`"port:t2-ints" [ label="t2-ints", shape=ellipse ];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("missing %s in DOT graph:\n%s", want, dot)
+		}
+	}
+}
+
+func TestDataFlowHTTP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	app := newapp(5, 0)
+	app.Create(job.C{
+		Type:  "go-hep.org/x/hep/fwk/testdata.task1",
+		Name:  "t1",
+		Props: job.P{"Ints1": "t1-ints1", "Ints2": "t1-ints2"},
+	})
+	app.Create(job.C{
+		Type:  "go-hep.org/x/hep/fwk/testdata.task2",
+		Name:  "t2",
+		Props: job.P{"Input": "t1-ints1", "Output": "t2-ints"},
+	})
+	err = app.App().SetProp(app.App().GetSvc("dataflow"), "HTTP", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string) []byte {
+		resp, err := http.Get("http://" + addr + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s: %s", path, resp.Status)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	ui := app.App().Scripter()
+	for _, step := range []func() error{
+		ui.Configure,
+		ui.Start,
+		func() error { return ui.Run(5) },
+	} {
+		err = step()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page := string(get("/"))
+	for _, want := range []string{
+		`<tr id="task-t2">`,
+		`t1-ints1 <i>(int64)</i>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("missing %s in page:\n%s", want, page)
+		}
+	}
+
+	if dot := string(get("/dataflow.dot")); !strings.Contains(dot, `"task:t1"->"port:t1-ints1"`) {
+		t.Errorf("invalid DOT graph:\n%s", dot)
+	}
+
+	var stats struct {
+		Tasks []struct {
+			Task string `json:"task"`
+			N    int64  `json:"n"`
+		} `json:"tasks"`
+	}
+	err = json.Unmarshal(get("/stats"), &stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := make(map[string]int64)
+	for _, st := range stats.Tasks {
+		n[st.Task] = st.N
+	}
+	if want := map[string]int64{"t1": 5, "t2": 5}; !reflect.DeepEqual(n, want) {
+		t.Fatalf("invalid stats: got %v. want %v", n, want)
+	}
+
+	for _, step := range []func() error{ui.Stop, ui.Shutdown} {
+		err = step()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = http.Get("http://" + addr + "/stats")
+	if err == nil {
+		t.Fatalf("expected the data-flow view to be closed")
+	}
+}
+
 func getsumsq(n int64) int64 {
 	sum := int64(0)
 	for i := int64(0); i < n; i++ {
@@ -537,3 +751,1022 @@ func Benchmark__ConcApp(b *testing.B) {
 		}
 	}
 }
+
+func TestRunLumiTransitions(t *testing.T) {
+	newReader := func() io.Reader {
+		buf := new(bytes.Buffer)
+		for _, v := range []struct {
+			run, lumi int64
+			n         int
+		}{
+			{1, 1, 3},
+			{1, 2, 2},
+			{2, 1, 4},
+			{3, 5, 1},
+		} {
+			for i := 0; i < v.n; i++ {
+				fmt.Fprintf(buf, "%d %d\n", v.run, v.lumi)
+			}
+		}
+		return buf
+	}
+
+	for _, test := range []struct {
+		evtmax int64
+		want   []string
+	}{
+		{
+			evtmax: -1,
+			want: []string{
+				"svc: begin-run 1",
+				"tsk: begin-run 1",
+				"tsk: begin-lumi 1/1",
+				"tsk: end-lumi 1/1 n=3",
+				"tsk: begin-lumi 1/2",
+				"tsk: end-lumi 1/2 n=2",
+				"tsk: end-run 1",
+				"svc: end-run 1",
+				"svc: begin-run 2",
+				"tsk: begin-run 2",
+				"tsk: begin-lumi 2/1",
+				"tsk: end-lumi 2/1 n=4",
+				"tsk: end-run 2",
+				"svc: end-run 2",
+				"svc: begin-run 3",
+				"tsk: begin-run 3",
+				"tsk: begin-lumi 3/5",
+				"tsk: end-lumi 3/5 n=1",
+				"tsk: end-run 3",
+				"svc: end-run 3",
+			},
+		},
+		{
+			evtmax: 4,
+			want: []string{
+				"svc: begin-run 1",
+				"tsk: begin-run 1",
+				"tsk: begin-lumi 1/1",
+				"tsk: end-lumi 1/1 n=3",
+				"tsk: begin-lumi 1/2",
+				"tsk: end-lumi 1/2 n=1",
+				"tsk: end-run 1",
+				"svc: end-run 1",
+			},
+		},
+		{
+			evtmax: 0,
+			want:   nil,
+		},
+	} {
+		for _, nprocs := range []int{0, 1, 2, 4, 8} {
+			log := new(testdata.RunLumiLog)
+			app := newapp(test.evtmax, nprocs)
+
+			app.Create(job.C{
+				Type: "go-hep.org/x/hep/fwk/testdata.runsvc",
+				Name: "svc",
+				Props: job.P{
+					"Log": log,
+				},
+			})
+
+			app.Create(job.C{
+				Type: "go-hep.org/x/hep/fwk/testdata.runlumitask",
+				Name: "tsk",
+				Props: job.P{
+					"Input": "evtid",
+					"Log":   log,
+				},
+			})
+
+			app.Create(job.C{
+				Type: "go-hep.org/x/hep/fwk.InputStream",
+				Name: "input",
+				Props: job.P{
+					"Ports": []fwk.Port{
+						{
+							Name: "evtid",
+							Type: reflect.TypeOf(fwk.EventID{}),
+						},
+					},
+					"Streamer": &testdata.RunLumiStream{
+						R: newReader(),
+					},
+				},
+			})
+
+			err := app.App().Run()
+			if err != nil {
+				t.Errorf("error (evtmax=%d nprocs=%d): %v\n", test.evtmax, nprocs, err)
+				continue
+			}
+
+			if !reflect.DeepEqual(log.Log, test.want) {
+				t.Errorf("evtmax=%d nprocs=%d: invalid transitions.\ngot= %q\nwant=%q\n",
+					test.evtmax, nprocs, log.Log, test.want,
+				)
+			}
+		}
+	}
+}
+
+func TestRunLumiNoEventIDer(t *testing.T) {
+	for _, nprocs := range []int{0, 1, 2, 4, 8} {
+		log := new(testdata.RunLumiLog)
+		app := newapp(-1, nprocs)
+
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk/testdata.runlumitask",
+			Name: "tsk",
+			Props: job.P{
+				"Log": log,
+			},
+		})
+
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk.InputStream",
+			Name: "input",
+			Props: job.P{
+				"Ports": []fwk.Port{
+					{
+						Name: "t1-ints1",
+						Type: reflect.TypeOf(int64(1)),
+					},
+				},
+				"Streamer": &testdata.InputStream{
+					R: newTestReader(10),
+				},
+			},
+		})
+
+		err := app.App().Run()
+		if err != nil {
+			t.Errorf("error (nprocs=%d): %v\n", nprocs, err)
+			continue
+		}
+
+		want := []string{
+			"tsk: begin-run 0",
+			"tsk: begin-lumi 0/0",
+			"tsk: end-lumi 0/0 n=10",
+			"tsk: end-run 0",
+		}
+		if !reflect.DeepEqual(log.Log, want) {
+			t.Errorf("nprocs=%d: invalid transitions.\ngot= %q\nwant=%q\n", nprocs, log.Log, want)
+		}
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	for _, nprocs := range []int{0, 1, 4} {
+		for _, ntasks := range []int{1, 2, 4} {
+			tracker := new(testdata.Tracker)
+			app := job.NewJob(nil, job.P{
+				"EvtMax":   int64(50),
+				"NProcs":   nprocs,
+				"NTasks":   ntasks,
+				"MsgLevel": job.MsgLevel("ERROR"),
+			})
+
+			// c depends on b which depends on a. d and e are independent.
+			for _, tsk := range []struct {
+				name  string
+				input string
+			}{
+				{"c", "b"},
+				{"b", "a"},
+				{"a", ""},
+				{"d", ""},
+				{"e", ""},
+			} {
+				app.Create(job.C{
+					Type: "go-hep.org/x/hep/fwk/testdata.schedtask",
+					Name: tsk.name,
+					Props: job.P{
+						"Input":   tsk.input,
+						"Tracker": tracker,
+					},
+				})
+			}
+
+			err := app.App().Run()
+			if err != nil {
+				t.Errorf("error (nprocs=%d ntasks=%d): %v\n", nprocs, ntasks, err)
+				continue
+			}
+
+			if tracker.Max > ntasks {
+				t.Errorf("nprocs=%d ntasks=%d: too many concurrent tasks: %d\n",
+					nprocs, ntasks, tracker.Max,
+				)
+			}
+		}
+	}
+}
+
+func TestUndeclaredInput(t *testing.T) {
+	for _, nprocs := range []int{0, 1, 4} {
+		app := job.NewJob(nil, job.P{
+			"EvtMax":   int64(10),
+			"NProcs":   nprocs,
+			"MsgLevel": job.MsgLevel("ERROR"),
+		})
+
+		tracker := new(testdata.Tracker)
+		app.Create(job.C{
+			Type:  "go-hep.org/x/hep/fwk/testdata.schedtask",
+			Name:  "a",
+			Props: job.P{"Tracker": tracker},
+		})
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk/testdata.schedtask",
+			Name: "b",
+			Props: job.P{
+				"Undeclared": "a",
+				"Tracker":    tracker,
+			},
+		})
+
+		err := app.App().Run()
+		if err == nil {
+			t.Fatalf("nprocs=%d: expected an error", nprocs)
+		}
+		if !strings.Contains(err.Error(), "task [b] did not declare port [a] as input") {
+			t.Fatalf("nprocs=%d: invalid error: %v", nprocs, err)
+		}
+	}
+}
+
+func TestNonReentrantTasks(t *testing.T) {
+	for _, nprocs := range []int{0, 1, 4} {
+		tracker := new(testdata.Tracker)
+		app := job.NewJob(nil, job.P{
+			"EvtMax":   int64(50),
+			"NProcs":   nprocs,
+			"NTasks":   8,
+			"MsgLevel": job.MsgLevel("ERROR"),
+		})
+
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk/testdata.schedtask",
+			Name: "serial",
+			Props: job.P{
+				"Tracker":   tracker,
+				"Reentrant": false,
+			},
+		})
+
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk/testdata.clonetask",
+			Name: "clone",
+			Props: job.P{
+				"Input":   "serial",
+				"Tracker": tracker,
+			},
+		})
+
+		err := app.App().Run()
+		if err != nil {
+			t.Errorf("error (nprocs=%d): %v\n", nprocs, err)
+			continue
+		}
+
+		nslots := nprocs
+		if nslots < 1 {
+			nslots = 1
+		}
+		for name, n := range tracker.Task {
+			if n != 1 {
+				t.Errorf("nprocs=%d: task %q processed %d events concurrently\n", nprocs, name, n)
+			}
+			var slot int
+			switch {
+			case name == "serial/0":
+			case strings.HasPrefix(name, "clone/"):
+				fmt.Sscanf(name, "clone/%d", &slot)
+				if slot < 0 || slot >= nslots {
+					t.Errorf("nprocs=%d: invalid clone slot: %q\n", nprocs, name)
+				}
+			default:
+				t.Errorf("nprocs=%d: unexpected task instance %q\n", nprocs, name)
+			}
+		}
+	}
+}
+
+func TestFilterPaths(t *testing.T) {
+	const max = 100
+	for _, nprocs := range []int{0, 1, 2, 4} {
+		app := job.NewJob(nil, job.P{
+			"EvtMax":   int64(-1),
+			"NProcs":   nprocs,
+			"MsgLevel": job.MsgLevel("ERROR"),
+			"Paths": []fwk.Path{
+				{Name: "even", Tasks: []string{"even", "sq-even"}},
+				{Name: "by3", Tasks: []string{"by3"}},
+			},
+		})
+
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk.InputStream",
+			Name: "input",
+			Props: job.P{
+				"Ports": []fwk.Port{
+					{Name: "ints", Type: reflect.TypeOf(int64(1))},
+				},
+				"Streamer": &testdata.InputStream{
+					R: newTestReader(max),
+				},
+			},
+		})
+
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk/testdata.filter",
+			Name: "even",
+			Props: job.P{
+				"Input": "ints",
+				"Mod":   int64(2),
+			},
+		})
+
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk/testdata.filter",
+			Name: "by3",
+			Props: job.P{
+				"Input": "ints",
+				"Mod":   int64(3),
+			},
+		})
+
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk/testdata.task2",
+			Name: "sq-even",
+			Props: job.P{
+				"Input":  "ints",
+				"Output": "sq-even",
+			},
+		})
+
+		outputs := []struct {
+			name string
+			port string
+			sel  []string
+			want func(i int64) (int64, bool)
+		}{
+			{
+				name: "skim-even",
+				port: "sq-even",
+				sel:  []string{"even"},
+				want: func(i int64) (int64, bool) { return i * i, i%2 == 0 },
+			},
+			{
+				name: "skim-even-or-by3",
+				port: "ints",
+				sel:  []string{"even", "by3"},
+				want: func(i int64) (int64, bool) { return i, i%2 == 0 || i%3 == 0 },
+			},
+			{
+				name: "skim-by3",
+				port: "ints",
+				sel:  []string{"by3"},
+				want: func(i int64) (int64, bool) { return i, i%3 == 0 },
+			},
+			{
+				name: "summary",
+				port: "ints",
+				want: func(i int64) (int64, bool) { return i, true },
+			},
+			{
+				// sq-even is only produced for the events accepted by "even".
+				name: "summary-sq",
+				port: "sq-even",
+				want: func(i int64) (int64, bool) { return i * i, i%2 == 0 },
+			},
+		}
+
+		bufs := make([]bytes.Buffer, len(outputs))
+		for i, out := range outputs {
+			app.Create(job.C{
+				Type: "go-hep.org/x/hep/fwk.OutputStream",
+				Name: out.name,
+				Props: job.P{
+					"Ports": []fwk.Port{
+						{Name: out.port, Type: reflect.TypeOf(int64(1))},
+					},
+					"Streamer": &testdata.OutputStream{W: &bufs[i]},
+					"Select":   out.sel,
+				},
+			})
+		}
+
+		err := app.App().Run()
+		if err != nil {
+			t.Errorf("nprocs=%d: error: %v\n", nprocs, err)
+			continue
+		}
+
+		for i, out := range outputs {
+			want := make(map[int64]bool)
+			for j := int64(0); j < max; j++ {
+				if v, ok := out.want(j); ok {
+					want[v] = true
+				}
+			}
+			got := make(map[int64]bool)
+			for {
+				var v int64
+				_, err := fmt.Fscanf(&bufs[i], "%d\n", &v)
+				if err != nil {
+					break
+				}
+				if got[v] {
+					t.Errorf("nprocs=%d output=%s: duplicate value %d\n", nprocs, out.name, v)
+				}
+				got[v] = true
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("nprocs=%d output=%s: got %d values. want %d\n", nprocs, out.name, len(got), len(want))
+			}
+		}
+	}
+}
+
+func TestFilterPathsInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		paths []fwk.Path
+		sel   []string
+	}{
+		{
+			name:  "no-such-task",
+			paths: []fwk.Path{{Name: "p1", Tasks: []string{"t1", "t4"}}},
+		},
+		{
+			name: "dup-path",
+			paths: []fwk.Path{
+				{Name: "p1", Tasks: []string{"t1"}},
+				{Name: "p1", Tasks: []string{"t2"}},
+			},
+		},
+		{
+			name:  "no-such-path",
+			paths: []fwk.Path{{Name: "p1", Tasks: []string{"t1"}}},
+			sel:   []string{"p2"},
+		},
+		{
+			// t3 consumes the output of t2.
+			name:  "cycle",
+			paths: []fwk.Path{{Name: "p1", Tasks: []string{"t3", "t2"}}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for _, nprocs := range []int{0, 2} {
+				app := job.NewJob(nil, job.P{
+					"EvtMax":   int64(10),
+					"NProcs":   nprocs,
+					"MsgLevel": job.MsgLevel("ERROR"),
+					"Paths":    test.paths,
+				})
+
+				app.Create(job.C{
+					Type: "go-hep.org/x/hep/fwk/testdata.task1",
+					Name: "t1",
+					Props: job.P{
+						"Ints1": "t1-ints1",
+						"Ints2": "t1-ints2",
+					},
+				})
+
+				app.Create(job.C{
+					Type: "go-hep.org/x/hep/fwk/testdata.task2",
+					Name: "t2",
+					Props: job.P{
+						"Input":  "t1-ints1",
+						"Output": "t2-ints",
+					},
+				})
+
+				app.Create(job.C{
+					Type: "go-hep.org/x/hep/fwk/testdata.task2",
+					Name: "t3",
+					Props: job.P{
+						"Input":  "t2-ints",
+						"Output": "t3-ints",
+					},
+				})
+
+				app.Create(job.C{
+					Type: "go-hep.org/x/hep/fwk.OutputStream",
+					Name: "output",
+					Props: job.P{
+						"Ports": []fwk.Port{
+							{Name: "t3-ints", Type: reflect.TypeOf(int64(1))},
+						},
+						"Streamer": &testdata.OutputStream{W: new(bytes.Buffer)},
+						"Select":   test.sel,
+					},
+				})
+
+				err := app.App().Run()
+				if err == nil {
+					t.Errorf("nprocs=%d: expected an error\n", nprocs)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	const (
+		max  = 100
+		fail = 57
+	)
+
+	dir, err := ioutil.TempDir("", "fwk-checkpoint-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	newjob := func(nprocs int, fname string, resume bool, fail int64, streamer fwk.InputStreamer) (*job.Job, *testdata.Sum) {
+		app := job.NewJob(nil, job.P{
+			"EvtMax":          int64(-1),
+			"NProcs":          nprocs,
+			"MsgLevel":        job.MsgLevel("ERROR"),
+			"Checkpoint":      fname,
+			"CheckpointEvery": int64(10),
+			"Resume":          resume,
+		})
+
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk.InputStream",
+			Name: "input",
+			Props: job.P{
+				"Ports": []fwk.Port{
+					{Name: "ints", Type: reflect.TypeOf(int64(1))},
+				},
+				"Streamer": streamer,
+			},
+		})
+
+		sum := new(testdata.Sum)
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk/testdata.accum",
+			Name: "accum",
+			Props: job.P{
+				"Input": "ints",
+				"Fail":  fail,
+				"Sum":   sum,
+			},
+		})
+		return app, sum
+	}
+
+	for _, nprocs := range []int{0, 1, 2, 4} {
+		fname := filepath.Join(dir, fmt.Sprintf("ckpt-%d.gob", nprocs))
+
+		app, _ := newjob(nprocs, fname, false, fail, &testdata.InputStream{R: newTestReader(max)})
+		err := app.App().Run()
+		if err == nil {
+			t.Fatalf("nprocs=%d: expected an error\n", nprocs)
+		}
+
+		// resume from the last checkpoint, before the failing event.
+		// the events already processed are skipped without being read.
+		seeker := &testdata.SeekInputStream{InputStream: testdata.InputStream{R: newTestReader(max)}}
+		app, sum := newjob(nprocs, fname, true, -1, seeker)
+		err = app.App().Run()
+		if err != nil {
+			t.Fatalf("nprocs=%d: error resuming: %v\n", nprocs, err)
+		}
+		if sum.N != max || sum.Sum != max*(max-1)/2 {
+			t.Fatalf("nprocs=%d: got n=%d sum=%d. want n=%d sum=%d\n",
+				nprocs, sum.N, sum.Sum, max, max*(max-1)/2,
+			)
+		}
+		if seeker.Skipped == 0 || seeker.Skipped > fail {
+			t.Fatalf("nprocs=%d: skipped %d events. want (0, %d]\n", nprocs, seeker.Skipped, fail)
+		}
+
+		// resume from the checkpoint of the completed job.
+		app, sum = newjob(nprocs, fname, true, 0, &testdata.InputStream{R: newTestReader(max)})
+		err = app.App().Run()
+		if err != nil {
+			t.Fatalf("nprocs=%d: error resuming: %v\n", nprocs, err)
+		}
+		if sum.N != max || sum.Sum != max*(max-1)/2 {
+			t.Fatalf("nprocs=%d: got n=%d sum=%d. want n=%d sum=%d\n",
+				nprocs, sum.N, sum.Sum, max, max*(max-1)/2,
+			)
+		}
+	}
+}
+
+func TestCheckpointInvalid(t *testing.T) {
+	app := job.NewJob(nil, job.P{
+		"EvtMax":   int64(10),
+		"NProcs":   0,
+		"MsgLevel": job.MsgLevel("ERROR"),
+		"Resume":   true,
+	})
+	err := app.App().Run()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestCheckpointOutputs(t *testing.T) {
+	const max = 20
+
+	dir, err := ioutil.TempDir("", "fwk-checkpoint-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fname := filepath.Join(dir, "ckpt.gob")
+	newjob := func(resume bool, streamer fwk.OutputStreamer) *job.Job {
+		app := job.NewJob(nil, job.P{
+			"EvtMax":     int64(-1),
+			"NProcs":     0,
+			"MsgLevel":   job.MsgLevel("ERROR"),
+			"Checkpoint": fname,
+			"Resume":     resume,
+		})
+
+		ports := []fwk.Port{{Name: "ints", Type: reflect.TypeOf(int64(1))}}
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk.InputStream",
+			Name: "input",
+			Props: job.P{
+				"Ports":    ports,
+				"Streamer": &testdata.InputStream{R: newTestReader(max)},
+			},
+		})
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk.OutputStream",
+			Name: "output",
+			Props: job.P{
+				"Ports":    ports,
+				"Streamer": streamer,
+			},
+		})
+		return app
+	}
+
+	out1 := filepath.Join(dir, "out-1.rio")
+	err = newjob(false, &frio.OutputStreamer{Name: out1}).App().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name     string
+		streamer fwk.OutputStreamer
+	}{
+		{"same-file", &frio.OutputStreamer{Name: out1}},
+		{"unnamed-files", &testdata.OutputStream{W: new(bytes.Buffer)}},
+	} {
+		err = newjob(true, test.streamer).App().Run()
+		if err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+
+	raw, err := ioutil.ReadFile(out1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) == 0 {
+		t.Fatalf("output file written before the checkpoint was overwritten")
+	}
+
+	err = newjob(true, &frio.OutputStreamer{Name: filepath.Join(dir, "out-2.rio")}).App().Run()
+	if err != nil {
+		t.Fatalf("error resuming with a new output file: %v", err)
+	}
+
+	// the output files of all the previous jobs are protected.
+	err = newjob(true, &frio.OutputStreamer{Name: out1}).App().Run()
+	if err == nil {
+		t.Fatalf("expected an error resuming with the output file of the first job")
+	}
+	err = newjob(true, &frio.OutputStreamer{Name: filepath.Join(dir, "out-3.rio")}).App().Run()
+	if err != nil {
+		t.Fatalf("error resuming with a new output file: %v", err)
+	}
+}
+
+func newDistJob(props job.P) (*job.Job, *testdata.Sum) {
+	const max = 100
+	p := job.P{
+		"EvtMax":   int64(max),
+		"NProcs":   2,
+		"MsgLevel": job.MsgLevel("ERROR"),
+	}
+	for k, v := range props {
+		p[k] = v
+	}
+	app := job.NewJob(nil, p)
+
+	app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk.InputStream",
+		Name: "input",
+		Props: job.P{
+			"Ports": []fwk.Port{
+				{Name: "ints", Type: reflect.TypeOf(int64(1))},
+			},
+			"Streamer": &testdata.InputStream{
+				R: newTestReader(max),
+			},
+		},
+	})
+
+	sum := new(testdata.Sum)
+	app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk/testdata.accum",
+		Name: "accum",
+		Props: job.P{
+			"Input": "ints",
+			"Sum":   sum,
+		},
+	})
+
+	app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk.OutputStream",
+		Name: "output",
+		Props: job.P{
+			"Ports": []fwk.Port{
+				{Name: "ints", Type: reflect.TypeOf(int64(1))},
+			},
+			"Streamer": &frio.OutputStreamer{Name: "ints.rio"},
+		},
+	})
+	return app, sum
+}
+
+// TestDistributedWorker is the worker process run by TestDistributed.
+func TestDistributedWorker(t *testing.T) {
+	if os.Getenv("FWK_WORKER") == "" {
+		t.Skip("not a worker")
+	}
+	app, _ := newDistJob(nil)
+	err := app.App().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDistributed(t *testing.T) {
+	const max = 100
+
+	exe, err := filepath.Abs(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "fwk-dist-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(pwd)
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go dist.Serve(l, "s3cr3t")
+
+	defer os.Setenv(dist.SecretEnv, os.Getenv(dist.SecretEnv))
+	os.Setenv(dist.SecretEnv, "s3cr3t")
+
+	for _, test := range []struct {
+		name    string
+		workers int
+		hosts   []string
+	}{
+		{name: "local-1", workers: 1},
+		{name: "local-3", workers: 3},
+		{name: "local-remote", workers: 1, hosts: []string{l.Addr().String()}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			app, sum := newDistJob(job.P{
+				"Workers":   test.workers,
+				"Hosts":     test.hosts,
+				"WorkDir":   test.name,
+				"WorkerCmd": []string{exe, "-test.run=^TestDistributedWorker$"},
+			})
+			err := app.App().Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if sum.N != max || sum.Sum != max*(max-1)/2 {
+				t.Fatalf("got n=%d sum=%d. want n=%d sum=%d\n",
+					sum.N, sum.Sum, max, max*(max-1)/2,
+				)
+			}
+
+			f, err := os.Open("ints.rio")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			r, err := rio.NewReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+
+			scan := rio.NewScanner(r)
+			scan.Select([]rio.Selector{{Name: "ints", Unpack: true}})
+			got := make(map[int64]bool)
+			for scan.Scan() {
+				var v int64
+				err = scan.Record().Block("ints").Read(&v)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got[v] {
+					t.Fatalf("duplicate value %d\n", v)
+				}
+				got[v] = true
+			}
+			if err := scan.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != max {
+				t.Fatalf("got %d merged events. want %d\n", len(got), max)
+			}
+		})
+	}
+}
+
+func TestDistributedInvalid(t *testing.T) {
+	app, _ := newDistJob(job.P{
+		"EvtMax":  int64(-1),
+		"Workers": 2,
+	})
+	err := app.App().Run()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+type syncbuf struct {
+	bytes.Buffer
+}
+
+func (*syncbuf) Sync() error { return nil }
+
+func TestMsgStream(t *testing.T) {
+	w := new(syncbuf)
+	msg := fwk.NewMsgStream("t0", fwk.LvlInfo, w)
+	msg.Debugf("debug %d\n", 1)
+	msg.Infof("info %d\n", 2)
+	msg.Warnf("warn %d\n", 3)
+	fwk.Log(msg, fwk.LvlDebug, "debug")
+	fwk.Log(msg, fwk.LvlError, "event failed", "id", 42, "err", "no such key", "n")
+	// a MsgStream which is not a StructuredLogger.
+	fwk.Log(struct{ fwk.MsgStream }{msg}, fwk.LvlWarning, "no input", "name", "evt=1")
+
+	want := strings.Join([]string{
+		"t0                   INFO info 2",
+		"t0                   WARN warn 3",
+		`t0                   ERR  event failed id=42 err="no such key" n=(MISSING)`,
+		`t0                   WARN no input name="evt=1"`,
+		"",
+	}, "\n")
+	if got := w.String(); got != want {
+		t.Fatalf("invalid messages.\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMsgLog(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "fwk-msg-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, test := range []struct {
+		name   string
+		nprocs int
+		input  bool
+		tasks  []string
+	}{
+		{name: "concurrent", nprocs: 2, tasks: []string{"t0", "t1"}},
+		// t0 comes right after the input stream, which is taken out of the tasks.
+		{name: "sequential-input", nprocs: 0, input: true, tasks: []string{"t0"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fname := filepath.Join(tmp, test.name+".log")
+			app := job.NewJob(nil, job.P{
+				"EvtMax":       int64(10),
+				"NProcs":       test.nprocs,
+				"MsgLevel":     fwk.LvlError,
+				"MsgLevels":    map[string]fwk.Level{"t0": fwk.LvlInfo},
+				"MsgFormat":    "json",
+				"MsgFile":      fname,
+				"MsgRateLimit": 3,
+			})
+			if test.input {
+				app.Create(job.C{
+					Type: "go-hep.org/x/hep/fwk.InputStream",
+					Name: "input",
+					Props: job.P{
+						"Ports": []fwk.Port{
+							{Name: "ints", Type: reflect.TypeOf(int64(1))},
+						},
+						"Streamer": &testdata.InputStream{
+							R: newTestReader(10),
+						},
+					},
+				})
+			}
+			for _, name := range test.tasks {
+				app.Create(job.C{
+					Type: "go-hep.org/x/hep/fwk/testdata.task1",
+					Name: name,
+					Props: job.P{
+						"Ints1": name + "-ints1",
+						"Ints2": name + "-ints2",
+					},
+				})
+			}
+			app.Run()
+
+			f, err := os.Open(fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			msgs := make(map[string]int)
+			dec := json.NewDecoder(f)
+			for {
+				var rec struct {
+					Level string
+					Name  string
+					Msg   string
+					Limit int
+				}
+				err = dec.Decode(&rec)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if rec.Name != "t0" || rec.Level != "INFO" {
+					t.Fatalf("unexpected message: %+v", rec)
+				}
+				if strings.HasPrefix(rec.Msg, "proc... ") {
+					rec.Msg = "proc..."
+				}
+				msgs[rec.Msg]++
+				if rec.Limit != 0 && rec.Limit != 3 {
+					t.Fatalf("invalid rate-limit: %+v", rec)
+				}
+			}
+
+			want := map[string]int{
+				"configure ...":        1,
+				"configure ... [done]": 1,
+				"start...":             1,
+				"proc...":              3,
+				"message repeated too many times: further occurrences suppressed": 1,
+				"stop...": 1,
+			}
+			if !reflect.DeepEqual(msgs, want) {
+				t.Fatalf("invalid messages.\ngot:  %v\nwant: %v", msgs, want)
+			}
+		})
+	}
+}
+
+func TestMsgInvalid(t *testing.T) {
+	for _, props := range []job.P{
+		{"MsgFormat": "xml"},
+		{"MsgRateLimit": -1},
+		{"MsgFile": filepath.Join("no", "such", "dir", "job.log")},
+	} {
+		app := job.NewJob(nil, props)
+		err := app.App().Run()
+		if err == nil {
+			t.Fatalf("%v: expected an error", props)
+		}
+	}
+}
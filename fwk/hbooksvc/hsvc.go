@@ -5,6 +5,9 @@
 package hbooksvc // import "go-hep.org/x/hep/fwk/hbooksvc"
 
 import (
+	"encoding"
+	"encoding/gob"
+	"io"
 	"os"
 	"reflect"
 	"strings"
@@ -39,6 +42,7 @@ type s2d struct {
 type hsvc struct {
 	fwk.SvcBase
 
+	mu   sync.RWMutex // protects the maps of histograms and streams
 	h1ds map[fwk.HID]*h1d
 	h2ds map[fwk.HID]*h2d
 	p1ds map[fwk.HID]*p1d
@@ -155,6 +159,9 @@ func (svc *hsvc) BookH1D(name string, nbins int, low, high float64) (fwk.H1D, er
 		return h, fwk.Errorf("fwk: can not book histograms during FSM-state %v", svc.FSMState())
 	}
 
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
 	stream, hid := svc.split(name)
 	h = fwk.H1D{
 		ID:   fwk.HID(hid),
@@ -210,6 +217,9 @@ func (svc *hsvc) BookH2D(name string, nx int, xmin, xmax float64, ny int, ymin,
 		return h, fwk.Errorf("fwk: can not book histograms during FSM-state %v", svc.FSMState())
 	}
 
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
 	stream, hid := svc.split(name)
 	h = fwk.H2D{
 		ID:   fwk.HID(hid),
@@ -265,6 +275,9 @@ func (svc *hsvc) BookP1D(name string, nbins int, low, high float64) (fwk.P1D, er
 		return h, fwk.Errorf("fwk: can not book histograms during FSM-state %v", svc.FSMState())
 	}
 
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
 	stream, hid := svc.split(name)
 	h = fwk.P1D{
 		ID:      fwk.HID(hid),
@@ -320,6 +333,9 @@ func (svc *hsvc) BookS2D(name string) (fwk.S2D, error) {
 		return h, fwk.Errorf("fwk: can not book histograms during FSM-state %v", svc.FSMState())
 	}
 
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
 	stream, hid := svc.split(name)
 	h = fwk.S2D{
 		ID:      fwk.HID(hid),
@@ -404,34 +420,217 @@ func (svc *hsvc) split(n string) (string, string) {
 }
 
 func (svc *hsvc) FillH1D(id fwk.HID, x, w float64) {
+	svc.mu.RLock()
 	h := svc.h1ds[id]
+	svc.mu.RUnlock()
 	h.mu.Lock()
 	h.Hist.Fill(x, w)
 	h.mu.Unlock()
 }
 
 func (svc *hsvc) FillH2D(id fwk.HID, x, y, w float64) {
+	svc.mu.RLock()
 	h := svc.h2ds[id]
+	svc.mu.RUnlock()
 	h.mu.Lock()
 	h.Hist.Fill(x, y, w)
 	h.mu.Unlock()
 }
 
 func (svc *hsvc) FillP1D(id fwk.HID, x, y, w float64) {
+	svc.mu.RLock()
 	h := svc.p1ds[id]
+	svc.mu.RUnlock()
 	h.mu.Lock()
 	h.Profile.Fill(x, y, w)
 	h.mu.Unlock()
 }
 
 func (svc *hsvc) FillS2D(id fwk.HID, x, y float64) {
+	svc.mu.RLock()
 	h := svc.s2ds[id]
+	svc.mu.RUnlock()
 	h.mu.Lock()
 	// FIXME(sbinet): weight?
 	h.Scatter.Fill(hbook.Point2D{X: x, Y: y})
 	h.mu.Unlock()
 }
 
+// state is the state of the histograms saved in checkpoints.
+type state struct {
+	H1Ds map[fwk.HID]*hbook.H1D
+	H2Ds map[fwk.HID]*hbook.H2D
+	P1Ds map[fwk.HID]*hbook.P1D
+	S2Ds map[fwk.HID]*hbook.S2D
+}
+
+// SaveState saves the content of the booked histograms.
+// Histograms read from input streams are not saved.
+func (svc *hsvc) SaveState(w io.Writer) error {
+	st, err := svc.snapshot()
+	if err != nil {
+		return fwk.Errorf("%s: could not save histograms: %v", svc.Name(), err)
+	}
+
+	err = gob.NewEncoder(w).Encode(st)
+	if err != nil {
+		return fwk.Errorf("%s: could not save histograms: %v", svc.Name(), err)
+	}
+	return nil
+}
+
+// snapshot returns a copy of the booked histograms, each taken while the
+// histogram is locked.
+func (svc *hsvc) snapshot() (state, error) {
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
+
+	read := make(map[fwk.HID]bool)
+	for _, r := range svc.r {
+		for _, obj := range r.objs {
+			read[fwk.HID(obj.Name())] = true
+		}
+	}
+
+	st := state{
+		H1Ds: make(map[fwk.HID]*hbook.H1D, len(svc.h1ds)),
+		H2Ds: make(map[fwk.HID]*hbook.H2D, len(svc.h2ds)),
+		P1Ds: make(map[fwk.HID]*hbook.P1D, len(svc.p1ds)),
+		S2Ds: make(map[fwk.HID]*hbook.S2D, len(svc.s2ds)),
+	}
+	for id, h := range svc.h1ds {
+		if read[id] {
+			continue
+		}
+		v := new(hbook.H1D)
+		h.mu.RLock()
+		err := clone(v, h.Hist)
+		h.mu.RUnlock()
+		if err != nil {
+			return st, err
+		}
+		st.H1Ds[id] = v
+	}
+	for id, h := range svc.h2ds {
+		if read[id] {
+			continue
+		}
+		v := new(hbook.H2D)
+		h.mu.RLock()
+		err := clone(v, h.Hist)
+		h.mu.RUnlock()
+		if err != nil {
+			return st, err
+		}
+		st.H2Ds[id] = v
+	}
+	for id, h := range svc.p1ds {
+		if read[id] {
+			continue
+		}
+		v := new(hbook.P1D)
+		h.mu.RLock()
+		err := clone(v, h.Profile)
+		h.mu.RUnlock()
+		if err != nil {
+			return st, err
+		}
+		st.P1Ds[id] = v
+	}
+	for id, h := range svc.s2ds {
+		if read[id] {
+			continue
+		}
+		v := new(hbook.S2D)
+		h.mu.RLock()
+		err := clone(v, h.Scatter)
+		h.mu.RUnlock()
+		if err != nil {
+			return st, err
+		}
+		st.S2Ds[id] = v
+	}
+	return st, nil
+}
+
+// clone copies src into dst.
+func clone(dst encoding.BinaryUnmarshaler, src encoding.BinaryMarshaler) error {
+	buf, err := src.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return dst.UnmarshalBinary(buf)
+}
+
+// LoadState adds the saved content of histograms to the booked histograms.
+// All the saved histograms must have been booked.
+func (svc *hsvc) LoadState(r io.Reader) error {
+	var st state
+	err := gob.NewDecoder(r).Decode(&st)
+	if err != nil {
+		return fwk.Errorf("%s: could not load histograms: %v", svc.Name(), err)
+	}
+
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
+
+	for id, v := range st.H1Ds {
+		h, ok := svc.h1ds[id]
+		if !ok {
+			return fwk.Errorf("%s: no H1D [%s] booked", svc.Name(), id)
+		}
+		h.mu.Lock()
+		sum, err := hbook.AddH1D(h.Hist, v)
+		if err == nil {
+			*h.Hist = *sum
+		}
+		h.mu.Unlock()
+		if err != nil {
+			return fwk.Errorf("%s: could not load H1D [%s]: %v", svc.Name(), id, err)
+		}
+	}
+	for id, v := range st.H2Ds {
+		h, ok := svc.h2ds[id]
+		if !ok {
+			return fwk.Errorf("%s: no H2D [%s] booked", svc.Name(), id)
+		}
+		h.mu.Lock()
+		sum, err := hbook.AddH2D(h.Hist, v)
+		if err == nil {
+			*h.Hist = *sum
+		}
+		h.mu.Unlock()
+		if err != nil {
+			return fwk.Errorf("%s: could not load H2D [%s]: %v", svc.Name(), id, err)
+		}
+	}
+	for id, v := range st.P1Ds {
+		h, ok := svc.p1ds[id]
+		if !ok {
+			return fwk.Errorf("%s: no P1D [%s] booked", svc.Name(), id)
+		}
+		h.mu.Lock()
+		sum, err := hbook.AddP1D(h.Profile, v)
+		if err == nil {
+			*h.Profile = *sum
+		}
+		h.mu.Unlock()
+		if err != nil {
+			return fwk.Errorf("%s: could not load P1D [%s]: %v", svc.Name(), id, err)
+		}
+	}
+	for id, v := range st.S2Ds {
+		h, ok := svc.s2ds[id]
+		if !ok {
+			return fwk.Errorf("%s: no S2D [%s] booked", svc.Name(), id)
+		}
+		h.mu.Lock()
+		h.Scatter.Fill(v.Points()...)
+		h.mu.Unlock()
+	}
+	return nil
+}
+
 func newhsvc(typ, name string, mgr fwk.App) (fwk.Component, error) {
 	var err error
 	svc := &hsvc{
@@ -456,4 +655,7 @@ func init() {
 	fwk.Register(reflect.TypeOf(hsvc{}), newhsvc)
 }
 
-var _ fwk.HistSvc = (*hsvc)(nil)
+var (
+	_ fwk.HistSvc      = (*hsvc)(nil)
+	_ fwk.Checkpointer = (*hsvc)(nil)
+)
@@ -6,7 +6,9 @@ package hbooksvc
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -90,6 +92,57 @@ func TestHbookSvcConc(t *testing.T) {
 	}
 }
 
+func TestHbookSvcCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fwk-hbooksvc-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	newjob := func(evtmax int64, nprocs int, fname string, resume bool) *job.Job {
+		app := job.NewJob(nil, job.P{
+			"EvtMax":          evtmax,
+			"NProcs":          nprocs,
+			"MsgLevel":        job.MsgLevel("ERROR"),
+			"Checkpoint":      fname,
+			"CheckpointEvery": int64(10),
+			"Resume":          resume,
+		})
+
+		for i := 0; i < nhists; i++ {
+			app.Create(job.C{
+				Type: "go-hep.org/x/hep/fwk/hbooksvc.testhsvc",
+				Name: fmt.Sprintf("t%03d", i),
+				Props: job.P{
+					// only the resumed job has seen all the entries.
+					"Check": resume,
+				},
+			})
+		}
+
+		app.Create(job.C{
+			Type:  "go-hep.org/x/hep/fwk/hbooksvc.hsvc",
+			Name:  "histsvc",
+			Props: job.P{},
+		})
+		return app
+	}
+
+	for _, nprocs := range []int{0, 1, 4} {
+		fname := filepath.Join(dir, fmt.Sprintf("ckpt-%d.gob", nprocs))
+
+		err := newjob(40, nprocs, fname, false).App().Run()
+		if err != nil {
+			t.Fatalf("nprocs=%d: error: %v", nprocs, err)
+		}
+
+		err = newjob(nentries, nprocs, fname, true).App().Run()
+		if err != nil {
+			t.Fatalf("nprocs=%d: error resuming: %v", nprocs, err)
+		}
+	}
+}
+
 func TestHbookStreamName(t *testing.T) {
 	var svc hsvc
 	for _, test := range []struct {
@@ -155,6 +208,7 @@ type testhsvc struct {
 	hsvc   fwk.HistSvc
 	h1d    fwk.H1D
 	stream string
+	check  bool // whether to check the content of h1d when stopped
 }
 
 func (tsk *testhsvc) Configure(ctx fwk.Context) error {
@@ -191,6 +245,10 @@ func (tsk *testhsvc) StartTask(ctx fwk.Context) error {
 func (tsk *testhsvc) StopTask(ctx fwk.Context) error {
 	var err error
 
+	if !tsk.check {
+		return err
+	}
+
 	h := tsk.h1d.Hist
 	if got := h.Entries(); got != nentries {
 		return fwk.Errorf("got %d entries. want=%d", got, nentries)
@@ -218,6 +276,7 @@ func newtesthsvc(typ, name string, mgr fwk.App) (fwk.Component, error) {
 	tsk := &testhsvc{
 		TaskBase: fwk.NewTask(typ, name, mgr),
 		stream:   "",
+		check:    true,
 	}
 
 	err = tsk.DeclProp("Stream", &tsk.stream)
@@ -225,6 +284,11 @@ func newtesthsvc(typ, name string, mgr fwk.App) (fwk.Component, error) {
 		return nil, err
 	}
 
+	err = tsk.DeclProp("Check", &tsk.check)
+	if err != nil {
+		return nil, err
+	}
+
 	return tsk, err
 }
 
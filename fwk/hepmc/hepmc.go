@@ -0,0 +1,27 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hepmc provides a fwk.InputStreamer and a fwk.OutputStreamer
+// reading and writing HepMC events, one event per Read or Write.
+//
+// The streamers expect a single fwk.Port, of type hepmc.Event.
+package hepmc // import "go-hep.org/x/hep/fwk/hepmc"
+
+import (
+	"reflect"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/hepmc"
+)
+
+// checkPorts checks ports hold a single hepmc.Event.
+func checkPorts(ports []fwk.Port) error {
+	if len(ports) != 1 {
+		return fwk.Errorf("fwk/hepmc: expected 1 port. got=%d", len(ports))
+	}
+	if rt := reflect.TypeOf(hepmc.Event{}); ports[0].Type != rt {
+		return fwk.Errorf("fwk/hepmc: invalid port %q. expected type=%v. got=%v", ports[0].Name, rt, ports[0].Type)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hepmc
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/fwk/job"
+	"go-hep.org/x/hep/hepmc"
+)
+
+const fname = "../../hepmc/testdata/test.hepmc"
+
+// readEvents returns the events of the HepMC file fname.
+func readEvents(t *testing.T, fname string) []hepmc.Event {
+	f, err := os.Open(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var evts []hepmc.Event
+	dec := hepmc.NewDecoder(bufio.NewReader(f))
+	for {
+		var evt hepmc.Event
+		err = dec.Decode(&evt)
+		if err == io.EOF {
+			return evts
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		evts = append(evts, evt)
+	}
+}
+
+// TestStreamers copies the events of two input files into an output file.
+func TestStreamers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fwk-hepmc-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oname := filepath.Join(dir, "out.hepmc")
+	ports := []fwk.Port{{Name: "mcevt", Type: reflect.TypeOf(hepmc.Event{})}}
+
+	app := job.NewJob(nil, job.P{
+		"EvtMax":   int64(-1),
+		"NProcs":   0,
+		"MsgLevel": job.MsgLevel("ERROR"),
+	})
+	app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk.InputStream",
+		Name: "input",
+		Props: job.P{
+			"Ports":    ports,
+			"Streamer": &InputStreamer{Names: []string{fname, fname}},
+		},
+	})
+	app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk.OutputStream",
+		Name: "output",
+		Props: job.P{
+			"Ports":    ports,
+			"Streamer": &OutputStreamer{Name: oname},
+		},
+	})
+
+	err = app.App().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref := readEvents(t, fname)
+	ref = append(ref, ref...)
+	evts := readEvents(t, oname)
+	if len(evts) != len(ref) {
+		t.Fatalf("got %d events. want %d", len(evts), len(ref))
+	}
+	for i := range evts {
+		got, want := &evts[i], &ref[i]
+		if got.EventNumber != want.EventNumber ||
+			len(got.Particles) != len(want.Particles) ||
+			len(got.Vertices) != len(want.Vertices) {
+			t.Errorf("event #%d: got evt=%d (%d particles, %d vertices). want evt=%d (%d particles, %d vertices)",
+				i,
+				got.EventNumber, len(got.Particles), len(got.Vertices),
+				want.EventNumber, len(want.Particles), len(want.Vertices),
+			)
+		}
+	}
+}
+
+// TestNoEvents checks a file without events is a valid HepMC file.
+func TestNoEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fwk-hepmc-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oname := filepath.Join(dir, "out.hepmc")
+	o := &OutputStreamer{Name: oname}
+	err = o.Connect([]fwk.Port{{Name: "evt", Type: reflect.TypeOf(hepmc.Event{})}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = o.Disconnect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if evts := readEvents(t, oname); len(evts) != 0 {
+		t.Fatalf("got %d events. want 0", len(evts))
+	}
+	raw, err := ioutil.ReadFile(oname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(raw, []byte("HepMC::Version")) {
+		t.Fatalf("no HepMC header:\n%s", raw)
+	}
+}
+
+func TestCheckPorts(t *testing.T) {
+	for _, ports := range [][]fwk.Port{
+		nil,
+		{{Name: "mcevt", Type: reflect.TypeOf(&hepmc.Event{})}},
+		{
+			{Name: "mcevt1", Type: reflect.TypeOf(hepmc.Event{})},
+			{Name: "mcevt2", Type: reflect.TypeOf(hepmc.Event{})},
+		},
+	} {
+		if err := checkPorts(ports); err == nil {
+			t.Errorf("ports=%v: expected an error", ports)
+		}
+	}
+}
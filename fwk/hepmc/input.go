@@ -0,0 +1,114 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hepmc
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/hepmc"
+)
+
+// InputStreamer reads HepMC events from a (set of) file(s).
+// Files are read one after the other.
+type InputStreamer struct {
+	Names []string // input filenames
+
+	port  fwk.Port       // input port to populate
+	ifile int            // index of the current input file
+	r     io.ReadCloser  // current input file
+	dec   *hepmc.Decoder // current HepMC decoder
+}
+
+func (input *InputStreamer) Connect(ports []fwk.Port) error {
+	var err error
+
+	if len(input.Names) == 0 {
+		return fwk.Errorf("fwk/hepmc: no input file")
+	}
+
+	err = checkPorts(ports)
+	if err != nil {
+		return err
+	}
+	input.port = ports[0]
+
+	input.ifile = 0
+	err = input.open()
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+// open opens the current input file.
+func (input *InputStreamer) open() error {
+	var err error
+
+	input.r, err = os.Open(input.Names[input.ifile])
+	if err != nil {
+		return err
+	}
+
+	input.dec = hepmc.NewDecoder(bufio.NewReader(input.r))
+	return err
+}
+
+// close closes the current input file.
+func (input *InputStreamer) close() error {
+	var err error
+	if input.r != nil {
+		err = input.r.Close()
+		input.r = nil
+		input.dec = nil
+	}
+	return err
+}
+
+func (input *InputStreamer) Read(ctx fwk.Context) error {
+	var err error
+
+	var evt hepmc.Event
+	for {
+		err = input.dec.Decode(&evt)
+		if err == nil {
+			break
+		}
+		if err != io.EOF {
+			return fwk.Errorf("fwk/hepmc: could not decode event from %q: %v", input.Names[input.ifile], err)
+		}
+		if input.ifile+1 >= len(input.Names) {
+			return io.EOF
+		}
+		err = input.close()
+		if err != nil {
+			return err
+		}
+		input.ifile++
+		err = input.open()
+		if err != nil {
+			return err
+		}
+		evt = hepmc.Event{}
+	}
+
+	err = ctx.Store().Put(input.port.Name, evt)
+	if err != nil {
+		return fwk.Errorf("store-put error: %v", err)
+	}
+
+	return err
+}
+
+func (input *InputStreamer) Disconnect() error {
+	return input.close()
+}
+
+var (
+	_ fwk.InputStreamer = (*InputStreamer)(nil)
+)
@@ -0,0 +1,136 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hepmc
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/hepmc"
+)
+
+// OutputStreamer writes HepMC events to a file.
+type OutputStreamer struct {
+	Name string // output filename
+
+	port fwk.Port       // output port to write out
+	w    io.WriteCloser // underlying output file
+	buf  *bufio.Writer
+	enc  *hepmc.Encoder
+}
+
+func (o *OutputStreamer) Connect(ports []fwk.Port) error {
+	var err error
+
+	err = checkPorts(ports)
+	if err != nil {
+		return err
+	}
+	o.port = ports[0]
+
+	o.w, err = os.Create(o.Name)
+	if err != nil {
+		return err
+	}
+
+	o.buf = bufio.NewWriter(o.w)
+	o.enc = hepmc.NewEncoder(o.buf)
+	return err
+}
+
+func (o *OutputStreamer) Write(ctx fwk.Context) error {
+	var err error
+
+	v, err := ctx.Store().Get(o.port.Name)
+	if err != nil {
+		return err
+	}
+
+	evt, ok := v.(hepmc.Event)
+	if !ok {
+		return fwk.Errorf("fwk/hepmc: port %q: got type=%T. want type=hepmc.Event", o.port.Name, v)
+	}
+
+	err = o.enc.Encode(&evt)
+	if err != nil {
+		return fwk.Errorf("fwk/hepmc: could not encode event: %v", err)
+	}
+
+	return err
+}
+
+func (o *OutputStreamer) Disconnect() error {
+	// make sure we don't leak filedescriptors
+	defer o.w.Close()
+
+	err := o.enc.Close()
+	if err != nil {
+		return err
+	}
+
+	err = o.buf.Flush()
+	if err != nil {
+		return err
+	}
+
+	err = o.w.Close()
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+// MergeOutputs appends the events of the files named Name written by the
+// workers of a distributed job, in the directories dirs.
+func (o *OutputStreamer) MergeOutputs(dirs []string) error {
+	for _, dir := range dirs {
+		err := o.merge(filepath.Join(dir, o.Name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OutputStreamer) merge(fname string) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := hepmc.NewDecoder(bufio.NewReader(f))
+	for {
+		var evt hepmc.Event
+		err = dec.Decode(&evt)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fwk.Errorf("fwk/hepmc: could not decode event from %q: %v", fname, err)
+		}
+		err = o.enc.Encode(&evt)
+		if err != nil {
+			return fwk.Errorf("fwk/hepmc: could not encode event: %v", err)
+		}
+	}
+
+	return f.Close()
+}
+
+// OutputFiles returns the name of the output file.
+func (o *OutputStreamer) OutputFiles() []string {
+	return []string{o.Name}
+}
+
+var (
+	_ fwk.OutputStreamer = (*OutputStreamer)(nil)
+	_ fwk.OutputFiler    = (*OutputStreamer)(nil)
+	_ fwk.OutputMerger   = (*OutputStreamer)(nil)
+)
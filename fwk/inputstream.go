@@ -21,8 +21,9 @@ import (
 type InputStream struct {
 	TaskBase
 
-	streamer InputStreamer
-	ctrl     StreamControl
+	streamer  InputStreamer
+	ctrl      StreamControl
+	connected bool // whether the streamer is connected
 }
 
 // Configure declares the output ports defined by the 'Ports' property.
@@ -63,6 +64,7 @@ func (tsk *InputStream) connect(ctrl StreamControl) error {
 	if err != nil {
 		return err
 	}
+	tsk.connected = true
 
 	go tsk.read()
 
@@ -70,6 +72,10 @@ func (tsk *InputStream) connect(ctrl StreamControl) error {
 }
 
 func (tsk *InputStream) disconnect() error {
+	if !tsk.connected {
+		return nil
+	}
+	tsk.connected = false
 	return tsk.streamer.Disconnect()
 }
 
@@ -101,6 +107,16 @@ func (tsk *InputStream) Process(ctx Context) error {
 	return err
 }
 
+// eventID returns the identifier of the last event read,
+// if the underlying InputStreamer is an EventIDer.
+func (tsk *InputStream) eventID() (EventID, bool) {
+	ider, ok := tsk.streamer.(EventIDer)
+	if !ok {
+		return EventID{}, false
+	}
+	return ider.EventID(), true
+}
+
 func newInputStream(typ, name string, mgr App) (Component, error) {
 	var err error
 
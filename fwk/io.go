@@ -30,6 +30,40 @@ type InputStreamer interface {
 	Disconnect() error
 }
 
+// InputSeeker is the interface implemented by InputStreamers which can skip
+// input events without reading them.
+//
+// SeekEvent is called once, after Connect and before the first Read, when the
+// first n input events were processed by a previous job (see Checkpointer)
+// or are processed by the other workers of a distributed job.
+// The next Read must read the input event n, or return io.EOF if there are
+// at most n input events.
+// The input events of InputStreamers which are not InputSeekers are read
+// and discarded.
+type InputSeeker interface {
+	SeekEvent(n int64) error
+}
+
+// EventID identifies an event by its run, luminosity block and event numbers.
+type EventID struct {
+	Run   int64 // run number
+	Lumi  int64 // luminosity block number
+	Event int64 // event number
+}
+
+// EventIDer is the interface implemented by InputStreamers which know
+// the run and luminosity block of the events they read.
+//
+// EventID is called after each successful Read and returns the
+// identifier of the event just read.
+// Consecutive events with different run or luminosity block numbers
+// trigger the RunHandler and LumiBlockHandler transitions.
+// If the InputStreamer is not an EventIDer, all the events are
+// considered part of the run 0 and of the luminosity block 0.
+type EventIDer interface {
+	EventID() EventID
+}
+
 // OutputStreamer gets data from the Context
 // and writes it to the underlying io.Writer
 type OutputStreamer interface {
@@ -47,3 +81,25 @@ type OutputStreamer interface {
 	// It does not (and can not) close the underlying io.Writer.
 	Disconnect() error
 }
+
+// OutputMerger is the interface implemented by OutputStreamers which can
+// merge the outputs written by the worker processes of a distributed job.
+//
+// MergeOutputs is called by the coordinator of the job, once all the
+// workers completed, after Connect and before Disconnect.
+// dirs are the directories where the workers wrote their outputs, in the
+// order of the ranges of input events they processed.
+type OutputMerger interface {
+	MergeOutputs(dirs []string) error
+}
+
+// OutputFiler is the interface implemented by OutputStreamers writing
+// their outputs to files.
+//
+// OutputFiles returns the names of the files written by the streamer.
+// A job resumed from a checkpoint only runs output streams whose
+// streamers are OutputFilers writing to files different from the ones
+// written before the checkpoint.
+type OutputFiler interface {
+	OutputFiles() []string
+}
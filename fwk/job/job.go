@@ -5,6 +5,8 @@
 package job // import "go-hep.org/x/hep/fwk/job"
 
 import (
+	"reflect"
+
 	"go-hep.org/x/hep/fwk"
 )
 
@@ -75,13 +77,26 @@ func (job *Job) UI() UI {
 // data held by cfg.
 // Create panics if no such component was registered with fwk.
 func (job *Job) Create(cfg C) fwk.Component {
+	return job.create(cfg, false)
+}
+
+// create creates a fwk.Component according to cfg.
+// If conv is set, the values of the properties are first converted to the
+// types of the properties declared by the component, as for the values
+// decoded from a job description.
+func (job *Job) create(cfg C, conv bool) fwk.Component {
 	c, err := job.app.New(cfg.Type, cfg.Name)
 	if err != nil {
 		job.Errorf("could not create [%s:%s]: %v\n", cfg.Type, cfg.Name, err)
 		panic(err)
 	}
-	if cfg.Props == nil {
-		return c
+
+	if conv {
+		props := make(P, len(cfg.Props))
+		for k, v := range cfg.Props {
+			props[k] = job.convert(c, k, v)
+		}
+		cfg.Props = props
 	}
 
 	for k, v := range cfg.Props {
@@ -112,6 +127,46 @@ func (job *Job) SetProp(c fwk.Component, name string, value interface{}) {
 	})
 }
 
+// convert converts the value v, decoded from a job description, to the type
+// of the property name of the component c.
+// Values already assignable to that type are returned as is.
+// convert panics if the component does not have such property or if the
+// value can not be converted.
+func (job *Job) convert(c fwk.Component, name string, v interface{}) interface{} {
+	if !job.app.HasProp(c, name) {
+		err := fwk.Errorf("component [%s:%s] has no property named %q\n",
+			c.Type(),
+			c.Name(),
+			name,
+		)
+		job.Errorf("%v", err)
+		panic(err)
+	}
+
+	def, err := job.app.GetProp(c, name)
+	if err != nil {
+		job.Errorf("could not get property %q of [%s]: %v\n", name, c.Name(), err)
+		panic(err)
+	}
+	if def == nil || v == nil {
+		// property of interface type, holding no value.
+		return v
+	}
+
+	rt := reflect.TypeOf(def)
+	if reflect.TypeOf(v).AssignableTo(rt) {
+		return v
+	}
+
+	rv, err := tomlConvert(v, rt)
+	if err != nil {
+		err = fwk.Errorf("invalid value for property %q of [%s]: %v", name, c.Name(), err)
+		job.Errorf("%v\n", err)
+		panic(err)
+	}
+	return rv.Interface()
+}
+
 func (job *Job) setProp(c fwk.Component, name string, value interface{}) {
 	if !job.app.HasProp(c, name) {
 		err := fwk.Errorf("component [%s:%s] has no property named %q\n",
@@ -119,7 +174,7 @@ func (job *Job) setProp(c fwk.Component, name string, value interface{}) {
 			c.Name(),
 			name,
 		)
-		job.Errorf(err.Error())
+		job.Errorf("%v", err)
 		panic(err)
 	}
 
@@ -135,6 +190,86 @@ func (job *Job) setProp(c fwk.Component, name string, value interface{}) {
 	}
 }
 
+// Exec executes the statements of a job description, as loaded from a
+// Decoder, on this Job: it configures the fwk.App with the properties of
+// the StmtNewApp statements, creates the components of the StmtCreate
+// statements and sets the properties of the StmtSetProp statements.
+// The values of the properties are converted to the types of the
+// properties, as needed for values decoded from a TOML description.
+//
+// A StmtNewApp statement naming the application differently replaces the
+// fwk.App of the Job with a new one, with that name and the properties set
+// so far, provided no component was created yet.
+//
+// Exec panics if a component could not be created or configured.
+func (job *Job) Exec(stmts []Stmt) {
+	for _, stmt := range stmts {
+		switch stmt.Type {
+		case StmtNewApp:
+			if name := stmt.Data.Name; name != "" && name != job.app.Name() {
+				job.rename(name)
+			}
+			for k, v := range stmt.Data.Props {
+				job.SetProp(job.app, k, job.convert(job.app, k, v))
+			}
+		case StmtCreate:
+			job.create(stmt.Data, true)
+		case StmtSetProp:
+			var c fwk.Component = job.app
+			if stmt.Data.Name != job.app.Name() {
+				c = job.app.Component(stmt.Data.Name)
+			}
+			if c == nil {
+				err := fwk.Errorf("no component named [%s]", stmt.Data.Name)
+				job.Errorf("could not set properties: %v\n", err)
+				panic(err)
+			}
+			for k, v := range stmt.Data.Props {
+				job.SetProp(c, k, job.convert(c, k, v))
+			}
+		default:
+			panic(fwk.Errorf("invalid statement type (%d)", int(stmt.Type)))
+		}
+	}
+}
+
+// rename replaces the fwk.App of the job with a new application named name,
+// configured with the properties set so far on the current one.
+// rename panics if components were already created.
+func (job *Job) rename(name string) {
+	old := job.app.Name()
+	for _, stmt := range job.stmts {
+		if stmt.Type == StmtCreate {
+			err := fwk.Errorf("could not rename application [%s] to [%s]: component [%s] already created", old, name, stmt.Data.Name)
+			job.Errorf("%v\n", err)
+			panic(err)
+		}
+	}
+
+	c, err := job.app.New(job.app.Type(), name)
+	if err != nil {
+		job.Errorf("could not create application [%s]: %v\n", name, err)
+		panic(err)
+	}
+	app, ok := c.(fwk.App)
+	if !ok {
+		err = fwk.Errorf("component [%s:%s] is not a fwk.App", c.Type(), name)
+		job.Errorf("%v\n", err)
+		panic(err)
+	}
+
+	job.app = app
+	for i, stmt := range job.stmts {
+		if stmt.Data.Name != old {
+			continue
+		}
+		for k, v := range stmt.Data.Props {
+			job.setProp(app, k, v)
+		}
+		job.stmts[i].Data.Name = name
+	}
+}
+
 // Run runs the underlying fwk.App.
 // Run panics if an error occurred during any of the execution
 // stages of the application.
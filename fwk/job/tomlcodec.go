@@ -0,0 +1,614 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package job
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"go-hep.org/x/hep/fwk"
+)
+
+// NewTOMLEncoder returns a new encoder that writes to w
+func NewTOMLEncoder(w io.Writer) *TOMLEncoder {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &TOMLEncoder{w: w}
+}
+
+// A TOMLEncoder writes the TOML description of a job to an output stream.
+//
+// The statements setting properties are folded into the description of the
+// application or of the component they modify.
+// Properties whose values have no TOML representation (pointers, functions,
+// interfaces, ...) are written as comments.
+type TOMLEncoder struct {
+	w io.Writer
+}
+
+// Encode encodes data into the underlying io.Writer
+func (enc *TOMLEncoder) Encode(data interface{}) error {
+	stmts, ok := data.([]Stmt)
+	if !ok {
+		return fmt.Errorf("fwk/job: expected a []job.Stmt as input. got %T", data)
+	}
+
+	var (
+		app   *C
+		comps []*C
+		names = make(map[string]*C)
+	)
+	for _, stmt := range stmts {
+		c := C{
+			Name:  stmt.Data.Name,
+			Type:  stmt.Data.Type,
+			Props: make(P, len(stmt.Data.Props)),
+		}
+		for k, v := range stmt.Data.Props {
+			c.Props[k] = v
+		}
+
+		switch stmt.Type {
+		case StmtNewApp:
+			app = &c
+			names[c.Name] = app
+		case StmtCreate:
+			comps = append(comps, &c)
+			names[c.Name] = &c
+		case StmtSetProp:
+			dst, ok := names[c.Name]
+			if !ok {
+				return fmt.Errorf("fwk/job: SetProp statement for unknown component [%s]", c.Name)
+			}
+			for k, v := range c.Props {
+				dst.Props[k] = v
+			}
+		default:
+			return fmt.Errorf("fwk/job: invalid statement type (%d)", int(stmt.Type))
+		}
+	}
+
+	w := bufio.NewWriter(enc.w)
+	fmt.Fprintf(w, "# automatically generated by go-hep.org/x/hep/fwk/job.\n")
+	if app != nil {
+		encodeTOMLComp(w, "[app]", "[app.props]", app)
+	}
+	for _, c := range comps {
+		encodeTOMLComp(w, "[[component]]", "[component.props]", c)
+	}
+	return w.Flush()
+}
+
+func encodeTOMLComp(w io.Writer, table, props string, c *C) {
+	fmt.Fprintf(w, "\n%s\nname = %s\ntype = %s\n", table, tomlString(c.Name), tomlString(c.Type))
+	if len(c.Props) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(c.Props))
+	for k := range c.Props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(w, "\n%s\n", props)
+	for _, k := range keys {
+		buf := new(bytes.Buffer)
+		err := encodeTOMLValue(buf, reflect.ValueOf(c.Props[k]))
+		if err != nil {
+			fmt.Fprintf(w, "# %s: %v\n", tomlKey(k), err)
+			continue
+		}
+		fmt.Fprintf(w, "%s = %s\n", tomlKey(k), buf.Bytes())
+	}
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	levelType    = reflect.TypeOf(fwk.Level(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+func encodeTOMLValue(w *bytes.Buffer, rv reflect.Value) error {
+	if !rv.IsValid() {
+		return fmt.Errorf("no TOML representation for nil values")
+	}
+
+	if rv.Type() == durationType {
+		w.WriteString(tomlString(time.Duration(rv.Int()).String()))
+		return nil
+	}
+
+	if rv.Type() == levelType {
+		switch lvl := fwk.Level(rv.Int()); lvl {
+		case fwk.LvlDebug, fwk.LvlInfo, fwk.LvlWarning, fwk.LvlError:
+			w.WriteString(tomlString(lvl.String()))
+			return nil
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		w.WriteString(strconv.FormatBool(rv.Bool()))
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.WriteString(strconv.FormatInt(rv.Int(), 10))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return fmt.Errorf("no TOML representation for integer %d (overflows int64)", rv.Uint())
+		}
+		w.WriteString(strconv.FormatUint(rv.Uint(), 10))
+
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		switch {
+		case math.IsInf(f, +1):
+			w.WriteString("inf")
+		case math.IsInf(f, -1):
+			w.WriteString("-inf")
+		case math.IsNaN(f):
+			w.WriteString("nan")
+		default:
+			str := strconv.FormatFloat(f, 'g', -1, rv.Type().Bits())
+			if !strings.ContainsAny(str, ".e") {
+				str += ".0"
+			}
+			w.WriteString(str)
+		}
+
+	case reflect.String:
+		w.WriteString(tomlString(rv.String()))
+
+	case reflect.Slice, reflect.Array:
+		w.WriteString("[")
+		for i := 0; i < rv.Len(); i++ {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			err := encodeTOMLValue(w, rv.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+		w.WriteString("]")
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("no TOML representation for values of type %v", rv.Type())
+		}
+		keys := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		w.WriteString("{")
+		for i, k := range keys {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			fmt.Fprintf(w, "%s = ", tomlKey(k))
+			err := encodeTOMLValue(w, rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())))
+			if err != nil {
+				return err
+			}
+		}
+		w.WriteString("}")
+
+	case reflect.Struct:
+		rt := rv.Type()
+		w.WriteString("{")
+		n := 0
+		for i := 0; i < rt.NumField(); i++ {
+			f := rt.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if n > 0 {
+				w.WriteString(", ")
+			}
+			n++
+			fmt.Fprintf(w, "%s = ", tomlKey(f.Name))
+			err := encodeTOMLValue(w, rv.Field(i))
+			if err != nil {
+				return err
+			}
+		}
+		w.WriteString("}")
+
+	case reflect.Interface:
+		if rv.IsNil() {
+			return fmt.Errorf("no TOML representation for nil values of type %v", rv.Type())
+		}
+		return encodeTOMLValue(w, rv.Elem())
+
+	default:
+		return fmt.Errorf("no TOML representation for values of type %v", rv.Type())
+	}
+	return nil
+}
+
+// tomlString returns the TOML basic string representation of s.
+func tomlString(s string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&buf, `\u%04x`, r)
+				continue
+			}
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// tomlKey returns the TOML representation of the key k.
+func tomlKey(k string) string {
+	if k == "" {
+		return `""`
+	}
+	for i := 0; i < len(k); i++ {
+		if !isBareKey(k[i]) {
+			return tomlString(k)
+		}
+	}
+	return k
+}
+
+// NewTOMLDecoder returns a new decoder that reads from r.
+func NewTOMLDecoder(r io.Reader) *TOMLDecoder {
+	name := "<input>"
+	if f, ok := r.(interface {
+		Name() string
+	}); ok {
+		name = f.Name()
+	}
+	return &TOMLDecoder{r: r, Name: name}
+}
+
+// A TOMLDecoder reads the TOML description of a job from an input stream:
+//
+//	[app]             # optional
+//	name = "app"      # optional
+//	type = "go-hep.org/x/hep/fwk.appmgr" # optional
+//
+//	[app.props]
+//	EvtMax = 100
+//
+//	[[component]]     # one per component, in order of creation
+//	name = "my-task"
+//	type = "go-hep.org/x/hep/fwk/testdata.task1"
+//
+//	[component.props] # properties of the previous component
+//	Ints1 = "t0-ints1"
+//
+// Unless Raw is set, the description is validated against the components
+// registered with fwk: each component needs a unique name and a registered
+// type.
+//
+// The values of the properties are left as decoded, as bool, int64,
+// float64, string, time.Time, []interface{}, []map[string]interface{} or
+// map[string]interface{} values.
+// Job.Exec checks the components declare these properties and converts the
+// values to the types of the properties, once the components are created.
+//
+// 'fwk-app run job.toml' runs such a description, and 'fwk-app dump'
+// writes the description of a job (see TOMLEncoder.)
+type TOMLDecoder struct {
+	r io.Reader
+
+	// Name is the name of the input, used in error messages.
+	// Name defaults to the name of the input file, if any.
+	Name string
+
+	// Raw disables the validation of the description against the
+	// registered components, e.g. when these are not linked in.
+	Raw bool
+}
+
+// tomlDoc is the layout of a TOML job description.
+type tomlDoc struct {
+	App       tomlComp   `toml:"app"`
+	Component []tomlComp `toml:"component"`
+}
+
+// tomlComp is the TOML description of the application or of a component.
+type tomlComp struct {
+	Name  string                 `toml:"name"`
+	Type  string                 `toml:"type"`
+	Props map[string]interface{} `toml:"props"`
+}
+
+const appType = "go-hep.org/x/hep/fwk.appmgr"
+
+// Decode decodes the statements of a job description into ptr, a *[]Stmt.
+func (dec *TOMLDecoder) Decode(ptr interface{}) error {
+	stmts, ok := ptr.(*[]Stmt)
+	if !ok {
+		return fmt.Errorf("fwk/job: expected a *[]job.Stmt as input. got %T", ptr)
+	}
+
+	var doc tomlDoc
+	md, err := toml.DecodeReader(dec.r, &doc)
+	if err != nil {
+		return dec.errorf("%v", err)
+	}
+
+	for _, key := range md.Undecoded() {
+		if len(key) > 2 && key[1] == "props" {
+			// keys of the tables and arrays of tables of the properties.
+			continue
+		}
+		return dec.errorf("unknown key %q (properties go in the props table)", key.String())
+	}
+
+	if doc.App.Name == "" {
+		doc.App.Name = "app"
+	}
+	if doc.App.Type == "" {
+		doc.App.Type = appType
+	}
+	if !dec.Raw && doc.App.Type != appType {
+		return dec.errorf("unknown application type [%s] (want [%s])", doc.App.Type, appType)
+	}
+
+	var registered map[string]bool
+	if !dec.Raw {
+		registered = make(map[string]bool)
+		for _, typ := range fwk.Registry() {
+			registered[typ] = true
+		}
+	}
+
+	names := map[string]bool{doc.App.Name: true}
+	out := make([]Stmt, 0, len(doc.Component)+1)
+	out = append(out, Stmt{Type: StmtNewApp, Data: doc.App.config()})
+	for i, c := range doc.Component {
+		switch {
+		case c.Name == "":
+			return dec.errorf("component #%d with no name", i)
+		case c.Type == "":
+			return dec.errorf("component [%s] with no type", c.Name)
+		case names[c.Name]:
+			return dec.errorf("component with name [%s] already created", c.Name)
+		case registered != nil && !registered[c.Type]:
+			return dec.errorf("no component with type [%s] registered (component [%s])", c.Type, c.Name)
+		}
+		names[c.Name] = true
+		out = append(out, Stmt{Type: StmtCreate, Data: c.config()})
+	}
+
+	*stmts = out
+	return nil
+}
+
+func (c tomlComp) config() C {
+	cfg := C{
+		Name:  c.Name,
+		Type:  c.Type,
+		Props: make(P, len(c.Props)),
+	}
+	for k, v := range c.Props {
+		cfg.Props[k] = v
+	}
+	return cfg
+}
+
+func (dec *TOMLDecoder) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %s", dec.Name, fmt.Sprintf(format, args...))
+}
+
+// tomlConvert converts the decoded TOML value v to a value of type rt.
+func tomlConvert(v interface{}, rt reflect.Type) (reflect.Value, error) {
+	rv := reflect.New(rt).Elem()
+	mismatch := func() (reflect.Value, error) {
+		return rv, fmt.Errorf("cannot use TOML %s as %v", tomlTypeName(v), rt)
+	}
+
+	if rt == durationType {
+		switch v := v.(type) {
+		case string:
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return rv, err
+			}
+			rv.SetInt(int64(d))
+			return rv, nil
+		default:
+			return mismatch()
+		}
+	}
+
+	if rt == timeType {
+		t, ok := v.(time.Time)
+		if !ok {
+			return mismatch()
+		}
+		rv.Set(reflect.ValueOf(t))
+		return rv, nil
+	}
+
+	if s, ok := v.(string); ok && rt == levelType {
+		switch strings.ToUpper(s) {
+		case "DEBUG", "INFO", "WARNING", "WARN", "ERROR", "ERR":
+			rv.SetInt(int64(MsgLevel(s)))
+			return rv, nil
+		}
+		return rv, fmt.Errorf("invalid message level %q", s)
+	}
+
+	switch rt.Kind() {
+	case reflect.Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return mismatch()
+		}
+		rv.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := v.(int64)
+		if !ok {
+			return mismatch()
+		}
+		if rv.OverflowInt(i) {
+			return rv, fmt.Errorf("integer %d overflows %v", i, rt)
+		}
+		rv.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := v.(int64)
+		if !ok {
+			return mismatch()
+		}
+		if i < 0 || rv.OverflowUint(uint64(i)) {
+			return rv, fmt.Errorf("integer %d overflows %v", i, rt)
+		}
+		rv.SetUint(uint64(i))
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch v := v.(type) {
+		case float64:
+			f = v
+		case int64:
+			f = float64(v)
+		default:
+			return mismatch()
+		}
+		if rv.OverflowFloat(f) {
+			return rv, fmt.Errorf("float %v overflows %v", f, rt)
+		}
+		rv.SetFloat(f)
+
+	case reflect.String:
+		s, ok := v.(string)
+		if !ok {
+			return mismatch()
+		}
+		rv.SetString(s)
+
+	case reflect.Slice, reflect.Array:
+		var vs []interface{}
+		switch v := v.(type) {
+		case []interface{}:
+			vs = v
+		case []map[string]interface{}:
+			// array of tables.
+			for _, table := range v {
+				vs = append(vs, table)
+			}
+		default:
+			return mismatch()
+		}
+		if rt.Kind() == reflect.Slice {
+			rv.Set(reflect.MakeSlice(rt, len(vs), len(vs)))
+		} else if len(vs) != rt.Len() {
+			return rv, fmt.Errorf("cannot use TOML array of length %d as %v", len(vs), rt)
+		}
+		for i, v := range vs {
+			elem, err := tomlConvert(v, rt.Elem())
+			if err != nil {
+				return rv, fmt.Errorf("index %d: %v", i, err)
+			}
+			rv.Index(i).Set(elem)
+		}
+
+	case reflect.Map:
+		table, ok := v.(map[string]interface{})
+		if !ok || rt.Key().Kind() != reflect.String {
+			return mismatch()
+		}
+		rv.Set(reflect.MakeMap(rt))
+		for k, v := range table {
+			elem, err := tomlConvert(v, rt.Elem())
+			if err != nil {
+				return rv, fmt.Errorf("key %q: %v", k, err)
+			}
+			rv.SetMapIndex(reflect.ValueOf(k).Convert(rt.Key()), elem)
+		}
+
+	case reflect.Struct:
+		table, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		for k, v := range table {
+			f, ok := rt.FieldByName(k)
+			if !ok || f.PkgPath != "" {
+				return rv, fmt.Errorf("%v has no field %q", rt, k)
+			}
+			field, err := tomlConvert(v, f.Type)
+			if err != nil {
+				return rv, fmt.Errorf("field %q: %v", k, err)
+			}
+			rv.FieldByIndex(f.Index).Set(field)
+		}
+
+	case reflect.Interface:
+		if rt.NumMethod() != 0 {
+			return mismatch()
+		}
+		rv.Set(reflect.ValueOf(v))
+
+	default:
+		return mismatch()
+	}
+	return rv, nil
+}
+
+// tomlTypeName returns the name of the TOML type of the decoded value v.
+func tomlTypeName(v interface{}) string {
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case int64:
+		return "integer"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case time.Time:
+		return "date-time"
+	case []interface{}, []map[string]interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "table"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// isBareKey returns whether c may appear in a TOML bare key.
+func isBareKey(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '_' || c == '-'
+}
@@ -0,0 +1,409 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package job
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/fwk/testdata"
+	"go-hep.org/x/hep/fwk/utils/errstack"
+)
+
+func TestTOMLEncode(t *testing.T) {
+	appcfg := C{
+		Name: "app",
+		Type: "go-hep.org/x/hep/fwk.appmgr",
+		Props: P{
+			"EvtMax":   int64(10),
+			"NProcs":   42,
+			"MsgLevel": fwk.LvlDebug,
+			"Hosts":    []string{"host1:7070", "host2:7070"},
+		},
+	}
+
+	cfg0 := C{
+		Type: "go-hep.org/x/hep/fwk/testdata.task1",
+		Name: "t0",
+		Props: P{
+			"Ints1": "t0-ints1",
+			"Ints2": "t0-ints2",
+		},
+	}
+
+	cfg1 := C{
+		Type: "go-hep.org/x/hep/fwk/testdata.task1",
+		Name: "t1",
+		Props: P{
+			"Ints1": "t1-ints1",
+			"Ints2": "t1-ints2",
+		},
+	}
+
+	cfg2 := C{
+		Type: "go-hep.org/x/hep/fwk/testdata.svc1",
+		Name: "svc1",
+		Props: P{
+			"Int":    testdata.MyInt(12),
+			"Struct": testdata.MyStruct{I: 12},
+		},
+	}
+
+	job := NewJob(
+		fwk.NewApp(),
+		appcfg.Props,
+	)
+
+	if job == nil {
+		t.Fatalf("got nil job.Job")
+	}
+
+	job.Create(cfg0)
+
+	comp1 := job.Create(cfg1)
+	job.SetProp(comp1, "Ints1", "t1-ints1-modified")
+
+	job.Create(cfg2)
+
+	buf := new(bytes.Buffer)
+	enc := NewTOMLEncoder(buf)
+	err := enc.Encode(job.Stmts())
+	if err != nil {
+		t.Fatalf("error toml-encoding: %v\n", err)
+	}
+
+	dec := NewTOMLDecoder(bytes.NewReader(buf.Bytes()))
+	stmts := make([]Stmt, 0)
+	err = dec.Decode(&stmts)
+	if err != nil {
+		t.Fatalf("error toml-decoding: %v\n%s", err, buf.String())
+	}
+
+	var names []string
+	for _, stmt := range stmts {
+		if stmt.Type != StmtCreate {
+			continue
+		}
+		names = append(names, stmt.Data.Name)
+	}
+	if want := []string{"t0", "t1", "svc1"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("got components %v. want %v\ntoml:\n%s", names, want, buf.String())
+	}
+
+	job = NewJob(fwk.NewApp(), nil)
+	job.Exec(stmts)
+	app := job.App()
+	for _, tc := range []struct {
+		c    fwk.Component
+		prop string
+		want interface{}
+	}{
+		{app, "EvtMax", int64(10)},
+		{app, "NProcs", 42},
+		{app, "MsgLevel", fwk.LvlDebug},
+		{app, "Hosts", []string{"host1:7070", "host2:7070"}},
+		{app.Component("t0"), "Ints2", "t0-ints2"},
+		{app.Component("t1"), "Ints1", "t1-ints1-modified"},
+		{app.Component("svc1"), "Int", testdata.MyInt(12)},
+		{app.Component("svc1"), "Struct", testdata.MyStruct{I: 12}},
+	} {
+		got, err := app.GetProp(tc.c, tc.prop)
+		if err != nil {
+			t.Fatalf("%s.%s: %v", tc.c.Name(), tc.prop, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s.%s: got %#v. want %#v", tc.c.Name(), tc.prop, got, tc.want)
+		}
+	}
+}
+
+func TestTOMLEncodeUnsupported(t *testing.T) {
+	job := NewJob(fwk.NewApp(), nil)
+	job.Create(C{
+		Type: "go-hep.org/x/hep/fwk/testdata.task2",
+		Name: "t2",
+		Props: P{
+			"Input":  "ints",
+			"Output": "floats",
+			"Fct":    func(f int64) int64 { return f },
+		},
+	})
+
+	buf := new(bytes.Buffer)
+	err := NewTOMLEncoder(buf).Encode(job.Stmts())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "\n# Fct: no TOML representation for values of type func(int64) int64\n") {
+		t.Fatalf("expected a comment for the 'Fct' property:\n%s", buf.String())
+	}
+
+	var stmts []Stmt
+	err = NewTOMLDecoder(buf).Decode(&stmts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stmts[1].Data.Props, (P{"Input": "ints", "Output": "floats"}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v. want %v", got, want)
+	}
+}
+
+func TestTOMLDecode(t *testing.T) {
+	const doc = `# a job.
+[app]
+name = "my-app"
+
+[app.props]
+EvtMax = 1_000 # events
+MsgLevel = "debug"
+
+[[component]]
+name = "t0"
+type = 'go-hep.org/x/hep/fwk/testdata.task1'
+[component.props]
+"Ints1" = "t0-\"ints1\"é"
+
+[[component]]
+name = "svc"
+type = "go-hep.org/x/hep/fwk/testdata.svc1"
+
+[component.props]
+Struct = { I = 16 }
+
+[[component]]
+name = "t2"
+type = "go-hep.org/x/hep/fwk/testdata.task1"
+`
+
+	var stmts []Stmt
+	err := NewTOMLDecoder(strings.NewReader(doc)).Decode(&stmts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []Stmt{
+		{
+			Type: StmtNewApp,
+			Data: C{
+				Name:  "my-app",
+				Type:  "go-hep.org/x/hep/fwk.appmgr",
+				Props: P{"EvtMax": int64(1000), "MsgLevel": "debug"},
+			},
+		},
+		{
+			Type: StmtCreate,
+			Data: C{
+				Name:  "t0",
+				Type:  "go-hep.org/x/hep/fwk/testdata.task1",
+				Props: P{"Ints1": "t0-\"ints1\"é"},
+			},
+		},
+		{
+			Type: StmtCreate,
+			Data: C{
+				Name:  "svc",
+				Type:  "go-hep.org/x/hep/fwk/testdata.svc1",
+				Props: P{"Struct": map[string]interface{}{"I": int64(16)}},
+			},
+		},
+		{
+			Type: StmtCreate,
+			Data: C{
+				Name:  "t2",
+				Type:  "go-hep.org/x/hep/fwk/testdata.task1",
+				Props: P{},
+			},
+		},
+	}
+	if !reflect.DeepEqual(stmts, exp) {
+		t.Fatalf("unexpected statements:\nexp=%#v\ngot=%#v", exp, stmts)
+	}
+
+	job := NewJob(fwk.NewApp(), P{"NProcs": 2})
+	job.Exec(stmts)
+	app := job.App()
+	if got, want := app.Name(), "my-app"; got != want {
+		t.Fatalf("got app name %q. want %q", got, want)
+	}
+	for _, tc := range []struct {
+		c    fwk.Component
+		prop string
+		want interface{}
+	}{
+		{app, "EvtMax", int64(1000)},
+		{app, "NProcs", 2},
+		{app, "MsgLevel", fwk.LvlDebug},
+		{app.Component("svc"), "Struct", testdata.MyStruct{I: 16}},
+	} {
+		got, err := app.GetProp(tc.c, tc.prop)
+		if err != nil {
+			t.Fatalf("%s.%s: %v", tc.c.Name(), tc.prop, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s.%s: got %#v. want %#v", tc.c.Name(), tc.prop, got, tc.want)
+		}
+	}
+
+	for _, stmt := range job.Stmts() {
+		if stmt.Type != StmtCreate || stmt.Data.Name != "svc" {
+			continue
+		}
+		if got, want := stmt.Data.Props["Struct"], (testdata.MyStruct{I: 16}); !reflect.DeepEqual(got, want) {
+			t.Fatalf("recorded statement: got %#v. want %#v", got, want)
+		}
+	}
+
+	dec := NewTOMLDecoder(strings.NewReader("[[component]]\nname = \"t0\"\ntype = \"my/pkg.Task\"\n[component.props]\nFloats = [[1, 2], [2.5]]\n"))
+	dec.Raw = true
+	err = dec.Decode(&stmts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{[]interface{}{int64(1), int64(2)}, []interface{}{2.5}}
+	if got := stmts[1].Data.Props["Floats"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v. want %#v", got, want)
+	}
+}
+
+func TestTOMLDecodeErrors(t *testing.T) {
+	const task1 = "[[component]]\nname = \"t0\"\ntype = \"go-hep.org/x/hep/fwk/testdata.task1\"\n[component.props]\n"
+	for _, tc := range []struct {
+		doc string
+		err string
+	}{
+		{
+			doc: "EvtMax = 10\n",
+			err: `<input>: unknown key "EvtMax" (properties go in the props table)`,
+		},
+		{
+			doc: "[app]\nEvtMax = 10\n",
+			err: `<input>: unknown key "app.EvtMax" (properties go in the props table)`,
+		},
+		{
+			doc: "[app.props]\nEvtMax = 10\nEvtMax = 20\n",
+			err: `<input>: Near line 3 (last key parsed 'app.props.EvtMax'): Key 'app.props.EvtMax' has already been defined.`,
+		},
+		{
+			doc: "[app.props]\nEvtMax = 10\n\n[apps]\n",
+			err: `<input>: unknown key "apps" (properties go in the props table)`,
+		},
+		{
+			doc: "[app.props]\nEvtMax = \"10\n",
+			err: `<input>: Near line 2 (last key parsed 'app.props.EvtMax'): strings cannot contain newlines`,
+		},
+		{
+			doc: "[app]\ntype = \"my.app\"\n",
+			err: `<input>: unknown application type [my.app] (want [go-hep.org/x/hep/fwk.appmgr])`,
+		},
+		{
+			doc: "[app.props]\n\n[[component]]\nname = \"t0\"\ntype = \"no/such.Type\"\n",
+			err: `<input>: no component with type [no/such.Type] registered (component [t0])`,
+		},
+		{
+			doc: "[[component]]\ntype = \"go-hep.org/x/hep/fwk/testdata.task1\"\n",
+			err: `<input>: component #0 with no name`,
+		},
+		{
+			doc: "[[component]]\nname = \"t0\"\n",
+			err: `<input>: component [t0] with no type`,
+		},
+		{
+			doc: task1 + "\n" + task1,
+			err: `<input>: component with name [t0] already created`,
+		},
+	} {
+		var stmts []Stmt
+		err := NewTOMLDecoder(strings.NewReader(tc.doc)).Decode(&stmts)
+		if err == nil {
+			t.Errorf("%q: expected an error", tc.doc)
+			continue
+		}
+		if got := err.Error(); got != tc.err {
+			t.Errorf("%q: invalid error.\ngot:  %s\nwant: %s", tc.doc, got, tc.err)
+		}
+	}
+}
+
+func TestExecErrors(t *testing.T) {
+	const (
+		task1 = "[[component]]\nname = \"t0\"\ntype = \"go-hep.org/x/hep/fwk/testdata.task1\"\n[component.props]\n"
+		svc1  = "[[component]]\nname = \"svc\"\ntype = \"go-hep.org/x/hep/fwk/testdata.svc1\"\n[component.props]\n"
+	)
+	for _, tc := range []struct {
+		doc string
+		err string
+	}{
+		{
+			doc: "[app.props]\nNoSuchProp = 10\n",
+			err: `component [go-hep.org/x/hep/fwk.appmgr:app] has no property named "NoSuchProp"`,
+		},
+		{
+			doc: "[app.props]\nEvtMax = \"10\"\n",
+			err: `invalid value for property "EvtMax" of [app]: cannot use TOML string as int64`,
+		},
+		{
+			doc: "[app.props]\nMsgLevel = \"VERBOSE\"\n",
+			err: `invalid value for property "MsgLevel" of [app]: invalid message level "VERBOSE"`,
+		},
+		{
+			doc: "[app.props]\nHosts = [1, 2]\n",
+			err: `invalid value for property "Hosts" of [app]: index 0: cannot use TOML integer as string`,
+		},
+		{
+			doc: task1 + "Ints = \"ints\"\n",
+			err: `component [go-hep.org/x/hep/fwk/testdata.task1:t0] has no property named "Ints"`,
+		},
+		{
+			doc: svc1 + "Int = 1.5\n",
+			err: `invalid value for property "Int" of [svc]: cannot use TOML float as testdata.MyInt`,
+		},
+		{
+			doc: svc1 + "Struct = { J = 1 }\n",
+			err: `invalid value for property "Struct" of [svc]: testdata.MyStruct has no field "J"`,
+		},
+	} {
+		var stmts []Stmt
+		err := NewTOMLDecoder(strings.NewReader(tc.doc)).Decode(&stmts)
+		if err != nil {
+			t.Errorf("%q: %v", tc.doc, err)
+			continue
+		}
+		err = execStmts(NewJob(fwk.NewApp(), P{"MsgLevel": fwk.LvlError}), stmts)
+		if err == nil {
+			t.Errorf("%q: expected an error", tc.doc)
+			continue
+		}
+		if got := strings.TrimSpace(err.Error()); got != tc.err {
+			t.Errorf("%q: invalid error.\ngot:  %s\nwant: %s", tc.doc, got, tc.err)
+		}
+	}
+
+	job := NewJob(fwk.NewApp(), P{"MsgLevel": fwk.LvlError})
+	job.Create(C{Type: "go-hep.org/x/hep/fwk/testdata.task1", Name: "t0"})
+	err := execStmts(job, []Stmt{{Type: StmtNewApp, Data: C{Name: "my-app"}}})
+	want := "could not rename application [app] to [my-app]: component [t0] already created"
+	if err == nil || err.Error() != want {
+		t.Fatalf("got error %v. want %q", err, want)
+	}
+}
+
+// execStmts executes stmts on job and returns the error Exec panicked
+// with, if any.
+func execStmts(job *Job, stmts []Stmt) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = e.(error)
+			if e, ok := err.(*errstack.Error); ok {
+				err = e.Err
+			}
+		}
+	}()
+	job.Exec(stmts)
+	return nil
+}
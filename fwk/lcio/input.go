@@ -0,0 +1,133 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lcio
+
+import (
+	"io"
+	"reflect"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/lcio"
+)
+
+// InputStreamer reads LCIO events from a (set of) file(s).
+// Files are read one after the other.
+type InputStreamer struct {
+	Names []string // input filenames
+
+	ports streamPorts  // input ports to populate
+	ifile int          // index of the current input file
+	r     *lcio.Reader // current LCIO reader
+	evtid fwk.EventID  // identifier of the last event read
+}
+
+func (input *InputStreamer) Connect(ports []fwk.Port) error {
+	var err error
+
+	if len(input.Names) == 0 {
+		return fwk.Errorf("fwk/lcio: no input file")
+	}
+
+	input.ports, err = newStreamPorts(ports)
+	if err != nil {
+		return err
+	}
+
+	input.ifile = 0
+	input.r, err = lcio.Open(input.Names[input.ifile])
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+// close closes the current input file.
+func (input *InputStreamer) close() error {
+	var err error
+	if input.r != nil {
+		err = input.r.Close()
+		input.r = nil
+	}
+	return err
+}
+
+func (input *InputStreamer) Read(ctx fwk.Context) error {
+	var err error
+	store := ctx.Store()
+
+	for !input.r.Next() {
+		err = input.r.Err()
+		if err != io.EOF {
+			return fwk.Errorf("fwk/lcio: could not read event from %q: %v", input.Names[input.ifile], err)
+		}
+		if input.ifile+1 >= len(input.Names) {
+			return io.EOF
+		}
+		err = input.close()
+		if err != nil {
+			return err
+		}
+		input.ifile++
+		input.r, err = lcio.Open(input.Names[input.ifile])
+		if err != nil {
+			return err
+		}
+	}
+	if err = input.r.Err(); err != nil {
+		return fwk.Errorf("fwk/lcio: could not read event from %q: %v", input.Names[input.ifile], err)
+	}
+
+	evt := input.r.Event()
+	input.evtid = fwk.EventID{
+		Run:   int64(evt.RunNumber),
+		Event: int64(evt.EventNumber),
+	}
+
+	for _, port := range input.ports.colls {
+		ptr := evt.Get(port.Name)
+		if ptr == nil {
+			return fwk.Errorf("fwk/lcio: event %d has no collection %q", evt.EventNumber, port.Name)
+		}
+		v := reflect.ValueOf(ptr).Elem()
+		if v.Type() != port.Type {
+			return fwk.Errorf("fwk/lcio: collection %q holds values of type %v (port type=%v)", port.Name, v.Type(), port.Type)
+		}
+		err = store.Put(port.Name, v.Interface())
+		if err != nil {
+			return fwk.Errorf("store-put error: %v", err)
+		}
+	}
+
+	if port := input.ports.ehdr; port != nil {
+		err = store.Put(port.Name, input.r.EventHeader())
+		if err != nil {
+			return fwk.Errorf("store-put error: %v", err)
+		}
+	}
+
+	if port := input.ports.rhdr; port != nil {
+		err = store.Put(port.Name, input.r.RunHeader())
+		if err != nil {
+			return fwk.Errorf("store-put error: %v", err)
+		}
+	}
+
+	return err
+}
+
+// EventID returns the run and event numbers of the last event read.
+func (input *InputStreamer) EventID() fwk.EventID {
+	return input.evtid
+}
+
+func (input *InputStreamer) Disconnect() error {
+	return input.close()
+}
+
+var (
+	_ fwk.InputStreamer = (*InputStreamer)(nil)
+	_ fwk.EventIDer     = (*InputStreamer)(nil)
+)
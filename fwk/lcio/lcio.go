@@ -0,0 +1,65 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lcio provides a fwk.InputStreamer and a fwk.OutputStreamer
+// reading and writing LCIO events, one event per Read or Write.
+//
+// Each fwk.Port is mapped onto the LCIO collection with the same name.
+// The type of the port is the type of the collection, e.g.
+// lcio.McParticleContainer or lcio.CalorimeterHitContainer.
+//
+// Two kinds of ports are handled specially:
+//  - a port of type lcio.EventHeader holds the header of the event,
+//  - a port of type lcio.RunHeader holds the header of the run of the event.
+//
+// The InputStreamer implements fwk.EventIDer, from the run and event
+// numbers of the LCIO events.
+package lcio // import "go-hep.org/x/hep/fwk/lcio"
+
+import (
+	"reflect"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/lcio"
+	"go-hep.org/x/hep/sio"
+)
+
+var (
+	ehdrType  = reflect.TypeOf(lcio.EventHeader{})
+	rhdrType  = reflect.TypeOf(lcio.RunHeader{})
+	codecType = reflect.TypeOf((*sio.Codec)(nil)).Elem()
+)
+
+// streamPorts holds the ports of a LCIO stream.
+type streamPorts struct {
+	ehdr  *fwk.Port  // event header port, if any
+	rhdr  *fwk.Port  // run header port, if any
+	colls []fwk.Port // collections ports
+}
+
+// newStreamPorts sorts ports into headers and collections ports.
+func newStreamPorts(ports []fwk.Port) (streamPorts, error) {
+	var sp streamPorts
+	for i := range ports {
+		port := &ports[i]
+		switch port.Type {
+		case ehdrType:
+			if sp.ehdr != nil {
+				return sp, fwk.Errorf("fwk/lcio: duplicate %v ports (%q and %q)", ehdrType, sp.ehdr.Name, port.Name)
+			}
+			sp.ehdr = port
+		case rhdrType:
+			if sp.rhdr != nil {
+				return sp, fwk.Errorf("fwk/lcio: duplicate %v ports (%q and %q)", rhdrType, sp.rhdr.Name, port.Name)
+			}
+			sp.rhdr = port
+		default:
+			if !reflect.PtrTo(port.Type).Implements(codecType) {
+				return sp, fwk.Errorf("fwk/lcio: invalid port %q. type %v is not a LCIO collection", port.Name, port.Type)
+			}
+			sp.colls = append(sp.colls, *port)
+		}
+	}
+	return sp, nil
+}
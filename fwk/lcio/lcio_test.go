@@ -0,0 +1,181 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lcio
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/fwk/job"
+	"go-hep.org/x/hep/lcio"
+)
+
+const fname = "../../lcio/testdata/event_golden.slcio"
+
+var ports = []fwk.Port{
+	{Name: "McParticles", Type: reflect.TypeOf(lcio.McParticleContainer{})},
+	{Name: "SimCaloHits", Type: reflect.TypeOf(lcio.SimCalorimeterHitContainer{})},
+	{Name: "CaloHits", Type: reflect.TypeOf(lcio.CalorimeterHitContainer{})},
+	{Name: "EventHeader", Type: reflect.TypeOf(lcio.EventHeader{})},
+	{Name: "RunHeader", Type: reflect.TypeOf(lcio.RunHeader{})},
+}
+
+// newapp returns a job reading names and, if oname is not empty, writing
+// the ports to oname.
+func newapp(ports []fwk.Port, oname string, names ...string) *job.Job {
+	app := job.NewJob(nil, job.P{
+		"EvtMax":   int64(-1),
+		"NProcs":   0,
+		"MsgLevel": job.MsgLevel("ERROR"),
+	})
+
+	app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk.InputStream",
+		Name: "input",
+		Props: job.P{
+			"Ports":    ports,
+			"Streamer": &InputStreamer{Names: names},
+		},
+	})
+
+	if oname != "" {
+		app.Create(job.C{
+			Type: "go-hep.org/x/hep/fwk.OutputStream",
+			Name: "output",
+			Props: job.P{
+				"Ports":    ports,
+				"Streamer": &OutputStreamer{Name: oname},
+			},
+		})
+	}
+
+	return app
+}
+
+// event holds the content of a LCIO event.
+type event struct {
+	rhdr  lcio.RunHeader
+	evt   lcio.Event
+	colls map[string]interface{}
+}
+
+func readFile(fname string) ([]event, error) {
+	r, err := lcio.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var evts []event
+	for r.Next() {
+		evt := r.Event()
+		colls := make(map[string]interface{})
+		for _, name := range evt.Names() {
+			colls[name] = evt.Get(name)
+		}
+		evts = append(evts, event{rhdr: r.RunHeader(), evt: evt, colls: colls})
+	}
+
+	err = r.Err()
+	if err == io.EOF {
+		err = nil
+	}
+	return evts, err
+}
+
+// TestStreamers copies the event of the reference file, read twice, into
+// an output file and checks the headers and collections survive the round trip.
+func TestStreamers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fwk-lcio-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ref, err := readFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ref) != 1 {
+		t.Fatalf("invalid number of reference events: %d", len(ref))
+	}
+
+	oname := filepath.Join(dir, "out.slcio")
+	err = newapp(ports, oname, fname, fname).App().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evts, err := readFile(oname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evts) != 2 {
+		t.Fatalf("got %d events. want 2", len(evts))
+	}
+
+	want := ref[0]
+	for i, got := range evts {
+		if !reflect.DeepEqual(got.rhdr, want.rhdr) {
+			t.Errorf("evt=%d: run headers differ.\ngot= %#v\nwant=%#v", i, got.rhdr, want.rhdr)
+		}
+		if got.evt.RunNumber != want.evt.RunNumber ||
+			got.evt.EventNumber != want.evt.EventNumber ||
+			got.evt.TimeStamp != want.evt.TimeStamp ||
+			got.evt.Detector != want.evt.Detector ||
+			!reflect.DeepEqual(got.evt.Params, want.evt.Params) {
+			t.Errorf("evt=%d: event headers differ.\ngot= %v\nwant=%v", i, &got.evt, &want.evt)
+		}
+		if !reflect.DeepEqual(got.colls, want.colls) {
+			t.Errorf("evt=%d: collections differ.\ngot= %v\nwant=%v", i, got.colls, want.colls)
+		}
+	}
+}
+
+// TestNoEvents checks a file without events is a valid LCIO file.
+func TestNoEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fwk-lcio-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oname := filepath.Join(dir, "out.slcio")
+	o := &OutputStreamer{Name: oname}
+	err = o.Connect(ports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = o.Disconnect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evts, err := readFile(oname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evts) != 0 {
+		t.Fatalf("got %d events. want 0", len(evts))
+	}
+}
+
+func TestInvalidPorts(t *testing.T) {
+	for _, port := range []fwk.Port{
+		{Name: "NoSuchColl", Type: reflect.TypeOf(lcio.TrackContainer{})},
+		{Name: "CaloHits", Type: reflect.TypeOf(lcio.SimCalorimeterHitContainer{})},
+		{Name: "CaloHits", Type: reflect.TypeOf(int64(0))},
+	} {
+		err := newapp([]fwk.Port{port}, "", fname).App().Run()
+		if err == nil {
+			t.Errorf("port=%v: expected an error", port)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lcio
+
+import (
+	"io"
+	"path/filepath"
+	"reflect"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/lcio"
+)
+
+// OutputStreamer writes LCIO events to a file.
+//
+// The run header is written before the first event and whenever the run
+// number of the lcio.RunHeader port changes.
+// Without a lcio.EventHeader port, events are numbered after the
+// fwk.Context.ID of the event.
+type OutputStreamer struct {
+	Name string // output filename
+
+	ports streamPorts     // output ports to write out
+	w     *lcio.Writer    // output LCIO writer
+	rhdr  *lcio.RunHeader // last run header written, if any
+}
+
+func (o *OutputStreamer) Connect(ports []fwk.Port) error {
+	var err error
+
+	o.ports, err = newStreamPorts(ports)
+	if err != nil {
+		return err
+	}
+
+	o.w, err = lcio.Create(o.Name)
+	if err != nil {
+		return err
+	}
+	o.rhdr = nil
+
+	return err
+}
+
+func (o *OutputStreamer) Write(ctx fwk.Context) error {
+	var err error
+	store := ctx.Store()
+
+	if port := o.ports.rhdr; port != nil {
+		v, err := store.Get(port.Name)
+		if err != nil {
+			return err
+		}
+		rhdr, ok := v.(lcio.RunHeader)
+		if !ok {
+			return fwk.Errorf("fwk/lcio: port %q: got type=%T. want type=lcio.RunHeader", port.Name, v)
+		}
+		if o.rhdr == nil || o.rhdr.RunNumber != rhdr.RunNumber {
+			err = o.w.WriteRunHeader(&rhdr)
+			if err != nil {
+				return fwk.Errorf("fwk/lcio: could not write run header: %v", err)
+			}
+			o.rhdr = &rhdr
+		}
+	}
+
+	evt := lcio.Event{EventNumber: int32(ctx.ID())}
+	if port := o.ports.ehdr; port != nil {
+		v, err := store.Get(port.Name)
+		if err != nil {
+			return err
+		}
+		ehdr, ok := v.(lcio.EventHeader)
+		if !ok {
+			return fwk.Errorf("fwk/lcio: port %q: got type=%T. want type=lcio.EventHeader", port.Name, v)
+		}
+		evt.RunNumber = ehdr.RunNumber
+		evt.EventNumber = ehdr.EventNumber
+		evt.TimeStamp = ehdr.TimeStamp
+		evt.Detector = ehdr.Detector
+		evt.Params = ehdr.Params
+	}
+
+	for _, port := range o.ports.colls {
+		v, err := store.Get(port.Name)
+		if err != nil {
+			return err
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Type() != port.Type {
+			return fwk.Errorf("fwk/lcio: port %q: got type=%v. want type=%v", port.Name, rv.Type(), port.Type)
+		}
+		ptr := reflect.New(port.Type)
+		ptr.Elem().Set(rv)
+		evt.Add(port.Name, ptr.Interface())
+	}
+
+	err = o.w.WriteEvent(&evt)
+	if err != nil {
+		return fwk.Errorf("fwk/lcio: could not write event: %v", err)
+	}
+
+	return err
+}
+
+func (o *OutputStreamer) Disconnect() error {
+	return o.w.Close()
+}
+
+// MergeOutputs appends the events of the files named Name written by the
+// workers of a distributed job, in the directories dirs.
+func (o *OutputStreamer) MergeOutputs(dirs []string) error {
+	for _, dir := range dirs {
+		err := o.merge(filepath.Join(dir, o.Name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OutputStreamer) merge(fname string) error {
+	r, err := lcio.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		if o.ports.rhdr != nil {
+			rhdr := r.RunHeader()
+			if o.rhdr == nil || o.rhdr.RunNumber != rhdr.RunNumber {
+				err = o.w.WriteRunHeader(&rhdr)
+				if err != nil {
+					return fwk.Errorf("fwk/lcio: could not write run header: %v", err)
+				}
+				o.rhdr = &rhdr
+			}
+		}
+
+		evt := r.Event()
+		err = o.w.WriteEvent(&evt)
+		if err != nil {
+			return fwk.Errorf("fwk/lcio: could not write event: %v", err)
+		}
+	}
+
+	err = r.Err()
+	if err != nil && err != io.EOF {
+		return fwk.Errorf("fwk/lcio: could not read %q: %v", fname, err)
+	}
+
+	return r.Close()
+}
+
+// OutputFiles returns the name of the output file.
+func (o *OutputStreamer) OutputFiles() []string {
+	return []string{o.Name}
+}
+
+var (
+	_ fwk.OutputStreamer = (*OutputStreamer)(nil)
+	_ fwk.OutputFiler    = (*OutputStreamer)(nil)
+	_ fwk.OutputMerger   = (*OutputStreamer)(nil)
+)
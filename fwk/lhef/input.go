@@ -0,0 +1,126 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lhef
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/lhef"
+)
+
+// InputStreamer reads LHEF events from a (set of) file(s).
+// Files are read one after the other.
+type InputStreamer struct {
+	Names []string // input filenames
+
+	evt   *fwk.Port     // input port for the events
+	run   *fwk.Port     // input port for the run information, if any
+	ifile int           // index of the current input file
+	r     io.ReadCloser // current input file
+	dec   *lhef.Decoder // current LHEF decoder
+}
+
+func (input *InputStreamer) Connect(ports []fwk.Port) error {
+	var err error
+
+	if len(input.Names) == 0 {
+		return fwk.Errorf("fwk/lhef: no input file")
+	}
+
+	input.evt, input.run, err = checkPorts(ports)
+	if err != nil {
+		return err
+	}
+
+	input.ifile = 0
+	err = input.open()
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+// open opens the current input file and decodes its run information.
+func (input *InputStreamer) open() error {
+	var err error
+	fname := input.Names[input.ifile]
+
+	input.r, err = os.Open(fname)
+	if err != nil {
+		return err
+	}
+
+	input.dec, err = lhef.NewDecoder(bufio.NewReader(input.r))
+	if err != nil {
+		return fwk.Errorf("fwk/lhef: could not open LHEF file %q: %v", fname, err)
+	}
+
+	return err
+}
+
+// close closes the current input file.
+func (input *InputStreamer) close() error {
+	var err error
+	if input.r != nil {
+		err = input.r.Close()
+		input.r = nil
+		input.dec = nil
+	}
+	return err
+}
+
+func (input *InputStreamer) Read(ctx fwk.Context) error {
+	var err error
+	store := ctx.Store()
+
+	var evt *lhef.HEPEUP
+	for {
+		evt, err = input.dec.Decode()
+		if err == nil {
+			break
+		}
+		if err != io.EOF {
+			return fwk.Errorf("fwk/lhef: could not decode event from %q: %v", input.Names[input.ifile], err)
+		}
+		if input.ifile+1 >= len(input.Names) {
+			return io.EOF
+		}
+		err = input.close()
+		if err != nil {
+			return err
+		}
+		input.ifile++
+		err = input.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = store.Put(input.evt.Name, *evt)
+	if err != nil {
+		return fwk.Errorf("store-put error: %v", err)
+	}
+
+	if input.run != nil {
+		err = store.Put(input.run.Name, input.dec.Run)
+		if err != nil {
+			return fwk.Errorf("store-put error: %v", err)
+		}
+	}
+
+	return err
+}
+
+func (input *InputStreamer) Disconnect() error {
+	return input.close()
+}
+
+var (
+	_ fwk.InputStreamer = (*InputStreamer)(nil)
+)
@@ -0,0 +1,49 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lhef provides a fwk.InputStreamer and a fwk.OutputStreamer
+// reading and writing Les Houches Event Files, one event per Read or Write.
+//
+// The streamers expect a fwk.Port of type lhef.HEPEUP, holding the event,
+// and an optional fwk.Port of type lhef.HEPRUP, holding the run
+// information of the file the event was read from (or written to.)
+package lhef // import "go-hep.org/x/hep/fwk/lhef"
+
+import (
+	"reflect"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/lhef"
+)
+
+var (
+	evtType = reflect.TypeOf(lhef.HEPEUP{})
+	runType = reflect.TypeOf(lhef.HEPRUP{})
+)
+
+// checkPorts checks ports hold one lhef.HEPEUP and at most one lhef.HEPRUP,
+// and returns them.
+func checkPorts(ports []fwk.Port) (evt, run *fwk.Port, err error) {
+	for i := range ports {
+		port := &ports[i]
+		switch port.Type {
+		case evtType:
+			if evt != nil {
+				return nil, nil, fwk.Errorf("fwk/lhef: duplicate %v ports (%q and %q)", evtType, evt.Name, port.Name)
+			}
+			evt = port
+		case runType:
+			if run != nil {
+				return nil, nil, fwk.Errorf("fwk/lhef: duplicate %v ports (%q and %q)", runType, run.Name, port.Name)
+			}
+			run = port
+		default:
+			return nil, nil, fwk.Errorf("fwk/lhef: invalid port %q. expected type=%v or %v. got=%v", port.Name, evtType, runType, port.Type)
+		}
+	}
+	if evt == nil {
+		return nil, nil, fwk.Errorf("fwk/lhef: missing %v port", evtType)
+	}
+	return evt, run, err
+}
@@ -0,0 +1,235 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lhef
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/fwk/job"
+	"go-hep.org/x/hep/lhef"
+)
+
+const fname = "../../lhef/testdata/ttbar.lhe"
+
+var scales = []float64{1.733125e+02, 2.453729e+02} // SCALUP of the events in fname
+
+// TestStreamers copies the events of two input files into an output file,
+// together with the run information of the first one.
+func TestStreamers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fwk-lhef-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oname := filepath.Join(dir, "out.lhe")
+	ports := []fwk.Port{
+		{Name: "lhevt", Type: reflect.TypeOf(lhef.HEPEUP{})},
+		{Name: "lhrun", Type: reflect.TypeOf(lhef.HEPRUP{})},
+	}
+
+	app := job.NewJob(nil, job.P{
+		"EvtMax":   int64(-1),
+		"NProcs":   0,
+		"MsgLevel": job.MsgLevel("ERROR"),
+	})
+	app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk.InputStream",
+		Name: "input",
+		Props: job.P{
+			"Ports":    ports,
+			"Streamer": &InputStreamer{Names: []string{fname, fname}},
+		},
+	})
+	app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk.OutputStream",
+		Name: "output",
+		Props: job.P{
+			"Ports":    ports,
+			"Streamer": &OutputStreamer{Name: oname},
+		},
+	})
+
+	err = app.App().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(oname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	dec, err := lhef.NewDecoder(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [2]int64{2212, -2212}; dec.Run.IDBMUP != want || dec.Run.NPRUP != 2 {
+		t.Fatalf("invalid run information: %#v", dec.Run)
+	}
+
+	var got []float64
+	for {
+		evt, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, evt.SCALUP)
+	}
+	want := append(append([]float64{}, scales...), scales...)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid events.\ngot= %v\nwant=%v", got, want)
+	}
+}
+
+// TestNoEvents checks a file without events is a valid LHEF file.
+func TestNoEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fwk-lhef-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oname := filepath.Join(dir, "out.lhe")
+	o := &OutputStreamer{
+		Name: oname,
+		Run:  lhef.HEPRUP{IDBMUP: [2]int64{2212, -2212}},
+	}
+	err = o.Connect([]fwk.Port{{Name: "lhevt", Type: reflect.TypeOf(lhef.HEPEUP{})}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = o.Disconnect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(oname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	dec, err := lhef.NewDecoder(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [2]int64{2212, -2212}; dec.Run.IDBMUP != want {
+		t.Fatalf("invalid run information: %#v", dec.Run)
+	}
+	_, err = dec.Decode()
+	if err != io.EOF {
+		t.Fatalf("got err=%v. want io.EOF", err)
+	}
+}
+
+// TestMergeOutputs merges the files written by two workers.
+func TestMergeOutputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fwk-lhef-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	raw, err := ioutil.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dirs []string
+	for _, name := range []string{"worker-0", "worker-1"} {
+		wdir := filepath.Join(dir, name)
+		err = os.Mkdir(wdir, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(filepath.Join(wdir, "out.lhe"), raw, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dirs = append(dirs, wdir)
+	}
+
+	// the output files of distributed jobs are relative to the directory
+	// of each process.
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(pwd)
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := &OutputStreamer{Name: "out.lhe"}
+	err = o.Connect([]fwk.Port{{Name: "lhevt", Type: reflect.TypeOf(lhef.HEPEUP{})}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = o.MergeOutputs(dirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = o.Disconnect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "out.lhe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	dec, err := lhef.NewDecoder(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [2]int64{2212, -2212}; dec.Run.IDBMUP != want || dec.Run.NPRUP != 2 {
+		t.Fatalf("invalid run information: %#v", dec.Run)
+	}
+
+	var got []float64
+	for {
+		evt, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, evt.SCALUP)
+	}
+	want := append(append([]float64{}, scales...), scales...)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("invalid events.\ngot= %v\nwant=%v", got, want)
+	}
+}
+
+func TestCheckPorts(t *testing.T) {
+	for _, ports := range [][]fwk.Port{
+		nil,
+		{{Name: "lhrun", Type: reflect.TypeOf(lhef.HEPRUP{})}},
+		{{Name: "lhevt", Type: reflect.TypeOf(&lhef.HEPEUP{})}},
+		{
+			{Name: "lhevt1", Type: reflect.TypeOf(lhef.HEPEUP{})},
+			{Name: "lhevt2", Type: reflect.TypeOf(lhef.HEPEUP{})},
+		},
+	} {
+		if _, _, err := checkPorts(ports); err == nil {
+			t.Errorf("ports=%v: expected an error", ports)
+		}
+	}
+}
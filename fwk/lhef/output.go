@@ -0,0 +1,170 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lhef
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/lhef"
+)
+
+// OutputStreamer writes LHEF events to a file.
+//
+// The run information written in the header of the file is taken from the
+// lhef.HEPRUP port of the first event, if any, or from the Run field.
+type OutputStreamer struct {
+	Name string      // output filename
+	Run  lhef.HEPRUP // run information
+
+	evt  *fwk.Port      // output port for the events
+	run  *fwk.Port      // output port for the run information, if any
+	nevt int64          // number of events written
+	w    io.WriteCloser // underlying output file
+	buf  *bufio.Writer
+	enc  *lhef.Encoder
+}
+
+func (o *OutputStreamer) Connect(ports []fwk.Port) error {
+	var err error
+
+	o.evt, o.run, err = checkPorts(ports)
+	if err != nil {
+		return err
+	}
+
+	o.w, err = os.Create(o.Name)
+	if err != nil {
+		return err
+	}
+
+	o.buf = bufio.NewWriter(o.w)
+	o.enc, err = lhef.NewEncoder(o.buf)
+	if err != nil {
+		return err
+	}
+	o.enc.Run = o.Run
+	o.nevt = 0
+
+	return err
+}
+
+func (o *OutputStreamer) Write(ctx fwk.Context) error {
+	var err error
+	store := ctx.Store()
+
+	if o.nevt == 0 && o.run != nil {
+		v, err := store.Get(o.run.Name)
+		if err != nil {
+			return err
+		}
+		run, ok := v.(lhef.HEPRUP)
+		if !ok {
+			return fwk.Errorf("fwk/lhef: port %q: got type=%T. want type=lhef.HEPRUP", o.run.Name, v)
+		}
+		o.enc.Run = run
+	}
+
+	v, err := store.Get(o.evt.Name)
+	if err != nil {
+		return err
+	}
+
+	evt, ok := v.(lhef.HEPEUP)
+	if !ok {
+		return fwk.Errorf("fwk/lhef: port %q: got type=%T. want type=lhef.HEPEUP", o.evt.Name, v)
+	}
+
+	err = o.enc.Encode(&evt)
+	if err != nil {
+		return fwk.Errorf("fwk/lhef: could not encode event: %v", err)
+	}
+	o.nevt++
+
+	return err
+}
+
+func (o *OutputStreamer) Disconnect() error {
+	// make sure we don't leak filedescriptors
+	defer o.w.Close()
+
+	err := o.enc.Close()
+	if err != nil {
+		return err
+	}
+
+	err = o.buf.Flush()
+	if err != nil {
+		return err
+	}
+
+	err = o.w.Close()
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+// MergeOutputs appends the events of the files named Name written by the
+// workers of a distributed job, in the directories dirs.
+// The run information of the merged file is taken from the first file,
+// if no event was written yet.
+func (o *OutputStreamer) MergeOutputs(dirs []string) error {
+	for _, dir := range dirs {
+		err := o.merge(filepath.Join(dir, o.Name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OutputStreamer) merge(fname string) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec, err := lhef.NewDecoder(f)
+	if err != nil {
+		return fwk.Errorf("fwk/lhef: could not open %q: %v", fname, err)
+	}
+	if o.nevt == 0 {
+		o.enc.Run = dec.Run
+	}
+
+	for {
+		evt, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fwk.Errorf("fwk/lhef: could not decode event from %q: %v", fname, err)
+		}
+		err = o.enc.Encode(evt)
+		if err != nil {
+			return fwk.Errorf("fwk/lhef: could not encode event: %v", err)
+		}
+		o.nevt++
+	}
+
+	return f.Close()
+}
+
+// OutputFiles returns the name of the output file.
+func (o *OutputStreamer) OutputFiles() []string {
+	return []string{o.Name}
+}
+
+var (
+	_ fwk.OutputStreamer = (*OutputStreamer)(nil)
+	_ fwk.OutputFiler    = (*OutputStreamer)(nil)
+	_ fwk.OutputMerger   = (*OutputStreamer)(nil)
+)
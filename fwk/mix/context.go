@@ -0,0 +1,48 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mix
+
+import (
+	"go-hep.org/x/hep/fwk"
+)
+
+// context is the fwk.Context passed to the primary and secondary streams,
+// so their data is collected before being merged into the event store.
+type context struct {
+	fwk.Context
+	store store
+}
+
+func newContext(ctx fwk.Context) context {
+	return context{Context: ctx, store: make(store)}
+}
+
+func (ctx context) Store() fwk.Store {
+	return ctx.store
+}
+
+// store is a simple, non concurrent-safe, fwk.Store.
+type store map[string]interface{}
+
+func (s store) Get(k string) (interface{}, error) {
+	v, ok := s[k]
+	if !ok {
+		return nil, fwk.Errorf("Store.Get: no such key [%v]", k)
+	}
+	return v, nil
+}
+
+func (s store) Put(k string, v interface{}) error {
+	if _, dup := s[k]; dup {
+		return fwk.Errorf("Store.Put: duplicate key [%v]", k)
+	}
+	s[k] = v
+	return nil
+}
+
+func (s store) Has(k string) bool {
+	_, ok := s[k]
+	return ok
+}
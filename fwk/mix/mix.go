@@ -0,0 +1,312 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mix provides a fwk.InputStreamer overlaying events read from
+// secondary streams (e.g. minimum-bias pile-up events) onto the events
+// read from a primary stream (e.g. signal events.)
+//
+// For each primary event, each secondary source contributes a number of
+// events, either fixed or Poisson-distributed around a mean value.
+// Secondary events are read sequentially, rewinding the source when it is
+// exhausted, or drawn at random from the whole source, loaded in memory.
+//
+// The collections of the ports with a slice type are merged: the elements
+// of the primary event come first, followed by the elements of each
+// secondary event.
+// The values of the other ports are taken from the primary event.
+//
+// A port of type mix.Provenance describes where the elements of the merged
+// collections come from.
+package mix // import "go-hep.org/x/hep/fwk/mix"
+
+import (
+	"io"
+	"math"
+	"math/rand"
+	"reflect"
+
+	"go-hep.org/x/hep/fwk"
+)
+
+// Provenance describes the events merged into a mixed event.
+// The primary event comes first, followed by the secondary events.
+type Provenance []Origin
+
+// Origin describes an event merged into a mixed event.
+type Origin struct {
+	Source string            // name of the source of the event ("" for the primary stream)
+	Entry  int64             // index of the event in its source
+	Ranges map[string][2]int // [beg, end) range of the elements of each merged collection coming from this event
+}
+
+// Source describes a stream of secondary events.
+type Source struct {
+	Name     string            // name of the source, recorded in the provenance
+	Streamer fwk.InputStreamer // secondary events
+	Mean     float64           // mean number of secondary events per primary event
+	Fixed    bool              // overlay exactly Mean events, instead of a Poisson-distributed number of events
+	Random   bool              // draw events at random, instead of reading them sequentially
+
+	ports  []fwk.Port
+	evts   []event // events of a random source, loaded with the first primary event
+	loaded bool
+	entry  int64 // index of the next event of a sequential source
+}
+
+// event holds the data of an event read from a source.
+type event struct {
+	entry int64
+	data  map[string]interface{}
+}
+
+// InputStreamer overlays events read from secondary sources onto the
+// events read from a primary stream.
+type InputStreamer struct {
+	Primary     fwk.InputStreamer // primary events
+	Secondaries []Source          // secondary events, overlaid onto each primary event
+	Seed        int64             // seed of the random numbers generator
+
+	ports []fwk.Port // ports read from the primary stream
+	merge []fwk.Port // ports merged with the secondary streams
+	prov  *fwk.Port  // provenance port, if any
+	rng   *rand.Rand
+	entry int64 // index of the next primary event
+	evtid fwk.EventID
+}
+
+func (input *InputStreamer) Connect(ports []fwk.Port) error {
+	var err error
+
+	if input.Primary == nil {
+		return fwk.Errorf("fwk/mix: no primary stream")
+	}
+
+	input.ports = nil
+	input.merge = nil
+	input.prov = nil
+	for i, port := range ports {
+		switch {
+		case port.Type == reflect.TypeOf(Provenance{}):
+			if input.prov != nil {
+				return fwk.Errorf("fwk/mix: duplicate provenance ports (%q and %q)", input.prov.Name, port.Name)
+			}
+			input.prov = &ports[i]
+			continue
+		case port.Type.Kind() == reflect.Slice:
+			input.merge = append(input.merge, port)
+		}
+		input.ports = append(input.ports, port)
+	}
+
+	input.rng = rand.New(rand.NewSource(input.Seed))
+	input.entry = 0
+	input.evtid = fwk.EventID{}
+
+	err = input.Primary.Connect(input.ports)
+	if err != nil {
+		return err
+	}
+
+	for i := range input.Secondaries {
+		src := &input.Secondaries[i]
+		if src.Streamer == nil {
+			return fwk.Errorf("fwk/mix: source %q has no streamer", src.Name)
+		}
+		if src.Mean < 0 {
+			return fwk.Errorf("fwk/mix: source %q has an invalid mean number of events (%v)", src.Name, src.Mean)
+		}
+		src.ports = input.merge
+		src.evts = nil
+		src.loaded = false
+		src.entry = 0
+		err = src.Streamer.Connect(src.ports)
+		if err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (input *InputStreamer) Read(ctx fwk.Context) error {
+	var err error
+
+	pctx := newContext(ctx)
+	err = input.Primary.Read(pctx)
+	if err != nil {
+		return err
+	}
+	if ider, ok := input.Primary.(fwk.EventIDer); ok {
+		input.evtid = ider.EventID()
+	}
+
+	evts := []event{{entry: input.entry, data: pctx.store}}
+	srcs := []string{""}
+	input.entry++
+
+	for i := range input.Secondaries {
+		src := &input.Secondaries[i]
+		n := int(src.Mean)
+		if !src.Fixed {
+			n = poisson(input.rng, src.Mean)
+		}
+		for j := 0; j < n; j++ {
+			evt, err := src.next(ctx, input.rng)
+			if err != nil {
+				return err
+			}
+			evts = append(evts, evt)
+			srcs = append(srcs, src.Name)
+		}
+	}
+
+	prov := make(Provenance, len(evts))
+	for i := range prov {
+		prov[i] = Origin{
+			Source: srcs[i],
+			Entry:  evts[i].entry,
+			Ranges: make(map[string][2]int, len(input.merge)),
+		}
+	}
+
+	store := ctx.Store()
+	for _, port := range input.ports {
+		v, err := pctx.store.Get(port.Name)
+		if err != nil {
+			return fwk.Errorf("fwk/mix: primary stream: %v", err)
+		}
+		if port.Type.Kind() == reflect.Slice {
+			out := reflect.MakeSlice(port.Type, 0, 0)
+			for i, evt := range evts {
+				rv := reflect.ValueOf(evt.data[port.Name])
+				if !rv.IsValid() || rv.Type() != port.Type {
+					return fwk.Errorf("fwk/mix: source %q: invalid value for port %q (type=%T)", srcs[i], port.Name, evt.data[port.Name])
+				}
+				beg := out.Len()
+				out = reflect.AppendSlice(out, rv)
+				prov[i].Ranges[port.Name] = [2]int{beg, out.Len()}
+			}
+			v = out.Interface()
+		}
+		err = store.Put(port.Name, v)
+		if err != nil {
+			return fwk.Errorf("store-put error: %v", err)
+		}
+	}
+
+	if input.prov != nil {
+		err = store.Put(input.prov.Name, prov)
+		if err != nil {
+			return fwk.Errorf("store-put error: %v", err)
+		}
+	}
+
+	return err
+}
+
+// EventID returns the identifier of the last primary event read, if the
+// primary stream is a fwk.EventIDer.
+func (input *InputStreamer) EventID() fwk.EventID {
+	return input.evtid
+}
+
+func (input *InputStreamer) Disconnect() error {
+	var err error
+	for i := range input.Secondaries {
+		src := &input.Secondaries[i]
+		src.evts = nil
+		e := src.Streamer.Disconnect()
+		if e != nil && err == nil {
+			err = e
+		}
+	}
+
+	e := input.Primary.Disconnect()
+	if e != nil && err == nil {
+		err = e
+	}
+	return err
+}
+
+// load reads all the events of a random source.
+func (src *Source) load(parent fwk.Context) error {
+	src.loaded = true
+	for {
+		ctx := newContext(parent)
+		err := src.Streamer.Read(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fwk.Errorf("fwk/mix: could not read source %q: %v", src.Name, err)
+		}
+		src.evts = append(src.evts, event{entry: int64(len(src.evts)), data: ctx.store})
+	}
+	if len(src.evts) == 0 {
+		return fwk.Errorf("fwk/mix: source %q is empty", src.Name)
+	}
+	return nil
+}
+
+// next returns the next event of the source.
+func (src *Source) next(parent fwk.Context, rng *rand.Rand) (event, error) {
+	if src.Random {
+		if !src.loaded {
+			err := src.load(parent)
+			if err != nil {
+				return event{}, err
+			}
+		}
+		return src.evts[rng.Intn(len(src.evts))], nil
+	}
+
+	rewound := false
+	for {
+		ctx := newContext(parent)
+		err := src.Streamer.Read(ctx)
+		if err == nil {
+			evt := event{entry: src.entry, data: ctx.store}
+			src.entry++
+			return evt, nil
+		}
+		if err != io.EOF {
+			return event{}, fwk.Errorf("fwk/mix: could not read source %q: %v", src.Name, err)
+		}
+		if rewound {
+			return event{}, fwk.Errorf("fwk/mix: source %q is empty", src.Name)
+		}
+
+		// rewind the source.
+		err = src.Streamer.Disconnect()
+		if err != nil {
+			return event{}, err
+		}
+		err = src.Streamer.Connect(src.ports)
+		if err != nil {
+			return event{}, err
+		}
+		src.entry = 0
+		rewound = true
+	}
+}
+
+// poisson returns a Poisson-distributed random number with the given mean.
+func poisson(rng *rand.Rand, mean float64) int {
+	n := 0
+	for mean > 0 {
+		// exp(-m) underflows for large m: sum Poisson numbers of smaller means.
+		m := math.Min(mean, 500)
+		mean -= m
+		lim := math.Exp(-m)
+		for p := rng.Float64(); p > lim; p *= rng.Float64() {
+			n++
+		}
+	}
+	return n
+}
+
+var (
+	_ fwk.InputStreamer = (*InputStreamer)(nil)
+	_ fwk.EventIDer     = (*InputStreamer)(nil)
+)
@@ -0,0 +1,293 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mix
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/fwk/job"
+)
+
+var ports = []fwk.Port{
+	{Name: "ids", Type: reflect.TypeOf([]int64{})},
+	{Name: "evt", Type: reflect.TypeOf(int64(0))},
+	{Name: "prov", Type: reflect.TypeOf(Provenance{})},
+}
+
+func newapp(evtmax int64, nprocs int, streamer *InputStreamer) (*job.Job, *testtask) {
+	app := job.NewJob(nil, job.P{
+		"EvtMax":   evtmax,
+		"NProcs":   nprocs,
+		"MsgLevel": job.MsgLevel("ERROR"),
+	})
+
+	app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk.InputStream",
+		Name: "input",
+		Props: job.P{
+			"Ports":    ports,
+			"Streamer": streamer,
+		},
+	})
+
+	tsk := app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk/mix.testtask",
+		Name: "check",
+	}).(*testtask)
+
+	return app, tsk
+}
+
+func TestMixSequential(t *testing.T) {
+	const nevts = 10
+	for _, nprocs := range []int{0, 1, 4} {
+		app, tsk := newapp(nevts, nprocs, &InputStreamer{
+			Primary: &memstream{n: 100},
+			Secondaries: []Source{
+				{Name: "minbias", Streamer: &memstream{base: 1000, n: 3}, Mean: 2, Fixed: true},
+			},
+		})
+		err := app.App().Run()
+		if err != nil {
+			t.Fatalf("nprocs=%d: error: %v", nprocs, err)
+		}
+
+		if len(tsk.provs) != nevts {
+			t.Fatalf("nprocs=%d: got %d events. want %d", nprocs, len(tsk.provs), nevts)
+		}
+		for i := int64(0); i < nevts; i++ {
+			want := Provenance{
+				{Entry: i, Ranges: map[string][2]int{"ids": {0, 1}}},
+				{Source: "minbias", Entry: (2 * i) % 3, Ranges: map[string][2]int{"ids": {1, 3}}},
+				{Source: "minbias", Entry: (2*i + 1) % 3, Ranges: map[string][2]int{"ids": {3, 5}}},
+			}
+			if got := tsk.provs[i]; !reflect.DeepEqual(got, want) {
+				t.Errorf("nprocs=%d evt=%d: invalid provenance.\ngot= %v\nwant=%v", nprocs, i, got, want)
+			}
+		}
+	}
+}
+
+func TestMixRandom(t *testing.T) {
+	const (
+		nevts = 2000
+		mean  = 3.5
+	)
+
+	run := func(seed int64) []Provenance {
+		app, tsk := newapp(nevts, 4, &InputStreamer{
+			Primary: &memstream{n: nevts},
+			Seed:    seed,
+			Secondaries: []Source{
+				{Name: "minbias", Streamer: &memstream{base: 1000, n: 50}, Mean: mean, Random: true},
+				{Name: "cavern", Streamer: &memstream{base: 2000, n: 5}, Mean: 1},
+			},
+		})
+		err := app.App().Run()
+		if err != nil {
+			t.Fatalf("seed=%d: error: %v", seed, err)
+		}
+		return tsk.provs
+	}
+
+	provs := run(1234)
+	if len(provs) != nevts {
+		t.Fatalf("got %d events. want %d", len(provs), nevts)
+	}
+
+	n := make(map[string]int)
+	for _, prov := range provs {
+		for _, o := range prov[1:] {
+			n[o.Source]++
+		}
+	}
+	for _, src := range []struct {
+		name string
+		mean float64
+	}{
+		{"minbias", mean},
+		{"cavern", 1},
+	} {
+		got := float64(n[src.name]) / nevts
+		if math.Abs(got-src.mean) > 5*math.Sqrt(src.mean/nevts) {
+			t.Errorf("source %q: got a mean of %v events. want %v", src.name, got, src.mean)
+		}
+	}
+
+	if again := run(1234); !reflect.DeepEqual(again, provs) {
+		t.Errorf("mixing is not reproducible")
+	}
+}
+
+func TestMixEmptySource(t *testing.T) {
+	for _, random := range []bool{false, true} {
+		app, _ := newapp(10, 0, &InputStreamer{
+			Primary: &memstream{n: 100},
+			Secondaries: []Source{
+				{Name: "empty", Streamer: &memstream{}, Mean: 1, Fixed: true, Random: random},
+			},
+		})
+		err := app.App().Run()
+		if err == nil {
+			t.Errorf("random=%v: expected an error", random)
+		}
+	}
+}
+
+func TestPoisson(t *testing.T) {
+	const n = 10000
+	rng := rand.New(rand.NewSource(42))
+	for _, mean := range []float64{0, 0.5, 20, 1200} {
+		sum := 0.0
+		for i := 0; i < n; i++ {
+			sum += float64(poisson(rng, mean))
+		}
+		got := sum / n
+		if math.Abs(got-mean) > 5*math.Sqrt(mean/n) {
+			t.Errorf("mean=%v: got %v", mean, got)
+		}
+	}
+}
+
+// memstream is an InputStreamer of n events.
+// The collection 'ids' of event i holds i+base (twice, if base > 0) and
+// the value 'evt' holds i.
+type memstream struct {
+	base  int64
+	n     int64
+	ports []fwk.Port
+	i     int64
+}
+
+func (s *memstream) Connect(ports []fwk.Port) error {
+	s.ports = ports
+	s.i = 0
+	return nil
+}
+
+func (s *memstream) Read(ctx fwk.Context) error {
+	if s.i >= s.n {
+		return io.EOF
+	}
+	store := ctx.Store()
+	for _, port := range s.ports {
+		var v interface{}
+		switch port.Name {
+		case "ids":
+			ids := []int64{s.base + s.i}
+			if s.base > 0 {
+				ids = append(ids, s.base+s.i)
+			}
+			v = ids
+		case "evt":
+			v = s.i
+		default:
+			return fmt.Errorf("memstream: invalid port %q", port.Name)
+		}
+		err := store.Put(port.Name, v)
+		if err != nil {
+			return err
+		}
+	}
+	s.i++
+	return nil
+}
+
+func (s *memstream) Disconnect() error {
+	return nil
+}
+
+// testtask checks the mixed events and records their provenance.
+type testtask struct {
+	fwk.TaskBase
+
+	mu    sync.Mutex
+	provs []Provenance
+}
+
+func (tsk *testtask) Configure(ctx fwk.Context) error {
+	var err error
+	for _, port := range ports {
+		err = tsk.DeclInPort(port.Name, port.Type)
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (tsk *testtask) StartTask(ctx fwk.Context) error {
+	return nil
+}
+
+func (tsk *testtask) StopTask(ctx fwk.Context) error {
+	return nil
+}
+
+func (tsk *testtask) Process(ctx fwk.Context) error {
+	store := ctx.Store()
+	v, err := store.Get("ids")
+	if err != nil {
+		return err
+	}
+	ids := v.([]int64)
+
+	v, err = store.Get("evt")
+	if err != nil {
+		return err
+	}
+	evt := v.(int64)
+
+	v, err = store.Get("prov")
+	if err != nil {
+		return err
+	}
+	prov := v.(Provenance)
+
+	if prov[0].Source != "" || prov[0].Entry != evt {
+		return fmt.Errorf("evt %d: invalid primary provenance: %v", evt, prov[0])
+	}
+
+	bases := map[string]int64{"": 0, "minbias": 1000, "cavern": 2000}
+	end := 0
+	for _, o := range prov {
+		rng := o.Ranges["ids"]
+		if rng[0] != end {
+			return fmt.Errorf("evt %d: invalid provenance ranges: %v", evt, prov)
+		}
+		end = rng[1]
+		for _, id := range ids[rng[0]:rng[1]] {
+			if id != bases[o.Source]+o.Entry {
+				return fmt.Errorf("evt %d: invalid id %d for %v", evt, id, o)
+			}
+		}
+	}
+	if end != len(ids) {
+		return fmt.Errorf("evt %d: invalid provenance ranges: %v (ids=%v)", evt, prov, ids)
+	}
+
+	tsk.mu.Lock()
+	defer tsk.mu.Unlock()
+	for int64(len(tsk.provs)) <= evt {
+		tsk.provs = append(tsk.provs, nil)
+	}
+	tsk.provs[evt] = prov
+	return nil
+}
+
+func init() {
+	fwk.Register(reflect.TypeOf(testtask{}),
+		func(typ, name string, mgr fwk.App) (fwk.Component, error) {
+			return &testtask{TaskBase: fwk.NewTask(typ, name, mgr)}, nil
+		},
+	)
+}
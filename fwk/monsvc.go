@@ -0,0 +1,88 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fwk
+
+import (
+	"time"
+)
+
+// TaskStat holds the measurements of the processing of an event by a task.
+//
+// CPU is the CPU time consumed by the OS thread running the task (only
+// available on linux), excluding the goroutines the task may have started.
+// Allocs and Bytes are the number of heap objects and bytes allocated by
+// the whole process while the task was running: they are only exact when
+// tasks do not run concurrently (NProcs=0 and NTasks=1).
+type TaskStat struct {
+	Event  int64         // event number
+	Slot   int           // event slot
+	Task   string        // name of the task
+	Wall   time.Duration // wall-clock time
+	CPU    time.Duration // CPU time
+	Allocs uint64        // number of heap objects allocated
+	Bytes  uint64        // number of heap bytes allocated
+}
+
+// EventStat holds the measurements of the processing of an event.
+type EventStat struct {
+	Event int64         // event number
+	Slot  int           // event slot
+	Start time.Time     // start of the processing of the event
+	Wall  time.Duration // wall-clock time to process the event with all tasks
+}
+
+// MonSvc is the interface of services monitoring the event loop.
+//
+// When the application holds a MonSvc, tasks are monitored while they
+// process events and the MonSvc is notified, possibly concurrently, of
+// the measurements.
+//
+// Allocations are measured with the runtime/metrics package.
+// Before Go 1.16, they are measured with runtime.ReadMemStats, which stops
+// the world twice per task and per event: monitoring then slows down the
+// event loop, especially a concurrent one.
+type MonSvc interface {
+	Svc
+
+	// MonTask is called after a task processed an event.
+	MonTask(stat TaskStat)
+
+	// MonEvent is called after all the tasks processed an event.
+	MonEvent(stat EventStat)
+
+	// MonQueue is called before an event is queued for the workers,
+	// with the number of events already queued and the capacity of
+	// the queue.
+	MonQueue(n, cap int)
+}
+
+// probe measures the resources consumed by a task.
+type probe struct {
+	beg    time.Time
+	cpu    time.Duration
+	allocs uint64
+	bytes  uint64
+}
+
+func newProbe() probe {
+	var p probe
+	p.allocs, p.bytes = readAllocs()
+	p.cpu = threadCPUTime()
+	p.beg = time.Now()
+	return p
+}
+
+// stat returns the resources consumed since the probe was created.
+func (p probe) stat() TaskStat {
+	wall := time.Since(p.beg)
+	cpu := threadCPUTime()
+	allocs, bytes := readAllocs()
+	return TaskStat{
+		Wall:   wall,
+		CPU:    cpu - p.cpu,
+		Allocs: allocs - p.allocs,
+		Bytes:  bytes - p.bytes,
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package monsvc provides a fwk.MonSvc recording the time and memory
+// consumed by each task, the occupancy of the queue of events waiting for
+// a worker and the events throughput.
+//
+// The service prints a summary table when it is stopped.
+// The per-task and per-event measurements can also be dumped to a JSON or
+// CSV file (selected from the extension of the 'Output' property), for
+// offline analysis.
+package monsvc // import "go-hep.org/x/hep/fwk/monsvc"
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-hep.org/x/hep/fwk"
+)
+
+// stats holds the measurements accumulated for a task.
+type stats struct {
+	Task   string        // name of the task
+	N      int64         // number of events processed
+	Wall   time.Duration // total wall-clock time
+	CPU    time.Duration // total CPU time
+	Allocs uint64        // total number of heap objects allocated
+	Bytes  uint64        // total number of heap bytes allocated
+}
+
+type msvc struct {
+	fwk.SvcBase
+
+	output string // path to the file where measurements are dumped
+
+	mu    sync.Mutex
+	tasks map[string]*stats
+	recs  []fwk.TaskStat // per-task and per-event measurements, if output is set
+
+	nevts int64
+	wall  time.Duration // total wall-clock time to process events
+	beg   time.Time     // start of the first event
+	end   time.Time     // end of the last event
+
+	nqueue int64 // number of queue samples
+	queue  int64 // sum of the queue samples
+	qmax   int
+	qcap   int
+}
+
+func (svc *msvc) Configure(ctx fwk.Context) error {
+	var err error
+
+	switch ext := filepath.Ext(svc.output); ext {
+	case "", ".json", ".csv":
+		// ok
+	default:
+		return fwk.Errorf("%s: invalid output file extension %q (want .json or .csv)", svc.Name(), ext)
+	}
+
+	return err
+}
+
+func (svc *msvc) StartSvc(ctx fwk.Context) error {
+	var err error
+
+	svc.tasks = make(map[string]*stats)
+	svc.recs = nil
+	svc.nevts = 0
+	svc.wall = 0
+	svc.beg = time.Time{}
+	svc.end = time.Time{}
+	svc.nqueue = 0
+	svc.queue = 0
+	svc.qmax = 0
+	svc.qcap = 0
+
+	return err
+}
+
+func (svc *msvc) StopSvc(ctx fwk.Context) error {
+	var err error
+
+	svc.summary(ctx.Msg())
+
+	if svc.output != "" {
+		err = svc.dump()
+		if err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (svc *msvc) MonTask(stat fwk.TaskStat) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	st, ok := svc.tasks[stat.Task]
+	if !ok {
+		st = &stats{Task: stat.Task}
+		svc.tasks[stat.Task] = st
+	}
+	st.N++
+	st.Wall += stat.Wall
+	st.CPU += stat.CPU
+	st.Allocs += stat.Allocs
+	st.Bytes += stat.Bytes
+
+	if svc.output != "" {
+		svc.recs = append(svc.recs, stat)
+	}
+}
+
+func (svc *msvc) MonEvent(stat fwk.EventStat) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	svc.nevts++
+	svc.wall += stat.Wall
+	if svc.beg.IsZero() || stat.Start.Before(svc.beg) {
+		svc.beg = stat.Start
+	}
+	if end := stat.Start.Add(stat.Wall); end.After(svc.end) {
+		svc.end = end
+	}
+}
+
+func (svc *msvc) MonQueue(n, cap int) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	svc.nqueue++
+	svc.queue += int64(n)
+	if n > svc.qmax {
+		svc.qmax = n
+	}
+	svc.qcap = cap
+}
+
+// stats returns the measurements accumulated for each task, sorted by name.
+func (svc *msvc) stats() []stats {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	sts := make([]stats, 0, len(svc.tasks))
+	for _, st := range svc.tasks {
+		sts = append(sts, *st)
+	}
+	sort.Sort(byTask(sts))
+	return sts
+}
+
+// throughput returns the number of events processed per second.
+func (svc *msvc) throughput() float64 {
+	dt := svc.end.Sub(svc.beg).Seconds()
+	if dt <= 0 {
+		return 0
+	}
+	return float64(svc.nevts) / dt
+}
+
+func (svc *msvc) summary(msg fwk.MsgStream) {
+	sts := svc.stats()
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	var tot time.Duration
+	for _, st := range sts {
+		tot += st.Wall
+	}
+
+	msg.Infof("%-20s %8s %12s %12s %12s %12s %7s\n",
+		"task", "events", "wall/evt", "cpu/evt", "allocs/evt", "bytes/evt", "wall(%)",
+	)
+	for _, st := range sts {
+		n := st.N
+		if n == 0 {
+			n = 1
+		}
+		frac := 0.0
+		if tot > 0 {
+			frac = 100 * float64(st.Wall) / float64(tot)
+		}
+		msg.Infof("%-20s %8d %12v %12v %12d %12d %7.2f\n",
+			st.Task, st.N,
+			st.Wall/time.Duration(n),
+			st.CPU/time.Duration(n),
+			st.Allocs/uint64(n),
+			st.Bytes/uint64(n),
+			frac,
+		)
+	}
+
+	mean := time.Duration(0)
+	if svc.nevts > 0 {
+		mean = svc.wall / time.Duration(svc.nevts)
+	}
+	msg.Infof("events: %d (wall/evt: %v, throughput: %.2f evts/s)\n",
+		svc.nevts, mean, svc.throughput(),
+	)
+
+	if svc.nqueue > 0 {
+		msg.Infof("queue:  mean=%.2f max=%d capacity=%d\n",
+			float64(svc.queue)/float64(svc.nqueue), svc.qmax, svc.qcap,
+		)
+	}
+}
+
+// record is the JSON and CSV representation of a fwk.TaskStat.
+// Times are in seconds.
+type record struct {
+	Event  int64   `json:"event"`
+	Slot   int     `json:"slot"`
+	Task   string  `json:"task"`
+	Wall   float64 `json:"wall"`
+	CPU    float64 `json:"cpu"`
+	Allocs uint64  `json:"allocs"`
+	Bytes  uint64  `json:"bytes"`
+}
+
+func (svc *msvc) dump() error {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	recs := make([]record, len(svc.recs))
+	for i, r := range svc.recs {
+		recs[i] = record{
+			Event:  r.Event,
+			Slot:   r.Slot,
+			Task:   r.Task,
+			Wall:   r.Wall.Seconds(),
+			CPU:    r.CPU.Seconds(),
+			Allocs: r.Allocs,
+			Bytes:  r.Bytes,
+		}
+	}
+
+	f, err := os.Create(svc.output)
+	if err != nil {
+		return fwk.Errorf("%s: could not create output file: %v", svc.Name(), err)
+	}
+	defer f.Close()
+
+	switch filepath.Ext(svc.output) {
+	case ".json":
+		err = json.NewEncoder(f).Encode(recs)
+	case ".csv":
+		w := csv.NewWriter(f)
+		err = w.Write([]string{"event", "slot", "task", "wall", "cpu", "allocs", "bytes"})
+		for _, r := range recs {
+			if err != nil {
+				break
+			}
+			err = w.Write([]string{
+				strconv.FormatInt(r.Event, 10),
+				strconv.Itoa(r.Slot),
+				r.Task,
+				strconv.FormatFloat(r.Wall, 'g', -1, 64),
+				strconv.FormatFloat(r.CPU, 'g', -1, 64),
+				strconv.FormatUint(r.Allocs, 10),
+				strconv.FormatUint(r.Bytes, 10),
+			})
+		}
+		if err == nil {
+			w.Flush()
+			err = w.Error()
+		}
+	}
+	if err != nil {
+		return fwk.Errorf("%s: could not write output file: %v", svc.Name(), err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fwk.Errorf("%s: could not close output file: %v", svc.Name(), err)
+	}
+
+	return err
+}
+
+type byTask []stats
+
+func (p byTask) Len() int           { return len(p) }
+func (p byTask) Less(i, j int) bool { return p[i].Task < p[j].Task }
+func (p byTask) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+func newmsvc(typ, name string, mgr fwk.App) (fwk.Component, error) {
+	var err error
+	svc := &msvc{
+		SvcBase: fwk.NewSvc(typ, name, mgr),
+		tasks:   make(map[string]*stats),
+	}
+
+	err = svc.DeclProp("Output", &svc.output)
+	if err != nil {
+		return nil, err
+	}
+
+	return svc, err
+}
+
+func init() {
+	fwk.Register(reflect.TypeOf(msvc{}), newmsvc)
+}
+
+var _ fwk.MonSvc = (*msvc)(nil)
@@ -0,0 +1,147 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package monsvc
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-hep.org/x/hep/fwk/job"
+	_ "go-hep.org/x/hep/fwk/testdata"
+)
+
+const nevts = 50
+
+func newapp(nprocs int, output string) (*job.Job, *msvc) {
+	app := job.NewJob(nil, job.P{
+		"EvtMax":   int64(nevts),
+		"NProcs":   nprocs,
+		"MsgLevel": job.MsgLevel("ERROR"),
+	})
+
+	app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk/testdata.task1",
+		Name: "t1",
+		Props: job.P{
+			"Ints1": "t1-ints1",
+			"Ints2": "t1-ints2",
+		},
+	})
+
+	app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk/testdata.task2",
+		Name: "t2",
+		Props: job.P{
+			"Input":  "t1-ints1",
+			"Output": "t1-ints1-massaged",
+		},
+	})
+
+	svc := app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk/monsvc.msvc",
+		Name: "monsvc",
+		Props: job.P{
+			"Output": output,
+		},
+	}).(*msvc)
+
+	return app, svc
+}
+
+func TestMonSvc(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fwk-monsvc-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, nprocs := range []int{0, 1, 4} {
+		for _, ext := range []string{"", ".csv", ".json"} {
+			output := ""
+			if ext != "" {
+				output = filepath.Join(dir, fmt.Sprintf("mon-%d%s", nprocs, ext))
+			}
+			app, svc := newapp(nprocs, output)
+			err := app.App().Run()
+			if err != nil {
+				t.Fatalf("nprocs=%d output=%q: error: %v", nprocs, output, err)
+			}
+
+			if svc.nevts != nevts {
+				t.Errorf("nprocs=%d: got %d events. want %d", nprocs, svc.nevts, nevts)
+			}
+			if svc.throughput() <= 0 {
+				t.Errorf("nprocs=%d: invalid throughput %v", nprocs, svc.throughput())
+			}
+
+			sts := svc.stats()
+			if len(sts) != 2 || sts[0].Task != "t1" || sts[1].Task != "t2" {
+				t.Fatalf("nprocs=%d: invalid tasks: %v", nprocs, sts)
+			}
+			for _, st := range sts {
+				if st.N != nevts {
+					t.Errorf("nprocs=%d: task %q: got %d events. want %d", nprocs, st.Task, st.N, nevts)
+				}
+				if st.Wall <= 0 {
+					t.Errorf("nprocs=%d: task %q: invalid wall time %v", nprocs, st.Task, st.Wall)
+				}
+			}
+
+			switch {
+			case nprocs == 0 && svc.nqueue != 0:
+				t.Errorf("nprocs=%d: got %d queue samples. want 0", nprocs, svc.nqueue)
+			case nprocs > 0 && svc.nqueue != nevts:
+				t.Errorf("nprocs=%d: got %d queue samples. want %d", nprocs, svc.nqueue, nevts)
+			}
+
+			if output == "" {
+				continue
+			}
+
+			f, err := os.Open(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			var nrecs int
+			switch ext {
+			case ".csv":
+				rows, err := csv.NewReader(f).ReadAll()
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := []string{"event", "slot", "task", "wall", "cpu", "allocs", "bytes"}
+				if fmt.Sprint(rows[0]) != fmt.Sprint(want) {
+					t.Errorf("invalid CSV header: got=%q want=%q", rows[0], want)
+				}
+				nrecs = len(rows) - 1
+			case ".json":
+				var recs []record
+				err = json.NewDecoder(f).Decode(&recs)
+				if err != nil {
+					t.Fatal(err)
+				}
+				nrecs = len(recs)
+			}
+			if nrecs != 2*nevts {
+				t.Errorf("nprocs=%d output=%q: got %d records. want %d", nprocs, output, nrecs, 2*nevts)
+			}
+		}
+	}
+}
+
+func TestMonSvcInvalidOutput(t *testing.T) {
+	app, _ := newapp(0, "mon.txt")
+	err := app.App().Run()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
@@ -5,9 +5,15 @@
 package fwk
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 // WriteSyncer is an io.Writer which can be sync'ed/flushed.
@@ -17,9 +23,10 @@ type WriteSyncer interface {
 }
 
 type msgstream struct {
-	lvl Level
-	w   WriteSyncer
-	n   string
+	lvl  Level
+	name string
+	n    string // name, padded for the text format
+	log  *msglog
 }
 
 // NewMsgStream creates a new MsgStream value with name name and minimum
@@ -33,11 +40,11 @@ func newMsgStream(name string, lvl Level, w WriteSyncer) msgstream {
 	if w == nil {
 		w = os.Stdout
 	}
-
 	return msgstream{
-		lvl: lvl,
-		w:   w,
-		n:   fmt.Sprintf("%-20s ", name),
+		lvl:  lvl,
+		name: name,
+		n:    fmt.Sprintf("%-20s ", name),
+		log:  newMsgLog(w),
 	}
 }
 
@@ -68,10 +75,265 @@ func (msg msgstream) Msg(lvl Level, format string, a ...interface{}) (int, error
 	if lvl < msg.lvl {
 		return 0, nil
 	}
-	format = msg.n + msg.lvl.msgstring() + " " + format
-	return fmt.Fprintf(msg.w, format, a...)
+	return msg.log.write(msg, lvl, format, fmt.Sprintf(format, a...), nil)
+}
+
+// Log displays a structured message with level lvl.
+func (msg msgstream) Log(lvl Level, text string, kvs ...interface{}) error {
+	if lvl < msg.lvl {
+		return nil
+	}
+	if kvs == nil {
+		kvs = []interface{}{}
+	}
+	_, err := msg.log.write(msg, lvl, text, text, kvs)
+	return err
+}
+
+// Log displays a structured message with level lvl on msg: the message text
+// followed by a list of alternating keys and values.
+//
+//	fwk.Log(ctx.Msg(), fwk.LvlInfo, "event processed", "id", ctx.ID(), "njets", len(jets))
+//
+// If msg is not a StructuredLogger, the keys and values are appended to the
+// text of a formatted message.
+func Log(msg MsgStream, lvl Level, text string, kvs ...interface{}) error {
+	if log, ok := msg.(StructuredLogger); ok {
+		return log.Log(lvl, text, kvs...)
+	}
+	if len(kvs)%2 != 0 {
+		kvs = append(kvs, "(MISSING)")
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString(text)
+	for i := 0; i < len(kvs); i += 2 {
+		fmt.Fprintf(buf, " %s=%s", textValue(kvs[i]), textValue(kvs[i+1]))
+	}
+	_, err := msg.Msg(lvl, "%s\n", buf.String())
+	return err
 }
 
 func (msg msgstream) flush() error {
-	return msg.w.Sync()
+	return msg.log.sync()
+}
+
+// Message formats.
+const (
+	msgText = "text" // human-readable text, one message per line
+	msgJSON = "json" // one JSON object per message and per line
+)
+
+// msgkey identifies the messages counted for the rate-limiting.
+type msgkey struct {
+	name   string
+	lvl    Level
+	format string
+}
+
+// msglog is the backend shared by the message streams of an application.
+//
+// msglog formats the messages, limits the number of times a given message
+// is displayed and routes messages to the console and to the log file.
+// When a log file is set up, all messages are written to the log file,
+// while the console only receives the warnings and errors, and a summary
+// of the messages when the log is closed.
+type msglog struct {
+	mu      sync.Mutex
+	console WriteSyncer
+	fname   string      // name of the log file
+	file    WriteSyncer // log file, if any
+	format  string      // format of the messages sent to the log file, or to the console
+	limit   int         // maximum number of times a message is displayed (0: no limit)
+	seen    map[msgkey]int
+	counts  map[Level]int // number of messages, by level
+	nsup    int           // number of suppressed messages
+}
+
+func newMsgLog(w WriteSyncer) *msglog {
+	return &msglog{
+		console: w,
+		format:  msgText,
+		seen:    make(map[msgkey]int),
+		counts:  make(map[Level]int),
+	}
+}
+
+// setup configures the format, the rate-limit and the log file of the
+// backend.
+func (log *msglog) setup(format string, limit int, fname string) error {
+	switch format {
+	case "", msgText:
+		format = msgText
+	case msgJSON:
+	default:
+		return Errorf("fwk: invalid message format %q (want %q or %q)", format, msgText, msgJSON)
+	}
+	if limit < 0 {
+		return Errorf("fwk: invalid message rate-limit (%d)", limit)
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	log.format = format
+	log.limit = limit
+	if fname == "" || fname == log.fname {
+		return nil
+	}
+	if log.file != nil {
+		return Errorf("fwk: log file [%s] already opened", log.fname)
+	}
+	f, err := os.Create(fname)
+	if err != nil {
+		return Errorf("fwk: could not create log file: %v", err)
+	}
+	log.file = f
+	log.fname = fname
+	return nil
+}
+
+// write formats and writes the message text emitted by the stream msg,
+// followed by the key/value pairs kvs.
+// format identifies the message for the rate-limiting.
+func (log *msglog) write(msg msgstream, lvl Level, format, text string, kvs []interface{}) (int, error) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	log.counts[lvl]++
+	if log.limit > 0 {
+		key := msgkey{msg.name, lvl, format}
+		n := log.seen[key] + 1
+		log.seen[key] = n
+		switch {
+		case n > log.limit:
+			log.nsup++
+			return 0, nil
+		case n == log.limit:
+			defer log.emit(msg, lvl, "message repeated too many times: further occurrences suppressed", []interface{}{"limit", log.limit})
+		}
+	}
+	return log.emit(msg, lvl, text, kvs)
+}
+
+// emit writes a message to the console and to the log file.
+func (log *msglog) emit(msg msgstream, lvl Level, text string, kvs []interface{}) (int, error) {
+	if log.file == nil {
+		return log.console.Write(log.encode(log.format, msg, lvl, text, kvs))
+	}
+
+	n, err := log.file.Write(log.encode(log.format, msg, lvl, text, kvs))
+	if err != nil {
+		return n, err
+	}
+	if lvl >= LvlWarning {
+		_, err = log.console.Write(log.encode(msgText, msg, lvl, text, kvs))
+	}
+	return n, err
+}
+
+func (log *msglog) encode(format string, msg msgstream, lvl Level, text string, kvs []interface{}) []byte {
+	if len(kvs)%2 != 0 {
+		kvs = append(kvs, "(MISSING)")
+	}
+
+	buf := new(bytes.Buffer)
+	switch format {
+	case msgJSON:
+		buf.WriteString(`{"time":`)
+		jsonValue(buf, time.Now().Format(time.RFC3339Nano))
+		buf.WriteString(`,"level":`)
+		jsonValue(buf, lvl.String())
+		buf.WriteString(`,"name":`)
+		jsonValue(buf, msg.name)
+		buf.WriteString(`,"msg":`)
+		jsonValue(buf, strings.TrimSuffix(text, "\n"))
+		for i := 0; i < len(kvs); i += 2 {
+			buf.WriteString(",")
+			jsonValue(buf, fmt.Sprint(kvs[i]))
+			buf.WriteString(":")
+			jsonValue(buf, kvs[i+1])
+		}
+		buf.WriteString("}\n")
+
+	default:
+		buf.WriteString(msg.n)
+		buf.WriteString(lvl.msgstring())
+		buf.WriteString(" ")
+		buf.WriteString(text)
+		if kvs == nil {
+			// formatted message, holding its own new line.
+			break
+		}
+		for i := 0; i < len(kvs); i += 2 {
+			fmt.Fprintf(buf, " %s=%s", textValue(kvs[i]), textValue(kvs[i+1]))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// jsonValue writes the JSON encoding of v, or of its string representation
+// if v can not be encoded.
+func jsonValue(buf *bytes.Buffer, v interface{}) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(b)
+}
+
+// textValue returns the string representation of v, quoted when needed.
+func textValue(v interface{}) string {
+	str := fmt.Sprint(v)
+	if str == "" || strings.ContainsAny(str, " \t\n\"=") {
+		return strconv.Quote(str)
+	}
+	return str
+}
+
+func (log *msglog) sync() error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if log.file != nil {
+		log.file.Sync()
+	}
+	return log.console.Sync()
+}
+
+// close closes the log file, if any, and writes on the console a summary
+// of the messages written to the log file or suppressed.
+func (log *msglog) close(msg msgstream) error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	if log.file == nil && log.nsup == 0 {
+		return nil
+	}
+
+	kvs := make([]interface{}, 0, 12)
+	for _, lvl := range []Level{LvlError, LvlWarning, LvlInfo, LvlDebug} {
+		kvs = append(kvs, lvl.String(), log.counts[lvl])
+	}
+	if log.nsup > 0 {
+		kvs = append(kvs, "suppressed", log.nsup)
+	}
+
+	var err error
+	if log.file != nil {
+		kvs = append(kvs, "file", log.fname)
+		if f, ok := log.file.(io.Closer); ok {
+			err = f.Close()
+		}
+		log.file = nil
+		log.fname = ""
+	}
+
+	_, werr := log.console.Write(log.encode(msgText, msg, LvlInfo, "messages:", kvs))
+	if err == nil {
+		err = werr
+	}
+	return err
 }
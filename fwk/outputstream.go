@@ -18,11 +18,18 @@ import (
 //
 // OutputStream declares a property 'Streamer', a fwk.OutputStreamer,
 // which will be used to actually write data to.
+//
+// OutputStream declares a property 'Select', a []string, the names of the
+// filter paths selecting the events to write out: events are written if
+// at least one of these paths accepted them.
+// By default, all the events are written out.
 type OutputStream struct {
 	TaskBase
 
-	streamer OutputStreamer
-	ctrl     StreamControl
+	streamer  OutputStreamer
+	ctrl      StreamControl
+	connected bool     // whether the streamer is connected
+	sel       []string // names of the filter paths selecting events
 }
 
 // Configure declares the input ports defined by the 'Ports' property.
@@ -63,6 +70,7 @@ func (tsk *OutputStream) connect(ctrl StreamControl) error {
 	if err != nil {
 		return err
 	}
+	tsk.connected = true
 
 	go tsk.write()
 
@@ -70,9 +78,32 @@ func (tsk *OutputStream) connect(ctrl StreamControl) error {
 }
 
 func (tsk *OutputStream) disconnect() error {
+	if !tsk.connected {
+		return nil
+	}
+	tsk.connected = false
 	return tsk.streamer.Disconnect()
 }
 
+// merge merges the outputs written in the directories dirs by the workers
+// of a distributed job.
+// merge returns false if the streamer is not an OutputMerger.
+func (tsk *OutputStream) merge(dirs []string) (bool, error) {
+	m, ok := tsk.streamer.(OutputMerger)
+	if !ok {
+		return false, nil
+	}
+
+	err := tsk.streamer.Connect(tsk.ctrl.Ports)
+	if err != nil {
+		return true, err
+	}
+	tsk.connected = true
+
+	err = m.MergeOutputs(dirs)
+	return true, err
+}
+
 func (tsk *OutputStream) write() {
 	for {
 		select {
@@ -121,6 +152,11 @@ func newOutputStream(typ, name string, mgr App) (Component, error) {
 		return nil, err
 	}
 
+	err = tsk.DeclProp("Select", &tsk.sel)
+	if err != nil {
+		return nil, err
+	}
+
 	return tsk, err
 }
 
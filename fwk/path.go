@@ -0,0 +1,36 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fwk
+
+// Path is a named sequence of tasks filtering events.
+//
+// The tasks of a path are run in order, after the tasks producing their
+// inputs.
+// A path accepts an event if all its tasks accepted it: once a task
+// rejected an event, the following tasks of the path are skipped, unless
+// they are also part of another path still accepting the event.
+//
+// Paths are declared with the 'Paths' property of the application.
+// OutputStreams select the events they write with their 'Select' property,
+// so a single job can write, e.g., a skim of the events accepted by a path
+// and a summary of all the events.
+type Path struct {
+	Name  string   // name of the path
+	Tasks []string // names of the tasks of the path
+}
+
+// SetAccepted marks the event being processed with ctx as accepted or
+// rejected by the task processing it.
+// Events are accepted by default.
+//
+// SetAccepted must be called from the Process method of the task.
+// SetAccepted is a no-op for contexts not created by a fwk application.
+func SetAccepted(ctx Context, accepted bool) {
+	c, ok := ctx.(context)
+	if !ok || c.accept == nil {
+		return
+	}
+	*c.accept = accepted
+}
@@ -19,6 +19,7 @@ type InputStreamer struct {
 	r     io.ReadCloser       // underlying input file(s)
 	rio   *rio.Reader         // input rio-stream
 	scan  *rio.Scanner        // input records-scanner
+	sel   []rio.Selector      // records to read
 	ports map[string]fwk.Port // input ports to read/populate
 }
 
@@ -50,11 +51,31 @@ func (input *InputStreamer) Connect(ports []fwk.Port) error {
 		recnames = append(recnames, rio.Selector{Name: port.Name, Unpack: true})
 	}
 
+	input.sel = recnames
 	input.scan = rio.NewScanner(input.rio)
 	input.scan.Select(recnames)
 	return err
 }
 
+// SeekEvent skips the records of the first n events, without unpacking them.
+func (input *InputStreamer) SeekEvent(n int64) error {
+	skip := make([]rio.Selector, len(input.sel))
+	for i, sel := range input.sel {
+		skip[i] = rio.Selector{Name: sel.Name, Unpack: false}
+	}
+	input.scan.Select(skip)
+	defer input.scan.Select(input.sel)
+
+	nrecs := n * int64(len(input.ports))
+	for i := int64(0); i < nrecs; i++ {
+		if !input.scan.Scan() {
+			// the next Read returns io.EOF, if no error occurred.
+			return input.scan.Err()
+		}
+	}
+	return nil
+}
+
 func (input *InputStreamer) Read(ctx fwk.Context) error {
 	var err error
 	store := ctx.Store()
@@ -104,3 +125,8 @@ func (input *InputStreamer) Disconnect() error {
 
 	return err
 }
+
+var (
+	_ fwk.InputStreamer = (*InputStreamer)(nil)
+	_ fwk.InputSeeker   = (*InputStreamer)(nil)
+)
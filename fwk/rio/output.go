@@ -7,6 +7,7 @@ package rio
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"reflect"
 
 	"go-hep.org/x/hep/fwk"
@@ -103,3 +104,84 @@ func (o *OutputStreamer) Write(ctx fwk.Context) error {
 	}
 	return err
 }
+
+// MergeOutputs appends the records of the files named Name written by the
+// workers of a distributed job, in the directories dirs.
+func (o *OutputStreamer) MergeOutputs(dirs []string) error {
+	for _, dir := range dirs {
+		err := o.merge(filepath.Join(dir, o.Name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OutputStreamer) merge(fname string) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := rio.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	recs := make(map[string]*rio.Record, len(o.recs))
+	sel := make([]rio.Selector, len(o.recs))
+	for i, rec := range o.recs {
+		recs[rec.Name()] = rec
+		sel[i] = rio.Selector{Name: rec.Name(), Unpack: true}
+		err = r.Record(rec.Name()).Connect(rec.Name(), reflect.New(o.ports[i].Type))
+		if err != nil {
+			return err
+		}
+	}
+
+	types := make(map[string]reflect.Type, len(o.ports))
+	for _, port := range o.ports {
+		types[port.Name] = port.Type
+	}
+
+	scan := rio.NewScanner(r)
+	scan.Select(sel)
+	for scan.Scan() {
+		rec := scan.Record()
+		n := rec.Name()
+		obj := reflect.New(types[n])
+		err = rec.Block(n).Read(obj.Interface())
+		if err != nil {
+			return fwk.Errorf("%s: block-read error: %v", fname, err)
+		}
+
+		out := recs[n]
+		err = out.Block(n).Write(obj.Elem().Interface())
+		if err != nil {
+			return err
+		}
+		err = out.Write()
+		if err != nil {
+			return err
+		}
+	}
+	err = scan.Err()
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// OutputFiles returns the name of the output file.
+func (o *OutputStreamer) OutputFiles() []string {
+	return []string{o.Name}
+}
+
+var (
+	_ fwk.OutputStreamer = (*OutputStreamer)(nil)
+	_ fwk.OutputFiler    = (*OutputStreamer)(nil)
+	_ fwk.OutputMerger   = (*OutputStreamer)(nil)
+)
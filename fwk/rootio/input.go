@@ -0,0 +1,244 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rootio
+
+import (
+	"io"
+	"reflect"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/rootio"
+)
+
+// InputStreamer reads data from the entries of a TTree in a (set of)
+// ROOT file(s).
+// Files are read one after the other.
+type InputStreamer struct {
+	Names []string // input filenames
+	Tree  string   // name of the tree to read
+
+	ports []fwk.Port          // input ports to read/populate
+	ifile int                 // index of the current input file
+	f     *rootio.File        // current input file
+	n     int64               // number of entries of the current tree
+	scan  *rootio.TreeScanner // current tree scanner
+}
+
+func (input *InputStreamer) Connect(ports []fwk.Port) error {
+	var err error
+
+	if len(input.Names) == 0 {
+		return fwk.Errorf("fwk/rootio: no input file")
+	}
+
+	input.ports = make([]fwk.Port, len(ports))
+	copy(input.ports, ports)
+
+	input.ifile = 0
+	err = input.open()
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+// open opens the current input file and connects the ports to the branches
+// of its tree.
+func (input *InputStreamer) open() (err error) {
+	fname := input.Names[input.ifile]
+
+	input.f, err = rootio.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			// make sure we don't leak filedescriptors
+			input.f.Close()
+			input.f = nil
+		}
+	}()
+
+	obj, err := input.f.Get(input.Tree)
+	if err != nil {
+		return fwk.Errorf("fwk/rootio: could not find tree %q in file %q: %v", input.Tree, fname, err)
+	}
+
+	tree, ok := obj.(rootio.Tree)
+	if !ok {
+		return fwk.Errorf("fwk/rootio: object %q in file %q is not a tree (type=%s)", input.Tree, fname, obj.Class())
+	}
+
+	vars := make([]rootio.ScanVar, len(input.ports))
+	for i, port := range input.ports {
+		br := tree.Branch(port.Name)
+		if br == nil {
+			return fwk.Errorf("fwk/rootio: tree %q in file %q has no branch %q", input.Tree, fname, port.Name)
+		}
+		err = checkType(br, port.Type)
+		if err != nil {
+			return fwk.Errorf("fwk/rootio: file %q: %v", fname, err)
+		}
+		vars[i] = rootio.ScanVar{Name: port.Name}
+	}
+
+	input.n = tree.Entries()
+	input.scan, err = rootio.NewTreeScannerVars(tree, vars...)
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+// close closes the current input file.
+func (input *InputStreamer) close() error {
+	var err error
+	if input.scan != nil {
+		err = input.scan.Close()
+		input.scan = nil
+		if err != nil {
+			return err
+		}
+	}
+	if input.f != nil {
+		err = input.f.Close()
+		input.f = nil
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (input *InputStreamer) Read(ctx fwk.Context) error {
+	var err error
+	store := ctx.Store()
+
+	for !input.scan.Next() {
+		err = input.scan.Err()
+		if err != nil {
+			return err
+		}
+		if input.ifile+1 >= len(input.Names) {
+			return io.EOF
+		}
+		err = input.close()
+		if err != nil {
+			return err
+		}
+		input.ifile++
+		err = input.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	vals := make([]reflect.Value, len(input.ports))
+	args := make([]interface{}, len(input.ports))
+	for i, port := range input.ports {
+		vals[i] = reflect.New(port.Type)
+		args[i] = vals[i].Interface()
+	}
+
+	err = input.scan.Scan(args...)
+	if err != nil {
+		return fwk.Errorf("fwk/rootio: could not read entry %d: %v", input.scan.Entry(), err)
+	}
+
+	for i, port := range input.ports {
+		err = store.Put(port.Name, vals[i].Elem().Interface())
+		if err != nil {
+			return fwk.Errorf("store-put error: %v", err)
+		}
+	}
+
+	return err
+}
+
+// SeekEvent positions the streamer on the entry n of the chain of trees,
+// without reading the entries before it.
+func (input *InputStreamer) SeekEvent(n int64) error {
+	for n >= input.n && input.ifile+1 < len(input.Names) {
+		n -= input.n
+		err := input.close()
+		if err != nil {
+			return err
+		}
+		input.ifile++
+		err = input.open()
+		if err != nil {
+			return err
+		}
+	}
+	return input.scan.SeekEntry(n)
+}
+
+func (input *InputStreamer) Disconnect() error {
+	return input.close()
+}
+
+// checkType checks values of type rt can be read from the branch br.
+func checkType(br rootio.Branch, rt reflect.Type) error {
+	leaves := br.Leaves()
+	if len(leaves) != 1 {
+		return fwk.Errorf("branch %q has %d leaves: only single-leaf branches are supported", br.Name(), len(leaves))
+	}
+
+	leaf := leaves[0]
+	switch leaf.(type) {
+	case *rootio.LeafO, *rootio.LeafB, *rootio.LeafS, *rootio.LeafI,
+		*rootio.LeafL, *rootio.LeafF, *rootio.LeafD, *rootio.LeafC:
+		// ok. simple leaves.
+	default:
+		return fwk.Errorf("branch %q holds objects (leaf=%s): only branches of simple types are supported", br.Name(), leaf.Class())
+	}
+
+	elem := rt
+	switch rt.Kind() {
+	case reflect.Array, reflect.Slice:
+		elem = rt.Elem()
+	}
+
+	want := leaf.Type()
+	if leaf.IsUnsigned() {
+		switch want.Kind() {
+		case reflect.Int8:
+			want = reflect.TypeOf(uint8(0))
+		case reflect.Int16:
+			want = reflect.TypeOf(uint16(0))
+		case reflect.Int32:
+			want = reflect.TypeOf(uint32(0))
+		case reflect.Int64:
+			want = reflect.TypeOf(uint64(0))
+		}
+	}
+
+	if elem != want {
+		return fwk.Errorf("branch %q holds values of type %v (port type=%v)", br.Name(), want, rt)
+	}
+
+	if _, ok := leaf.(*rootio.LeafC); ok {
+		// strings are stored as arrays of characters.
+		return nil
+	}
+
+	switch {
+	case leaf.LeafCount() != nil && rt.Kind() != reflect.Slice:
+		return fwk.Errorf("branch %q holds variable-size arrays of %v (port type=%v)", br.Name(), want, rt)
+	case leaf.LeafCount() == nil && leaf.Len() > 1 && rt.Kind() != reflect.Array:
+		return fwk.Errorf("branch %q holds arrays of %d %v (port type=%v)", br.Name(), leaf.Len(), want, rt)
+	case leaf.LeafCount() == nil && leaf.Len() > 1 && rt.Len() != leaf.Len():
+		return fwk.Errorf("branch %q holds arrays of %d %v (port type=%v)", br.Name(), leaf.Len(), want, rt)
+	}
+
+	return nil
+}
+
+var (
+	_ fwk.InputStreamer = (*InputStreamer)(nil)
+	_ fwk.InputSeeker   = (*InputStreamer)(nil)
+)
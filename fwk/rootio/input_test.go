@@ -0,0 +1,318 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rootio
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/fwk/job"
+)
+
+const (
+	fname    = "../../rootio/testdata/small-flat-tree.root"
+	nentries = 100
+)
+
+func newapp(nprocs int, ports []fwk.Port, names ...string) (*job.Job, *testtask) {
+	app := job.NewJob(nil, job.P{
+		"EvtMax":   int64(-1),
+		"NProcs":   nprocs,
+		"MsgLevel": job.MsgLevel("ERROR"),
+	})
+
+	app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk.InputStream",
+		Name: "input",
+		Props: job.P{
+			"Ports": ports,
+			"Streamer": &InputStreamer{
+				Names: names,
+				Tree:  "tree",
+			},
+		},
+	})
+
+	tsk := app.Create(job.C{
+		Type: "go-hep.org/x/hep/fwk/rootio.testtask",
+		Name: "check",
+	}).(*testtask)
+
+	return app, tsk
+}
+
+func TestInputStreamer(t *testing.T) {
+	ports := []fwk.Port{
+		{Name: "Int64", Type: reflect.TypeOf(int64(0))},
+		{Name: "Float64", Type: reflect.TypeOf(float64(0))},
+		{Name: "Str", Type: reflect.TypeOf("")},
+		{Name: "ArrayFloat64", Type: reflect.TypeOf([10]float64{})},
+		{Name: "N", Type: reflect.TypeOf(int32(0))},
+		{Name: "SliceFloat64", Type: reflect.TypeOf([]float64{})},
+	}
+
+	for _, nprocs := range []int{0, 1, 4} {
+		for _, names := range [][]string{
+			{fname},
+			{fname, fname},
+		} {
+			app, tsk := newapp(nprocs, ports, names...)
+			err := app.App().Run()
+			if err != nil {
+				t.Errorf("nprocs=%d files=%d: error: %v", nprocs, len(names), err)
+				continue
+			}
+			if want := int64(len(names) * nentries); tsk.n != want {
+				t.Errorf("nprocs=%d files=%d: got %d events. want %d", nprocs, len(names), tsk.n, want)
+			}
+		}
+	}
+}
+
+func TestInputStreamerInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		port fwk.Port
+	}{
+		{
+			name: "no-such-branch",
+			port: fwk.Port{Name: "NoSuchBranch", Type: reflect.TypeOf(int64(0))},
+		},
+		{
+			name: "scalar-type",
+			port: fwk.Port{Name: "Int64", Type: reflect.TypeOf(float64(0))},
+		},
+		{
+			name: "array-len",
+			port: fwk.Port{Name: "ArrayFloat64", Type: reflect.TypeOf([5]float64{})},
+		},
+		{
+			name: "array-slice",
+			port: fwk.Port{Name: "ArrayFloat64", Type: reflect.TypeOf([]float64{})},
+		},
+		{
+			name: "slice-array",
+			port: fwk.Port{Name: "SliceFloat64", Type: reflect.TypeOf([10]float64{})},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ports := []fwk.Port{
+				{Name: "Int64", Type: reflect.TypeOf(int64(0))},
+				{Name: "Float64", Type: reflect.TypeOf(float64(0))},
+				{Name: "Str", Type: reflect.TypeOf("")},
+				{Name: "ArrayFloat64", Type: reflect.TypeOf([10]float64{})},
+				{Name: "N", Type: reflect.TypeOf(int32(0))},
+				{Name: "SliceFloat64", Type: reflect.TypeOf([]float64{})},
+			}
+			for i, port := range ports {
+				if port.Name == test.port.Name {
+					ports[i] = test.port
+				}
+			}
+			if test.port.Name == "NoSuchBranch" {
+				ports = append(ports, test.port)
+			}
+
+			app, _ := newapp(0, ports, fname)
+			err := app.App().Run()
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+		})
+	}
+}
+
+// TestConnectInvalid checks invalid inputs are reported by Connect, which
+// closes the input file.
+func TestConnectInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		fname string
+		tree  string
+		port  fwk.Port
+	}{
+		{
+			name:  "no-such-tree",
+			fname: fname,
+			tree:  "NoSuchTree",
+			port:  fwk.Port{Name: "Int64", Type: reflect.TypeOf(int64(0))},
+		},
+		{
+			name:  "type",
+			fname: fname,
+			tree:  "tree",
+			port:  fwk.Port{Name: "Int64", Type: reflect.TypeOf(int32(0))},
+		},
+		{
+			name:  "object",
+			fname: "../../rootio/testdata/small-evnt-tree-fullsplit.root",
+			tree:  "tree",
+			port:  fwk.Port{Name: "evt", Type: reflect.TypeOf(struct{ I32 int32 }{})},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			input := &InputStreamer{Names: []string{test.fname}, Tree: test.tree}
+			err := input.Connect([]fwk.Port{test.port})
+			if err == nil {
+				input.Disconnect()
+				t.Fatalf("expected an error")
+			}
+			if input.f != nil {
+				t.Fatalf("input file was not closed")
+			}
+		})
+	}
+}
+
+// context is a minimal fwk.Context to drive the streamer.
+type context struct {
+	store store
+}
+
+func (ctx context) ID() int64                     { return 0 }
+func (ctx context) Slot() int                     { return 0 }
+func (ctx context) Store() fwk.Store              { return ctx.store }
+func (ctx context) Msg() fwk.MsgStream            { return nil }
+func (ctx context) Svc(n string) (fwk.Svc, error) { return nil, fmt.Errorf("no service [%s]", n) }
+
+type store map[string]interface{}
+
+func (s store) Get(k string) (interface{}, error) { return s[k], nil }
+func (s store) Put(k string, v interface{}) error { s[k] = v; return nil }
+func (s store) Has(k string) bool                 { _, ok := s[k]; return ok }
+
+func TestSeek(t *testing.T) {
+	ports := []fwk.Port{{Name: "Int64", Type: reflect.TypeOf(int64(0))}}
+	for _, n := range []int64{0, 42, 100, 150, 200, 250} {
+		input := &InputStreamer{Names: []string{fname, fname}, Tree: "tree"}
+		err := input.Connect(ports)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = input.SeekEvent(n)
+		if err != nil {
+			t.Fatalf("seek %d: %v", n, err)
+		}
+
+		ctx := context{store: make(store)}
+		nevts := int64(0)
+		for {
+			err = input.Read(ctx)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("seek %d: %v", n, err)
+			}
+			if got, want := ctx.store["Int64"].(int64), (n+nevts)%nentries; got != want {
+				t.Fatalf("seek %d: got entry %d. want %d", n, got, want)
+			}
+			nevts++
+		}
+
+		want := 2*nentries - n
+		if want < 0 {
+			want = 0
+		}
+		if nevts != want {
+			t.Fatalf("seek %d: got %d events. want %d", n, nevts, want)
+		}
+
+		err = input.Disconnect()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testtask checks the data read from small-flat-tree.root
+type testtask struct {
+	fwk.TaskBase
+
+	mu sync.Mutex
+	n  int64
+}
+
+func (tsk *testtask) Configure(ctx fwk.Context) error {
+	var err error
+	for _, port := range []fwk.Port{
+		{Name: "Int64", Type: reflect.TypeOf(int64(0))},
+		{Name: "Float64", Type: reflect.TypeOf(float64(0))},
+		{Name: "Str", Type: reflect.TypeOf("")},
+		{Name: "ArrayFloat64", Type: reflect.TypeOf([10]float64{})},
+		{Name: "N", Type: reflect.TypeOf(int32(0))},
+		{Name: "SliceFloat64", Type: reflect.TypeOf([]float64{})},
+	} {
+		err = tsk.DeclInPort(port.Name, port.Type)
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (tsk *testtask) StartTask(ctx fwk.Context) error {
+	return nil
+}
+
+func (tsk *testtask) StopTask(ctx fwk.Context) error {
+	return nil
+}
+
+func (tsk *testtask) Process(ctx fwk.Context) error {
+	store := ctx.Store()
+	get := func(name string) interface{} {
+		v, err := store.Get(name)
+		if err != nil {
+			panic(err)
+		}
+		return v
+	}
+
+	i64 := get("Int64").(int64)
+	f64 := get("Float64").(float64)
+	str := get("Str").(string)
+	arr := get("ArrayFloat64").([10]float64)
+	n := get("N").(int32)
+	sli := get("SliceFloat64").([]float64)
+
+	if f64 != float64(i64) {
+		return fmt.Errorf("entry %d: invalid Float64: %v", i64, f64)
+	}
+	if want := fmt.Sprintf("evt-%03d", i64); str != want {
+		return fmt.Errorf("entry %d: invalid Str: %q (want=%q)", i64, str, want)
+	}
+	for _, v := range arr {
+		if v != f64 {
+			return fmt.Errorf("entry %d: invalid ArrayFloat64: %v", i64, arr)
+		}
+	}
+	if int64(n) != i64%10 || len(sli) != int(n) {
+		return fmt.Errorf("entry %d: invalid N=%d or SliceFloat64: %v", i64, n, sli)
+	}
+	for _, v := range sli {
+		if v != f64 {
+			return fmt.Errorf("entry %d: invalid SliceFloat64: %v", i64, sli)
+		}
+	}
+
+	tsk.mu.Lock()
+	tsk.n++
+	tsk.mu.Unlock()
+	return nil
+}
+
+func init() {
+	fwk.Register(reflect.TypeOf(testtask{}),
+		func(typ, name string, mgr fwk.App) (fwk.Component, error) {
+			return &testtask{TaskBase: fwk.NewTask(typ, name, mgr)}, nil
+		},
+	)
+}
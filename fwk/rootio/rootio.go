@@ -0,0 +1,18 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rootio provides a fwk.InputStreamer reading events from the
+// entries of a ROOT TTree.
+//
+// Each fwk.Port is mapped onto the tree branch with the same name. The
+// type of the port must match the type of the branch: e.g. float64 for a
+// Double_t branch, [N]float64 for a fixed-size array of Double_t and
+// []float64 for a variable-size array of Double_t.
+// Only branches with a single leaf of a simple type are supported: branches
+// with several leaves or holding objects are rejected by Connect.
+//
+// go-hep.org/x/hep/rootio can not write ROOT files yet: there is thus no
+// OutputStreamer writing into a TTree. It will be provided once rootio
+// supports writing.
+package rootio // import "go-hep.org/x/hep/fwk/rootio"
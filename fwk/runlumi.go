@@ -0,0 +1,175 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fwk
+
+// transitions drives the run and luminosity-block transitions of the
+// components of an application.
+//
+// transitions is not concurrent-safe: it must be used from the goroutine
+// feeding events to the event loop, while no event is being processed.
+type transitions struct {
+	app  *appmgr
+	runs []runHandler
+	lumi []lumiHandler
+
+	cur  EventID // run and luminosity block currently open
+	open bool    // whether a run and a luminosity block are currently open
+}
+
+type runHandler struct {
+	h   RunHandler
+	ctx context
+}
+
+type lumiHandler struct {
+	h   LumiBlockHandler
+	ctx context
+}
+
+// newTransitions collects the services, tasks and task clones of app
+// implementing RunHandler or LumiBlockHandler. Services come first.
+func newTransitions(app *appmgr) *transitions {
+	tr := &transitions{app: app}
+	comps := make([]Component, 0, len(app.svcs)+len(app.tsks))
+	for _, svc := range app.svcs {
+		comps = append(comps, svc)
+	}
+	for _, tsk := range app.tsks {
+		comps = append(comps, tsk)
+		for _, clone := range app.clones[tsk.Name()] {
+			comps = append(comps, clone)
+		}
+	}
+
+	for _, c := range comps {
+		ctx := context{
+			id:    -1,
+			slot:  0,
+			store: app.store,
+			msg:   app.msgstream(c.Name()),
+			mgr:   app,
+		}
+		if h, ok := c.(RunHandler); ok {
+			tr.runs = append(tr.runs, runHandler{h: h, ctx: ctx})
+		}
+		if h, ok := c.(LumiBlockHandler); ok {
+			tr.lumi = append(tr.lumi, lumiHandler{h: h, ctx: ctx})
+		}
+	}
+	return tr
+}
+
+// eventID returns the identifier of the event just read by the input stream.
+func (tr *transitions) eventID() EventID {
+	in, ok := tr.app.istream.(*InputStream)
+	if !ok {
+		return EventID{}
+	}
+	id, _ := in.eventID()
+	return id
+}
+
+// changes returns whether processing the event id needs a transition.
+func (tr *transitions) changes(id EventID) bool {
+	return !tr.open || id.Run != tr.cur.Run || id.Lumi != tr.cur.Lumi
+}
+
+// next performs the transitions needed before processing the event id.
+func (tr *transitions) next(id EventID) error {
+	if !tr.changes(id) {
+		return nil
+	}
+
+	newRun := !tr.open || id.Run != tr.cur.Run
+	if tr.open {
+		err := tr.endLumi()
+		if err != nil {
+			return err
+		}
+		if newRun {
+			err = tr.endRun()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	tr.cur = id
+	tr.open = true
+	if newRun {
+		err := tr.beginRun()
+		if err != nil {
+			return err
+		}
+	}
+	return tr.beginLumi()
+}
+
+// end closes the luminosity block and the run currently open, if any.
+func (tr *transitions) end() error {
+	if !tr.open {
+		return nil
+	}
+	tr.open = false
+	err := tr.endLumi()
+	if err != nil {
+		return err
+	}
+	return tr.endRun()
+}
+
+func (tr *transitions) beginRun() error {
+	run := tr.cur.Run
+	tr.app.msg.Debugf(">>> begin-run %d\n", run)
+	for _, h := range tr.runs {
+		err := h.h.BeginRun(h.ctx, run)
+		h.ctx.msg.flush()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tr *transitions) endRun() error {
+	run := tr.cur.Run
+	tr.app.msg.Debugf(">>> end-run %d\n", run)
+	for i := len(tr.runs) - 1; i >= 0; i-- {
+		h := tr.runs[i]
+		err := h.h.EndRun(h.ctx, run)
+		h.ctx.msg.flush()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tr *transitions) beginLumi() error {
+	run, lumi := tr.cur.Run, tr.cur.Lumi
+	tr.app.msg.Debugf(">>> begin-lumi-block %d/%d\n", run, lumi)
+	for _, h := range tr.lumi {
+		err := h.h.BeginLumiBlock(h.ctx, run, lumi)
+		h.ctx.msg.flush()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tr *transitions) endLumi() error {
+	run, lumi := tr.cur.Run, tr.cur.Lumi
+	tr.app.msg.Debugf(">>> end-lumi-block %d/%d\n", run, lumi)
+	for i := len(tr.lumi) - 1; i >= 0; i-- {
+		h := tr.lumi[i]
+		err := h.h.EndLumiBlock(h.ctx, run, lumi)
+		h.ctx.msg.flush()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -15,10 +15,6 @@ type irunner struct {
 
 var _ Scripter = (*irunner)(nil)
 
-func (ui irunner) lvl() Level {
-	return ui.app.msg.lvl
-}
-
 func (ui irunner) state() fsm.State {
 	return ui.app.state
 }
@@ -29,7 +25,7 @@ func (ui *irunner) Configure() error {
 		id:    0,
 		slot:  0,
 		store: nil,
-		msg:   newMsgStream("<root>", ui.lvl(), nil),
+		msg:   ui.app.msgstream("<root>"),
 	}
 
 	err = ui.app.configure(ctx)
@@ -46,7 +42,7 @@ func (ui *irunner) Start() error {
 		id:    0,
 		slot:  0,
 		store: nil,
-		msg:   newMsgStream("<root>", ui.lvl(), nil),
+		msg:   ui.app.msgstream("<root>"),
 	}
 
 	if ui.state() < fsm.Configured {
@@ -67,7 +63,7 @@ func (ui *irunner) Run(evtmax int64) error {
 		id:    0,
 		slot:  0,
 		store: nil,
-		msg:   newMsgStream("<root>", ui.lvl(), nil),
+		msg:   ui.app.msgstream("<root>"),
 	}
 
 	if ui.state() < fsm.Started {
@@ -88,7 +84,7 @@ func (ui *irunner) Stop() error {
 		id:    0,
 		slot:  0,
 		store: nil,
-		msg:   newMsgStream("<root>", ui.lvl(), nil),
+		msg:   ui.app.msgstream("<root>"),
 	}
 
 	if ui.state() < fsm.Running {
@@ -109,17 +105,17 @@ func (ui *irunner) Shutdown() error {
 		id:    0,
 		slot:  0,
 		store: nil,
-		msg:   newMsgStream("<root>", ui.lvl(), nil),
+		msg:   ui.app.msgstream("<root>"),
 	}
 
 	if ui.state() < fsm.Stopped {
 		return Errorf("fwk: invalid app state (%v). need at least %s", ui.state(), fsm.Stopped)
 	}
 
-	err = ui.app.start(ctx)
+	err = ui.app.shutdown(ctx)
 	if err != nil {
 		return err
 	}
 
-	return err
+	return ui.app.msg.log.close(ui.app.msg)
 }
@@ -0,0 +1,412 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fwk
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	nctx "golang.org/x/net/context"
+)
+
+// scheduler runs the tasks of an event, following the data-flow graph:
+// a task is processed once all the tasks producing its inputs have been
+// processed, so independent tasks run concurrently.
+//
+// The tasks of a filter path are run in order and skipped once the event
+// was rejected by all the paths they belong to.
+// Tasks whose inputs were not produced, because their producer was
+// skipped, are skipped as well.
+//
+// The number of tasks running concurrently, across all the events in
+// flight, is bounded.
+type scheduler struct {
+	tsks  [][]Task      // tasks of each event slot
+	deps  [][]int       // deps[i]: tasks to run before task i
+	data  [][]int       // data[i]: tasks producing the inputs of task i
+	users [][]int       // users[i]: tasks to run after task i
+	paths [][]int       // paths[i]: filter paths of task i
+	sel   [][]int       // sel[i]: filter paths selecting the events of task i
+	names []string      // names of the filter paths
+	ports [][]string    // ports[i]: ports task i may get from the store
+	locks []*sync.Mutex // serialise the non re-entrant tasks without clones
+	sema  chan struct{} // tokens of the tasks running concurrently
+	mon   MonSvc        // monitoring service, if any
+	dflow *dflowsvc     // data-flow service, if it serves the live view
+}
+
+type taskResult struct {
+	i   int
+	err error
+}
+
+// newScheduler creates a scheduler for the tasks of app, with nslots
+// event slots and at most ntasks concurrently running tasks.
+func newScheduler(app *appmgr, nslots, ntasks int) (*scheduler, error) {
+	n := len(app.tsks)
+	s := &scheduler{
+		tsks:  make([][]Task, nslots),
+		deps:  make([][]int, n),
+		data:  make([][]int, n),
+		users: make([][]int, n),
+		paths: make([][]int, n),
+		sel:   make([][]int, n),
+		ports: make([][]string, n),
+		locks: make([]*sync.Mutex, n),
+		sema:  make(chan struct{}, ntasks),
+	}
+
+	for _, svc := range app.svcs {
+		if mon, ok := svc.(MonSvc); ok {
+			s.mon = mon
+			break
+		}
+	}
+	if app.dflow.srv != nil {
+		s.dflow = app.dflow
+	}
+
+	idx := make(map[string]int, n)
+	for i, tsk := range app.tsks {
+		idx[tsk.Name()] = i
+	}
+
+	// outport-name -> index of producer task.
+	// ports produced by the input stream are always available.
+	producer := make(map[string]int)
+	for name, node := range app.dflow.nodes {
+		i, ok := idx[name]
+		if !ok {
+			continue
+		}
+		for k := range node.out {
+			producer[k] = i
+		}
+	}
+
+	deps := make([]map[int]struct{}, n)
+	for i, tsk := range app.tsks {
+		deps[i] = make(map[int]struct{})
+		node, ok := app.dflow.nodes[tsk.Name()]
+		if !ok {
+			continue
+		}
+		for k := range node.in {
+			s.ports[i] = append(s.ports[i], k)
+		}
+		for k := range node.out {
+			s.ports[i] = append(s.ports[i], k)
+		}
+		for k := range node.in {
+			j, ok := producer[k]
+			if !ok || j == i {
+				continue
+			}
+			deps[i][j] = struct{}{}
+		}
+		for j := range deps[i] {
+			s.data[i] = append(s.data[i], j)
+		}
+		sort.Ints(s.data[i])
+	}
+
+	// filter paths.
+	pidx := make(map[string]int, len(app.paths))
+	ptsks := make([][]int, len(app.paths))
+	for ip, path := range app.paths {
+		if _, dup := pidx[path.Name]; dup {
+			return nil, Errorf("fwk: duplicate path [%s]", path.Name)
+		}
+		pidx[path.Name] = ip
+		s.names = append(s.names, path.Name)
+		for k, name := range path.Tasks {
+			i, ok := idx[name]
+			if !ok {
+				return nil, Errorf("fwk: path [%s]: no such task [%s]", path.Name, name)
+			}
+			ptsks[ip] = append(ptsks[ip], i)
+			s.paths[i] = append(s.paths[i], ip)
+			if k > 0 {
+				deps[i][ptsks[ip][k-1]] = struct{}{}
+			}
+		}
+	}
+
+	for i, tsk := range app.tsks {
+		out, ok := tsk.(*OutputStream)
+		if !ok {
+			continue
+		}
+		for _, name := range out.sel {
+			ip, ok := pidx[name]
+			if !ok {
+				return nil, Errorf("fwk: output stream [%s]: no such path [%s]", out.Name(), name)
+			}
+			s.sel[i] = append(s.sel[i], ip)
+			for _, j := range ptsks[ip] {
+				if j != i {
+					deps[i][j] = struct{}{}
+				}
+			}
+		}
+	}
+
+	for i := range deps {
+		for j := range deps[i] {
+			s.deps[i] = append(s.deps[i], j)
+		}
+		sort.Ints(s.deps[i])
+		for _, j := range s.deps[i] {
+			s.users[j] = append(s.users[j], i)
+		}
+	}
+
+	err := s.checkCycles(app)
+	if err != nil {
+		return nil, err
+	}
+
+	for slot := range s.tsks {
+		s.tsks[slot] = make([]Task, n)
+		copy(s.tsks[slot], app.tsks)
+	}
+
+	for i, tsk := range app.tsks {
+		if r, ok := tsk.(Reentrant); !ok || r.Reentrant() {
+			continue
+		}
+		clones := app.clones[tsk.Name()]
+		if len(clones) < nslots-1 {
+			s.locks[i] = new(sync.Mutex)
+			continue
+		}
+		for slot := 1; slot < nslots; slot++ {
+			s.tsks[slot][i] = clones[slot-1]
+		}
+	}
+
+	return s, nil
+}
+
+// checkCycles checks the ordering of the tasks imposed by the filter paths
+// is consistent with the data-flow.
+func (s *scheduler) checkCycles(app *appmgr) error {
+	// task -> tasks to run after it.
+	graph := make(map[string][]string, len(s.users))
+	for i, users := range s.users {
+		name := app.tsks[i].Name()
+		for _, j := range users {
+			graph[name] = append(graph[name], app.tsks[j].Name())
+		}
+	}
+
+	cycles := findCycles(graph)
+	if len(cycles) == 0 {
+		return nil
+	}
+
+	paths := make([]string, len(cycles))
+	for i, cycle := range cycles {
+		paths[i] = strings.Join(cycle, " -> ")
+	}
+	plural := ""
+	if len(cycles) > 1 {
+		plural = "s"
+	}
+	return Errorf("fwk: filter paths inconsistent with data-flow: cycle%s detected: %s", plural, strings.Join(paths, "; "))
+}
+
+// run processes the event ievt with the tasks of the given slot.
+// ctxs are the contexts of the tasks, store the event store.
+//
+// run returns the first error returned by a task or, if evtctx is
+// cancelled, the error of evtctx.
+// On error, the caller must cancel evtctx and close store to release
+// the tasks still running or waiting to run.
+func (s *scheduler) run(evtctx nctx.Context, slot int, ievt int64, ctxs []context, store Store) error {
+	tsks := s.tsks[slot]
+	if len(tsks) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	done := make(chan taskResult, len(tsks))
+	accepted := make([]bool, len(tsks))
+	launch := func(i int) {
+		accepted[i] = true
+		ctx := ctxs[i]
+		ctx.id = ievt
+		ctx.store = taskstore{Store: store, task: tsks[i].Name(), ports: s.ports[i]}
+		ctx.ctx = evtctx
+		ctx.accept = &accepted[i]
+		go s.process(evtctx, i, tsks[i], ctx, done)
+	}
+
+	alive := make([]bool, len(s.names)) // paths still accepting the event
+	for i := range alive {
+		alive[i] = true
+	}
+	skipped := make([]bool, len(tsks))
+	reject := func(i int) {
+		for _, ip := range s.paths[i] {
+			alive[ip] = false
+		}
+	}
+
+	var ready []int
+	ndeps := make([]int, len(tsks))
+	for i, deps := range s.deps {
+		ndeps[i] = len(deps)
+		if ndeps[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	nleft := len(tsks)
+	release := func(i int) {
+		for _, j := range s.users[i] {
+			ndeps[j]--
+			if ndeps[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	// schedule launches the ready tasks, or skips them.
+	schedule := func() {
+		for len(ready) > 0 {
+			i := ready[0]
+			ready = ready[1:]
+			if !s.skip(i, alive, skipped) {
+				launch(i)
+				continue
+			}
+			skipped[i] = true
+			reject(i)
+			nleft--
+			release(i)
+		}
+	}
+
+	schedule()
+	for nleft > 0 {
+		select {
+		case res := <-done:
+			if res.err != nil {
+				return res.err
+			}
+			nleft--
+			if !accepted[res.i] {
+				reject(res.i)
+			}
+			release(res.i)
+			schedule()
+		case <-evtctx.Done():
+			return evtctx.Err()
+		}
+	}
+
+	if s.mon != nil {
+		s.mon.MonEvent(EventStat{
+			Event: ievt,
+			Slot:  slot,
+			Start: start,
+			Wall:  time.Since(start),
+		})
+	}
+	return nil
+}
+
+// skip returns whether the task i should be skipped: some of its inputs
+// were not produced, or the event was rejected by all its filter paths
+// or by all the paths selecting its events.
+func (s *scheduler) skip(i int, alive, skipped []bool) bool {
+	for _, j := range s.data[i] {
+		if skipped[j] {
+			return true
+		}
+	}
+
+	accepting := func(paths []int) bool {
+		for _, ip := range paths {
+			if alive[ip] {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(s.paths[i]) > 0 && !accepting(s.paths[i]) {
+		return true
+	}
+	if len(s.sel[i]) > 0 && !accepting(s.sel[i]) {
+		return true
+	}
+	return false
+}
+
+func (s *scheduler) process(evtctx nctx.Context, i int, tsk Task, ctx context, done chan<- taskResult) {
+	// the events of a serialised task wait for its lock before taking a
+	// token, so they do not hold tokens the other tasks could use.
+	if mu := s.locks[i]; mu != nil {
+		mu.Lock()
+		defer mu.Unlock()
+	}
+
+	select {
+	case s.sema <- struct{}{}:
+	case <-evtctx.Done():
+		done <- taskResult{i: i, err: evtctx.Err()}
+		return
+	}
+	defer func() { <-s.sema }()
+
+	var p probe
+	if s.mon != nil {
+		// measure the CPU time of the thread running the task.
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		p = newProbe()
+	}
+
+	var beg time.Time
+	if s.dflow != nil {
+		beg = time.Now()
+	}
+	err := tsk.Process(ctx)
+	if s.dflow != nil {
+		s.dflow.record(tsk.Name(), time.Since(beg))
+	}
+	if s.mon != nil {
+		stat := p.stat()
+		stat.Event = ctx.id
+		stat.Slot = ctx.slot
+		stat.Task = tsk.Name()
+		s.mon.MonTask(stat)
+	}
+	ctx.msg.flush()
+	done <- taskResult{i: i, err: err}
+}
+
+// taskstore is the view of the event store of a task: the task may only get
+// the ports it declared, as the scheduler only waits for the producers of
+// these ports before running the task.
+type taskstore struct {
+	Store
+	task  string
+	ports []string
+}
+
+func (store taskstore) Get(key string) (interface{}, error) {
+	for _, k := range store.ports {
+		if k == key {
+			return store.Store.Get(key)
+		}
+	}
+	return nil, Errorf("fwk: task [%s] did not declare port [%s] as input", store.task, key)
+}
@@ -0,0 +1,121 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testdata
+
+import (
+	"encoding/gob"
+	"io"
+	"reflect"
+	"sync"
+
+	"go-hep.org/x/hep/fwk"
+)
+
+// Sum accumulates the values seen by an accum task.
+type Sum struct {
+	mu  sync.Mutex
+	N   int64 // number of values
+	Sum int64 // sum of the values
+}
+
+func (sum *Sum) add(n, v int64) {
+	sum.mu.Lock()
+	sum.N += n
+	sum.Sum += v
+	sum.mu.Unlock()
+}
+
+// accum sums its input values into its Sum property.
+// accum fails when its input value is Fail.
+//
+// accum saves its sum in the checkpoints of the application.
+type accum struct {
+	fwk.TaskBase
+
+	input string
+	fail  int64
+	sum   *Sum
+}
+
+func (tsk *accum) Configure(ctx fwk.Context) error {
+	var err error
+
+	err = tsk.DeclInPort(tsk.input, reflect.TypeOf(int64(1)))
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+func (tsk *accum) StartTask(ctx fwk.Context) error {
+	return nil
+}
+
+func (tsk *accum) StopTask(ctx fwk.Context) error {
+	return nil
+}
+
+func (tsk *accum) Process(ctx fwk.Context) error {
+	v, err := ctx.Store().Get(tsk.input)
+	if err != nil {
+		return err
+	}
+	i := v.(int64)
+	if i == tsk.fail {
+		return fwk.Errorf("%s: failing on value %d", tsk.Name(), i)
+	}
+	tsk.sum.add(1, i)
+	return nil
+}
+
+func (tsk *accum) SaveState(w io.Writer) error {
+	tsk.sum.mu.Lock()
+	defer tsk.sum.mu.Unlock()
+	return gob.NewEncoder(w).Encode([2]int64{tsk.sum.N, tsk.sum.Sum})
+}
+
+func (tsk *accum) LoadState(r io.Reader) error {
+	var state [2]int64
+	err := gob.NewDecoder(r).Decode(&state)
+	if err != nil {
+		return err
+	}
+	tsk.sum.add(state[0], state[1])
+	return nil
+}
+
+func init() {
+	fwk.Register(reflect.TypeOf(accum{}),
+		func(typ, name string, mgr fwk.App) (fwk.Component, error) {
+			var err error
+			tsk := &accum{
+				TaskBase: fwk.NewTask(typ, name, mgr),
+				input:    "ints1",
+				fail:     -1,
+				sum:      new(Sum),
+			}
+
+			err = tsk.DeclProp("Input", &tsk.input)
+			if err != nil {
+				return nil, err
+			}
+
+			err = tsk.DeclProp("Fail", &tsk.fail)
+			if err != nil {
+				return nil, err
+			}
+
+			err = tsk.DeclProp("Sum", &tsk.sum)
+			if err != nil {
+				return nil, err
+			}
+
+			return tsk, err
+		},
+	)
+}
+
+var _ fwk.Checkpointer = (*accum)(nil)
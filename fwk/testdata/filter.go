@@ -0,0 +1,72 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testdata
+
+import (
+	"reflect"
+
+	"go-hep.org/x/hep/fwk"
+)
+
+// filter accepts the events whose input value is a multiple of Mod.
+type filter struct {
+	fwk.TaskBase
+
+	input string
+	mod   int64
+}
+
+func (tsk *filter) Configure(ctx fwk.Context) error {
+	var err error
+
+	err = tsk.DeclInPort(tsk.input, reflect.TypeOf(int64(1)))
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+func (tsk *filter) StartTask(ctx fwk.Context) error {
+	return nil
+}
+
+func (tsk *filter) StopTask(ctx fwk.Context) error {
+	return nil
+}
+
+func (tsk *filter) Process(ctx fwk.Context) error {
+	v, err := ctx.Store().Get(tsk.input)
+	if err != nil {
+		return err
+	}
+	fwk.SetAccepted(ctx, v.(int64)%tsk.mod == 0)
+	return nil
+}
+
+func init() {
+	fwk.Register(reflect.TypeOf(filter{}),
+		func(typ, name string, mgr fwk.App) (fwk.Component, error) {
+			var err error
+			tsk := &filter{
+				TaskBase: fwk.NewTask(typ, name, mgr),
+				input:    "ints1",
+				mod:      1,
+			}
+
+			err = tsk.DeclProp("Input", &tsk.input)
+			if err != nil {
+				return nil, err
+			}
+
+			err = tsk.DeclProp("Mod", &tsk.mod)
+			if err != nil {
+				return nil, err
+			}
+
+			return tsk, err
+		},
+	)
+}
@@ -44,3 +44,26 @@ func (stream *InputStream) Disconnect() error {
 	var err error
 	return err
 }
+
+// SeekInputStream is an InputStream which can skip input events.
+type SeekInputStream struct {
+	InputStream
+	Skipped int64 // number of input events skipped by SeekEvent
+}
+
+func (stream *SeekInputStream) SeekEvent(n int64) error {
+	for i := int64(0); i < n; i++ {
+		var data int64
+		_, err := fmt.Fscanf(stream.R, "%d\n", &data)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	stream.Skipped = n
+	return nil
+}
+
+var _ fwk.InputSeeker = (*SeekInputStream)(nil)
@@ -0,0 +1,221 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testdata
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"go-hep.org/x/hep/fwk"
+)
+
+// RunLumiStream reads (run, lumi-block) pairs of numbers and publishes
+// them as a fwk.EventID on its first port.
+type RunLumiStream struct {
+	output string
+	R      io.Reader
+	id     fwk.EventID
+}
+
+func (stream *RunLumiStream) Connect(ports []fwk.Port) error {
+	var err error
+	stream.output = ports[0].Name
+
+	return err
+}
+
+func (stream *RunLumiStream) Read(ctx fwk.Context) error {
+	var err error
+	store := ctx.Store()
+	var id fwk.EventID
+	_, err = fmt.Fscanf(stream.R, "%d %d\n", &id.Run, &id.Lumi)
+	if err != nil {
+		return err
+	}
+	id.Event = ctx.ID()
+	stream.id = id
+
+	err = store.Put(stream.output, id)
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+func (stream *RunLumiStream) EventID() fwk.EventID {
+	return stream.id
+}
+
+func (stream *RunLumiStream) Disconnect() error {
+	var err error
+	return err
+}
+
+// RunLumiLog records the run and luminosity-block transitions.
+type RunLumiLog struct {
+	mu  sync.Mutex
+	Log []string
+}
+
+func (log *RunLumiLog) add(format string, args ...interface{}) {
+	log.mu.Lock()
+	log.Log = append(log.Log, fmt.Sprintf(format, args...))
+	log.mu.Unlock()
+}
+
+// runlumitask checks the events it processes belong to the currently
+// open run and luminosity block.
+type runlumitask struct {
+	fwk.TaskBase
+
+	input string
+	log   *RunLumiLog
+
+	mu   sync.Mutex
+	open bool
+	cur  fwk.EventID
+	n    int
+}
+
+func (tsk *runlumitask) Configure(ctx fwk.Context) error {
+	if tsk.input == "" {
+		return nil
+	}
+	return tsk.DeclInPort(tsk.input, reflect.TypeOf(fwk.EventID{}))
+}
+
+func (tsk *runlumitask) StartTask(ctx fwk.Context) error {
+	return nil
+}
+
+func (tsk *runlumitask) StopTask(ctx fwk.Context) error {
+	return nil
+}
+
+func (tsk *runlumitask) Process(ctx fwk.Context) error {
+	tsk.mu.Lock()
+	defer tsk.mu.Unlock()
+	if !tsk.open {
+		return fmt.Errorf("event %d processed outside of a luminosity block", ctx.ID())
+	}
+	tsk.n++
+
+	if tsk.input == "" {
+		return nil
+	}
+	v, err := ctx.Store().Get(tsk.input)
+	if err != nil {
+		return err
+	}
+	id := v.(fwk.EventID)
+	if id.Run != tsk.cur.Run || id.Lumi != tsk.cur.Lumi {
+		return fmt.Errorf("event %d (run=%d lumi=%d) processed during run=%d lumi=%d",
+			ctx.ID(), id.Run, id.Lumi, tsk.cur.Run, tsk.cur.Lumi,
+		)
+	}
+	return nil
+}
+
+func (tsk *runlumitask) BeginRun(ctx fwk.Context, run int64) error {
+	tsk.log.add("%s: begin-run %d", tsk.Name(), run)
+	return nil
+}
+
+func (tsk *runlumitask) EndRun(ctx fwk.Context, run int64) error {
+	tsk.log.add("%s: end-run %d", tsk.Name(), run)
+	return nil
+}
+
+func (tsk *runlumitask) BeginLumiBlock(ctx fwk.Context, run, lumi int64) error {
+	tsk.mu.Lock()
+	defer tsk.mu.Unlock()
+	tsk.open = true
+	tsk.cur = fwk.EventID{Run: run, Lumi: lumi}
+	tsk.n = 0
+	tsk.log.add("%s: begin-lumi %d/%d", tsk.Name(), run, lumi)
+	return nil
+}
+
+func (tsk *runlumitask) EndLumiBlock(ctx fwk.Context, run, lumi int64) error {
+	tsk.mu.Lock()
+	defer tsk.mu.Unlock()
+	tsk.open = false
+	tsk.log.add("%s: end-lumi %d/%d n=%d", tsk.Name(), run, lumi, tsk.n)
+	return nil
+}
+
+// runsvc records the run transitions.
+type runsvc struct {
+	fwk.SvcBase
+
+	log *RunLumiLog
+}
+
+func (svc *runsvc) StartSvc(ctx fwk.Context) error {
+	return nil
+}
+
+func (svc *runsvc) StopSvc(ctx fwk.Context) error {
+	return nil
+}
+
+func (svc *runsvc) BeginRun(ctx fwk.Context, run int64) error {
+	svc.log.add("%s: begin-run %d", svc.Name(), run)
+	return nil
+}
+
+func (svc *runsvc) EndRun(ctx fwk.Context, run int64) error {
+	svc.log.add("%s: end-run %d", svc.Name(), run)
+	return nil
+}
+
+func init() {
+	fwk.Register(reflect.TypeOf(runlumitask{}),
+		func(typ, name string, mgr fwk.App) (fwk.Component, error) {
+			var err error
+			tsk := &runlumitask{
+				TaskBase: fwk.NewTask(typ, name, mgr),
+			}
+
+			err = tsk.DeclProp("Input", &tsk.input)
+			if err != nil {
+				return nil, err
+			}
+
+			err = tsk.DeclProp("Log", &tsk.log)
+			if err != nil {
+				return nil, err
+			}
+
+			return tsk, err
+		},
+	)
+
+	fwk.Register(reflect.TypeOf(runsvc{}),
+		func(typ, name string, mgr fwk.App) (fwk.Component, error) {
+			var err error
+			svc := &runsvc{
+				SvcBase: fwk.NewSvc(typ, name, mgr),
+			}
+
+			err = svc.DeclProp("Log", &svc.log)
+			if err != nil {
+				return nil, err
+			}
+
+			return svc, err
+		},
+	)
+}
+
+var (
+	_ fwk.EventIDer        = (*RunLumiStream)(nil)
+	_ fwk.RunHandler       = (*runlumitask)(nil)
+	_ fwk.LumiBlockHandler = (*runlumitask)(nil)
+	_ fwk.RunHandler       = (*runsvc)(nil)
+)
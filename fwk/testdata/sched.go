@@ -0,0 +1,204 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testdata
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"go-hep.org/x/hep/fwk"
+)
+
+// Tracker records the number of tasks running concurrently.
+type Tracker struct {
+	mu   sync.Mutex
+	n    int
+	cur  map[string]int
+	Max  int            // maximum number of tasks running concurrently
+	Task map[string]int // maximum number of concurrent calls, per task instance
+}
+
+func (t *Tracker) begin(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cur == nil {
+		t.cur = make(map[string]int)
+		t.Task = make(map[string]int)
+	}
+	t.n++
+	if t.n > t.Max {
+		t.Max = t.n
+	}
+	t.cur[name]++
+	if t.cur[name] > t.Task[name] {
+		t.Task[name] = t.cur[name]
+	}
+}
+
+func (t *Tracker) end(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.n--
+	t.cur[name]--
+}
+
+// schedtask copies its (optional) input to its output, while recording
+// its concurrency.
+// schedtask also gets its (optional) undeclared port, which it did not
+// declare as input.
+type schedtask struct {
+	fwk.TaskBase
+
+	input      string
+	output     string
+	undeclared string
+	tracker    *Tracker
+	reentrant  bool
+	slot       int
+}
+
+func (tsk *schedtask) Configure(ctx fwk.Context) error {
+	var err error
+
+	if tsk.input != "" {
+		err = tsk.DeclInPort(tsk.input, reflect.TypeOf(int64(1)))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tsk.DeclOutPort(tsk.output, reflect.TypeOf(int64(1)))
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+func (tsk *schedtask) StartTask(ctx fwk.Context) error {
+	return nil
+}
+
+func (tsk *schedtask) StopTask(ctx fwk.Context) error {
+	return nil
+}
+
+func (tsk *schedtask) Process(ctx fwk.Context) error {
+	name := fmt.Sprintf("%s/%d", tsk.Name(), tsk.slot)
+	tsk.tracker.begin(name)
+	defer tsk.tracker.end(name)
+
+	store := ctx.Store()
+	v := ctx.ID()
+	if tsk.input != "" {
+		vv, err := store.Get(tsk.input)
+		if err != nil {
+			return err
+		}
+		v = vv.(int64)
+	}
+	if tsk.undeclared != "" {
+		_, err := store.Get(tsk.undeclared)
+		if err != nil {
+			return err
+		}
+	}
+
+	time.Sleep(100 * time.Microsecond)
+	return store.Put(tsk.output, v)
+}
+
+func (tsk *schedtask) Reentrant() bool {
+	return tsk.reentrant
+}
+
+// clonetask is a schedtask which can be cloned for each event slot.
+type clonetask struct {
+	schedtask
+}
+
+func (tsk *clonetask) CloneTask(slot int) (fwk.Task, error) {
+	clone := *tsk
+	clone.slot = slot
+	return &clone, nil
+}
+
+func (tsk *schedtask) declProps() error {
+	var err error
+
+	err = tsk.DeclProp("Input", &tsk.input)
+	if err != nil {
+		return err
+	}
+
+	err = tsk.DeclProp("Output", &tsk.output)
+	if err != nil {
+		return err
+	}
+
+	err = tsk.DeclProp("Undeclared", &tsk.undeclared)
+	if err != nil {
+		return err
+	}
+
+	err = tsk.DeclProp("Tracker", &tsk.tracker)
+	if err != nil {
+		return err
+	}
+
+	err = tsk.DeclProp("Reentrant", &tsk.reentrant)
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+func init() {
+	fwk.Register(reflect.TypeOf(schedtask{}),
+		func(typ, name string, mgr fwk.App) (fwk.Component, error) {
+			var err error
+			tsk := &schedtask{
+				TaskBase:  fwk.NewTask(typ, name, mgr),
+				output:    name,
+				reentrant: true,
+			}
+
+			err = tsk.declProps()
+			if err != nil {
+				return nil, err
+			}
+
+			return tsk, err
+		},
+	)
+
+	fwk.Register(reflect.TypeOf(clonetask{}),
+		func(typ, name string, mgr fwk.App) (fwk.Component, error) {
+			var err error
+			tsk := &clonetask{
+				schedtask: schedtask{
+					TaskBase:  fwk.NewTask(typ, name, mgr),
+					output:    name,
+					reentrant: false,
+				},
+			}
+
+			err = tsk.declProps()
+			if err != nil {
+				return nil, err
+			}
+
+			return tsk, err
+		},
+	)
+}
+
+var (
+	_ fwk.Reentrant  = (*schedtask)(nil)
+	_ fwk.TaskCloner = (*clonetask)(nil)
+)
@@ -2,7 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// package builder builds a fwk-app binary from a list of go files.
+// package builder builds a fwk-app binary from a list of go files and of
+// TOML job descriptions (see go-hep.org/x/hep/fwk/job.TOMLDecoder).
 //
 // builder's architecture and sources are heavily inspired from golint:
 //   https://github.com/golang/lint
@@ -10,6 +11,7 @@
 package builder // import "go-hep.org/x/hep/fwk/utils/builder"
 
 import (
+	"bytes"
 	"go/ast"
 	"go/parser"
 	"go/token"
@@ -19,8 +21,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/fwk/job"
 )
 
 type file struct {
@@ -31,10 +36,18 @@ type file struct {
 	name string
 }
 
+// jobfile is a TOML job description.
+type jobfile struct {
+	Name string
+	Src  []byte
+}
+
 // Builder generates and builds fwk-based applications.
 type Builder struct {
 	fset  *token.FileSet
 	files map[string]*file
+	jobs  []jobfile
+	pkgs  map[string]struct{} // packages of the components of the job descriptions
 
 	pkg  *types.Package
 	info *types.Info
@@ -52,6 +65,7 @@ func NewBuilder(fnames ...string) (*Builder, error) {
 	b := &Builder{
 		fset:  token.NewFileSet(),
 		files: make(map[string]*file, len(fnames)),
+		pkgs:  make(map[string]struct{}),
 		funcs: make([]string, 0),
 		Usage: `Usage: %[1]s [options] <input> <output>
 
@@ -68,6 +82,13 @@ options:
 			return nil, fwk.Error(err)
 		}
 		fm := fi.Mode()
+		if fm.IsRegular() && filepath.Ext(fname) == ".toml" {
+			err = b.addJob(fname)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
 		if fm.IsRegular() {
 			src, err := ioutil.ReadFile(fname)
 			if err != nil {
@@ -104,23 +125,25 @@ func (b *Builder) Build() error {
 		b.Name = filepath.Base(pwd)
 	}
 
-	err = b.doTypeCheck()
-	if err != nil {
-		return err
-	}
+	if len(b.files) > 0 {
+		err = b.doTypeCheck()
+		if err != nil {
+			return err
+		}
 
-	// check we build a 'main' package
-	if !b.isMain() {
-		return fwk.Errorf("not a 'main' package")
-	}
+		// check we build a 'main' package
+		if !b.isMain() {
+			return fwk.Errorf("not a 'main' package")
+		}
 
-	err = b.scanSetupFuncs()
-	if err != nil {
-		return err
+		err = b.scanSetupFuncs()
+		if err != nil {
+			return err
+		}
 	}
 
-	if len(b.funcs) <= 0 {
-		return fwk.Errorf("no setup function found")
+	if len(b.funcs) <= 0 && len(b.jobs) <= 0 {
+		return fwk.Errorf("no setup function nor job description found")
 	}
 
 	err = b.genSources()
@@ -157,6 +180,42 @@ func (b *Builder) doTypeCheck() error {
 	return err
 }
 
+// addJob adds the TOML job description fname and collects the packages
+// of its components.
+// The description is validated when the application starts, once these
+// packages are linked in.
+func (b *Builder) addJob(fname string) error {
+	src, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return fwk.Error(err)
+	}
+
+	dec := job.NewTOMLDecoder(bytes.NewReader(src))
+	dec.Name = fname
+	dec.Raw = true
+	stmts, err := job.Load(dec)
+	if err != nil {
+		return fwk.Error(err)
+	}
+
+	for _, stmt := range stmts {
+		i := strings.LastIndex(stmt.Data.Type, ".")
+		if i <= 0 {
+			return fwk.Errorf("%s: invalid component type %q", fname, stmt.Data.Type)
+		}
+		switch pkg := stmt.Data.Type[:i]; pkg {
+		case "main", "go-hep.org/x/hep/fwk":
+			// components of the main package come from the go files,
+			// fwk is always imported.
+		default:
+			b.pkgs[pkg] = struct{}{}
+		}
+	}
+
+	b.jobs = append(b.jobs, jobfile{Name: fname, Src: src})
+	return nil
+}
+
 func (b *Builder) typeOf(expr ast.Expr) types.Type {
 	if b.info == nil {
 		return nil
@@ -280,14 +339,24 @@ func (b *Builder) genSources() error {
 	}
 	defer f.Close()
 
+	imports := make([]string, 0, len(b.pkgs))
+	for pkg := range b.pkgs {
+		imports = append(imports, pkg)
+	}
+	sort.Strings(imports)
+
 	data := struct {
 		Usage      string
 		Name       string
 		SetupFuncs []string
+		Imports    []string
+		Jobs       []jobfile
 	}{
 		Usage:      b.Usage,
 		Name:       b.Name,
 		SetupFuncs: b.funcs,
+		Imports:    imports,
+		Jobs:       b.jobs,
 	}
 
 	err = render(f, tmpl, data)
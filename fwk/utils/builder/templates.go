@@ -6,6 +6,7 @@ package builder
 
 import (
 	"io"
+	"strconv"
 	"strings"
 	"text/template"
 )
@@ -20,9 +21,14 @@ import (
 	"fmt"
 	"os"
 	"runtime/pprof"
+	"strings"
 
-	_ "go-hep.org/x/hep/fwk"
+	"go-hep.org/x/hep/fwk"
 	"go-hep.org/x/hep/fwk/job"
+	"go-hep.org/x/hep/fwk/utils/errstack"
+{{- range .Imports}}
+	_ "{{.}}"
+{{- end}}
 )
 
 var (
@@ -30,6 +36,30 @@ var (
 	g_evtmax   = flag.Int("evtmax", -1, "number of events to process")
 	g_nprocs   = flag.Int("nprocs", 0, "number of concurrent events to process")
 	g_cpu_prof = flag.Bool("cpu-prof", false, "enable CPU profiling")
+	g_ckpt     = flag.String("checkpoint", "", "file where to save checkpoints")
+	g_ckpt_n   = flag.Int("checkpoint-every", 1000, "number of events between checkpoints")
+	g_resume   = flag.Bool("resume", false, "resume from the checkpoint file")
+	g_workers  = flag.Int("workers", 0, "number of local worker processes")
+	g_hosts    = flag.String("hosts", "", "comma-separated list of worker agents (host:port)")
+	g_log_file = flag.String("log-file", "", "file where to write all messages (the console only shows warnings, errors and a summary)")
+	g_log_fmt  = flag.String("log-format", "text", "format of the messages (text|json)")
+	g_dump     = flag.String("dump", "", "file where to write the TOML description of the job, instead of running it")
+	g_graph    = flag.String("graph", "", "file where to write the data-flow graph of the job (DOT), instead of running it")
+	g_dflow    = flag.String("dflow-http", "", "address where to serve a live view of the data-flow (e.g. 127.0.0.1:8080; the view is not authenticated: use a loopback address)")
+
+	// g_props associates command-line flags with the properties of the application.
+	g_props = map[string]string{
+		"l":                "MsgLevel",
+		"evtmax":           "EvtMax",
+		"nprocs":           "NProcs",
+		"checkpoint":       "Checkpoint",
+		"checkpoint-every": "CheckpointEvery",
+		"resume":           "Resume",
+		"workers":          "Workers",
+		"hosts":            "Hosts",
+		"log-file":         "MsgFile",
+		"log-format":       "MsgFormat",
+	}
 )
 
 func main() {
@@ -51,13 +81,105 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	app := job.New(job.P{
-		"EvtMax":   int64(*g_evtmax),
-		"NProcs":   *g_nprocs,
-		"MsgLevel": job.MsgLevel(*g_lvl),
-	})
+	var hosts []string
+	if *g_hosts != "" {
+		hosts = strings.Split(*g_hosts, ",")
+	}
+
+	props := job.P{
+		"EvtMax":          int64(*g_evtmax),
+		"NProcs":          *g_nprocs,
+		"MsgLevel":        job.MsgLevel(*g_lvl),
+		"Checkpoint":      *g_ckpt,
+		"CheckpointEvery": int64(*g_ckpt_n),
+		"Resume":          *g_resume,
+		"Workers":         *g_workers,
+		"Hosts":           hosts,
+		"MsgFile":         *g_log_file,
+		"MsgFormat":       *g_log_fmt,
+	}
+	app := job.New(props)
 
     {{with .SetupFuncs}}{{. | gen_setups}}{{end}}
+{{with .Jobs}}
+	for _, desc := range []struct {
+		name string
+		src  string
+	}{
+{{. | gen_jobs}}
+	} {
+		dec := job.NewTOMLDecoder(strings.NewReader(desc.src))
+		dec.Name = desc.name
+		var stmts []job.Stmt
+		err := dec.Decode(&stmts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "::: {{$.Name}}: invalid job description: %v\n", err)
+			os.Exit(1)
+		}
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					fmt.Fprintf(os.Stderr, "::: {{$.Name}}: invalid job description: %s: %v\n", desc.name, err)
+					os.Exit(1)
+				}
+			}()
+			app.Exec(stmts)
+		}()
+	}
+
+	// flags given on the command-line take precedence over job descriptions.
+	flag.Visit(func(f *flag.Flag) {
+		if prop, ok := g_props[f.Name]; ok {
+			app.SetProp(app.App(), prop, props[prop])
+		}
+	})
+{{end}}
+	if *g_dump != "" {
+		f, err := os.Create(*g_dump)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		err = job.Save(app.Stmts(), job.NewTOMLEncoder(f))
+		if err != nil {
+			panic(err)
+		}
+		err = f.Close()
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("::: {{.Name}}... [job description written to %s]\n", *g_dump)
+		return
+	}
+
+	if *g_graph != "" {
+		f, err := os.Create(*g_graph)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		errg := fwk.DumpDataFlow(f, app.App())
+		err = f.Close()
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("::: {{.Name}}... [data-flow graph written to %s]\n", *g_graph)
+		if errg != nil {
+			if e, ok := errg.(*errstack.Error); ok {
+				errg = e.Err // the inconsistencies, without the stack trace.
+			}
+			fmt.Fprintf(os.Stderr, "::: {{.Name}}: %v\n", errg)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *g_dflow != "" {
+		err := app.App().SetProp(app.App().GetSvc("dataflow"), "HTTP", *g_dflow)
+		if err != nil {
+			panic(err)
+		}
+	}
 
 	app.Run()
 	fmt.Printf("::: {{.Name}}... [done]\n")
@@ -71,6 +193,7 @@ func render(w io.Writer, text string, data interface{}) error {
 		"trim":       strings.TrimSpace,
 		"gen_setups": gen_setups,
 		"gen_usage":  gen_usage,
+		"gen_jobs":   gen_jobs,
 	})
 	template.Must(t.Parse(text))
 	return t.Execute(w, data)
@@ -89,3 +212,13 @@ func gen_setups(setups []string) string {
 func gen_usage(usage string) string {
 	return "`" + usage + "`"
 }
+
+func gen_jobs(jobs []jobfile) string {
+	str := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		str = append(str,
+			"\t\t{"+strconv.Quote(job.Name)+", "+strconv.Quote(string(job.Src))+"},",
+		)
+	}
+	return strings.Join(str, "\n")
+}
@@ -0,0 +1,83 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dist runs the worker processes of a distributed job, either as
+// local processes or on remote agents reachable over a socket.
+//
+// fwk applications whose 'Workers' (resp. 'Hosts') property is set
+// partition the 'EvtMax' input events in ranges, each processed by the
+// same job run as a local worker process (resp. on a remote agent started
+// with 'fwk-app serve'.)
+// Once all workers completed, the coordinator merges the states of their
+// fwk.Checkpointer components and the outputs of the output streamers
+// implementing fwk.OutputMerger.
+//
+// A worker runs in its own directory, under the 'WorkDir' of the
+// application, where it writes its outputs: input files should be given as
+// absolute paths.
+// Workers run by a remote agent run in a temporary directory on the agent's
+// host, whose files are sent back, one at a time, to the directory of the
+// worker.
+//
+// Agents only run workers for the coordinators knowing their secret, set in
+// the environment variable FWK_DIST_SECRET of both.
+package dist // import "go-hep.org/x/hep/fwk/utils/dist"
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// LogFile is the name of the file where the standard output and error of
+// a worker are written, in the directory of the worker.
+const LogFile = "worker.log"
+
+// Job describes a worker process.
+type Job struct {
+	Exe  string   // path to the executable of the worker
+	Args []string // command-line arguments of the worker
+	Env  []string // additional environment variables, of the form "key=value"
+	Dir  string   // directory of the worker
+}
+
+// Runner runs worker processes.
+type Runner interface {
+	// Run runs the worker job and waits for its completion.
+	Run(job Job) error
+}
+
+// Local runs workers as processes on the local host.
+type Local struct{}
+
+// Run runs the worker job as a local process, in the directory of the job.
+func (Local) Run(job Job) error {
+	err := os.MkdirAll(job.Dir, 0755)
+	if err != nil {
+		return err
+	}
+	return run(job.Exe, job.Args, job.Env, job.Dir)
+}
+
+// run runs exe in dir, redirecting its outputs to the log file of dir.
+func run(exe string, args, env []string, dir string) error {
+	log, err := os.Create(filepath.Join(dir, LogFile))
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+
+	cmd := exec.Command(exe, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = log
+	cmd.Stderr = log
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("dist: worker in [%s] failed: %v (see %s)", dir, err, LogFile)
+	}
+
+	return log.Close()
+}
@@ -0,0 +1,138 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dist
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestHelperProcess is the worker run by the tests.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("DIST_HELPER") == "" {
+		t.Skip("not a worker")
+	}
+	if os.Getenv("DIST_FAIL") != "" {
+		os.Exit(1)
+	}
+	err := os.MkdirAll("sub", 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join("sub", "out.txt"), []byte(os.Getenv("DIST_HELPER")), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testRunner(t *testing.T, r Runner) {
+	dir, err := ioutil.TempDir("", "fwk-dist-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	exe, err := filepath.Abs(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		job := Job{
+			Exe:  exe,
+			Args: []string{"-test.run=^TestHelperProcess$"},
+			Env:  []string{fmt.Sprintf("DIST_HELPER=worker-%d", i)},
+			Dir:  filepath.Join(dir, fmt.Sprintf("worker-%d", i)),
+		}
+		err = r.Run(job)
+		if err != nil {
+			t.Fatalf("worker-%d: %v", i, err)
+		}
+
+		out, err := ioutil.ReadFile(filepath.Join(job.Dir, "sub", "out.txt"))
+		if err != nil {
+			t.Fatalf("worker-%d: %v", i, err)
+		}
+		if got, want := string(out), fmt.Sprintf("worker-%d", i); got != want {
+			t.Fatalf("worker-%d: got %q. want %q", i, got, want)
+		}
+		_, err = os.Stat(filepath.Join(job.Dir, LogFile))
+		if err != nil {
+			t.Fatalf("worker-%d: %v", i, err)
+		}
+	}
+
+	err = r.Run(Job{
+		Exe:  exe,
+		Args: []string{"-test.run=^TestHelperProcess$"},
+		Env:  []string{"DIST_HELPER=1", "DIST_FAIL=1"},
+		Dir:  filepath.Join(dir, "worker-fail"),
+	})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestLocal(t *testing.T) {
+	testRunner(t, Local{})
+}
+
+func TestRemote(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go Serve(l, "s3cr3t")
+
+	testRunner(t, Remote{Addr: l.Addr().String(), Secret: "s3cr3t"})
+
+	dir, err := ioutil.TempDir("", "fwk-dist-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	exe, err := filepath.Abs(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"", "secret"} {
+		err = Remote{Addr: l.Addr().String(), Secret: secret}.Run(Job{
+			Exe:  exe,
+			Args: []string{"-test.run=^TestHelperProcess$"},
+			Env:  []string{"DIST_HELPER=1"},
+			Dir:  dir,
+		})
+		if err == nil || err.Error() != errSecret.Error() {
+			t.Fatalf("secret=%q: got error %v. want %v", secret, err, errSecret)
+		}
+	}
+}
+
+func TestAgent(t *testing.T) {
+	_, err := NewAgent("")
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	agent, err := NewAgent("s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent.workers["w"] = &worker{dir: os.TempDir(), files: map[string]bool{"out.txt": true}}
+
+	for _, name := range []string{"../out.txt", "worker.exe", "work/out.txt"} {
+		var data []byte
+		err = agent.File(FileRequest{Secret: "s3cr3t", ID: "w", Name: name}, &data)
+		if err == nil {
+			t.Errorf("file %q: expected an error", name)
+		}
+	}
+}
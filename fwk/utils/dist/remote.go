@@ -0,0 +1,275 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dist
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SecretEnv is the environment variable holding the secret shared by an
+// agent and the coordinators allowed to run workers on it.
+const SecretEnv = "FWK_DIST_SECRET"
+
+var errSecret = errors.New("dist: invalid secret")
+
+// Remote runs workers on the agent listening at Addr.
+//
+// The executable of the worker is sent to the agent, which must run on a
+// host with the same operating system and architecture.
+type Remote struct {
+	Addr   string // address of the agent (e.g. "host:7070")
+	Secret string // secret shared with the agent
+}
+
+// Run sends the worker job to the remote agent, waits for its completion
+// and writes the files produced by the worker in the directory of the job.
+// The files are transferred one at a time.
+func (r Remote) Run(job Job) error {
+	exe, err := ioutil.ReadFile(job.Exe)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(job.Dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	client, err := rpc.Dial("tcp", r.Addr)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	req := Request{
+		Secret: r.Secret,
+		Exe:    exe,
+		Args:   job.Args,
+		Env:    job.Env,
+	}
+	var reply Reply
+	err = client.Call("Agent.Run", req, &reply)
+	if err != nil {
+		return err
+	}
+	defer client.Call("Agent.Release", FileRequest{Secret: r.Secret, ID: reply.ID}, new(bool))
+
+	for _, name := range reply.Files {
+		var data []byte
+		err = client.Call("Agent.File", FileRequest{Secret: r.Secret, ID: reply.ID, Name: name}, &data)
+		if err != nil {
+			return err
+		}
+
+		fname := filepath.Join(job.Dir, filepath.FromSlash(name))
+		err = os.MkdirAll(filepath.Dir(fname), 0755)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(fname, data, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	if reply.Err != "" {
+		return errors.New(reply.Err)
+	}
+	return nil
+}
+
+// Request is a request to run a worker, sent to an Agent.
+type Request struct {
+	Secret string   // secret shared with the agent
+	Exe    []byte   // executable of the worker
+	Args   []string // command-line arguments of the worker
+	Env    []string // additional environment variables
+}
+
+// Reply is the reply of an Agent to a Request.
+type Reply struct {
+	ID    string   // identifier of the worker, to retrieve its files
+	Files []string // slash-separated paths of the files produced by the worker
+	Err   string   // error of the worker, if any
+}
+
+// FileRequest is a request for a file produced by a worker, sent to an Agent.
+type FileRequest struct {
+	Secret string // secret shared with the agent
+	ID     string // identifier of the worker
+	Name   string // slash-separated path of the file
+}
+
+// Agent runs the workers requested by remote coordinators.
+//
+// Agent runs any executable sent by a coordinator knowing its secret:
+// the secret must only be shared with trusted users.
+// Requests and files are not encrypted.
+type Agent struct {
+	secret string
+
+	mu      sync.Mutex
+	workers map[string]*worker // workers whose files were not released yet
+}
+
+// worker is a worker run by an Agent.
+type worker struct {
+	dir   string          // temporary directory of the worker
+	files map[string]bool // files produced by the worker
+}
+
+// NewAgent returns an agent serving the coordinators sharing secret.
+func NewAgent(secret string) (*Agent, error) {
+	if secret == "" {
+		return nil, errors.New("dist: agent needs a secret")
+	}
+	return &Agent{
+		secret:  secret,
+		workers: make(map[string]*worker),
+	}, nil
+}
+
+func (agent *Agent) auth(secret string) error {
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(agent.secret)) != 1 {
+		return errSecret
+	}
+	return nil
+}
+
+// Run runs the requested worker in a temporary directory and replies with
+// the list of files it produced.
+// The temporary directory is kept until the files are released.
+func (agent *Agent) Run(req Request, reply *Reply) error {
+	err := agent.auth(req.Secret)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempDir("", "fwk-dist-")
+	if err != nil {
+		return err
+	}
+
+	w := &worker{
+		dir:   tmp,
+		files: make(map[string]bool),
+	}
+	err = w.run(req, reply)
+	if err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	reply.ID = filepath.Base(tmp)
+	agent.mu.Lock()
+	agent.workers[reply.ID] = w
+	agent.mu.Unlock()
+	return nil
+}
+
+func (w *worker) run(req Request, reply *Reply) error {
+	exe := filepath.Join(w.dir, "worker.exe")
+	err := ioutil.WriteFile(exe, req.Exe, 0755)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(w.dir, "work")
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	err = run(exe, req.Args, req.Env, dir)
+	if err != nil {
+		reply.Err = err.Error()
+	}
+
+	return filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+		w.files[name] = true
+		reply.Files = append(reply.Files, name)
+		return nil
+	})
+}
+
+// File replies with the content of a file produced by a worker.
+func (agent *Agent) File(req FileRequest, data *[]byte) error {
+	err := agent.auth(req.Secret)
+	if err != nil {
+		return err
+	}
+
+	agent.mu.Lock()
+	w, ok := agent.workers[req.ID]
+	agent.mu.Unlock()
+	if !ok {
+		return errors.New("dist: no worker " + req.ID)
+	}
+	if !w.files[req.Name] {
+		return errors.New("dist: no file " + req.Name + " for worker " + req.ID)
+	}
+
+	*data, err = ioutil.ReadFile(filepath.Join(w.dir, "work", filepath.FromSlash(req.Name)))
+	return err
+}
+
+// Release removes the temporary directory of a worker.
+func (agent *Agent) Release(req FileRequest, ok *bool) error {
+	err := agent.auth(req.Secret)
+	if err != nil {
+		return err
+	}
+
+	agent.mu.Lock()
+	w, found := agent.workers[req.ID]
+	delete(agent.workers, req.ID)
+	agent.mu.Unlock()
+	if !found {
+		return errors.New("dist: no worker " + req.ID)
+	}
+
+	*ok = true
+	return os.RemoveAll(w.dir)
+}
+
+// Serve accepts connections on l and runs the workers requested on these
+// connections by the coordinators sharing secret.
+// Serve blocks until l is closed.
+func Serve(l net.Listener, secret string) error {
+	agent, err := NewAgent(secret)
+	if err != nil {
+		return err
+	}
+	srv := rpc.NewServer()
+	err = srv.Register(agent)
+	if err != nil {
+		return err
+	}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go srv.ServeConn(conn)
+	}
+}
@@ -6,15 +6,36 @@ package fwk
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
 
 	nctx "golang.org/x/net/context"
 )
 
 type workercontrol struct {
-	evts   chan context
-	done   chan struct{}
-	errc   chan error
-	runctx nctx.Context
+	evts     chan context
+	done     chan struct{}
+	fed      chan struct{} // closed once the input stream is not used anymore
+	errc     chan error
+	runctx   nctx.Context
+	cancel   nctx.CancelFunc
+	inflight sync.WaitGroup // events sent to the workers and not yet processed
+	failed   int32          // set to 1, atomically, once an event failed
+	sched    *scheduler
+}
+
+// fail records err, if any, and stops the event loop.
+func (ctrl *workercontrol) fail(err error) {
+	atomic.StoreInt32(&ctrl.failed, 1)
+	if err != nil {
+		ctrl.errc <- err
+	}
+	ctrl.cancel()
+}
+
+// stopped reports whether the event loop was stopped.
+func (ctrl *workercontrol) stopped() bool {
+	return atomic.LoadInt32(&ctrl.failed) != 0 || ctrl.runctx.Err() != nil
 }
 
 type worker struct {
@@ -24,39 +45,44 @@ type worker struct {
 	ctxs []context
 	msg  msgstream
 
-	evts   <-chan context
-	done   chan<- struct{}
-	errc   chan<- error
-	runctx nctx.Context
+	evts     <-chan context
+	done     chan<- struct{}
+	fail     func(err error)
+	runctx   nctx.Context
+	inflight *sync.WaitGroup
+	sched    *scheduler
 }
 
 func newWorker(i int, app *appmgr, ctrl *workercontrol) *worker {
 	wrk := &worker{
-		slot:   i,
-		keys:   app.dflow.keys(),
-		ctxs:   make([]context, len(app.tsks)),
-		msg:    newMsgStream(fmt.Sprintf("%s-worker-%03d", app.name, i), app.msg.lvl, nil),
-		evts:   ctrl.evts,
-		done:   ctrl.done,
-		errc:   ctrl.errc,
-		runctx: ctrl.runctx,
+		slot:     i,
+		keys:     app.dflow.keys(),
+		ctxs:     make([]context, len(app.tsks)),
+		msg:      app.msgstream(fmt.Sprintf("%s-worker-%03d", app.name, i)),
+		evts:     ctrl.evts,
+		done:     ctrl.done,
+		fail:     ctrl.fail,
+		runctx:   ctrl.runctx,
+		inflight: &ctrl.inflight,
+		sched:    ctrl.sched,
 	}
 	for j, tsk := range app.tsks {
 		wrk.ctxs[j] = context{
 			id:   -1,
 			slot: i,
-			msg:  newMsgStream(tsk.Name(), app.msg.lvl, nil),
+			msg:  app.msgstream(tsk.Name()),
 			mgr:  nil, // nobody's supposed to access mgr's state during event-loop
 		}
 	}
 
-	go wrk.run(app.tsks)
+	go wrk.run()
 
 	return wrk
 }
 
-func (wrk *worker) run(tsks []Task) {
+func (wrk *worker) run() {
 	defer func() {
+		wrk.drain()
 		wrk.done <- struct{}{}
 	}()
 
@@ -66,53 +92,7 @@ func (wrk *worker) run(tsks []Task) {
 			if !ok {
 				return
 			}
-			wrk.msg.Debugf(">>> running evt=%d...\n", ievt.ID())
-
-			evtstore := ievt.store.(*datastore)
-			evtctx, evtCancel := nctx.WithCancel(wrk.runctx)
-			evt := taskrunner{
-				ievt:   ievt.ID(),
-				errc:   make(chan error, len(tsks)),
-				evtctx: evtctx,
-			}
-			for i, tsk := range tsks {
-				ctx := wrk.ctxs[i]
-				ctx.store = evtstore
-				ctx.ctx = evtctx
-				go evt.run(i, ctx, tsk)
-			}
-			ndone := 0
-		errloop:
-			for {
-				select {
-				case err, ok := <-evt.errc:
-					if !ok {
-						return
-					}
-					ndone++
-					if err != nil {
-						evtCancel()
-						evtstore.close()
-						wrk.msg.flush()
-
-						wrk.errc <- err
-						return
-					}
-					if ndone == len(tsks) {
-						break errloop
-					}
-				case <-evtctx.Done():
-					evtstore.close()
-					wrk.msg.flush()
-					return
-				}
-			}
-			err := evtstore.reset(wrk.keys)
-			evtstore.close()
-			wrk.msg.flush()
-
-			if err != nil {
-				wrk.errc <- err
+			if !wrk.process(ievt) {
 				return
 			}
 		case <-wrk.runctx.Done():
@@ -122,20 +102,47 @@ func (wrk *worker) run(tsks []Task) {
 	}
 }
 
-type taskrunner struct {
-	errc   chan error
-	evtctx nctx.Context
-
-	ievt int64
+// drain discards the events left in the channel once the worker stopped,
+// so the input stream is not left waiting for them.
+func (wrk *worker) drain() {
+	for ievt := range wrk.evts {
+		ievt.store.(*datastore).close()
+		wrk.inflight.Done()
+	}
 }
 
-func (run taskrunner) run(i int, ctx context, tsk Task) {
-	ctx.id = run.ievt
-	select {
-	case run.errc <- tsk.Process(ctx):
-		// FIXME(sbinet) dont be so eager to flush...
-		ctx.msg.flush()
-	case <-run.evtctx.Done():
-		ctx.msg.flush()
+// process processes the event ievt with all the tasks.
+// process returns false if the worker should stop.
+func (wrk *worker) process(ievt context) bool {
+	defer wrk.inflight.Done()
+
+	wrk.msg.Debugf(">>> running evt=%d...\n", ievt.ID())
+
+	evtstore := ievt.store.(*datastore)
+	evtctx, evtCancel := nctx.WithCancel(wrk.runctx)
+	err := wrk.sched.run(evtctx, wrk.slot, ievt.ID(), wrk.ctxs, evtstore)
+	if err != nil {
+		aborted := evtctx.Err() != nil
+		evtCancel()
+		evtstore.close()
+		wrk.msg.flush()
+
+		if aborted {
+			// another worker or the input stream failed first.
+			err = nil
+		}
+		wrk.fail(err)
+		return false
+	}
+	evtCancel()
+
+	err = evtstore.reset(wrk.keys)
+	evtstore.close()
+	wrk.msg.flush()
+
+	if err != nil {
+		wrk.fail(err)
+		return false
 	}
+	return true
 }
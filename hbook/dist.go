@@ -64,6 +64,13 @@ func (d *dist0D) scaleW(f float64) {
 	d.sumW2 *= f * f
 }
 
+// add adds the distribution o, with its weights scaled by f.
+func (d *dist0D) add(o *dist0D, f float64) {
+	d.n += o.n
+	d.sumW += f * o.sumW
+	d.sumW2 += f * f * o.sumW2
+}
+
 // dist1D is a 1-dim distribution.
 type dist1D struct {
 	dist   dist0D  // weight moments
@@ -171,6 +178,13 @@ func (d *dist1D) scaleX(f float64) {
 	d.sumWX2 *= f * f
 }
 
+// add adds the distribution o, with its weights scaled by f.
+func (d *dist1D) add(o *dist1D, f float64) {
+	d.dist.add(&o.dist, f)
+	d.sumWX += f * o.sumWX
+	d.sumWX2 += f * o.sumWX2
+}
+
 // dist2D is a 2-dim distribution.
 type dist2D struct {
 	x      dist1D  // x moments
@@ -289,6 +303,13 @@ func (d *dist2D) fill(x, y, w float64) {
 	d.sumWXY += w * x * y
 }
 
+// add adds the distribution o, with its weights scaled by f.
+func (d *dist2D) add(o *dist2D, f float64) {
+	d.x.add(&o.x, f)
+	d.y.add(&o.y, f)
+	d.sumWXY += f * o.sumWXY
+}
+
 func (d *dist2D) scaleW(f float64) {
 	d.x.scaleW(f)
 	d.y.scaleW(f)
@@ -12,7 +12,7 @@ import (
 // DivideH1D divides 2 1D-histograms and returns a 2D scatter.
 // DivideH1D returns an error if the binning of the 1D histograms are not compatible.
 func DivideH1D(num, den *H1D) (*S2D, error) {
-	var s2d S2D
+	s2d := NewS2D()
 
 	bins1 := num.Binning().Bins()
 	bins2 := den.Binning().Bins()
@@ -64,7 +64,125 @@ func DivideH1D(num, den *H1D) (*S2D, error) {
 
 		s2d.Fill(Point2D{X: x, Y: y, ErrX: Range{Min: exm, Max: exp}, ErrY: Range{Min: ey, Max: ey}})
 	}
-	return &s2d, nil
+	return s2d, nil
+}
+
+// AddH1D returns the bin-by-bin sum of 2 1D-histograms.
+// AddH1D returns an error if the binning of the 1D histograms are not compatible.
+func AddH1D(h1, h2 *H1D) (*H1D, error) {
+	return addScaledH1D(h1, h2, +1)
+}
+
+// SubH1D returns the bin-by-bin difference of 2 1D-histograms.
+// SubH1D returns an error if the binning of the 1D histograms are not compatible.
+func SubH1D(h1, h2 *H1D) (*H1D, error) {
+	return addScaledH1D(h1, h2, -1)
+}
+
+// addScaledH1D returns h1 + f*h2.
+func addScaledH1D(h1, h2 *H1D, f float64) (*H1D, error) {
+	bins1 := h1.bng.bins
+	bins2 := h2.bng.bins
+	if len(bins1) != len(bins2) {
+		return nil, fmt.Errorf("hbook: x binnings are not equivalent in %v and %v", h1.Name(), h2.Name())
+	}
+	for i := range bins1 {
+		b1 := &bins1[i]
+		b2 := &bins2[i]
+		if !fuzzyEq(b1.XMin(), b2.XMin()) || !fuzzyEq(b1.XMax(), b2.XMax()) {
+			return nil, fmt.Errorf("hbook: x binnings are not equivalent in %v and %v", h1.Name(), h2.Name())
+		}
+	}
+
+	h := &H1D{
+		bng: h1.bng,
+		ann: make(Annotation, len(h1.ann)),
+	}
+	for k, v := range h1.ann {
+		h.ann[k] = v
+	}
+	h.bng.bins = make([]Bin1D, len(bins1))
+	copy(h.bng.bins, bins1)
+
+	for i := range h.bng.bins {
+		h.bng.bins[i].dist.add(&bins2[i].dist, f)
+	}
+	h.bng.dist.add(&h2.bng.dist, f)
+	h.bng.outflows[0].add(&h2.bng.outflows[0], f)
+	h.bng.outflows[1].add(&h2.bng.outflows[1], f)
+	return h, nil
+}
+
+// AddH2D returns the bin-by-bin sum of 2 2D-histograms.
+// AddH2D returns an error if the binning of the 2D histograms are not compatible.
+func AddH2D(h1, h2 *H2D) (*H2D, error) {
+	bins1 := h1.bng.bins
+	bins2 := h2.bng.bins
+	if len(bins1) != len(bins2) {
+		return nil, fmt.Errorf("hbook: binnings are not equivalent in %v and %v", h1.Name(), h2.Name())
+	}
+	for i := range bins1 {
+		b1 := &bins1[i]
+		b2 := &bins2[i]
+		if !fuzzyEq(b1.XMin(), b2.XMin()) || !fuzzyEq(b1.XMax(), b2.XMax()) ||
+			!fuzzyEq(b1.YMin(), b2.YMin()) || !fuzzyEq(b1.YMax(), b2.YMax()) {
+			return nil, fmt.Errorf("hbook: binnings are not equivalent in %v and %v", h1.Name(), h2.Name())
+		}
+	}
+
+	h := &H2D{
+		bng: h1.bng,
+		ann: make(Annotation, len(h1.ann)),
+	}
+	for k, v := range h1.ann {
+		h.ann[k] = v
+	}
+	h.bng.bins = make([]Bin2D, len(bins1))
+	copy(h.bng.bins, bins1)
+
+	for i := range h.bng.bins {
+		h.bng.bins[i].dist.add(&bins2[i].dist, 1)
+	}
+	h.bng.dist.add(&h2.bng.dist, 1)
+	for i := range h.bng.outflows {
+		h.bng.outflows[i].add(&h2.bng.outflows[i], 1)
+	}
+	return h, nil
+}
+
+// AddP1D returns the bin-by-bin sum of 2 1D-profiles.
+// AddP1D returns an error if the binning of the 1D profiles are not compatible.
+func AddP1D(p1, p2 *P1D) (*P1D, error) {
+	bins1 := p1.bng.bins
+	bins2 := p2.bng.bins
+	if len(bins1) != len(bins2) {
+		return nil, fmt.Errorf("hbook: x binnings are not equivalent in %v and %v", p1.Name(), p2.Name())
+	}
+	for i := range bins1 {
+		b1 := &bins1[i]
+		b2 := &bins2[i]
+		if !fuzzyEq(b1.XMin(), b2.XMin()) || !fuzzyEq(b1.XMax(), b2.XMax()) {
+			return nil, fmt.Errorf("hbook: x binnings are not equivalent in %v and %v", p1.Name(), p2.Name())
+		}
+	}
+
+	p := &P1D{
+		bng: p1.bng,
+		ann: make(Annotation, len(p1.ann)),
+	}
+	for k, v := range p1.ann {
+		p.ann[k] = v
+	}
+	p.bng.bins = make([]BinP1D, len(bins1))
+	copy(p.bng.bins, bins1)
+
+	for i := range p.bng.bins {
+		p.bng.bins[i].dist.add(&bins2[i].dist, 1)
+	}
+	p.bng.dist.add(&p2.bng.dist, 1)
+	p.bng.outflows[0].add(&p2.bng.outflows[0], 1)
+	p.bng.outflows[1].add(&p2.bng.outflows[1], 1)
+	return p, nil
 }
 
 // fuzzyEq returns true if a and b are equal with a degree of fuzziness
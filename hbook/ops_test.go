@@ -44,3 +44,124 @@ END YODA_SCATTER2D
 		t.Fatalf("divide(num,den) differ:\ngot:\n%s\nwant:\n%s\n", string(chk), string(want))
 	}
 }
+
+func TestAddSubH1D(t *testing.T) {
+	h1 := NewH1D(5, 0, 5)
+	h2 := NewH1D(5, 0, 5)
+	for i := 0; i < 5; i++ {
+		h1.Fill(float64(i), float64(i+1))
+		h2.Fill(float64(i), 2)
+	}
+	h1.Fill(-1, 1)
+	h2.Fill(10, 3)
+
+	sum, err := AddH1D(h1, h2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err := SubH1D(h1, h2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, bin := range sum.Binning().Bins() {
+		if got, want := bin.SumW(), float64(i+1)+2; got != want {
+			t.Fatalf("sum: bin #%d: got=%v, want=%v", i, got, want)
+		}
+		if got, want := bin.SumW2(), float64((i+1)*(i+1))+4; got != want {
+			t.Fatalf("sum: bin #%d: got sumw2=%v, want=%v", i, got, want)
+		}
+	}
+	for i, bin := range diff.Binning().Bins() {
+		if got, want := bin.SumW(), float64(i+1)-2; got != want {
+			t.Fatalf("diff: bin #%d: got=%v, want=%v", i, got, want)
+		}
+		if got, want := bin.SumW2(), float64((i+1)*(i+1))+4; got != want {
+			t.Fatalf("diff: bin #%d: got sumw2=%v, want=%v", i, got, want)
+		}
+	}
+
+	if got, want := sum.Entries(), h1.Entries()+h2.Entries(); got != want {
+		t.Fatalf("sum: got %d entries, want=%d", got, want)
+	}
+	if got, want := sum.Binning().outflows[0].SumW(), 1.0; got != want {
+		t.Fatalf("sum: got underflow=%v, want=%v", got, want)
+	}
+	if got, want := diff.Binning().outflows[1].SumW(), -3.0; got != want {
+		t.Fatalf("diff: got overflow=%v, want=%v", got, want)
+	}
+
+	// h1 must not be modified.
+	if got, want := h1.Binning().Bins()[0].SumW(), 1.0; got != want {
+		t.Fatalf("h1 modified: got=%v, want=%v", got, want)
+	}
+
+	_, err = AddH1D(h1, NewH1D(4, 0, 5))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	_, err = SubH1D(h1, NewH1D(5, 0, 6))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestAddH2D(t *testing.T) {
+	h1 := NewH2D(2, 0, 2, 2, 0, 2)
+	h2 := NewH2D(2, 0, 2, 2, 0, 2)
+	ref := NewH2D(2, 0, 2, 2, 0, 2)
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			x, y := float64(i), float64(j)
+			h1.Fill(x, y, 1)
+			h2.Fill(x, y, 2)
+			ref.Fill(x, y, 1)
+			ref.Fill(x, y, 2)
+		}
+	}
+	h1.Fill(-1, 1, 1)
+	ref.Fill(-1, 1, 1)
+	h2.Fill(1, 10, 3)
+	ref.Fill(1, 10, 3)
+
+	sum, err := AddH2D(h1, h2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sum.bng, ref.bng) {
+		t.Fatalf("sum differs:\ngot= %#v\nwant=%#v", sum.bng, ref.bng)
+	}
+
+	_, err = AddH2D(h1, NewH2D(2, 0, 2, 2, 0, 3))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestAddP1D(t *testing.T) {
+	p1 := NewP1D(5, 0, 5)
+	p2 := NewP1D(5, 0, 5)
+	ref := NewP1D(5, 0, 5)
+	for i := 0; i < 5; i++ {
+		x := float64(i)
+		p1.Fill(x, 2*x, 1)
+		p2.Fill(x, 3*x, 2)
+		ref.Fill(x, 2*x, 1)
+		ref.Fill(x, 3*x, 2)
+	}
+	p1.Fill(-1, 1, 1)
+	ref.Fill(-1, 1, 1)
+
+	sum, err := AddP1D(p1, p2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sum.bng, ref.bng) {
+		t.Fatalf("sum differs:\ngot= %#v\nwant=%#v", sum.bng, ref.bng)
+	}
+
+	_, err = AddP1D(p1, NewP1D(4, 0, 5))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
@@ -22,41 +22,49 @@ func NewEncoder(w io.Writer) *Encoder {
 }
 
 // Close closes the encoder and adds a footer to the stream.
+// The header of the stream is written as well when no event was encoded.
 func (enc *Encoder) Close() error {
-	var err error
-	if enc.seenEvtHdr {
-		_, err = fmt.Fprintf(
-			enc.w,
-			"%s\n",
-			endGenEvent,
-		)
-		if err != nil {
-			return err
-		}
+	err := enc.writeHeader()
+	if err != nil {
+		return err
 	}
+	_, err = fmt.Fprintf(
+		enc.w,
+		"%s\n",
+		endGenEvent,
+	)
 	return err
 }
 
-// Encode writes evt into the stream.
-func (enc *Encoder) Encode(evt *Event) error {
-	var err error
+// writeHeader writes the header of the stream, once.
+func (enc *Encoder) writeHeader() error {
+	if enc.seenEvtHdr {
+		return nil
+	}
 
-	if !enc.seenEvtHdr {
-		_, err = fmt.Fprintf(
-			enc.w,
-			"\nHepMC::Version %s\n",
-			VersionName(),
-		)
-		if err != nil {
-			return err
-		}
+	_, err := fmt.Fprintf(
+		enc.w,
+		"\nHepMC::Version %s\n",
+		VersionName(),
+	)
+	if err != nil {
+		return err
+	}
 
-		_, err = fmt.Fprintf(enc.w, "%s\n", startGenEvent)
-		if err != nil {
-			return err
-		}
+	_, err = fmt.Fprintf(enc.w, "%s\n", startGenEvent)
+	if err != nil {
+		return err
+	}
+
+	enc.seenEvtHdr = true
+	return nil
+}
 
-		enc.seenEvtHdr = true
+// Encode writes evt into the stream.
+func (enc *Encoder) Encode(evt *Event) error {
+	err := enc.writeHeader()
+	if err != nil {
+		return err
 	}
 
 	sigBc := 0
@@ -0,0 +1,170 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hplot
+
+import (
+	"image/color"
+	"math"
+
+	"go-hep.org/x/hep/hbook"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// BinnedErrBand implements the plot.Plotter interface,
+// drawing a band of uncertainties over binned data.
+//
+// Each point of the data is drawn as a box spanning its x- and y-errors.
+type BinnedErrBand struct {
+	Data *hbook.S2D
+
+	// FillColor is the color used to fill the band.
+	// If the color is nil then the band is not filled.
+	FillColor color.Color
+
+	// LineStyle is the style of the outline of each box of the band.
+	draw.LineStyle
+
+	// Hatch is the style of the hatching of the band.
+	Hatch HatchStyle
+}
+
+// HatchStyle describes the hatching of an area.
+type HatchStyle struct {
+	// LineStyle is the style of the hatching lines.
+	draw.LineStyle
+
+	// Spacing is the distance between two hatching lines.
+	// If Spacing is zero then the area is not hatched.
+	Spacing vg.Length
+}
+
+// NewBinnedErrBand returns a new band of uncertainties from a scatter.
+// The band is drawn with gray hatching lines.
+func NewBinnedErrBand(s *hbook.S2D) *BinnedErrBand {
+	return &BinnedErrBand{
+		Data: s,
+		Hatch: HatchStyle{
+			LineStyle: draw.LineStyle{
+				Color: color.Gray{Y: 100},
+				Width: vg.Points(0.5),
+			},
+			Spacing: vg.Points(4),
+		},
+	}
+}
+
+// NewBinnedErrBandFromH1D returns a new band of uncertainties from the
+// content of each bin of a histogram and its statistical uncertainty.
+func NewBinnedErrBandFromH1D(h *hbook.H1D) *BinnedErrBand {
+	bins := h.Binning().Bins()
+	pts := make([]hbook.Point2D, len(bins))
+	for i, bin := range bins {
+		var (
+			dx  = 0.5 * bin.XWidth()
+			err = bin.ErrW()
+		)
+		pts[i] = hbook.Point2D{
+			X: bin.XMid(), Y: bin.SumW(),
+			ErrX: hbook.Range{Min: dx, Max: dx},
+			ErrY: hbook.Range{Min: err, Max: err},
+		}
+	}
+	return NewBinnedErrBand(hbook.NewS2D(pts...))
+}
+
+// Plot implements the plot.Plotter interface, drawing the band.
+func (b *BinnedErrBand) Plot(c draw.Canvas, p *plot.Plot) {
+	trX, trY := p.Transforms(&c)
+	for _, pt := range b.Data.Points() {
+		var (
+			xmin = trX(pt.X - pt.ErrX.Min)
+			xmax = trX(pt.X + pt.ErrX.Max)
+			ymin = trY(pt.Y - pt.ErrY.Min)
+			ymax = trY(pt.Y + pt.ErrY.Max)
+		)
+		b.drawBox(&c, vg.Rectangle{
+			Min: vg.Point{X: xmin, Y: ymin},
+			Max: vg.Point{X: xmax, Y: ymax},
+		})
+	}
+}
+
+// drawBox draws a box of the band.
+func (b *BinnedErrBand) drawBox(c *draw.Canvas, r vg.Rectangle) {
+	pts := []vg.Point{
+		{X: r.Min.X, Y: r.Min.Y},
+		{X: r.Max.X, Y: r.Min.Y},
+		{X: r.Max.X, Y: r.Max.Y},
+		{X: r.Min.X, Y: r.Max.Y},
+	}
+	if b.FillColor != nil {
+		c.FillPolygon(b.FillColor, c.ClipPolygonXY(pts))
+	}
+	if b.Hatch.Spacing > 0 {
+		c.StrokeLines(b.Hatch.LineStyle, c.ClipLinesXY(hatchLines(r, b.Hatch.Spacing)...)...)
+	}
+	if b.LineStyle.Width > 0 {
+		pts = append(pts, pts[0])
+		c.StrokeLines(b.LineStyle, c.ClipLinesXY(pts)...)
+	}
+}
+
+// hatchLines returns the 45-degree lines hatching the rectangle r.
+// The lines are anchored on a grid independent of r, so the hatching of
+// adjacent rectangles is continuous.
+func hatchLines(r vg.Rectangle, spacing vg.Length) [][]vg.Point {
+	var (
+		step  = spacing * math.Sqrt2
+		dmin  = r.Min.X - r.Max.Y
+		dmax  = r.Max.X - r.Min.Y
+		lines [][]vg.Point
+	)
+	// lines are defined by x = y + d.
+	for d := vg.Length(math.Ceil(float64(dmin/step))) * step; d <= dmax; d += step {
+		lo := vgMax(r.Min.Y, r.Min.X-d)
+		hi := vgMin(r.Max.Y, r.Max.X-d)
+		if lo >= hi {
+			continue
+		}
+		lines = append(lines, []vg.Point{{X: lo + d, Y: lo}, {X: hi + d, Y: hi}})
+	}
+	return lines
+}
+
+// DataRange returns the minimum and maximum X and Y values
+func (b *BinnedErrBand) DataRange() (xmin, xmax, ymin, ymax float64) {
+	xmin = math.Inf(+1)
+	xmax = math.Inf(-1)
+	ymin = math.Inf(+1)
+	ymax = math.Inf(-1)
+	for _, pt := range b.Data.Points() {
+		xmin = math.Min(xmin, pt.X-pt.ErrX.Min)
+		xmax = math.Max(xmax, pt.X+pt.ErrX.Max)
+		ymin = math.Min(ymin, pt.Y-pt.ErrY.Min)
+		ymax = math.Max(ymax, pt.Y+pt.ErrY.Max)
+	}
+	return xmin, xmax, ymin, ymax
+}
+
+// Thumbnail draws a box in the given style of the band,
+// implementing the plot.Thumbnailer interface.
+func (b *BinnedErrBand) Thumbnail(c *draw.Canvas) {
+	b.drawBox(c, c.Rectangle)
+}
+
+func vgMin(a, b vg.Length) vg.Length {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+var (
+	_ plot.Plotter     = (*BinnedErrBand)(nil)
+	_ plot.DataRanger  = (*BinnedErrBand)(nil)
+	_ plot.Thumbnailer = (*BinnedErrBand)(nil)
+)
@@ -0,0 +1,265 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go-hep.org/x/hep/hbook"
+	"go-hep.org/x/hep/hbook/rootcnv"
+	"go-hep.org/x/hep/hbook/yodacnv"
+	"go-hep.org/x/hep/rio"
+	"go-hep.org/x/hep/rootio"
+)
+
+// object is an hbook value read from a file.
+type object struct {
+	path string // path of the value in the file, e.g. "/dir/h1"
+	v    hbook.Object
+}
+
+// load reads all the hbook values of a YODA, rio or ROOT file.
+// Files with another extension are read as a stream of numbers,
+// histogrammed into a single H1D.
+// The values are sorted by path.
+func load(fname string) ([]object, error) {
+	var (
+		objs []object
+		err  error
+	)
+	switch ext := filepath.Ext(fname); ext {
+	case ".yoda":
+		objs, err = loadYODA(fname)
+	case ".rio":
+		objs, err = loadRIO(fname)
+	case ".root":
+		objs, err = loadROOT(fname)
+	default:
+		objs, err = loadValues(fname)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(byPath(objs))
+	return objs, nil
+}
+
+type byPath []object
+
+func (p byPath) Len() int           { return len(p) }
+func (p byPath) Less(i, j int) bool { return p[i].path < p[j].path }
+func (p byPath) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+func loadYODA(fname string) ([]object, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vs, err := yodacnv.Read(f)
+	if err != nil {
+		return nil, err
+	}
+
+	objs := make([]object, len(vs))
+	for i, v := range vs {
+		objs[i] = object{path: "/" + v.Name(), v: v}
+	}
+	return objs, nil
+}
+
+func loadValues(fname string) ([]object, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		vs       []float64
+		min, max = math.Inf(+1), math.Inf(-1)
+	)
+	for {
+		var v float64
+		_, err = fmt.Fscan(f, &v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	if len(vs) == 0 {
+		return nil, fmt.Errorf("no value in file")
+	}
+
+	// make sure the maximum value falls in the last bin.
+	max = math.Nextafter(max, math.Inf(+1))
+	if min == max {
+		min--
+		max++
+	}
+
+	h := hbook.NewH1D(16, min, max)
+	for _, v := range vs {
+		h.Fill(v, 1)
+	}
+	name := strings.TrimSuffix(filepath.Base(fname), filepath.Ext(fname))
+	h.Annotation()["name"] = name
+	return []object{{path: "/" + name, v: h}}, nil
+}
+
+func loadRIO(fname string) ([]object, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := rio.Open(f)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var objs []object
+	for _, key := range r.Keys() {
+		rt := typeFrom(key.Blocks[0].Type)
+		if rt == nil {
+			continue
+		}
+		v := reflect.New(rt.Elem())
+		err = r.Get(key.Name, v.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("error reading %q: %v", key.Name, err)
+		}
+		objs = append(objs, object{path: "/" + key.Name, v: v.Interface().(hbook.Object)})
+	}
+	return objs, nil
+}
+
+func loadROOT(fname string) ([]object, error) {
+	f, err := rootio.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var objs []object
+	err = walk(&objs, "", f)
+	if err != nil {
+		return nil, err
+	}
+	return objs, nil
+}
+
+// walk appends to objs the hbook values converted from the histograms and
+// graphs of the ROOT directory dir, and of its sub-directories.
+func walk(objs *[]object, path string, dir rootio.Directory) error {
+	for _, k := range uniq(dir.Keys()) {
+		name := path + "/" + k.Name()
+		switch obj := k.Value().(type) {
+		case rootio.Directory:
+			err := walk(objs, name, obj)
+			if err != nil {
+				return err
+			}
+		case rootio.Graph:
+			s, err := rootcnv.S2D(obj)
+			if err != nil {
+				return fmt.Errorf("error converting %q: %v", name, err)
+			}
+			*objs = append(*objs, object{path: name, v: s})
+		case yodacnv.Marshaler:
+			raw, err := obj.MarshalYODA()
+			if err != nil {
+				return fmt.Errorf("error converting %q: %v", name, err)
+			}
+			vs, err := yodacnv.Read(bytes.NewReader(raw))
+			if err != nil {
+				return fmt.Errorf("error converting %q: %v", name, err)
+			}
+			for _, v := range vs {
+				*objs = append(*objs, object{path: name, v: v})
+			}
+		}
+	}
+	return nil
+}
+
+// uniq returns the keys with the highest cycle for each name.
+func uniq(keys []rootio.Key) []rootio.Key {
+	set := make(map[string]rootio.Key, len(keys))
+	for _, k := range keys {
+		kk, dup := set[k.Name()]
+		if dup && kk.Cycle() > k.Cycle() {
+			continue
+		}
+		set[k.Name()] = k
+	}
+	out := make([]rootio.Key, 0, len(set))
+	for _, k := range set {
+		out = append(out, k)
+	}
+	return out
+}
+
+func nameFromType(rt reflect.Type) string {
+	if rt == nil {
+		return "interface"
+	}
+	// Default to printed representation for unnamed types
+	name := rt.String()
+
+	// But for named types (or pointers to them), qualify with import path.
+	// Dereference one pointer looking for a named type.
+	star := ""
+	if rt.Name() == "" {
+		pt := rt
+		if pt.Kind() == reflect.Ptr {
+			star = "*"
+			rt = pt.Elem()
+		}
+	}
+
+	if rt.Name() != "" {
+		switch rt.PkgPath() {
+		case "":
+			name = star + rt.Name()
+		default:
+			name = star + rt.PkgPath() + "." + rt.Name()
+		}
+	}
+
+	return name
+}
+
+func typeFrom(name string) reflect.Type {
+	for _, t := range hbookTypes {
+		if name == nameFromType(t) {
+			return t
+		}
+	}
+	return nil
+}
+
+var hbookTypes = []reflect.Type{
+	reflect.TypeOf((*hbook.H1D)(nil)),
+	reflect.TypeOf((*hbook.H2D)(nil)),
+	reflect.TypeOf((*hbook.P1D)(nil)),
+	reflect.TypeOf((*hbook.S2D)(nil)),
+}
@@ -2,74 +2,255 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// hplot is a simple gnuplot-like command to create plots
-package main
+// hplot plots the histograms and scatters stored in YODA, rio and ROOT files.
+//
+// The values with the same path in several files are overlaid on the same
+// plot, with a ratio pad displaying the ratio of each value to the one of
+// the first file.
+// Files with another extension are read as a stream of numbers, which are
+// histogrammed.
+//
+// Example:
+//
+//  $> hplot -o out -f png,pdf -s '/ATLAS_2017_I1234/*' mc1.yoda mc2.root data.rio
+//  $> hplot -l "Pythia,Herwig" -html -o report mc1.yoda mc2.yoda
+//
+// Usage: hplot [options] file1 [file2 [...]]
+//
+// options:
+//   -f string
+//     	comma-separated list of output formats (eps, jpg, pdf, png, svg, tex, tiff) (default "png")
+//   -height float
+//     	height of the plots, in centimeters (default 10)
+//   -html
+//     	write an HTML index of the plots
+//   -l string
+//     	comma-separated list of labels of the input files (default: the names of the files)
+//   -o string
+//     	output directory (default ".")
+//   -ratio
+//     	display a ratio pad when overlaying several files (default true)
+//   -s string
+//     	comma-separated list of glob patterns selecting the values by path (default: all)
+//   -width float
+//     	width of the plots, in centimeters (default 15)
+package main // import "go-hep.org/x/hep/hplot/cmd/hplot"
 
 import (
 	"flag"
 	"fmt"
-	"io"
+	"html/template"
+	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
-	"go-hep.org/x/hep/hplot"
-	"gonum.org/v1/plot/plotter"
+	"go-hep.org/x/hep/hbook"
+	"gonum.org/v1/plot/vg"
 )
 
-var g_persist = flag.Bool("p", false, "lets plot windows survive after hplot exists")
-var g_cmdlist = flag.String("e", "", "executes the requested commands before loading the next input file")
+var (
+	outFlag    = flag.String("o", ".", "output directory")
+	fmtFlag    = flag.String("f", "png", "comma-separated list of output formats (eps, jpg, pdf, png, svg, tex, tiff)")
+	selFlag    = flag.String("s", "", "comma-separated list of glob patterns selecting the values by path (default: all)")
+	lblFlag    = flag.String("l", "", "comma-separated list of labels of the input files (default: the names of the files)")
+	ratioFlag  = flag.Bool("ratio", true, "display a ratio pad when overlaying several files")
+	htmlFlag   = flag.Bool("html", false, "write an HTML index of the plots")
+	widthFlag  = flag.Float64("width", 15, "width of the plots, in centimeters")
+	heightFlag = flag.Float64("height", 10, "height of the plots, in centimeters")
+)
 
 func main() {
-	fmt.Printf(":: welcome to hplot\n")
+	log.SetPrefix("hplot: ")
+	log.SetFlags(0)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: hplot [options] file1 [file2 [...]]
+
+ex:
+ $> hplot -o out -f png,pdf -s '/ATLAS_2017_I1234/*' mc1.yoda mc2.root data.rio
+ $> hplot -l "Pythia,Herwig" -html -o report mc1.yoda mc2.yoda
+
+options:
+`,
+		)
+		flag.PrintDefaults()
+	}
+
 	flag.Parse()
 
-	if flag.NArg() < 1 {
-		fmt.Fprintf(os.Stderr, "**error** you need to give an input data file\n")
-		os.Exit(1)
+	if flag.NArg() == 0 {
+		flag.Usage()
+		log.Fatalf("need at least one input file")
 	}
 
-	f, err := os.Open(flag.Args()[0])
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "**error** opening file [%s]: %v\n", flag.Args()[0], err)
-		os.Exit(1)
+	var (
+		fnames   = flag.Args()
+		formats  = split(*fmtFlag)
+		patterns = split(*selFlag)
+		labels   = split(*lblFlag)
+	)
+
+	if len(formats) == 0 {
+		log.Fatalf("need at least one output format")
+	}
+
+	switch {
+	case len(labels) == 0:
+		for _, fname := range fnames {
+			labels = append(labels, filepath.Base(fname))
+		}
+	case len(labels) != len(fnames):
+		log.Fatalf("invalid number of labels (got=%d, want=%d)", len(labels), len(fnames))
 	}
-	defer f.Close()
 
-	p, err := hplot.New()
+	err := os.MkdirAll(*outFlag, 0755)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "**error** creating plotter: %v\n", err)
-		os.Exit(1)
+		log.Fatalf("could not create output directory: %v", err)
 	}
 
-	values := make(plotter.Values, 0, 10)
-	for err == nil {
-		var v float64
-		_, err = fmt.Fscanf(f, "%f\n", &v)
-		if err == io.EOF {
-			break
+	var (
+		paths []string
+		files = make([]map[string]hbook.Object, len(fnames))
+	)
+	for i, fname := range fnames {
+		objs, err := load(fname)
+		if err != nil {
+			log.Fatalf("could not read file %q: %v", fname, err)
 		}
+		files[i] = make(map[string]hbook.Object, len(objs))
+		for _, obj := range objs {
+			if !match(obj.path, patterns) {
+				continue
+			}
+			if _, dup := files[i][obj.path]; dup {
+				continue
+			}
+			files[i][obj.path] = obj.v
+			if !contains(paths, obj.path) {
+				paths = append(paths, obj.path)
+			}
+		}
+	}
+
+	var (
+		w     = vg.Length(*widthFlag) * vg.Centimeter
+		h     = vg.Length(*heightFlag) * vg.Centimeter
+		plots []string
+	)
+	for _, name := range paths {
+		vs := make([]hbook.Object, len(files))
+		for i, f := range files {
+			vs[i] = f[name]
+		}
+		p, err := newPlot(name, vs, labels, *ratioFlag)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "** error ** %v\n", err)
-			os.Exit(1)
+			log.Printf("could not create plot for %q: %v", name, err)
+			continue
+		}
+		for _, format := range formats {
+			oname := filepath.Join(*outFlag, fileName(name, format))
+			err = p.Save(w, h, oname)
+			if err != nil {
+				log.Fatalf("could not save plot %q: %v", oname, err)
+			}
 		}
+		plots = append(plots, name)
+	}
 
-		values = append(values, v)
+	log.Printf("created %d plots in %q", len(plots), *outFlag)
+
+	if *htmlFlag {
+		err = writeIndex(filepath.Join(*outFlag, "index.html"), plots, formats)
+		if err != nil {
+			log.Fatalf("could not write HTML index: %v", err)
+		}
 	}
+}
 
-	// create histogram from data
-	h, err := hplot.NewH1FromValuer(values, 16)
+// writeIndex writes an HTML page displaying all the plots, using the
+// first format of the plots displayable by a browser.
+func writeIndex(fname string, plots, formats []string) error {
+	img := ""
+	for _, format := range formats {
+		switch format {
+		case "png", "jpg", "jpeg", "svg":
+			img = format
+		}
+		if img != "" {
+			break
+		}
+	}
+
+	type entry struct {
+		Name  string
+		Img   string
+		Links []string
+	}
+	entries := make([]entry, len(plots))
+	for i, name := range plots {
+		e := entry{Name: name}
+		if img != "" {
+			e.Img = fileName(name, img)
+		}
+		for _, format := range formats {
+			e.Links = append(e.Links, fileName(name, format))
+		}
+		entries[i] = e
+	}
+
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = indexTmpl.Execute(f, entries)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "**error** creating histogram: %v\n", err)
-		os.Exit(1)
+		return err
 	}
+	return f.Close()
+}
 
-	p.Add(h)
-	p.Add(hplot.NewGrid())
+var indexTmpl = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>hplot</title>
+</head>
+<body>
+{{- range .}}
+<div style="display:inline-block; margin:1em; text-align:center">
+<h3>{{.Name}}</h3>
+{{- if .Img}}
+<img src="{{.Img}}" alt="{{.Name}}"><br>
+{{- end}}
+{{- range .Links}}
+<a href="{{.}}">{{.}}</a>
+{{- end}}
+</div>
+{{- end}}
+</body>
+</html>
+`))
 
-	// Save the plot to a PDF file.
-	if err := p.Save(6, 4, "hist.pdf"); err != nil {
-		fmt.Fprintf(os.Stderr, "**error** saving plot: %v\n", err)
-		os.Exit(1)
+func split(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		out = append(out, v)
 	}
+	return out
+}
 
-	fmt.Printf(":: bye.\n")
+func contains(vs []string, v string) bool {
+	for _, vv := range vs {
+		if vv == v {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,208 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"path"
+	"strings"
+
+	"go-hep.org/x/hep/hbook"
+	"go-hep.org/x/hep/hplot"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// saver is the interface implemented by all hplot plots.
+type saver interface {
+	Save(w, h vg.Length, file string) error
+}
+
+// newPlot creates the plot of the values with the same path from several
+// files. vs[i] is the value read from the i-th file, or nil if the i-th
+// file does not contain that path.
+func newPlot(name string, vs []hbook.Object, labels []string, ratio bool) (saver, error) {
+	ref := -1
+	for i, v := range vs {
+		if v != nil {
+			ref = i
+			break
+		}
+	}
+	if ref < 0 {
+		return nil, fmt.Errorf("no value for %q", name)
+	}
+
+	switch v := vs[ref].(type) {
+	case *hbook.H2D:
+		return newPlot2D(name, v)
+	case *hbook.H1D, *hbook.P1D, *hbook.S2D:
+		return newPlot1D(name, vs, ref, labels, ratio)
+	default:
+		return nil, fmt.Errorf("unhandled value type %T for %q", v, name)
+	}
+}
+
+// newPlot2D creates the plot of a 2-dim histogram, with its color bar.
+func newPlot2D(name string, h *hbook.H2D) (saver, error) {
+	h2 := hplot.NewH2D(h, nil)
+	p, err := hplot.NewColorBarPlot(h2.ColorBar())
+	if err != nil {
+		return nil, err
+	}
+	p.Plot.Title.Text = name
+	p.Plot.Add(h2)
+	return p, nil
+}
+
+// newPlot1D creates the plot of the overlay of 1-dim values.
+// If ratio is true and there are several values, a ratio pad displays
+// the ratio of each value to the reference one.
+func newPlot1D(name string, vs []hbook.Object, ref int, labels []string, ratio bool) (saver, error) {
+	var (
+		n    = 0
+		kind = fmt.Sprintf("%T", vs[ref])
+	)
+	for i, v := range vs {
+		if v == nil {
+			continue
+		}
+		if k := fmt.Sprintf("%T", v); k != kind {
+			log.Printf("%s: skipping %s from file #%d (type %s != %s)", name, labels[i], i, k, kind)
+			vs[i] = nil
+			continue
+		}
+		n++
+	}
+	ratio = ratio && n > 1
+
+	var (
+		top *hplot.Plot
+		rp  *hplot.RatioPlot
+		err error
+	)
+	switch {
+	case ratio:
+		rp, err = hplot.NewRatioPlot()
+		if err != nil {
+			return nil, err
+		}
+		top = rp.Top
+		rp.Bottom.X.Label.Text = name
+	default:
+		top, err = hplot.New()
+		if err != nil {
+			return nil, err
+		}
+		top.X.Label.Text = name
+	}
+	top.Title.Text = name
+	top.Legend.Top = true
+
+	sref := scatter(vs[ref])
+	if ratio {
+		rp.Bottom.Add(hplot.NewBinnedErrBand(hplot.RelErrS2D(sref)))
+	}
+
+	for i, v := range vs {
+		if v == nil {
+			continue
+		}
+		col := plotutil.Color(i)
+		var pl plot.Plotter
+		switch v := v.(type) {
+		case *hbook.H1D:
+			h, err := hplot.NewH1D(v)
+			if err != nil {
+				return nil, err
+			}
+			h.FillColor = nil
+			h.LineStyle.Color = col
+			pl = h
+		default:
+			s := hplot.NewS2D(scatter(v), hplot.WithXErrBars|hplot.WithYErrBars)
+			s.GlyphStyle.Color = col
+			s.GlyphStyle.Shape = plotutil.Shape(i)
+			pl = s
+		}
+		top.Add(pl)
+		if n > 1 {
+			top.Legend.Add(labels[i], pl.(plot.Thumbnailer))
+		}
+
+		if ratio && i != ref {
+			r := hplot.NewS2D(hplot.DivideS2D(scatter(v), sref), hplot.WithYErrBars)
+			r.GlyphStyle.Color = col
+			r.GlyphStyle.Shape = plotutil.Shape(i)
+			rp.Bottom.Add(r)
+		}
+	}
+
+	if ratio {
+		return rp, nil
+	}
+	return top, nil
+}
+
+// scatter returns the content of a 1-dim value as a scatter.
+// Points with an undefined position (e.g. empty bins of a profile)
+// are removed, and undefined errors are set to zero.
+func scatter(v hbook.Object) *hbook.S2D {
+	var s *hbook.S2D
+	switch v := v.(type) {
+	case *hbook.H1D:
+		s = hbook.NewS2DFromH1D(v)
+	case *hbook.P1D:
+		s = hbook.NewS2DFromP1D(v)
+	case *hbook.S2D:
+		s = v
+	default:
+		panic(fmt.Errorf("hplot: invalid 1-dim value type %T", v))
+	}
+
+	pts := make([]hbook.Point2D, 0, s.Len())
+	for _, pt := range s.Points() {
+		if math.IsNaN(pt.X) || math.IsNaN(pt.Y) {
+			continue
+		}
+		for _, v := range []*float64{&pt.ErrX.Min, &pt.ErrX.Max, &pt.ErrY.Min, &pt.ErrY.Max} {
+			if math.IsNaN(*v) {
+				*v = 0
+			}
+		}
+		pts = append(pts, pt)
+	}
+	return hbook.NewS2D(pts...)
+}
+
+// match returns whether the path of a value matches one of the patterns.
+// A value matches all the patterns if there are none.
+func match(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pat := range patterns {
+		if !strings.HasPrefix(pat, "/") {
+			pat = "/" + pat
+		}
+		if ok, _ := path.Match(pat, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fileName returns the name of the output file of the plot of the value
+// with the given path.
+func fileName(name, format string) string {
+	name = strings.Replace(strings.Trim(name, "/"), "/", "_", -1)
+	if name == "" {
+		name = "plot"
+	}
+	return name + "." + format
+}
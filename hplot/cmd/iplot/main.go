@@ -91,7 +91,7 @@ func main() {
 				log.Fatal(err)
 			}
 			go func() {
-				c.Run(nil)
+				p.Explore(c)
 				c.Release()
 			}()
 		}
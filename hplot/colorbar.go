@@ -0,0 +1,231 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hplot
+
+import (
+	"io"
+	"math"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/palette"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// ColorBar implements the plot.Plotter interface,
+// drawing the color scale of a heat map.
+//
+// The colors of the palette are drawn with the same mapping
+// than the one used by the heat map, over the [Min, Max] range.
+type ColorBar struct {
+	Palette palette.Palette
+
+	// Min and Max are the range of the z-values of the scale.
+	Min, Max float64
+
+	// LogZ draws the scale with a logarithmic mapping.
+	// The axis of the plot holding the color bar should then
+	// use a plot.LogScale.
+	LogZ bool
+
+	// Vertical draws the scale along the y-axis
+	// instead of the x-axis.
+	Vertical bool
+}
+
+// edges returns the z-values of the edges of the colors of the scale.
+func (cb *ColorBar) edges() []float64 {
+	var (
+		n        = len(cb.Palette.Colors())
+		min, max = cb.Min, cb.Max
+	)
+	if cb.LogZ {
+		min = math.Log10(min)
+		max = math.Log10(max)
+	}
+	zs := make([]float64, n+1)
+	zs[0] = min
+	zs[n] = max
+	if n > 1 {
+		dz := (max - min) / float64(n-1)
+		for i := 1; i < n; i++ {
+			zs[i] = min + (float64(i)-0.5)*dz
+		}
+	}
+	if cb.LogZ {
+		for i, z := range zs {
+			zs[i] = math.Pow(10, z)
+		}
+	}
+	return zs
+}
+
+// Plot implements the Plotter interface, drawing the color scale.
+func (cb *ColorBar) Plot(c draw.Canvas, p *plot.Plot) {
+	var (
+		trX, trY = p.Transforms(&c)
+		cols     = cb.Palette.Colors()
+		zs       = cb.edges()
+	)
+	for i, col := range cols {
+		r := vg.Rectangle{
+			Min: vg.Point{X: trX(zs[i]), Y: c.Min.Y},
+			Max: vg.Point{X: trX(zs[i+1]), Y: c.Max.Y},
+		}
+		if cb.Vertical {
+			r = vg.Rectangle{
+				Min: vg.Point{X: c.Min.X, Y: trY(zs[i])},
+				Max: vg.Point{X: c.Max.X, Y: trY(zs[i+1])},
+			}
+		}
+		c.FillPolygon(col, c.ClipPolygonXY([]vg.Point{
+			{X: r.Min.X, Y: r.Min.Y},
+			{X: r.Max.X, Y: r.Min.Y},
+			{X: r.Max.X, Y: r.Max.Y},
+			{X: r.Min.X, Y: r.Max.Y},
+		}))
+	}
+}
+
+// DataRange implements the DataRange method
+// of the plot.DataRanger interface.
+func (cb *ColorBar) DataRange() (xmin, xmax, ymin, ymax float64) {
+	if cb.Vertical {
+		return 0, 1, cb.Min, cb.Max
+	}
+	return cb.Min, cb.Max, 0, 1
+}
+
+// Thumbnail draws the colors of the palette side by side,
+// implementing the plot.Thumbnailer interface.
+func (cb *ColorBar) Thumbnail(c *draw.Canvas) {
+	cols := cb.Palette.Colors()
+	if len(cols) == 0 {
+		return
+	}
+	dx := (c.Max.X - c.Min.X) / vg.Length(len(cols))
+	for i, col := range cols {
+		x := c.Min.X + vg.Length(i)*dx
+		c.FillPolygon(col, []vg.Point{
+			{X: x, Y: c.Min.Y},
+			{X: x + dx, Y: c.Min.Y},
+			{X: x + dx, Y: c.Max.Y},
+			{X: x, Y: c.Max.Y},
+		})
+	}
+}
+
+// ColorBarPlot displays a plot with a vertical color bar on its right side.
+type ColorBarPlot struct {
+	Plot *Plot // main plot
+	Bar  *Plot // plot holding the color bar
+
+	// Width is the fraction of the total width
+	// used by the color bar. (default: 0.15)
+	Width float64
+}
+
+// NewColorBarPlot returns a new plot with the given color bar.
+//
+// The color bar is drawn vertically, and its y-axis uses
+// a logarithmic scale if the color bar has a logarithmic mapping.
+func NewColorBarPlot(cb *ColorBar) (*ColorBarPlot, error) {
+	p, err := New()
+	if err != nil {
+		return nil, err
+	}
+
+	bar, err := New()
+	if err != nil {
+		return nil, err
+	}
+
+	cb.Vertical = true
+	bar.HideX()
+	bar.X.Padding = 0
+	bar.Y.Padding = 0
+	if cb.LogZ {
+		bar.Y.Scale = plot.LogScale{}
+		bar.Y.Tick.Marker = plot.LogTicks{}
+	}
+	bar.Add(cb)
+
+	return &ColorBarPlot{Plot: p, Bar: bar, Width: 0.15}, nil
+}
+
+// Draw draws the plot and its color bar to a draw.Canvas.
+func (cp *ColorBarPlot) Draw(c draw.Canvas) {
+	width := cp.Width
+	if width <= 0 || width >= 1 {
+		width = 0.15
+	}
+
+	w := c.Max.X - c.Min.X
+	cplt := draw.Crop(c, 0, -vg.Length(width)*w, 0, 0)
+	cbar := draw.Crop(c, vg.Length(1-width)*w, 0, 0, 0)
+
+	cs := alignY([]*Plot{cp.Plot, cp.Bar}, []draw.Canvas{cplt, cbar})
+	cp.Plot.Draw(cs[0])
+	cp.Bar.Draw(cs[1])
+}
+
+// Save saves the plot and its color bar to an image file.
+// The file format is determined by the extension.
+//
+// Supported extensions are the same ones than hplot.Plot.Save.
+//
+// If w or h are <= 0, the value is chosen such that it follows the Golden Ratio.
+// If w and h are <= 0, the values are chosen such that they follow the Golden Ratio
+// (the width is defaulted to vgimg.DefaultWidth).
+func (cp *ColorBarPlot) Save(w, h vg.Length, file string) error {
+	return save(cp, w, h, file)
+}
+
+// WriterTo returns an io.WriterTo that will write the plot and its color bar
+// as the specified image format.
+//
+// Supported formats are the same ones than hplot.Plot.WriterTo
+func (cp *ColorBarPlot) WriterTo(w, h vg.Length, format string) (io.WriterTo, error) {
+	return writerTo(cp, w, h, format)
+}
+
+// Apply applies the style to the plot and to its color bar.
+func (cp *ColorBarPlot) Apply(sty Style) error {
+	for _, p := range []*Plot{cp.Plot, cp.Bar} {
+		err := p.Apply(sty)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// alignY crops the canvases of the given plots so that
+// the data areas of all the plots share the same vertical extent.
+func alignY(ps []*Plot, cs []draw.Canvas) []draw.Canvas {
+	var (
+		bottom vg.Length
+		top    vg.Length
+		das    = make([]draw.Canvas, len(ps))
+	)
+	for i, p := range ps {
+		das[i] = p.DataCanvas(cs[i])
+		bottom = vgMax(bottom, das[i].Min.Y-cs[i].Min.Y)
+		top = vgMax(top, cs[i].Max.Y-das[i].Max.Y)
+	}
+	out := make([]draw.Canvas, len(cs))
+	for i := range cs {
+		db := bottom - (das[i].Min.Y - cs[i].Min.Y)
+		dt := top - (cs[i].Max.Y - das[i].Max.Y)
+		out[i] = draw.Crop(cs[i], 0, 0, db, -dt)
+	}
+	return out
+}
+
+var (
+	_ plot.Plotter     = (*ColorBar)(nil)
+	_ plot.DataRanger  = (*ColorBar)(nil)
+	_ plot.Thumbnailer = (*ColorBar)(nil)
+)
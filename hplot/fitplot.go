@@ -0,0 +1,263 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hplot
+
+import (
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+
+	"go-hep.org/x/hep/fit"
+	"go-hep.org/x/hep/hbook"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/optimize"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// ResidualKind describes the quantity displayed in the
+// residual panel of a FitPlot.
+type ResidualKind int
+
+const (
+	Pulls     ResidualKind = iota // (data-fit)/error
+	Residuals                     // data-fit
+)
+
+// FitPlot displays a histogram together with the result of a fit.
+//
+// The data points, the fitted curve and the fitted parameters are
+// drawn in a main panel.
+// The residuals (or pulls) of the fit are drawn in a sub-panel,
+// sharing the x-axis of the main panel.
+type FitPlot struct {
+	Main *Plot // main panel
+	Sub  *Plot // residual panel
+
+	// Ratio is the fraction of the total height
+	// used by the residual panel. (default: 0.3)
+	Ratio float64
+
+	Data      *S2D              // data points with their error bars
+	Curve     *plotter.Function // fitted curve
+	Residuals *S2D              // residuals or pulls of the fit
+	Infos     *FitInfos         // fitted parameters
+
+	res *optimize.Result
+}
+
+// NewFitPlot returns a plot of the histogram h fitted with the function f,
+// where res is the result of the fit.
+//
+// The x-axis of the main panel is not labeled: the label of the x-axis
+// should be set on the residual panel.
+func NewFitPlot(h *hbook.H1D, f fit.Func1D, res *optimize.Result, kind ResidualKind) (*FitPlot, error) {
+	main, err := New()
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := New()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		fct   = func(x float64) float64 { return f.F(x, res.X) }
+		bins  = h.Binning().Bins()
+		data  = make([]hbook.Point2D, 0, len(bins))
+		resid = make([]hbook.Point2D, 0, len(bins))
+		chi2  = 0.0
+	)
+	for _, bin := range bins {
+		if bin.Entries() <= 0 {
+			continue
+		}
+		var (
+			x    = bin.XMid()
+			y    = bin.SumW()
+			err  = bin.ErrW()
+			dx   = 0.5 * bin.XWidth()
+			diff = y - fct(x)
+		)
+		data = append(data, hbook.Point2D{
+			X: x, Y: y,
+			ErrX: hbook.Range{Min: dx, Max: dx},
+			ErrY: hbook.Range{Min: err, Max: err},
+		})
+		if err > 0 {
+			chi2 += diff * diff / (err * err)
+		}
+		switch kind {
+		case Pulls:
+			if err <= 0 {
+				continue
+			}
+			resid = append(resid, hbook.Point2D{X: x, Y: diff / err})
+		case Residuals:
+			resid = append(resid, hbook.Point2D{
+				X: x, Y: diff,
+				ErrY: hbook.Range{Min: err, Max: err},
+			})
+		}
+	}
+
+	fp := &FitPlot{
+		Main:  main,
+		Sub:   sub,
+		Ratio: 0.3,
+		Data:  NewS2D(hbook.NewS2D(data...), WithXErrBars|WithYErrBars),
+		Curve: plotter.NewFunction(fct),
+		Infos: &FitInfos{
+			Values: res.X,
+			Errors: fitErrors(res),
+			Chi2:   chi2,
+			NDF:    len(data) - len(res.X),
+		},
+		res: res,
+	}
+	fp.Data.GlyphStyle.Shape = draw.CircleGlyph{}
+	fp.Curve.Color = color.RGBA{R: 255, A: 255}
+	fp.Curve.Width = vg.Points(1.5)
+	fp.Curve.Samples = 1000
+
+	switch kind {
+	case Pulls:
+		fp.Residuals = NewS2D(hbook.NewS2D(resid...))
+		sub.Y.Label.Text = "Pull"
+	case Residuals:
+		fp.Residuals = NewS2D(hbook.NewS2D(resid...), WithYErrBars)
+		sub.Y.Label.Text = "Data-Fit"
+	}
+	fp.Residuals.GlyphStyle.Shape = draw.CircleGlyph{}
+
+	main.X.Min = h.XMin()
+	main.X.Max = h.XMax()
+	main.X.Tick.Label.Color = color.Transparent
+	main.Add(fp.Data, fp.Curve, fp.Infos)
+	main.Legend.Add("data", fp.Data)
+	main.Legend.Add("fit", fp.Curve)
+	main.Legend.Top = true
+	main.Legend.Left = true
+
+	zero := plotter.NewFunction(func(float64) float64 { return 0 })
+	zero.Dashes = []vg.Length{vg.Points(2), vg.Points(2)}
+	sub.Add(zero, fp.Residuals)
+	sub.X.Min = h.XMin()
+	sub.X.Max = h.XMax()
+
+	return fp, nil
+}
+
+// AddComponent adds to the main panel the curve of a component of the
+// fitted model, evaluated with the fitted parameters.
+// AddComponent returns the added curve so its style can be modified.
+func (fp *FitPlot) AddComponent(name string, f func(x float64, ps []float64) float64) *plotter.Function {
+	fct := plotter.NewFunction(func(x float64) float64 { return f(x, fp.res.X) })
+	fct.Samples = 200
+	fct.Dashes = []vg.Length{vg.Points(4), vg.Points(2)}
+	fp.Main.Add(fct)
+	fp.Main.Legend.Add(name, fct)
+	return fct
+}
+
+// Draw draws the fit plot to a draw.Canvas.
+func (fp *FitPlot) Draw(c draw.Canvas) {
+	drawPads(c, fp.Main, fp.Sub, fp.Ratio)
+}
+
+// Save saves the fit plot to an image file.
+// The file format is determined by the extension.
+//
+// Supported extensions are the same ones than hplot.Plot.Save.
+//
+// If w or h are <= 0, the value is chosen such that it follows the Golden Ratio.
+// If w and h are <= 0, the values are chosen such that they follow the Golden Ratio
+// (the width is defaulted to vgimg.DefaultWidth).
+func (fp *FitPlot) Save(w, h vg.Length, file string) error {
+	return save(fp, w, h, file)
+}
+
+// WriterTo returns an io.WriterTo that will write the fit plot as
+// the specified image format.
+//
+// Supported formats are the same ones than hplot.Plot.WriterTo
+func (fp *FitPlot) WriterTo(w, h vg.Length, format string) (io.WriterTo, error) {
+	return writerTo(fp, w, h, format)
+}
+
+// FitInfos implements the plot.Plotter interface, drawing the values of
+// the fitted parameters and the goodness of the fit.
+type FitInfos struct {
+	Names  []string  // names of the parameters (default: p0, p1, ...)
+	Values []float64 // values of the parameters
+	Errors []float64 // uncertainties on the parameters, if any
+	Chi2   float64   // chi-square of the fit
+	NDF    int       // number of degrees of freedom of the fit
+
+	// TextStyle is the style of the text of the box.
+	draw.TextStyle
+}
+
+// Plot implements the plot.Plotter interface.
+func (fi *FitInfos) Plot(c draw.Canvas, p *plot.Plot) {
+	sty := fi.TextStyle
+	if sty.Font.Size == 0 {
+		fnt, err := vg.MakeFont(plotter.DefaultFont, plotter.DefaultFontSize)
+		if err != nil {
+			return
+		}
+		sty = draw.TextStyle{Font: fnt}
+	}
+	legend := histLegend{
+		ColWidth:  plotter.DefaultFontSize,
+		TextStyle: sty,
+		Top:       true,
+	}
+	if fi.NDF > 0 {
+		legend.Add("chi2/ndf", fmtFloat(fi.Chi2)+" / "+strconv.Itoa(fi.NDF))
+	}
+	for i, v := range fi.Values {
+		name := "p" + strconv.Itoa(i)
+		if i < len(fi.Names) {
+			name = fi.Names[i]
+		}
+		val := fmtFloat(v)
+		if i < len(fi.Errors) {
+			val += " ± " + fmtFloat(fi.Errors[i])
+		}
+		legend.Add(name, val)
+	}
+	legend.draw(c)
+}
+
+func fmtFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', displayPrecision, 64)
+}
+
+// fitErrors returns the uncertainties on the fitted parameters, from the
+// inverse of the Hessian of the cost function, when available.
+func fitErrors(res *optimize.Result) []float64 {
+	if res.Hessian == nil {
+		return nil
+	}
+	var inv mat.Dense
+	err := inv.Inverse(res.Hessian)
+	if err != nil {
+		return nil
+	}
+	errs := make([]float64, len(res.X))
+	for i := range errs {
+		errs[i] = math.Sqrt(math.Abs(inv.At(i, i)))
+	}
+	return errs
+}
+
+var (
+	_ plot.Plotter = (*FitInfos)(nil)
+)
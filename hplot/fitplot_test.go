@@ -0,0 +1,68 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hplot_test
+
+import (
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"go-hep.org/x/hep/fit"
+	"go-hep.org/x/hep/hbook"
+	"go-hep.org/x/hep/hplot"
+	"gonum.org/v1/gonum/optimize"
+	"gonum.org/v1/plot/vg"
+)
+
+// An example of plotting the result of a fit with a pull panel.
+func ExampleFitPlot(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1234))
+	hist := hbook.NewH1D(40, 0, 10)
+	for i := 0; i < 2000; i++ {
+		hist.Fill(5+0.5*rnd.NormFloat64(), 1)
+	}
+	for i := 0; i < 8000; i++ {
+		if v := rnd.ExpFloat64() / 0.3; v < 10 {
+			hist.Fill(v, 1)
+		}
+	}
+
+	var (
+		sig   = fit.Gaussian{Min: 0, Max: 10}
+		bkg   = fit.Exponential{Min: 0, Max: 10}
+		model = fit.ExtSum{Models: []fit.Model{sig, bkg}}
+		f     = fit.FuncH1D(hist, model, []float64{4.5, 1, -0.1, 1000, 8000})
+	)
+
+	res, err := fit.H1D(hist, f, nil, &optimize.NelderMead{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp, err := hplot.NewFitPlot(hist, f, res, hplot.Pulls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp.Main.Title.Text = "Signal + Background"
+	fp.Main.Y.Label.Text = "Events"
+	fp.Sub.X.Label.Text = "x"
+	fp.Infos.Names = []string{"mu", "sigma", "lambda", "Nsig", "Nbkg"}
+
+	const width = 10.0 / 40
+	bkgc := fp.AddComponent("bkg", func(x float64, ps []float64) float64 {
+		return ps[4] * width * bkg.PDF(x, ps[2:3])
+	})
+	bkgc.Color = color.RGBA{B: 255, A: 255}
+
+	err = fp.Save(15*vg.Centimeter, 15*vg.Centimeter, "testdata/fit_plot.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFitPlot(t *testing.T) {
+	ExampleFitPlot(t)
+	checkPlot(t, "testdata/fit_plot_golden.png")
+}
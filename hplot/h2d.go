@@ -5,11 +5,16 @@
 package hplot
 
 import (
+	"fmt"
+	"image/color"
+	"math"
+
 	"go-hep.org/x/hep/hbook"
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/palette"
 	"gonum.org/v1/plot/palette/brewer"
 	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
 	"gonum.org/v1/plot/vg/draw"
 )
 
@@ -28,6 +33,28 @@ type H2D struct {
 	// the histogram (entries, mean, rms)
 	Infos HInfos
 
+	// LogZ maps the content of the bins to the colors of
+	// the palette with a logarithmic scale.
+	// Bins with a null or negative content are not drawn.
+	LogZ bool
+
+	// Contours are the levels of the contour lines drawn
+	// on top of the heat map.
+	Contours []float64
+
+	// ContourStyle is the style of the contour lines.
+	ContourStyle draw.LineStyle
+
+	// Text displays the content of each non-empty bin
+	// at the center of the bin.
+	Text bool
+
+	// TextStyle is the style of the content of the bins.
+	TextStyle draw.TextStyle
+
+	// TextFormat is the format of the content of the bins. (default: "%g")
+	TextFormat string
+
 	p *plotter.HeatMap
 }
 
@@ -36,15 +63,24 @@ func NewH2D(h *hbook.H2D, p palette.Palette) *H2D {
 	if p == nil {
 		p, _ = brewer.GetPalette(brewer.TypeAny, "RdYlBu", 11)
 	}
+	fnt, _ := vg.MakeFont(plotter.DefaultFont, vg.Points(8))
 	return &H2D{
-		H:       h,
-		Palette: p,
+		H:            h,
+		Palette:      p,
+		ContourStyle: draw.LineStyle{Color: color.Black, Width: vg.Points(1)},
+		TextStyle: draw.TextStyle{
+			Color:  color.Black,
+			Font:   fnt,
+			XAlign: draw.XCenter,
+			YAlign: draw.YCenter,
+		},
+		TextFormat: "%g",
 	}
 }
 
 func (h *H2D) pltr() *plotter.HeatMap {
 	if h.p == nil {
-		h.p = plotter.NewHeatMap(h.H.GridXYZ(), h.Palette)
+		h.p = newHeatMap(h.H.GridXYZ(), h.Palette, h.LogZ)
 	}
 	return h.p
 }
@@ -52,7 +88,14 @@ func (h *H2D) pltr() *plotter.HeatMap {
 // Plot implements the Plotter interface, drawing a line
 // that connects each point in the Line.
 func (h *H2D) Plot(c draw.Canvas, p *plot.Plot) {
+	grid := h.H.GridXYZ()
 	h.pltr().Plot(c, p)
+	if len(h.Contours) > 0 {
+		drawContours(c, p, grid, h.Contours, h.ContourStyle)
+	}
+	if h.Text {
+		drawBinText(c, p, grid, h.TextStyle, h.TextFormat)
+	}
 }
 
 // DataRange implements the DataRange method
@@ -68,7 +111,91 @@ func (h *H2D) GlyphBoxes(p *plot.Plot) []plot.GlyphBox {
 	return h.pltr().GlyphBoxes(p)
 }
 
+// Thumbnail draws the palette of the histogram,
+// implementing the plot.Thumbnailer interface.
+func (h *H2D) Thumbnail(c *draw.Canvas) {
+	h.ColorBar().Thumbnail(c)
+}
+
+// ColorBar returns the color scale of the histogram.
+func (h *H2D) ColorBar() *ColorBar {
+	hm := h.pltr()
+	cb := &ColorBar{
+		Palette:  h.Palette,
+		Min:      hm.Min,
+		Max:      hm.Max,
+		LogZ:     h.LogZ,
+		Vertical: true,
+	}
+	if h.LogZ {
+		cb.Min = math.Pow(10, hm.Min)
+		cb.Max = math.Pow(10, hm.Max)
+	}
+	return cb
+}
+
+// newHeatMap returns a heat map of the grid g.
+// If logz is true, the heat map is built from the decimal logarithm
+// of the positive values of g.
+func newHeatMap(g plotter.GridXYZ, p palette.Palette, logz bool) *plotter.HeatMap {
+	if logz {
+		g = logGridXYZ{g}
+	}
+	hm := plotter.NewHeatMap(g, p)
+	if math.IsInf(hm.Min, 0) || math.IsInf(hm.Max, 0) {
+		// no valid value: nothing will be drawn.
+		hm.Min = 0
+		hm.Max = 1
+	}
+	return hm
+}
+
+// logGridXYZ is a plotter.GridXYZ returning the decimal logarithm of
+// the values of a grid. Null or negative values are mapped to NaN.
+type logGridXYZ struct {
+	plotter.GridXYZ
+}
+
+func (g logGridXYZ) Z(c, r int) float64 {
+	v := g.GridXYZ.Z(c, r)
+	if v <= 0 {
+		return math.NaN()
+	}
+	return math.Log10(v)
+}
+
+// drawContours draws the contour lines of the grid g at the given levels.
+func drawContours(c draw.Canvas, p *plot.Plot, g plotter.GridXYZ, levels []float64, sty draw.LineStyle) {
+	cnt := plotter.NewContour(g, levels, nil)
+	cnt.LineStyles = []draw.LineStyle{sty}
+	cnt.Plot(c, p)
+}
+
+// drawBinText draws the non-null values of the grid g at the center
+// of their bin.
+func drawBinText(c draw.Canvas, p *plot.Plot, g plotter.GridXYZ, sty draw.TextStyle, format string) {
+	if format == "" {
+		format = "%g"
+	}
+	trX, trY := p.Transforms(&c)
+	cols, rows := g.Dims()
+	for i := 0; i < cols; i++ {
+		for j := 0; j < rows; j++ {
+			v := g.Z(i, j)
+			if v == 0 || math.IsNaN(v) {
+				continue
+			}
+			pt := vg.Point{X: trX(g.X(i)), Y: trY(g.Y(j))}
+			if !c.Contains(pt) {
+				continue
+			}
+			c.FillText(sty, pt, fmt.Sprintf(format, v))
+		}
+	}
+}
+
 // check interfaces
 var _ plot.Plotter = (*H2D)(nil)
 var _ plot.DataRanger = (*H2D)(nil)
 var _ plot.GlyphBoxer = (*H2D)(nil)
+var _ plot.Thumbnailer = (*H2D)(nil)
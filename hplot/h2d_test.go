@@ -89,3 +89,76 @@ func TestH2DABCD(t *testing.T) {
 
 	checkPlot(t, "testdata/h2d_plot_abcd_golden.png")
 }
+
+func TestColorBarPlot(t *testing.T) {
+	ExampleColorBarPlot(t)
+	checkPlot(t, "testdata/h2d_colorbar_golden.png")
+}
+
+func ExampleColorBarPlot(t *testing.T) {
+	h := hbook.NewH2D(50, -10, 10, 50, -10, 10)
+
+	const npoints = 100000
+
+	dist, ok := distmv.NewNormal(
+		[]float64{0, 1},
+		mat.NewSymDense(2, []float64{4, 0, 0, 2}),
+		rand.New(rand.NewSource(1234)),
+	)
+	if !ok {
+		t.Fatalf("error creating distmv.Normal")
+	}
+
+	v := make([]float64, 2)
+	for i := 0; i < npoints; i++ {
+		v = dist.Rand(v)
+		h.Fill(v[0], v[1], 1)
+	}
+
+	h2 := hplot.NewH2D(h, nil)
+	h2.LogZ = true
+	h2.Contours = []float64{10, 100, 1000}
+
+	p, err := hplot.NewColorBarPlot(h2.ColorBar())
+	if err != nil {
+		t.Fatalf("error: %v\n", err)
+	}
+	p.Plot.Title.Text = "Hist-2D"
+	p.Plot.X.Label.Text = "x"
+	p.Plot.Y.Label.Text = "y"
+	p.Plot.Add(h2)
+	p.Bar.Y.Label.Text = "Entries"
+
+	err = p.Save(15*vg.Centimeter, 10*vg.Centimeter, "testdata/h2d_colorbar.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestH2DText(t *testing.T) {
+	h := hbook.NewH2D(2, 0, 2, 2, 0, 2)
+	h.Fill(0, 0, 1)
+	h.Fill(1, 0, 2)
+	h.Fill(0, 1, 3)
+	h.Fill(1, 1, 4.5)
+
+	h2 := hplot.NewH2D(h, nil)
+	h2.Text = true
+
+	p, err := hplot.New()
+	if err != nil {
+		t.Fatalf("error: %v\n", err)
+	}
+	p.Title.Text = "Hist-2D"
+	p.X.Label.Text = "x"
+	p.Y.Label.Text = "y"
+	p.Add(h2)
+	p.Legend.Add("h2d", h2)
+
+	err = p.Save(10*vg.Centimeter, 10*vg.Centimeter, "testdata/h2d_text.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkPlot(t, "testdata/h2d_text_golden.png")
+}
@@ -9,9 +9,17 @@
 package hplot // import "go-hep.org/x/hep/hplot"
 
 import (
+	"image/color"
+	"io"
 	"math"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"golang.org/x/exp/shiny/screen"
+	"golang.org/x/mobile/event/key"
+	"golang.org/x/mobile/event/mouse"
+	"golang.org/x/mobile/event/paint"
 
 	"go-hep.org/x/hep/hplot/vgshiny"
 	"gonum.org/v1/plot"
@@ -19,11 +27,15 @@ import (
 	"gonum.org/v1/plot/vg"
 	"gonum.org/v1/plot/vg/draw"
 	"gonum.org/v1/plot/vg/vgimg"
+	"gonum.org/v1/plot/vg/vgtex"
 )
 
 // Plot is the basic type representing a plot.
 type Plot struct {
 	plot.Plot
+
+	style    *Style         // style applied to the plot, if any
+	plotters []plot.Plotter // plotters added with Add
 }
 
 // New returns a new plot with some reasonable
@@ -36,7 +48,7 @@ func New() (*Plot, error) {
 	// p.X.Padding = 0
 	// p.Y.Padding = 0
 	// p.Style = GnuplotStyle{}
-	return &Plot{*p}, nil
+	return &Plot{Plot: *p}, nil
 }
 
 // Add adds a Plotters to the plot.
@@ -60,6 +72,13 @@ func (p *Plot) Add(ps ...plot.Plotter) {
 	}
 
 	p.Plot.Add(ps...)
+	p.plotters = append(p.plotters, ps...)
+}
+
+// Draw draws the plot to a draw.Canvas.
+func (p *Plot) Draw(c draw.Canvas) {
+	p.Plot.Draw(c)
+	p.drawFrame(p.DataCanvas(c))
 }
 
 // Save saves the plot to an image file.  The file format is determined
@@ -67,22 +86,28 @@ func (p *Plot) Add(ps ...plot.Plotter) {
 //
 // Supported extensions are:
 //
-//  .eps, .jpg, .jpeg, .pdf, .png, .svg, .tif and .tiff.
+//  .eps, .jpg, .jpeg, .pdf, .png, .svg, .tex, .tif and .tiff.
+//
+// The .tex format creates a standalone LaTeX document drawing the plot
+// with TikZ: LaTeX markup in the text of the plot is rendered by LaTeX.
 //
 // If w or h are <= 0, the value is chosen such that it follows the Golden Ratio.
 // If w and h are <= 0, the values are chosen such that they follow the Golden Ratio
 // (the width is defaulted to vgimg.DefaultWidth).
-func (p *Plot) Save(w, h vg.Length, file string) (err error) {
-	switch {
-	case w <= 0 && h <= 0:
-		w = vgimg.DefaultWidth
-		h = vgimg.DefaultWidth / math.Phi
-	case w <= 0:
-		w = h * math.Phi
-	case h <= 0:
-		h = w / math.Phi
-	}
-	return p.Plot.Save(w, h, file)
+func (p *Plot) Save(w, h vg.Length, file string) error {
+	return save(p, w, h, file)
+}
+
+// WriterTo returns an io.WriterTo that will write the plot as
+// the specified image format.
+//
+// Supported formats are the same ones than hplot.Plot.Save.
+//
+// If w or h are <= 0, the value is chosen such that it follows the Golden Ratio.
+// If w and h are <= 0, the values are chosen such that they follow the Golden Ratio
+// (the width is defaulted to vgimg.DefaultWidth).
+func (p *Plot) WriterTo(w, h vg.Length, format string) (io.WriterTo, error) {
+	return writerTo(p, w, h, format)
 }
 
 // Show displays the plot to the screen, with the given dimensions.
@@ -109,6 +134,142 @@ func (p *Plot) Show(w, h vg.Length, scr screen.Screen) (*vgshiny.Canvas, error)
 	return c, err
 }
 
+// Explore runs an interactive exploration of the plot, drawn on the
+// canvas c of a window previously created with Show.
+//
+// The mouse wheel zooms in and out around the cursor and dragging with
+// the left button pans the plot.
+// A crosshair displays the data coordinates under the cursor, and the
+// content of the bins of the H1D and H2D of the plot.
+// Pressing 'r' resets the ranges of the axes, 'q' or 'ESC' exits.
+//
+// The plot is drawn again only when its ranges are modified.
+// Explore returns when the exploration is exited.
+func (p *Plot) Explore(c *vgshiny.Canvas) {
+	v := NewView(p, draw.New(c))
+	redraw := func() {
+		c.Clear(color.White)
+		v.Draw()
+		c.Snapshot()
+	}
+	redraw()
+	c.Paint()
+
+	var (
+		drag bool
+		last vg.Point
+	)
+	c.Run(func(e interface{}) bool {
+		switch e := e.(type) {
+		case paint.Event:
+			c.Paint()
+
+		case key.Event:
+			if e.Direction != key.DirPress {
+				return true
+			}
+			switch e.Code {
+			case key.CodeEscape, key.CodeQ:
+				return false
+			case key.CodeR:
+				v.Reset()
+				redraw()
+				c.Paint()
+			}
+
+		case mouse.Event:
+			pt := c.Point(e.X, e.Y)
+			switch {
+			case e.Button == mouse.ButtonWheelUp:
+				v.Zoom(pt, 1.25)
+				redraw()
+			case e.Button == mouse.ButtonWheelDown:
+				v.Zoom(pt, 0.8)
+				redraw()
+			case e.Button == mouse.ButtonLeft && e.Direction == mouse.DirPress:
+				drag = true
+			case e.Button == mouse.ButtonLeft && e.Direction == mouse.DirRelease:
+				drag = false
+			case e.Direction == mouse.DirNone && drag:
+				v.Pan(last, pt)
+				redraw()
+			}
+			last = pt
+			c.Restore()
+			v.DrawCrosshair(pt)
+			c.Paint()
+		}
+		return true
+	})
+}
+
+// drawer is the interface implemented by composite plots.
+type drawer interface {
+	Draw(c draw.Canvas)
+}
+
+// save saves the drawer d to an image file.
+// The file format is determined by the extension.
+func save(d drawer, w, h vg.Length, file string) (err error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		e := f.Close()
+		if err == nil {
+			err = e
+		}
+	}()
+
+	format := strings.ToLower(filepath.Ext(file))
+	if len(format) != 0 {
+		format = format[1:]
+	}
+	c, err := writerTo(d, w, h, format)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.WriteTo(f)
+	return err
+}
+
+// writerTo returns an io.WriterTo that will write the drawer d as
+// the specified image format.
+func writerTo(d drawer, w, h vg.Length, format string) (io.WriterTo, error) {
+	switch {
+	case w <= 0 && h <= 0:
+		w = vgimg.DefaultWidth
+		h = vgimg.DefaultWidth / math.Phi
+	case w <= 0:
+		w = h * math.Phi
+	case h <= 0:
+		h = w / math.Phi
+	}
+
+	c, err := newFormattedCanvas(w, h, format)
+	if err != nil {
+		return nil, err
+	}
+	d.Draw(draw.New(c))
+	return c, nil
+}
+
+// newFormattedCanvas creates a new vg.CanvasWriterTo with the specified
+// image format.
+//
+// Supported formats are the ones of draw.NewFormattedCanvas and "tex".
+func newFormattedCanvas(w, h vg.Length, format string) (vg.CanvasWriterTo, error) {
+	switch format {
+	case "tex":
+		return vgtex.NewDocument(w, h), nil
+	default:
+		return draw.NewFormattedCanvas(w, h, format)
+	}
+}
+
 // zip zips together 2 slices and implements the plotter.XYer interface.
 type zip struct {
 	x []float64
@@ -0,0 +1,164 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hplot
+
+import (
+	"fmt"
+	"math"
+
+	"go-hep.org/x/hep/hbook"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// HStack implements the plot.Plotter interface,
+// drawing a stack of histograms.
+//
+// The first histogram is drawn at the bottom of the stack,
+// the last one at the top.
+// Each histogram is drawn with its own FillColor and LineStyle.
+type HStack struct {
+	Hs []*H1D // histograms of the stack
+}
+
+// NewHStack returns a new stack of histograms.
+// NewHStack returns an error if the histograms do not share the same binning.
+func NewHStack(hs []*H1D) (*HStack, error) {
+	if len(hs) == 0 {
+		return nil, fmt.Errorf("hplot: empty stack of histograms")
+	}
+	ref := hs[0].Hist.Binning().Bins()
+	for i, h := range hs[1:] {
+		bins := h.Hist.Binning().Bins()
+		if len(bins) != len(ref) {
+			return nil, fmt.Errorf("hplot: histogram #%d has an invalid number of bins (%d != %d)", i+1, len(bins), len(ref))
+		}
+		for j := range bins {
+			if !fuzzyEq(bins[j].XMin(), ref[j].XMin()) || !fuzzyEq(bins[j].XMax(), ref[j].XMax()) {
+				return nil, fmt.Errorf("hplot: histogram #%d has an invalid binning", i+1)
+			}
+		}
+	}
+	return &HStack{Hs: hs}, nil
+}
+
+// Plot implements the plot.Plotter interface, drawing the stack of histograms.
+func (hs *HStack) Plot(c draw.Canvas, p *plot.Plot) {
+	trX, trY := p.Transforms(&c)
+	bins := hs.Hs[0].Hist.Binning().Bins()
+	lo := make([]float64, len(bins))
+	hi := make([]float64, len(bins))
+	for _, h := range hs.Hs {
+		for i, bin := range h.Hist.Binning().Bins() {
+			hi[i] = lo[i] + bin.SumW()
+		}
+
+		upper := stepPoints(bins, hi, trX, trY)
+		lower := stepPoints(bins, lo, trX, trY)
+		if h.FillColor != nil {
+			poly := make([]vg.Point, 0, len(upper)+len(lower))
+			poly = append(poly, upper...)
+			for i := len(lower) - 1; i >= 0; i-- {
+				poly = append(poly, lower[i])
+			}
+			c.FillPolygon(h.FillColor, c.ClipPolygonXY(poly))
+		}
+		upper = append(
+			append([]vg.Point{{X: upper[0].X, Y: lower[0].Y}}, upper...),
+			vg.Point{X: upper[len(upper)-1].X, Y: lower[len(lower)-1].Y},
+		)
+		c.StrokeLines(h.LineStyle, c.ClipLinesXY(upper)...)
+
+		copy(lo, hi)
+	}
+}
+
+// DataRange returns the minimum and maximum X and Y values
+func (hs *HStack) DataRange() (xmin, xmax, ymin, ymax float64) {
+	ymin = math.Inf(+1)
+	ymax = math.Inf(-1)
+	bins := hs.Hs[0].Hist.Binning().Bins()
+	sum := make([]float64, len(bins))
+	for _, h := range hs.Hs {
+		for i, bin := range h.Hist.Binning().Bins() {
+			sum[i] += bin.SumW()
+			ymin = math.Min(ymin, sum[i])
+			ymax = math.Max(ymax, sum[i])
+		}
+	}
+	return bins[0].XMin(), bins[len(bins)-1].XMax(), math.Min(ymin, 0), ymax
+}
+
+// Total returns the sum of the histograms of the stack, as a scatter.
+// Each point of the scatter is located at the middle of its bin,
+// the x-errors span the width of the bin and the y-errors are the
+// statistical uncertainties of the sum.
+func (hs *HStack) Total() *hbook.S2D {
+	bins := hs.Hs[0].Hist.Binning().Bins()
+	pts := make([]hbook.Point2D, len(bins))
+	for i, bin := range bins {
+		dx := 0.5 * bin.XWidth()
+		pts[i] = hbook.Point2D{
+			X:    bin.XMid(),
+			ErrX: hbook.Range{Min: dx, Max: dx},
+		}
+	}
+	sumw2 := make([]float64, len(bins))
+	for _, h := range hs.Hs {
+		for i, bin := range h.Hist.Binning().Bins() {
+			pts[i].Y += bin.SumW()
+			sumw2[i] += bin.SumW2()
+		}
+	}
+	for i := range pts {
+		err := math.Sqrt(sumw2[i])
+		pts[i].ErrY = hbook.Range{Min: err, Max: err}
+	}
+	return hbook.NewS2D(pts...)
+}
+
+// AddLegend adds the histograms of the stack to the legend, with the
+// given names.
+// The entries are added from the top of the stack to its bottom, so the
+// legend is displayed in the same order than the stack.
+func (hs *HStack) AddLegend(l *plot.Legend, names ...string) {
+	n := len(names)
+	if n > len(hs.Hs) {
+		n = len(hs.Hs)
+	}
+	for i := n - 1; i >= 0; i-- {
+		l.Add(names[i], hs.Hs[i])
+	}
+}
+
+// stepPoints returns the outline of a binned distribution with the
+// given heights ys.
+func stepPoints(bins []hbook.Bin1D, ys []float64, trX, trY func(float64) vg.Length) []vg.Point {
+	pts := make([]vg.Point, 0, 2*len(bins))
+	for i, bin := range bins {
+		y := trY(ys[i])
+		pts = append(pts,
+			vg.Point{X: trX(bin.XMin()), Y: y},
+			vg.Point{X: trX(bin.XMax()), Y: y},
+		)
+	}
+	return pts
+}
+
+// fuzzyEq returns true if a and b are equal with a degree of fuzziness
+func fuzzyEq(a, b float64) bool {
+	const tol = 1e-5
+	aa := math.Abs(a)
+	bb := math.Abs(b)
+	absavg := 0.5 * (aa + bb)
+	absdiff := math.Abs(a - b)
+	return (aa < 1e-8 && bb < 1e-8) || absdiff < tol*absavg
+}
+
+var (
+	_ plot.Plotter    = (*HStack)(nil)
+	_ plot.DataRanger = (*HStack)(nil)
+)
@@ -0,0 +1,219 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hplot
+
+import (
+	"image/color"
+	"io"
+	"math"
+
+	"go-hep.org/x/hep/hbook"
+	"gonum.org/v1/gonum/stat/distuv"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// RatioPlot displays two plots sharing the same x-axis: a main plot at
+// the top and a ratio pad at the bottom.
+type RatioPlot struct {
+	Top    *Plot // main plot
+	Bottom *Plot // ratio pad
+
+	// Ratio is the fraction of the total height
+	// used by the ratio pad. (default: 0.3)
+	Ratio float64
+}
+
+// NewRatioPlot returns a new ratio plot.
+//
+// The x-axis of the top plot is not labeled: the label of the x-axis
+// should be set on the ratio pad.
+// A dashed line at y=1 is drawn in the ratio pad.
+func NewRatioPlot() (*RatioPlot, error) {
+	top, err := New()
+	if err != nil {
+		return nil, err
+	}
+
+	bot, err := New()
+	if err != nil {
+		return nil, err
+	}
+
+	top.X.Tick.Label.Color = color.Transparent
+	bot.Y.Label.Text = "Ratio"
+
+	one := plotter.NewFunction(func(float64) float64 { return 1 })
+	one.Dashes = []vg.Length{vg.Points(2), vg.Points(2)}
+	bot.Add(one)
+
+	return &RatioPlot{Top: top, Bottom: bot, Ratio: 0.3}, nil
+}
+
+// Draw draws the ratio plot to a draw.Canvas.
+//
+// The x-range of the ratio pad is set to the one of the top plot.
+func (rp *RatioPlot) Draw(c draw.Canvas) {
+	drawPads(c, rp.Top, rp.Bottom, rp.Ratio)
+}
+
+// Save saves the ratio plot to an image file.
+// The file format is determined by the extension.
+//
+// Supported extensions are the same ones than hplot.Plot.Save.
+//
+// If w or h are <= 0, the value is chosen such that it follows the Golden Ratio.
+// If w and h are <= 0, the values are chosen such that they follow the Golden Ratio
+// (the width is defaulted to vgimg.DefaultWidth).
+func (rp *RatioPlot) Save(w, h vg.Length, file string) error {
+	return save(rp, w, h, file)
+}
+
+// WriterTo returns an io.WriterTo that will write the ratio plot as
+// the specified image format.
+//
+// Supported formats are the same ones than hplot.Plot.WriterTo
+func (rp *RatioPlot) WriterTo(w, h vg.Length, format string) (io.WriterTo, error) {
+	return writerTo(rp, w, h, format)
+}
+
+// drawPads draws the top and bottom plots on the canvas, the bottom one
+// using the given fraction of the height of the canvas.
+// Both plots share the x-axis of the top one.
+func drawPads(c draw.Canvas, top, bot *Plot, ratio float64) {
+	if ratio <= 0 || ratio >= 1 {
+		ratio = 0.3
+	}
+
+	bot.X.Min = top.X.Min
+	bot.X.Max = top.X.Max
+
+	h := c.Max.Y - c.Min.Y
+	ctop := draw.Crop(c, 0, 0, vg.Length(ratio)*h, 0)
+	cbot := draw.Crop(c, 0, 0, 0, -vg.Length(1-ratio)*h)
+
+	cs := alignX([]*Plot{top, bot}, []draw.Canvas{ctop, cbot})
+	top.Draw(cs[0])
+	bot.Draw(cs[1])
+}
+
+// alignX crops the canvases of the given plots so that
+// the data areas of all the plots share the same horizontal extent.
+func alignX(ps []*Plot, cs []draw.Canvas) []draw.Canvas {
+	var (
+		left  vg.Length
+		right vg.Length
+		das   = make([]draw.Canvas, len(ps))
+	)
+	for i, p := range ps {
+		das[i] = p.DataCanvas(cs[i])
+		left = vgMax(left, das[i].Min.X-cs[i].Min.X)
+		right = vgMax(right, cs[i].Max.X-das[i].Max.X)
+	}
+	out := make([]draw.Canvas, len(cs))
+	for i := range cs {
+		dl := left - (das[i].Min.X - cs[i].Min.X)
+		dr := right - (cs[i].Max.X - das[i].Max.X)
+		out[i] = draw.Crop(cs[i], dl, -dr, 0, 0)
+	}
+	return out
+}
+
+func vgMax(a, b vg.Length) vg.Length {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// PoissonS2D returns the content of the bins of a histogram as a scatter,
+// with asymmetric Poisson uncertainties.
+//
+// The uncertainties are the 68.27% central confidence intervals of
+// Garwood. Empty bins are skipped.
+// The x-errors span the width of the bins.
+func PoissonS2D(h *hbook.H1D) *hbook.S2D {
+	bins := h.Binning().Bins()
+	pts := make([]hbook.Point2D, 0, len(bins))
+	for _, bin := range bins {
+		n := bin.SumW()
+		if n <= 0 {
+			continue
+		}
+		var (
+			lo, hi = poissonErrors(n)
+			dx     = 0.5 * bin.XWidth()
+		)
+		pts = append(pts, hbook.Point2D{
+			X: bin.XMid(), Y: n,
+			ErrX: hbook.Range{Min: dx, Max: dx},
+			ErrY: hbook.Range{Min: lo, Max: hi},
+		})
+	}
+	return hbook.NewS2D(pts...)
+}
+
+// poissonErrors returns the lower and upper uncertainties on n,
+// from the Garwood 68.27% central confidence interval.
+func poissonErrors(n float64) (lo, hi float64) {
+	const alpha = 1 - 0.682689492137086
+	if n > 0 {
+		lo = n - 0.5*distuv.ChiSquared{K: 2 * n}.Quantile(0.5*alpha)
+	}
+	hi = 0.5*distuv.ChiSquared{K: 2 * (n + 1)}.Quantile(1-0.5*alpha) - n
+	return lo, hi
+}
+
+// DivideS2D divides the points of num by the points of den with the same
+// x-value, and returns the ratios as a scatter.
+//
+// The y-errors of the ratios are the y-errors of num, divided by the
+// y-values of den: the uncertainties of den are expected to be displayed
+// separately, e.g. with RelErrS2D.
+// Points of num without a matching point of den, or with a null
+// denominator, are skipped.
+func DivideS2D(num, den *hbook.S2D) *hbook.S2D {
+	pts := make([]hbook.Point2D, 0, num.Len())
+	for _, pn := range num.Points() {
+		for _, pd := range den.Points() {
+			if !fuzzyEq(pn.X, pd.X) {
+				continue
+			}
+			if pd.Y == 0 {
+				break
+			}
+			pts = append(pts, hbook.Point2D{
+				X: pn.X, Y: pn.Y / pd.Y,
+				ErrX: pn.ErrX,
+				ErrY: hbook.Range{
+					Min: pn.ErrY.Min / math.Abs(pd.Y),
+					Max: pn.ErrY.Max / math.Abs(pd.Y),
+				},
+			})
+			break
+		}
+	}
+	return hbook.NewS2D(pts...)
+}
+
+// RelErrS2D returns the relative y-errors of a scatter, as a scatter
+// of points centered on 1.
+// Points with a null y-value are skipped.
+func RelErrS2D(s *hbook.S2D) *hbook.S2D {
+	pts := make([]hbook.Point2D, 0, s.Len())
+	for _, pt := range s.Points() {
+		if pt.Y == 0 {
+			continue
+		}
+		y := math.Abs(pt.Y)
+		pts = append(pts, hbook.Point2D{
+			X: pt.X, Y: 1,
+			ErrX: pt.ErrX,
+			ErrY: hbook.Range{Min: pt.ErrY.Min / y, Max: pt.ErrY.Max / y},
+		})
+	}
+	return hbook.NewS2D(pts...)
+}
@@ -0,0 +1,204 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hplot_test
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+
+	"go-hep.org/x/hep/hbook"
+	"go-hep.org/x/hep/hplot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// An example of a data/MC plot, with a stack of backgrounds,
+// the uncertainty band of the total background and a ratio pad.
+func ExampleRatioPlot(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1234))
+
+	var (
+		bkg1 = hbook.NewH1D(25, 0, 10)
+		bkg2 = hbook.NewH1D(25, 0, 10)
+		sig  = hbook.NewH1D(25, 0, 10)
+		data = hbook.NewH1D(25, 0, 10)
+	)
+	for i := 0; i < 2000; i++ {
+		bkg1.Fill(rnd.ExpFloat64()/0.3, 1)
+		data.Fill(rnd.ExpFloat64()/0.3, 1)
+	}
+	for i := 0; i < 1000; i++ {
+		bkg2.Fill(5+2*rnd.NormFloat64(), 1)
+		data.Fill(5+2*rnd.NormFloat64(), 1)
+	}
+	for i := 0; i < 200; i++ {
+		sig.Fill(6+0.5*rnd.NormFloat64(), 1)
+		data.Fill(6+0.5*rnd.NormFloat64(), 1)
+	}
+
+	var hs []*hplot.H1D
+	for _, v := range []struct {
+		h   *hbook.H1D
+		col color.Color
+	}{
+		{bkg1, color.RGBA{R: 255, G: 204, B: 0, A: 255}},
+		{bkg2, color.RGBA{R: 102, G: 153, B: 255, A: 255}},
+		{sig, color.RGBA{R: 255, G: 80, B: 80, A: 255}},
+	} {
+		h, err := hplot.NewH1D(v.h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.FillColor = v.col
+		hs = append(hs, h)
+	}
+
+	stack, err := hplot.NewHStack(hs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		total = stack.Total()
+		band  = hplot.NewBinnedErrBand(total)
+		pts   = hplot.PoissonS2D(data)
+		dpts  = hplot.NewS2D(pts, hplot.WithXErrBars|hplot.WithYErrBars)
+	)
+	dpts.GlyphStyle.Shape = draw.CircleGlyph{}
+
+	rp, err := hplot.NewRatioPlot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp.Top.Title.Text = "Data/MC"
+	rp.Top.Y.Label.Text = "Events"
+	rp.Top.Add(stack, band, dpts)
+	rp.Top.Legend.Add("data", dpts)
+	stack.AddLegend(&rp.Top.Legend, "bkg-1", "bkg-2", "signal")
+	rp.Top.Legend.Add("uncertainty", band)
+	rp.Top.Legend.Top = true
+
+	var (
+		rband  = hplot.NewBinnedErrBand(hplot.RelErrS2D(total))
+		rpts   = hplot.NewS2D(hplot.DivideS2D(pts, total), hplot.WithYErrBars)
+		ymin   = 0.5
+		ymax   = 1.5
+		bottom = rp.Bottom
+	)
+	rpts.GlyphStyle.Shape = draw.CircleGlyph{}
+	bottom.Add(rband, rpts)
+	bottom.X.Label.Text = "x"
+	bottom.Y.Label.Text = "Data/MC"
+	bottom.Y.Min = ymin
+	bottom.Y.Max = ymax
+
+	err = rp.Save(15*vg.Centimeter, 15*vg.Centimeter, "testdata/ratio_plot.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRatioPlot(t *testing.T) {
+	ExampleRatioPlot(t)
+	checkPlot(t, "testdata/ratio_plot_golden.png")
+}
+
+func TestHStack(t *testing.T) {
+	var (
+		h1 = hbook.NewH1D(10, 0, 10)
+		h2 = hbook.NewH1D(10, 0, 10)
+		h3 = hbook.NewH1D(5, 0, 10)
+	)
+	for i := 0; i < 10; i++ {
+		h1.Fill(float64(i)+0.5, 1)
+		h1.Fill(float64(i)+0.5, 1)
+		h2.Fill(float64(i)+0.5, 2)
+	}
+
+	p1, _ := hplot.NewH1D(h1)
+	p2, _ := hplot.NewH1D(h2)
+	p3, _ := hplot.NewH1D(h3)
+
+	_, err := hplot.NewHStack([]*hplot.H1D{p1, p3})
+	if err == nil {
+		t.Fatalf("expected an error for invalid binnings")
+	}
+
+	stack, err := hplot.NewHStack([]*hplot.H1D{p1, p2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xmin, xmax, ymin, ymax := stack.DataRange()
+	if xmin != 0 || xmax != 10 || ymin != 0 || ymax != 4 {
+		t.Fatalf("invalid data range: [%v, %v]x[%v, %v]", xmin, xmax, ymin, ymax)
+	}
+
+	for i, pt := range stack.Total().Points() {
+		if got, want := pt.Y, 4.0; got != want {
+			t.Fatalf("point %d: invalid y: got=%v, want=%v", i, got, want)
+		}
+		if got, want := pt.ErrY.Min, math.Sqrt(6); got != want {
+			t.Fatalf("point %d: invalid y-error: got=%v, want=%v", i, got, want)
+		}
+	}
+}
+
+func TestPoissonS2D(t *testing.T) {
+	h := hbook.NewH1D(3, 0, 3)
+	h.Fill(1.5, 1)
+	for i := 0; i < 10; i++ {
+		h.Fill(2.5, 1)
+	}
+
+	s := hplot.PoissonS2D(h)
+	if got, want := s.Len(), 2; got != want {
+		t.Fatalf("invalid number of points: got=%d, want=%d", got, want)
+	}
+	for i, want := range []hbook.Range{
+		{Min: 0.8274, Max: 2.2996},
+		{Min: 3.1087, Max: 4.2669},
+	} {
+		got := s.Point(i).ErrY
+		if math.Abs(got.Min-want.Min) > 1e-3 || math.Abs(got.Max-want.Max) > 1e-3 {
+			t.Fatalf("point %d: invalid errors: got=%v, want=%v", i, got, want)
+		}
+	}
+}
+
+func TestDivideS2D(t *testing.T) {
+	var (
+		num = hbook.NewS2D(
+			hbook.Point2D{X: 1, Y: 2, ErrY: hbook.Range{Min: 1, Max: 2}},
+			hbook.Point2D{X: 2, Y: 4, ErrY: hbook.Range{Min: 1, Max: 1}},
+			hbook.Point2D{X: 3, Y: 4, ErrY: hbook.Range{Min: 1, Max: 1}},
+		)
+		den = hbook.NewS2D(
+			hbook.Point2D{X: 1, Y: 4, ErrY: hbook.Range{Min: 2, Max: 2}},
+			hbook.Point2D{X: 2, Y: 0},
+			hbook.Point2D{X: 4, Y: 1},
+		)
+	)
+
+	ratio := hplot.DivideS2D(num, den)
+	if got, want := ratio.Len(), 1; got != want {
+		t.Fatalf("invalid number of points: got=%d, want=%d", got, want)
+	}
+	want := hbook.Point2D{X: 1, Y: 0.5, ErrY: hbook.Range{Min: 0.25, Max: 0.5}}
+	if got := ratio.Point(0); got != want {
+		t.Fatalf("invalid ratio: got=%v, want=%v", got, want)
+	}
+
+	rel := hplot.RelErrS2D(den)
+	if got, want := rel.Len(), 2; got != want {
+		t.Fatalf("invalid number of points: got=%d, want=%d", got, want)
+	}
+	want = hbook.Point2D{X: 1, Y: 1, ErrY: hbook.Range{Min: 0.5, Max: 0.5}}
+	if got := rel.Point(0); got != want {
+		t.Fatalf("invalid relative errors: got=%v, want=%v", got, want)
+	}
+}
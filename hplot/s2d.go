@@ -165,5 +165,17 @@ func (pts *S2D) GlyphBoxes(plt *plot.Plot) []plot.GlyphBox {
 // Thumbnail the thumbnail for the Scatter,
 // implementing the plot.Thumbnailer interface.
 func (pts *S2D) Thumbnail(c *draw.Canvas) {
+	if pts.ybars != nil {
+		sty := pts.ybars.LineStyle
+		sty.Color = pts.GlyphStyle.Color
+		x := c.Center().X
+		c.StrokeLine2(sty, x, c.Min.Y, x, c.Max.Y)
+	}
+	if pts.xbars != nil {
+		sty := pts.xbars.LineStyle
+		sty.Color = pts.GlyphStyle.Color
+		y := c.Center().Y
+		c.StrokeLine2(sty, c.Min.X, y, c.Max.X, y)
+	}
 	c.DrawGlyph(pts.GlyphStyle, c.Center())
 }
@@ -0,0 +1,286 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hplot
+
+import (
+	"image/color"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// Style describes the look of a plot: fonts, axes and ticks.
+//
+// A Style is applied to a plot with Plot.Apply.
+// Composite plots (TiledPlot, RatioPlot, FitPlot) apply the style
+// to all their sub-plots.
+type Style struct {
+	Name string // name of the style
+
+	Font       string // name of the font of the text of the plot
+	BoldFont   string // name of the font of the experiment name
+	StatusFont string // name of the font of the status of the plot
+
+	TitleSize     vg.Length // size of the font of the title
+	LabelSize     vg.Length // size of the font of the axes labels and legend
+	TickLabelSize vg.Length // size of the font of the tick labels
+
+	LineWidth  vg.Length // width of the axes and ticks lines
+	TickLength vg.Length // length of the major ticks (minor ticks are half as long)
+
+	// Frame draws a frame around the data area, with the ticks
+	// drawn inside the frame on all four sides.
+	Frame bool
+
+	// Experiment is the name of the experiment, displayed by ExpLabel.
+	Experiment string
+}
+
+var (
+	// ATLASStyle is a style similar to the one of the ATLAS experiment.
+	ATLASStyle = Style{
+		Name:          "ATLAS",
+		Font:          "Helvetica",
+		BoldFont:      "Helvetica-BoldOblique",
+		StatusFont:    "Helvetica",
+		TitleSize:     vg.Points(14),
+		LabelSize:     vg.Points(12),
+		TickLabelSize: vg.Points(11),
+		LineWidth:     vg.Points(1),
+		TickLength:    vg.Points(8),
+		Frame:         true,
+		Experiment:    "ATLAS",
+	}
+
+	// CMSStyle is a style similar to the one of the CMS experiment.
+	CMSStyle = Style{
+		Name:          "CMS",
+		Font:          "Helvetica",
+		BoldFont:      "Helvetica-Bold",
+		StatusFont:    "Helvetica-Oblique",
+		TitleSize:     vg.Points(14),
+		LabelSize:     vg.Points(12),
+		TickLabelSize: vg.Points(10),
+		LineWidth:     vg.Points(1),
+		TickLength:    vg.Points(7),
+		Frame:         true,
+		Experiment:    "CMS",
+	}
+
+	// LHCbStyle is a style similar to the one of the LHCb experiment.
+	LHCbStyle = Style{
+		Name:          "LHCb",
+		Font:          "Times-Roman",
+		BoldFont:      "Times-Roman",
+		StatusFont:    "Times-Roman",
+		TitleSize:     vg.Points(14),
+		LabelSize:     vg.Points(14),
+		TickLabelSize: vg.Points(12),
+		LineWidth:     vg.Points(1.5),
+		TickLength:    vg.Points(9),
+		Frame:         true,
+		Experiment:    "LHCb",
+	}
+)
+
+// Apply applies the style to the plot.
+func (p *Plot) Apply(sty Style) error {
+	title, err := vg.MakeFont(sty.Font, sty.TitleSize)
+	if err != nil {
+		return err
+	}
+	label, err := vg.MakeFont(sty.Font, sty.LabelSize)
+	if err != nil {
+		return err
+	}
+	tick, err := vg.MakeFont(sty.Font, sty.TickLabelSize)
+	if err != nil {
+		return err
+	}
+
+	p.Title.Font = title
+	p.Legend.Font = label
+	for _, axis := range []*plot.Axis{&p.X, &p.Y} {
+		axis.Label.Font = label
+		axis.Tick.Label.Font = tick
+		axis.LineStyle.Width = sty.LineWidth
+		axis.Tick.LineStyle.Width = sty.LineWidth
+		axis.Tick.Length = sty.TickLength
+		if sty.Frame {
+			// ticks and axes lines are drawn by the frame.
+			axis.LineStyle.Width = 0
+			axis.Tick.Length = 0
+			axis.Padding = sty.TickLength / 2
+		}
+	}
+	if sty.Frame {
+		p.Legend.XOffs = -sty.TickLength
+		p.Legend.YOffs = -sty.TickLength
+	}
+
+	p.style = &sty
+	return nil
+}
+
+// drawFrame draws a frame around the data canvas c of the plot,
+// with ticks drawn inside the frame.
+func (p *Plot) drawFrame(c draw.Canvas) {
+	if p.style == nil || !p.style.Frame {
+		return
+	}
+
+	var (
+		sty = draw.LineStyle{Color: color.Black, Width: p.style.LineWidth}
+		n   = p.style.TickLength
+	)
+
+	for _, t := range p.X.Tick.Marker.Ticks(p.X.Min, p.X.Max) {
+		x := c.X(p.X.Norm(t.Value))
+		if !c.ContainsX(x) {
+			continue
+		}
+		l := n
+		if t.IsMinor() {
+			l /= 2
+		}
+		c.StrokeLine2(sty, x, c.Min.Y, x, c.Min.Y+l)
+		c.StrokeLine2(sty, x, c.Max.Y, x, c.Max.Y-l)
+	}
+
+	for _, t := range p.Y.Tick.Marker.Ticks(p.Y.Min, p.Y.Max) {
+		y := c.Y(p.Y.Norm(t.Value))
+		if !c.ContainsY(y) {
+			continue
+		}
+		l := n
+		if t.IsMinor() {
+			l /= 2
+		}
+		c.StrokeLine2(sty, c.Min.X, y, c.Min.X+l, y)
+		c.StrokeLine2(sty, c.Max.X, y, c.Max.X-l, y)
+	}
+
+	c.StrokeLine2(sty, c.Min.X, c.Min.Y, c.Max.X, c.Min.Y)
+	c.StrokeLine2(sty, c.Max.X, c.Min.Y, c.Max.X, c.Max.Y)
+	c.StrokeLine2(sty, c.Max.X, c.Max.Y, c.Min.X, c.Max.Y)
+	c.StrokeLine2(sty, c.Min.X, c.Max.Y, c.Min.X, c.Min.Y)
+}
+
+// Apply applies the style to all the plots of the set of tiles.
+func (tp *TiledPlot) Apply(sty Style) error {
+	for _, p := range tp.Plots {
+		if p == nil {
+			continue
+		}
+		err := p.Apply(sty)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply applies the style to both plots of the ratio plot.
+func (rp *RatioPlot) Apply(sty Style) error {
+	for _, p := range []*Plot{rp.Top, rp.Bottom} {
+		err := p.Apply(sty)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply applies the style to both panels of the fit plot.
+func (fp *FitPlot) Apply(sty Style) error {
+	for _, p := range []*Plot{fp.Main, fp.Sub} {
+		err := p.Apply(sty)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpLabel implements the plot.Plotter interface, drawing the label of
+// an experiment (e.g. "ATLAS Preliminary") and additional lines of text
+// (e.g. the center-of-mass energy and the integrated luminosity) inside
+// the data area of a plot.
+type ExpLabel struct {
+	Experiment string   // name of the experiment
+	Status     string   // status of the plot (e.g. "Preliminary", "Simulation")
+	Lines      []string // additional lines of text
+
+	// X and Y are the position of the top-left corner of the label,
+	// in normalized coordinates of the data area. (default: 0.05, 0.95)
+	X, Y float64
+
+	ExpStyle    draw.TextStyle // style of the name of the experiment
+	StatusStyle draw.TextStyle // style of the status of the plot
+	TextStyle   draw.TextStyle // style of the additional lines of text
+}
+
+// NewExpLabel returns a new experiment label, using the fonts and the
+// experiment name of the given style.
+func NewExpLabel(sty Style, status string, lines ...string) (*ExpLabel, error) {
+	exp, err := vg.MakeFont(sty.BoldFont, sty.LabelSize)
+	if err != nil {
+		return nil, err
+	}
+	st, err := vg.MakeFont(sty.StatusFont, sty.LabelSize)
+	if err != nil {
+		return nil, err
+	}
+	txt, err := vg.MakeFont(sty.Font, sty.LabelSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExpLabel{
+		Experiment:  sty.Experiment,
+		Status:      status,
+		Lines:       lines,
+		X:           0.05,
+		Y:           0.95,
+		ExpStyle:    draw.TextStyle{Color: color.Black, Font: exp, YAlign: draw.YTop},
+		StatusStyle: draw.TextStyle{Color: color.Black, Font: st, YAlign: draw.YTop},
+		TextStyle:   draw.TextStyle{Color: color.Black, Font: txt, YAlign: draw.YTop},
+	}, nil
+}
+
+// Plot implements the plot.Plotter interface.
+func (l *ExpLabel) Plot(c draw.Canvas, p *plot.Plot) {
+	var (
+		x = c.X(l.X)
+		y = c.Y(l.Y)
+	)
+
+	if l.Experiment != "" || l.Status != "" {
+		h := vg.Length(0)
+		if l.Experiment != "" {
+			c.FillText(l.ExpStyle, vg.Point{X: x, Y: y}, l.Experiment)
+			h = l.ExpStyle.Height(l.Experiment)
+		}
+		if l.Status != "" {
+			xs := x
+			if l.Experiment != "" {
+				xs += l.ExpStyle.Width(l.Experiment + " ")
+			}
+			c.FillText(l.StatusStyle, vg.Point{X: xs, Y: y}, l.Status)
+			h = vgMax(h, l.StatusStyle.Height(l.Status))
+		}
+		y -= 1.2 * h
+	}
+
+	for _, line := range l.Lines {
+		c.FillText(l.TextStyle, vg.Point{X: x, Y: y}, line)
+		y -= 1.2 * l.TextStyle.Height(line)
+	}
+}
+
+var (
+	_ plot.Plotter = (*ExpLabel)(nil)
+)
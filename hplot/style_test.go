@@ -0,0 +1,122 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hplot_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-hep.org/x/hep/hbook"
+	"go-hep.org/x/hep/hplot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// An example of applying an experiment style to a set of tiled plots.
+func ExampleStyle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1234))
+
+	tp, err := hplot.NewTiledPlot(draw.Tiles{Cols: 2, Rows: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, sigma := range []float64{1, 2} {
+		hist := hbook.NewH1D(20, -5, +5)
+		for j := 0; j < 5000; j++ {
+			hist.Fill(sigma*rnd.NormFloat64(), 1)
+		}
+		h, err := hplot.NewH1D(hist)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.FillColor = nil
+
+		p := tp.Plot(0, i)
+		p.X.Label.Text = "x [GeV]"
+		p.Y.Label.Text = "Events"
+		p.Add(h)
+		p.Y.Min = 0
+		p.Y.Max *= 1.4
+	}
+
+	err = tp.Apply(hplot.ATLASStyle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lbl, err := hplot.NewExpLabel(hplot.ATLASStyle, "Preliminary", "√s = 13 TeV, 36.1 fb-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp.Plot(0, 0).Add(lbl)
+
+	err = tp.Save(20*vg.Centimeter, 10*vg.Centimeter, "testdata/style_plot.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStyle(t *testing.T) {
+	ExampleStyle(t)
+	checkPlot(t, "testdata/style_plot_golden.png")
+}
+
+func TestSaveFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hplot-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p, err := hplot.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Title.Text = `$\sqrt{s} = 13$ TeV`
+	p.X.Label.Text = `$p_T$ [GeV]`
+	err = p.Apply(hplot.CMSStyle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Add(hplot.NewFunction(func(x float64) float64 { return x * x }))
+	p.X.Min = 0
+	p.X.Max = 10
+	p.Y.Min = 0
+	p.Y.Max = 100
+
+	for _, tc := range []struct {
+		ext  string
+		want []byte
+	}{
+		{".tex", []byte(`\documentclass{standalone}`)},
+		{".pdf", []byte("%PDF")},
+		{".eps", []byte("PS-Adobe")},
+		{".svg", []byte("<svg")},
+	} {
+		t.Run(tc.ext, func(t *testing.T) {
+			fname := filepath.Join(dir, "plot"+tc.ext)
+			err := p.Save(10*vg.Centimeter, -1, fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw, err := ioutil.ReadFile(fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(raw, tc.want) {
+				t.Fatalf("invalid %s file: missing %q", tc.ext, tc.want)
+			}
+		})
+	}
+
+	if _, err := p.WriterTo(-1, -1, "invalid"); err == nil {
+		t.Fatalf("expected an error for an invalid format")
+	}
+}
@@ -7,9 +7,6 @@ package hplot
 import (
 	"io"
 	"math"
-	"os"
-	"path/filepath"
-	"strings"
 
 	"golang.org/x/exp/shiny/screen"
 
@@ -89,40 +86,8 @@ func (tp *TiledPlot) Draw(c draw.Canvas) {
 // If w or h are <= 0, the value is chosen such that it follows the Golden Ratio.
 // If w and h are <= 0, the values are chosen such that they follow the Golden Ratio
 // (the width is defaulted to vgimg.DefaultWidth).
-func (tp *TiledPlot) Save(w, h vg.Length, file string) (err error) {
-	switch {
-	case w <= 0 && h <= 0:
-		w = vgimg.DefaultWidth
-		h = vgimg.DefaultWidth / math.Phi
-	case w <= 0:
-		w = h * math.Phi
-	case h <= 0:
-		h = w / math.Phi
-	}
-
-	f, err := os.Create(file)
-	if err != nil {
-		return err
-	}
-
-	defer func() {
-		e := f.Close()
-		if err == nil {
-			err = e
-		}
-	}()
-
-	format := strings.ToLower(filepath.Ext(file))
-	if len(format) != 0 {
-		format = format[1:]
-	}
-	c, err := tp.WriterTo(w, h, format)
-	if err != nil {
-		return err
-	}
-
-	_, err = c.WriteTo(f)
-	return err
+func (tp *TiledPlot) Save(w, h vg.Length, file string) error {
+	return save(tp, w, h, file)
 }
 
 // WriterTo returns an io.WriterTo that will write the plots as
@@ -134,22 +99,7 @@ func (tp *TiledPlot) Save(w, h vg.Length, file string) (err error) {
 // If w and h are <= 0, the values are chosen such that they follow the Golden Ratio
 // (the width is defaulted to vgimg.DefaultWidth).
 func (tp *TiledPlot) WriterTo(w, h vg.Length, format string) (io.WriterTo, error) {
-	switch {
-	case w <= 0 && h <= 0:
-		w = vgimg.DefaultWidth
-		h = vgimg.DefaultWidth / math.Phi
-	case w <= 0:
-		w = h * math.Phi
-	case h <= 0:
-		h = w / math.Phi
-	}
-
-	c, err := draw.NewFormattedCanvas(w, h, format)
-	if err != nil {
-		return nil, err
-	}
-	tp.Draw(draw.New(c))
-	return c, nil
+	return writerTo(tp, w, h, format)
 }
 
 // Show displays the plots to the screen, with the given dimensions.
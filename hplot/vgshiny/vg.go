@@ -25,7 +25,8 @@ type Canvas struct {
 	win screen.Window
 	buf screen.Buffer
 
-	img draw.Image
+	img  draw.Image
+	snap *image.RGBA // snapshot of img, see Snapshot
 }
 
 // New creates a new canvas with the given width and height.
@@ -70,6 +71,41 @@ func (c *Canvas) Paint() screen.PublishResult {
 	return c.win.Publish()
 }
 
+// Clear fills the canvas with the given color.
+func (c *Canvas) Clear(col color.Color) {
+	draw.Draw(c.img, c.img.Bounds(), image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+// Snapshot saves the current content of the canvas.
+// The content can then be restored with Restore, e.g. to draw transient
+// decorations on top of a plot without drawing the plot again.
+func (c *Canvas) Snapshot() {
+	if c.snap == nil {
+		c.snap = image.NewRGBA(c.img.Bounds())
+	}
+	draw.Draw(c.snap, c.snap.Bounds(), c.img, image.Point{}, draw.Src)
+}
+
+// Restore restores the content of the canvas saved by the last call to Snapshot.
+// Restore is a no-op if Snapshot was never called.
+func (c *Canvas) Restore() {
+	if c.snap == nil {
+		return
+	}
+	draw.Draw(c.img, c.img.Bounds(), c.snap, image.Point{}, draw.Src)
+}
+
+// Point returns the point of the canvas at the position (x,y) in pixels of
+// the window, as given by mouse events.
+func (c *Canvas) Point(x, y float32) vg.Point {
+	_, h := c.Size()
+	scale := vg.Inch / vg.Length(c.DPI())
+	return vg.Point{
+		X: vg.Length(x) * scale,
+		Y: h - vg.Length(y)*scale,
+	}
+}
+
 // Release releases shiny/screen resources.
 func (c *Canvas) Release() {
 	c.buf.Release()
@@ -0,0 +1,181 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hplot
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// View handles the interactive exploration of a plot drawn on a canvas:
+// zooming, panning and reading the data coordinates under a cursor.
+//
+// Zooming and panning modify the ranges of the axes of the plot,
+// which is then redrawn with Draw.
+type View struct {
+	Plot   *Plot
+	Canvas draw.Canvas // canvas on which the plot is drawn
+
+	// CrossStyle is the style of the lines of the crosshair.
+	CrossStyle draw.LineStyle
+
+	// TextStyle is the style of the coordinates readout.
+	TextStyle draw.TextStyle
+
+	xmin, xmax float64 // initial range of the x-axis
+	ymin, ymax float64 // initial range of the y-axis
+}
+
+// NewView returns a new view of the plot p, drawn on the canvas c.
+func NewView(p *Plot, c draw.Canvas) *View {
+	fnt, _ := vg.MakeFont(plotter.DefaultFont, vg.Points(10))
+	return &View{
+		Plot:   p,
+		Canvas: c,
+		CrossStyle: draw.LineStyle{
+			Color:  color.Gray{Y: 100},
+			Width:  vg.Points(0.5),
+			Dashes: []vg.Length{vg.Points(2), vg.Points(2)},
+		},
+		TextStyle: draw.TextStyle{Color: color.Black, Font: fnt, YAlign: draw.YTop},
+		xmin:      p.X.Min,
+		xmax:      p.X.Max,
+		ymin:      p.Y.Min,
+		ymax:      p.Y.Max,
+	}
+}
+
+// Draw draws the plot on the canvas of the view.
+func (v *View) Draw() {
+	v.Plot.Draw(v.Canvas)
+}
+
+// Reset restores the initial ranges of the axes of the plot.
+func (v *View) Reset() {
+	v.Plot.X.Min, v.Plot.X.Max = v.xmin, v.xmax
+	v.Plot.Y.Min, v.Plot.Y.Max = v.ymin, v.ymax
+}
+
+// DataCoord returns the data coordinates of the point pt of the canvas.
+// DataCoord returns false if pt is outside of the data area of the plot.
+func (v *View) DataCoord(pt vg.Point) (x, y float64, ok bool) {
+	tx, ty := v.norm(pt)
+	x = invNorm(&v.Plot.X, tx)
+	y = invNorm(&v.Plot.Y, ty)
+	ok = 0 <= tx && tx <= 1 && 0 <= ty && ty <= 1
+	return x, y, ok
+}
+
+// Zoom zooms the plot by the given factor, around the point pt of the
+// canvas: the data under pt stays under pt.
+// A factor greater than 1 zooms in, a factor smaller than 1 zooms out.
+func (v *View) Zoom(pt vg.Point, factor float64) {
+	if factor <= 0 {
+		return
+	}
+	tx, ty := v.norm(pt)
+	zoom := func(a *plot.Axis, t float64) {
+		min := invNorm(a, t-t/factor)
+		max := invNorm(a, t+(1-t)/factor)
+		a.Min, a.Max = min, max
+	}
+	zoom(&v.Plot.X, tx)
+	zoom(&v.Plot.Y, ty)
+}
+
+// Pan moves the ranges of the axes of the plot so that
+// the data under the point from of the canvas ends up under the point to.
+func (v *View) Pan(from, to vg.Point) {
+	fx, fy := v.norm(from)
+	tx, ty := v.norm(to)
+	pan := func(a *plot.Axis, dt float64) {
+		min := invNorm(a, -dt)
+		max := invNorm(a, 1-dt)
+		a.Min, a.Max = min, max
+	}
+	pan(&v.Plot.X, tx-fx)
+	pan(&v.Plot.Y, ty-fy)
+}
+
+// Info returns the data coordinates of the point pt of the canvas and
+// the content of the bins under pt, for the H1D and H2D of the plot.
+func (v *View) Info(pt vg.Point) []string {
+	x, y, ok := v.DataCoord(pt)
+	if !ok {
+		return nil
+	}
+	out := []string{fmt.Sprintf("x=%g y=%g", x, y)}
+	for _, p := range v.Plot.plotters {
+		switch p := p.(type) {
+		case *H1D:
+			for _, bin := range p.Hist.Binning().Bins() {
+				if bin.XMin() <= x && x < bin.XMax() {
+					out = append(out, fmt.Sprintf(
+						"bin [%g, %g): %g ± %g",
+						bin.XMin(), bin.XMax(), bin.SumW(), bin.ErrW(),
+					))
+					break
+				}
+			}
+		case *H2D:
+			for _, bin := range p.H.Binning().Bins() {
+				if bin.XMin() <= x && x < bin.XMax() && bin.YMin() <= y && y < bin.YMax() {
+					out = append(out, fmt.Sprintf(
+						"bin [%g, %g)x[%g, %g): %g",
+						bin.XMin(), bin.XMax(), bin.YMin(), bin.YMax(), bin.SumW(),
+					))
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// DrawCrosshair draws a crosshair at the point pt of the canvas, with
+// the readout of Info at the top left corner of the data area.
+// Nothing is drawn if pt is outside of the data area of the plot.
+func (v *View) DrawCrosshair(pt vg.Point) {
+	lines := v.Info(pt)
+	if lines == nil {
+		return
+	}
+	c := v.Plot.DataCanvas(v.Canvas)
+	c.StrokeLine2(v.CrossStyle, c.Min.X, pt.Y, c.Max.X, pt.Y)
+	c.StrokeLine2(v.CrossStyle, pt.X, c.Min.Y, pt.X, c.Max.Y)
+
+	pad := vg.Points(4)
+	pos := vg.Point{X: c.Min.X + pad, Y: c.Max.Y - pad}
+	for _, txt := range lines {
+		c.FillText(v.TextStyle, pos, txt)
+		pos.Y -= 1.2 * v.TextStyle.Height(txt)
+	}
+}
+
+// norm returns the normalized coordinates of the point pt
+// within the data area of the plot.
+func (v *View) norm(pt vg.Point) (tx, ty float64) {
+	c := v.Plot.DataCanvas(v.Canvas)
+	tx = float64((pt.X - c.Min.X) / (c.Max.X - c.Min.X))
+	ty = float64((pt.Y - c.Min.Y) / (c.Max.Y - c.Min.Y))
+	return tx, ty
+}
+
+// invNorm returns the data value of the axis with normalized coordinate t.
+// Logarithmic scales are handled, other scales are assumed to be linear.
+func invNorm(a *plot.Axis, t float64) float64 {
+	if _, ok := a.Scale.(plot.LogScale); ok && a.Min > 0 && a.Max > 0 {
+		lmin := math.Log(a.Min)
+		lmax := math.Log(a.Max)
+		return math.Exp(lmin + t*(lmax-lmin))
+	}
+	return a.Min + t*(a.Max-a.Min)
+}
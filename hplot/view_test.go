@@ -0,0 +1,116 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hplot_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"go-hep.org/x/hep/hbook"
+	"go-hep.org/x/hep/hplot"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+)
+
+func TestView(t *testing.T) {
+	for _, logy := range []bool{false, true} {
+		h := hbook.NewH1D(10, 0, 10)
+		for i := 0; i < 10; i++ {
+			h.Fill(float64(i)+0.5, float64(i+1))
+		}
+
+		p, err := hplot.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Title.Text = "view"
+		switch {
+		case logy:
+			xs := []float64{0.5, 1.5, 2.5, 3.5, 4.5, 5.5, 6.5, 7.5, 8.5, 9.5}
+			ys := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+			s, err := plotter.NewScatter(hplot.ZipXY(xs, ys))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.Add(s)
+			p.X.Min = 0
+			p.X.Max = 10
+			p.Y.Scale = plot.LogScale{}
+			p.Y.Tick.Marker = plot.LogTicks{}
+		default:
+			hh, err := hplot.NewH1D(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.Add(hh)
+		}
+
+		v := hplot.NewView(p, draw.New(vgimg.New(10*vg.Centimeter, 10*vg.Centimeter)))
+		v.Draw()
+
+		point := func(x, y float64) vg.Point {
+			c := p.DataCanvas(v.Canvas)
+			return vg.Point{X: c.X(p.X.Norm(x)), Y: c.Y(p.Y.Norm(y))}
+		}
+		near := func(a, b float64) bool {
+			return math.Abs(a-b) < 1e-6*math.Max(1, math.Abs(b))
+		}
+
+		x, y, ok := v.DataCoord(point(3.25, 4))
+		if !ok || !near(x, 3.25) || !near(y, 4) {
+			t.Fatalf("logy=%v: invalid data coordinates: got=(%v, %v, %v)", logy, x, y, ok)
+		}
+
+		if _, _, ok := v.DataCoord(vg.Point{}); ok {
+			t.Fatalf("logy=%v: expected point outside of the data area", logy)
+		}
+
+		info := v.Info(point(3.25, 4))
+		switch {
+		case logy:
+			if len(info) != 1 || info[0] != "x=3.25 y=4" {
+				t.Fatalf("logy=%v: invalid info: %q", logy, info)
+			}
+		default:
+			if len(info) != 2 || !strings.HasPrefix(info[1], "bin [3, 4): 4 ") {
+				t.Fatalf("logy=%v: invalid info: %q", logy, info)
+			}
+		}
+
+		xmin, xmax := p.X.Min, p.X.Max
+		ymin, ymax := p.Y.Min, p.Y.Max
+
+		v.Zoom(point(3.25, 4), 2)
+		if got, want := p.X.Max-p.X.Min, 0.5*(xmax-xmin); !near(got, want) {
+			t.Fatalf("logy=%v: invalid zoomed x-range: got=%v, want=%v", logy, got, want)
+		}
+		x, y, _ = v.DataCoord(point(3.25, 4))
+		if !near(x, 3.25) || !near(y, 4) {
+			t.Fatalf("logy=%v: zoom moved the data: got=(%v, %v)", logy, x, y)
+		}
+
+		v.Reset()
+		from := point(2, 2)
+		to := point(4, 2)
+		v.Pan(from, to)
+		if !near(p.X.Min, xmin-2) || !near(p.X.Max, xmax-2) {
+			t.Fatalf("logy=%v: invalid panned x-range: got=[%v, %v]", logy, p.X.Min, p.X.Max)
+		}
+		if !near(p.Y.Min, ymin) || !near(p.Y.Max, ymax) {
+			t.Fatalf("logy=%v: invalid panned y-range: got=[%v, %v]", logy, p.Y.Min, p.Y.Max)
+		}
+
+		v.Reset()
+		if p.X.Min != xmin || p.X.Max != xmax || p.Y.Min != ymin || p.Y.Max != ymax {
+			t.Fatalf("logy=%v: invalid reset", logy)
+		}
+
+		v.DrawCrosshair(point(3.25, 4))
+	}
+}
@@ -176,9 +176,16 @@ func (e *Encoder) Encode(evt *HEPEUP) error {
 	return err
 }
 
+// Close writes the end of the stream, after the header of the stream when
+// no event was encoded, so the stream is always a valid LHEF stream.
 func (e *Encoder) Close() error {
+	var err error
+	e.once.Do(func() { err = e.init() })
+	if err != nil {
+		return err
+	}
 
-	_, err := fmt.Fprintf(
+	_, err = fmt.Fprintf(
 		e.w,
 		"</LesHouchesEvents>\n",
 	)
@@ -6,8 +6,10 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"strings"
@@ -32,8 +34,18 @@ type Cmd struct {
 	wmgr *winMgr
 	fmgr *fileMgr
 	hmgr *histMgr
+	nmgr *ntupMgr
+
+	vars  map[string]string // variables defined with /set
+	hist  []string          // history of commands
+	top   *frame            // frame of the interactive session
+	depth int               // number of nested /exec commands
 }
 
+// histFile is the name of the file where the history of commands is
+// persisted across sessions.
+const histFile = ".pawgo.history"
+
 func newCmd(scr screen.Screen) *Cmd {
 	c := Cmd{
 		msg:  log.New(os.Stdout, "paw: ", 0),
@@ -41,6 +53,9 @@ func newCmd(scr screen.Screen) *Cmd {
 		wmgr: newWinMgr(scr),
 		fmgr: newFileMgr(),
 		hmgr: newHistMgr(),
+		nmgr: newNtupMgr(),
+		vars: make(map[string]string),
+		top:  newFrame(nil),
 	}
 	c.cmds = map[string]Cmdr{
 		"/?": &cmdHelp{&c},
@@ -51,8 +66,19 @@ func newCmd(scr screen.Screen) *Cmd {
 		"/file/create": &cmdFileCreate{&c},
 		"/file/ls":     &cmdFileList{&c},
 
-		"/hist/open": &cmdH1DOpen{&c},
-		"/hist/plot": &cmdH1DPlot{&c},
+		"/hist/open": &cmdHistOpen{&c},
+		"/hist/plot": &cmdHistPlot{&c},
+		"/hist/fit":  &cmdHistFit{&c},
+		"/hist/op":   &cmdHistOp{&c},
+		"/hist/save": &cmdHistSave{&c},
+
+		"/ntuple/open": &cmdNtupleOpen{&c},
+		"/ntuple/plot": &cmdNtuplePlot{&c},
+
+		"/exec":    &cmdExec{&c},
+		"/set":     &cmdSet{&c},
+		"/echo":    &cmdEcho{&c},
+		"/history": &cmdHistory{&c},
 
 		"/quit": &cmdQuit{&c},
 	}
@@ -77,10 +103,16 @@ func newCmd(scr screen.Screen) *Cmd {
 		return o
 	})
 
-	f, err := os.Open(".pawgo.history")
+	raw, err := ioutil.ReadFile(histFile)
 	if err == nil {
-		defer f.Close()
-		c.rl.ReadHistory(f)
+		c.rl.ReadHistory(bytes.NewReader(raw))
+		scan := bufio.NewScanner(bytes.NewReader(raw))
+		for scan.Scan() {
+			line := scan.Text()
+			if !strings.HasPrefix(line, "/history") {
+				c.hist = append(c.hist, line)
+			}
+		}
 	}
 
 	return &c
@@ -90,14 +122,17 @@ func (c *Cmd) Close() error {
 	var err error
 
 	err = c.fmgr.Close()
+	if e := c.nmgr.Close(); e != nil && err == nil {
+		err = e
+	}
 
-	f, err := os.Create(".pawgo.history")
-	if err == nil {
+	f, e := os.Create(histFile)
+	if e == nil {
 		defer f.Close()
 		c.rl.WriteHistory(f)
 	}
 
-	e := c.rl.Close()
+	e = c.rl.Close()
 	if e != nil {
 		if err != nil {
 			err = e
@@ -121,42 +156,81 @@ func (c *Cmd) Run() error {
 		if o == "" {
 			continue
 		}
-		err = c.exec(o)
+		err = c.execInteractive(o)
 		if err != nil {
 			if err == io.EOF {
 				return err
 			}
 			c.msg.Printf("error: %v\n", err)
 		}
-		c.rl.AppendHistory(o)
 	}
 }
 
-func (c *Cmd) RunScript(r io.Reader) error {
-	scan := bufio.NewScanner(r)
-	for scan.Scan() {
-		err := scan.Err()
+// execInteractive executes a line typed at the prompt.
+// Control blocks (do, for, if) are read until their end statement
+// and then executed.
+func (c *Cmd) execInteractive(line string) error {
+	lines := []string{line}
+	for depth := blockDepth(line); depth > 0; {
+		o, err := c.rl.Prompt("...> ")
 		if err != nil {
-			break
-		}
-		line := scan.Text()
-		if line == "" || line[0] == '#' {
-			continue
-		}
-		fmt.Printf("# %s\n", line)
-		err = c.exec(line)
-		if err == io.EOF {
 			return err
 		}
-		if err != nil {
-			c.msg.Printf("error executing %q: %v\n", line, err)
-			return err
+		lines = append(lines, o)
+		depth += blockDepth(o)
+	}
+
+	for _, line := range lines {
+		c.rl.AppendHistory(line)
+		if !strings.HasPrefix(line, "/history") {
+			c.hist = append(c.hist, line)
 		}
 	}
 
-	err := scan.Err()
-	if err == io.EOF {
-		err = nil
+	if len(lines) == 1 {
+		return c.exec(c.subst(c.top, line))
+	}
+
+	p := parser{lines: lines}
+	stmts, end, err := p.block()
+	if err != nil {
+		return err
+	}
+	if end != "" {
+		return fmt.Errorf("paw: unexpected %q", end)
+	}
+	return runStmts(c, c.top, stmts)
+}
+
+// blockDepth returns +1 if line opens a control block,
+// -1 if it closes one and 0 otherwise.
+func blockDepth(line string) int {
+	kw, _ := keyword(strings.TrimSpace(line))
+	switch kw {
+	case "do", "for", "if":
+		return +1
+	case "enddo", "endfor", "endif":
+		return -1
+	}
+	return 0
+}
+
+// RunScript runs the commands of a script.
+// A script is a macro file: if it defines several macros, the first one
+// is run.
+func (c *Cmd) RunScript(r io.Reader) error {
+	ms, err := parseMacros(r)
+	if err != nil {
+		return err
+	}
+	m, err := findMacro(ms, "")
+	if err != nil {
+		return err
+	}
+
+	err = c.runMacro(m, nil)
+	if err != nil && err != io.EOF {
+		c.msg.Printf("%v\n", err)
 	}
 	return err
 }
@@ -166,6 +240,10 @@ func (c *Cmd) exec(line string) error {
 	if err != nil {
 		return fmt.Errorf("paw: splitting line failed: %v", err)
 	}
+	if len(args) == 0 {
+		// e.g. a macro line whose variables substitute to nothing.
+		return nil
+	}
 	cmd, ok := c.cmds[args[0]]
 	if !ok {
 		return fmt.Errorf("unknown command %q", args[0])
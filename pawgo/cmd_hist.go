@@ -0,0 +1,247 @@
+// Copyright 2015 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// cmdHistOpen opens a histogram
+type cmdHistOpen struct {
+	ctx *Cmd
+}
+
+func (cmd *cmdHistOpen) Name() string {
+	return "/hist/open"
+}
+
+func (cmd *cmdHistOpen) Run(args []string) error {
+	var err error
+	if len(args) < 2 {
+		return fmt.Errorf("%s: need histo-id and histo-name (got=%v)", cmd.Name(), args)
+	}
+
+	hid := args[0]
+
+	// e.g: /file/id/1/my-histo
+	hname := args[1]
+
+	err = cmd.ctx.hmgr.open(cmd.ctx.fmgr, hid, hname)
+	return err
+}
+
+func (cmd *cmdHistOpen) Help(w io.Writer) {
+	fmt.Fprintf(w, "%s \t-- open a histogram\n", cmd.Name())
+}
+
+func (cmd *cmdHistOpen) Complete(line string) []string {
+	var o []string
+	args := strings.Split(line, " ")
+	switch len(args) {
+	case 0, 1:
+		return o
+	case 2:
+		return o
+	case 3:
+		if args[2] == "" {
+			args[2] = "/file/id/"
+		}
+		for id := range cmd.ctx.fmgr.rfds {
+			switch {
+			case strings.HasPrefix("/file/id/"+id+"/", args[2]):
+				r := cmd.ctx.fmgr.rfds[id]
+				v := "/file/id/" + id + "/"
+				for _, k := range r.keys() {
+					if strings.HasPrefix(v+k, args[2]) {
+						o = append(o, strings.Join(args[:2], " ")+" "+v+k)
+					}
+				}
+			case strings.HasPrefix("/file/id/"+id, args[2]):
+				o = append(o, strings.Join(args[:2], " ")+" /file/id/"+id)
+			}
+		}
+	}
+	return o
+}
+
+// cmdHistPlot plots histograms
+type cmdHistPlot struct {
+	ctx *Cmd
+}
+
+func (cmd *cmdHistPlot) Name() string {
+	return "/hist/plot"
+}
+
+func (cmd *cmdHistPlot) Run(args []string) error {
+	var err error
+	if len(args) < 1 {
+		return fmt.Errorf("%s: need a histo-id to plot", cmd.Name())
+	}
+
+	err = cmd.ctx.hmgr.plot(cmd.ctx.wmgr, args...)
+	return err
+}
+
+func (cmd *cmdHistPlot) Help(w io.Writer) {
+	fmt.Fprintf(w, "%s \t-- plot histograms (e.g. /hist/plot h1 [h2 ...])\n", cmd.Name())
+}
+
+func (cmd *cmdHistPlot) Complete(line string) []string {
+	return completeHistIDs(cmd.ctx, line)
+}
+
+// cmdHistFit fits a histogram
+type cmdHistFit struct {
+	ctx *Cmd
+}
+
+func (cmd *cmdHistFit) Name() string {
+	return "/hist/fit"
+}
+
+func (cmd *cmdHistFit) Run(args []string) error {
+	var err error
+	if len(args) < 2 {
+		return fmt.Errorf("%s: need a histo-id and a function name (got=%v)", cmd.Name(), args)
+	}
+
+	hid := args[0]
+	fct := args[1]
+
+	var ps []float64
+	for _, arg := range args[2:] {
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("%s: invalid parameter %q: %v", cmd.Name(), arg, err)
+		}
+		ps = append(ps, v)
+	}
+
+	err = cmd.ctx.hmgr.fit(cmd.ctx.wmgr, hid, fct, ps)
+	return err
+}
+
+func (cmd *cmdHistFit) Help(w io.Writer) {
+	fmt.Fprintf(w, "%s \t-- fit a histogram (e.g. /hist/fit h gaus|expo|polN [p0 p1 ...])\n", cmd.Name())
+}
+
+func (cmd *cmdHistFit) Complete(line string) []string {
+	var o []string
+	args := strings.Split(line, " ")
+	switch len(args) {
+	case 2:
+		return completeHistIDs(cmd.ctx, line)
+	case 3:
+		for _, fct := range []string{"gaus", "expo", "pol1", "pol2"} {
+			if strings.HasPrefix(fct, args[2]) {
+				o = append(o, strings.Join(args[:2], " ")+" "+fct)
+			}
+		}
+	}
+	return o
+}
+
+// cmdHistOp stores the result of an operation on histograms
+type cmdHistOp struct {
+	ctx *Cmd
+}
+
+func (cmd *cmdHistOp) Name() string {
+	return "/hist/op"
+}
+
+func (cmd *cmdHistOp) Run(args []string) error {
+	var err error
+	if len(args) < 2 {
+		return fmt.Errorf("%s: need a histo-id and an operation (got=%v)", cmd.Name(), args)
+	}
+
+	dst := args[0]
+	op := args[1]
+
+	err = cmd.ctx.hmgr.op(dst, op, args[2:])
+	return err
+}
+
+func (cmd *cmdHistOp) Help(w io.Writer) {
+	fmt.Fprintf(w, "%s \t-- operation on histograms (e.g. /hist/op h add|sub|div h1 h2, /hist/op h scale h1 2.5)\n", cmd.Name())
+}
+
+func (cmd *cmdHistOp) Complete(line string) []string {
+	var o []string
+	args := strings.Split(line, " ")
+	switch len(args) {
+	case 3:
+		for _, op := range []string{"add", "sub", "div", "scale"} {
+			if strings.HasPrefix(op, args[2]) {
+				o = append(o, strings.Join(args[:2], " ")+" "+op)
+			}
+		}
+	case 4, 5:
+		return completeHistIDs(cmd.ctx, line)
+	}
+	return o
+}
+
+// cmdHistSave saves histograms to a file
+type cmdHistSave struct {
+	ctx *Cmd
+}
+
+func (cmd *cmdHistSave) Name() string {
+	return "/hist/save"
+}
+
+func (cmd *cmdHistSave) Run(args []string) error {
+	var err error
+	if len(args) < 2 {
+		return fmt.Errorf("%s: need a file-id and histo-ids (got=%v)", cmd.Name(), args)
+	}
+
+	fid := args[0]
+	err = cmd.ctx.hmgr.save(cmd.ctx.fmgr, fid, args[1:]...)
+	return err
+}
+
+func (cmd *cmdHistSave) Help(w io.Writer) {
+	fmt.Fprintf(w, "%s \t-- save histograms to a file (e.g. /hist/save f h1 [h2 ...])\n", cmd.Name())
+}
+
+func (cmd *cmdHistSave) Complete(line string) []string {
+	var o []string
+	args := strings.Split(line, " ")
+	switch len(args) {
+	case 2:
+		for id := range cmd.ctx.fmgr.wfds {
+			if strings.HasPrefix(id, args[1]) {
+				o = append(o, args[0]+" "+id)
+			}
+		}
+		return o
+	}
+	return completeHistIDs(cmd.ctx, line)
+}
+
+// completeHistIDs completes the last argument of line with the ids
+// of the known histograms.
+func completeHistIDs(ctx *Cmd, line string) []string {
+	var o []string
+	args := strings.Split(line, " ")
+	if len(args) < 2 {
+		return o
+	}
+	last := args[len(args)-1]
+	head := strings.Join(args[:len(args)-1], " ")
+	for _, id := range ctx.hmgr.ids() {
+		if strings.HasPrefix(id, last) {
+			o = append(o, head+" "+id)
+		}
+	}
+	return o
+}
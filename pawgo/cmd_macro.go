@@ -0,0 +1,194 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxExecDepth is the maximum number of nested /exec commands.
+const maxExecDepth = 64
+
+// cmdExec executes a macro
+type cmdExec struct {
+	ctx *Cmd
+}
+
+func (cmd *cmdExec) Name() string {
+	return "/exec"
+}
+
+func (cmd *cmdExec) Run(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s: need a macro file name", cmd.Name())
+	}
+
+	// e.g: /exec file.kumac#name arg1 arg2
+	fname := args[0]
+	name := ""
+	if i := strings.Index(fname, "#"); i >= 0 {
+		fname, name = fname[:i], fname[i+1:]
+	}
+	if filepath.Ext(fname) == "" {
+		if _, err := os.Stat(fname); os.IsNotExist(err) {
+			fname += ".kumac"
+		}
+	}
+
+	if cmd.ctx.depth >= maxExecDepth {
+		return fmt.Errorf("%s: too many nested macros", cmd.Name())
+	}
+	cmd.ctx.depth++
+	defer func() { cmd.ctx.depth-- }()
+
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ms, err := parseMacros(f)
+	if err != nil {
+		return fmt.Errorf("%s: %s: %v", cmd.Name(), fname, err)
+	}
+
+	m, err := findMacro(ms, name)
+	if err != nil {
+		return fmt.Errorf("%s: %s: %v", cmd.Name(), fname, err)
+	}
+
+	return cmd.ctx.runMacro(m, args[1:])
+}
+
+func (cmd *cmdExec) Help(w io.Writer) {
+	fmt.Fprintf(w, "%s \t-- execute a macro (e.g. /exec file.kumac[#name] [arg1 arg2 ...])\n", cmd.Name())
+}
+
+func (cmd *cmdExec) Complete(line string) []string {
+	var o []string
+	args := strings.Split(line, " ")
+	if len(args) != 2 {
+		return o
+	}
+	matches, err := filepath.Glob(args[1] + "*")
+	if err != nil {
+		return o
+	}
+	for _, m := range matches {
+		o = append(o, args[0]+" "+m)
+	}
+	return o
+}
+
+// cmdSet sets a variable
+type cmdSet struct {
+	ctx *Cmd
+}
+
+func (cmd *cmdSet) Name() string {
+	return "/set"
+}
+
+func (cmd *cmdSet) Run(args []string) error {
+	switch len(args) {
+	case 0:
+		names := make([]string, 0, len(cmd.ctx.vars))
+		for k := range cmd.ctx.vars {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			fmt.Printf("%s = %q\n", k, cmd.ctx.vars[k])
+		}
+	case 1:
+		delete(cmd.ctx.vars, args[0])
+	default:
+		cmd.ctx.vars[args[0]] = strings.Join(args[1:], " ")
+	}
+	return nil
+}
+
+func (cmd *cmdSet) Help(w io.Writer) {
+	fmt.Fprintf(w, "%s \t-- set a variable, used as [name] (e.g. /set name value)\n", cmd.Name())
+}
+
+func (cmd *cmdSet) Complete(line string) []string {
+	var o []string
+	return o
+}
+
+// cmdEcho prints a message
+type cmdEcho struct {
+	ctx *Cmd
+}
+
+func (cmd *cmdEcho) Name() string {
+	return "/echo"
+}
+
+func (cmd *cmdEcho) Run(args []string) error {
+	fmt.Println(strings.Join(args, " "))
+	return nil
+}
+
+func (cmd *cmdEcho) Help(w io.Writer) {
+	fmt.Fprintf(w, "%s \t-- print a message\n", cmd.Name())
+}
+
+func (cmd *cmdEcho) Complete(line string) []string {
+	var o []string
+	return o
+}
+
+// cmdHistory prints or saves the history of commands
+type cmdHistory struct {
+	ctx *Cmd
+}
+
+func (cmd *cmdHistory) Name() string {
+	return "/history"
+}
+
+func (cmd *cmdHistory) Run(args []string) error {
+	hist := cmd.ctx.hist
+	switch len(args) {
+	case 0:
+		for i, line := range hist {
+			fmt.Printf("%5d  %s\n", i+1, line)
+		}
+		return nil
+	case 2:
+		if args[0] != "save" {
+			break
+		}
+		f, err := os.Create(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		for _, line := range hist {
+			_, err = fmt.Fprintln(f, line)
+			if err != nil {
+				return err
+			}
+		}
+		return f.Close()
+	}
+	return fmt.Errorf("%s: invalid arguments %q", cmd.Name(), args)
+}
+
+func (cmd *cmdHistory) Help(w io.Writer) {
+	fmt.Fprintf(w, "%s \t-- print the history of commands, or save it as a macro (e.g. /history save file.kumac)\n", cmd.Name())
+}
+
+func (cmd *cmdHistory) Complete(line string) []string {
+	var o []string
+	return o
+}
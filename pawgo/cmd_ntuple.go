@@ -0,0 +1,99 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// cmdNtupleOpen opens a n-tuple
+type cmdNtupleOpen struct {
+	ctx *Cmd
+}
+
+func (cmd *cmdNtupleOpen) Name() string {
+	return "/ntuple/open"
+}
+
+func (cmd *cmdNtupleOpen) Run(args []string) error {
+	var err error
+	if len(args) < 2 {
+		return fmt.Errorf("%s: need a n-tuple-id and a file name (got=%v)", cmd.Name(), args)
+	}
+
+	nid := args[0]
+	fname := args[1]
+
+	header := false
+	if len(args) > 2 {
+		switch args[2] {
+		case "header":
+			header = true
+		default:
+			return fmt.Errorf("%s: invalid option %q", cmd.Name(), args[2])
+		}
+	}
+
+	err = cmd.ctx.nmgr.open(nid, fname, header)
+	return err
+}
+
+func (cmd *cmdNtupleOpen) Help(w io.Writer) {
+	fmt.Fprintf(w, "%s \t-- open a CSV n-tuple (e.g. /ntuple/open nt file.csv [header])\n", cmd.Name())
+}
+
+func (cmd *cmdNtupleOpen) Complete(line string) []string {
+	var o []string
+	return o
+}
+
+// cmdNtuplePlot plots the distribution of n-tuple variables
+type cmdNtuplePlot struct {
+	ctx *Cmd
+}
+
+func (cmd *cmdNtuplePlot) Name() string {
+	return "/ntuple/plot"
+}
+
+func (cmd *cmdNtuplePlot) Run(args []string) error {
+	var err error
+	if len(args) < 2 {
+		return fmt.Errorf("%s: need a n-tuple-id and variables (got=%v)", cmd.Name(), args)
+	}
+
+	nid := args[0]
+	vars := args[1]
+	cut := strings.Join(args[2:], " ")
+
+	// the resulting histogram can be fitted or saved with the /hist commands.
+	hid := nid + "/" + vars
+	err = cmd.ctx.nmgr.plot(cmd.ctx.wmgr, cmd.ctx.hmgr, nid, hid, vars, cut)
+	if err != nil {
+		return err
+	}
+	cmd.ctx.msg.Printf("histogram stored as [id=%s]\n", hid)
+	return err
+}
+
+func (cmd *cmdNtuplePlot) Help(w io.Writer) {
+	fmt.Fprintf(w, "%s \t-- plot n-tuple variables (e.g. /ntuple/plot nt x[:y] [cut])\n", cmd.Name())
+}
+
+func (cmd *cmdNtuplePlot) Complete(line string) []string {
+	var o []string
+	args := strings.Split(line, " ")
+	if len(args) != 2 {
+		return o
+	}
+	for id := range cmd.ctx.nmgr.nts {
+		if strings.HasPrefix(id, args[1]) {
+			o = append(o, args[0]+" "+id)
+		}
+	}
+	return o
+}
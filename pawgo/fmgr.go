@@ -5,38 +5,64 @@
 package main
 
 import (
+	"encoding"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"text/tabwriter"
 
+	"go-hep.org/x/hep/hbook"
+	"go-hep.org/x/hep/hbook/rootcnv"
+	"go-hep.org/x/hep/hbook/yodacnv"
 	"go-hep.org/x/hep/rio"
+	"go-hep.org/x/hep/rootio"
 )
 
 type fileType interface {
 	io.Reader
+	io.ReaderAt
 	io.Seeker
 	io.Closer
 }
 
+// rfile is a file opened for read access.
+// rfile handles rio and ROOT files, detected by their magic header.
 type rfile struct {
-	id  string
-	n   string
-	r   fileType
-	rio *rio.File
+	id   string
+	n    string
+	r    fileType
+	rio  *rio.File
+	root *rootio.File
 }
 
 func (r *rfile) open(fname string) error {
 	var err error
 
 	r.n = fname
-	r.r, err = os.Open(fname)
+	f, err := os.Open(fname)
 	if err != nil {
 		return err
 	}
+	r.r = f
 
-	r.rio, err = rio.Open(r.r)
+	var magic [4]byte
+	_, err = f.ReadAt(magic[:], 0)
 	if err != nil {
+		f.Close()
+		return fmt.Errorf("could not read magic header of file [%s]: %v", fname, err)
+	}
+
+	switch string(magic[:]) {
+	case "root":
+		r.root, err = rootio.NewReader(f, fname)
+	default:
+		r.rio, err = rio.Open(f)
+	}
+	if err != nil {
+		f.Close()
 		return err
 	}
 
@@ -48,8 +74,13 @@ func (r *rfile) ls() error {
 
 	fmt.Printf("/file/id/%s name=%s\n", r.id, r.n)
 	w := tabwriter.NewWriter(os.Stdout, 0, 8, 0, '\t', 0)
-	for _, k := range r.rio.Keys() {
-		fmt.Fprintf(w, " \t- %s\t(type=%q)\n", k.Name, k.Blocks[0].Type)
+	switch {
+	case r.root != nil:
+		lsDir(w, r.root, "")
+	default:
+		for _, k := range r.rio.Keys() {
+			fmt.Fprintf(w, " \t- %s\t(type=%q)\n", k.Name, k.Blocks[0].Type)
+		}
 	}
 	w.Flush()
 	fmt.Printf("\n")
@@ -57,6 +88,44 @@ func (r *rfile) ls() error {
 	return err
 }
 
+// lsDir lists the content of the ROOT directory dir, and of its
+// sub-directories.
+func lsDir(w io.Writer, dir rootio.Directory, path string) {
+	for _, k := range uniq(dir.Keys()) {
+		name := path + k.Name()
+		fmt.Fprintf(w, " \t- %s\t(type=%q)\n", name, k.Class())
+		if sub, ok := k.Value().(rootio.Directory); ok {
+			lsDir(w, sub, name+"/")
+		}
+	}
+}
+
+// keys returns the names of the values of the file.
+// Values in ROOT directories are named after their path, e.g. "dir/h1".
+func (r *rfile) keys() []string {
+	var keys []string
+	switch {
+	case r.root != nil:
+		var walk func(dir rootio.Directory, path string)
+		walk = func(dir rootio.Directory, path string) {
+			for _, k := range uniq(dir.Keys()) {
+				name := path + k.Name()
+				if sub, ok := k.Value().(rootio.Directory); ok {
+					walk(sub, name+"/")
+					continue
+				}
+				keys = append(keys, name)
+			}
+		}
+		walk(r.root, "")
+	default:
+		for _, k := range r.rio.Keys() {
+			keys = append(keys, k.Name)
+		}
+	}
+	return keys
+}
+
 func (r *rfile) read(name string, ptr interface{}) error {
 	var err error
 
@@ -72,7 +141,116 @@ func (r *rfile) read(name string, ptr interface{}) error {
 	return err
 }
 
+// get reads the hbook value named name from the file.
+// ROOT histograms and graphs are converted to hbook values.
+func (r *rfile) get(name string) (hbook.Object, error) {
+	if r.root != nil {
+		return r.getROOT(name)
+	}
+
+	var typ string
+	for _, k := range r.rio.Keys() {
+		if k.Name == name && len(k.Blocks) > 0 {
+			typ = k.Blocks[0].Type
+			break
+		}
+	}
+
+	rt, ok := hbookTypes[typ]
+	if !ok {
+		return nil, fmt.Errorf("no histogram [%s] in file [id=%s name=%s] (type=%q)", name, r.id, r.n, typ)
+	}
+
+	v := reflect.New(rt.Elem())
+	err := r.read(name, v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface().(hbook.Object), nil
+}
+
+var hbookTypes = map[string]reflect.Type{
+	"*go-hep.org/x/hep/hbook.H1D": reflect.TypeOf((*hbook.H1D)(nil)),
+	"*go-hep.org/x/hep/hbook.H2D": reflect.TypeOf((*hbook.H2D)(nil)),
+	"*go-hep.org/x/hep/hbook.P1D": reflect.TypeOf((*hbook.P1D)(nil)),
+	"*go-hep.org/x/hep/hbook.S2D": reflect.TypeOf((*hbook.S2D)(nil)),
+}
+
+// getROOT reads the ROOT histogram or graph at path name,
+// and converts it to a hbook value.
+func (r *rfile) getROOT(name string) (hbook.Object, error) {
+	var (
+		dir  rootio.Directory = r.root
+		toks                  = strings.Split(name, "/")
+	)
+	for i, tok := range toks {
+		obj, err := dir.Get(tok)
+		if err != nil {
+			return nil, fmt.Errorf("no record [%s] in file [id=%s name=%s]", name, r.id, r.n)
+		}
+		if i < len(toks)-1 {
+			sub, ok := obj.(rootio.Directory)
+			if !ok {
+				return nil, fmt.Errorf("record [%s] in file [id=%s name=%s] is not a directory",
+					strings.Join(toks[:i+1], "/"), r.id, r.n,
+				)
+			}
+			dir = sub
+			continue
+		}
+
+		var (
+			o     hbook.Object
+			class = obj.Class()
+		)
+		switch obj := obj.(type) {
+		case rootio.Graph:
+			o, err = rootcnv.S2D(obj)
+		case yodacnv.Marshaler:
+			switch {
+			case strings.HasPrefix(class, "TH1"):
+				o, err = rootcnv.H1D(obj)
+			case strings.HasPrefix(class, "TH2"):
+				o, err = rootcnv.H2D(obj)
+			default:
+				err = fmt.Errorf("unhandled ROOT type %q", class)
+			}
+		default:
+			err = fmt.Errorf("unhandled ROOT type %q", class)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not convert [%s] from file [id=%s name=%s]: %v", name, r.id, r.n, err)
+		}
+		return o, nil
+	}
+	panic("unreachable")
+}
+
+// uniq returns the keys with the highest cycle for each name.
+func uniq(keys []rootio.Key) []rootio.Key {
+	var (
+		out = make([]rootio.Key, 0, len(keys))
+		idx = make(map[string]int, len(keys))
+	)
+	for _, k := range keys {
+		i, dup := idx[k.Name()]
+		if !dup {
+			idx[k.Name()] = len(out)
+			out = append(out, k)
+			continue
+		}
+		if k.Cycle() > out[i].Cycle() {
+			out[i] = k
+		}
+	}
+	return out
+}
+
 func (r *rfile) close() error {
+	if r.root != nil {
+		// closing the ROOT file closes the underlying file.
+		return r.root.Close()
+	}
 	defer r.r.Close()
 	err := r.rio.Close()
 	if err != nil {
@@ -81,6 +259,9 @@ func (r *rfile) close() error {
 	return r.r.Close()
 }
 
+// wfile is a file opened for write access.
+// Values are written in the YODA format if the name of the file
+// ends with ".yoda", in the rio format otherwise.
 type wfile struct {
 	id  string
 	n   string
@@ -97,6 +278,10 @@ func (w *wfile) create(fname string) error {
 		return err
 	}
 
+	if filepath.Ext(fname) == ".yoda" {
+		return err
+	}
+
 	w.rio, err = rio.NewWriter(w.w)
 	if err != nil {
 		return err
@@ -105,11 +290,51 @@ func (w *wfile) create(fname string) error {
 	return err
 }
 
+func (w *wfile) write(name string, v hbook.Object) error {
+	if w.rio == nil {
+		// YODA values are named after their "name" annotation:
+		// write a renamed copy, leaving v untouched.
+		o, err := renamed(v, name)
+		if err != nil {
+			return fmt.Errorf("value [%s] can not be saved as YODA: %v", name, err)
+		}
+		return yodacnv.Write(w.w, o)
+	}
+	return w.rio.WriteValue(name, v)
+}
+
+// renamed returns a copy of v, with its "name" annotation set to name.
+func renamed(v hbook.Object, name string) (yodacnv.Marshaler, error) {
+	src, ok := v.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("can not copy value (type=%T)", v)
+	}
+	buf, err := src.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	o := reflect.New(reflect.TypeOf(v).Elem()).Interface()
+	err = o.(encoding.BinaryUnmarshaler).UnmarshalBinary(buf)
+	if err != nil {
+		return nil, err
+	}
+	o.(hbook.Object).Annotation()["name"] = name
+
+	m, ok := o.(yodacnv.Marshaler)
+	if !ok {
+		return nil, fmt.Errorf("invalid YODA value (type=%T)", v)
+	}
+	return m, nil
+}
+
 func (w *wfile) close() error {
 	defer w.w.Close()
-	err := w.rio.Close()
-	if err != nil {
-		return err
+	if w.rio != nil {
+		err := w.rio.Close()
+		if err != nil {
+			return err
+		}
 	}
 	return w.w.Close()
 }
@@ -0,0 +1,213 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-hep.org/x/hep/hbook"
+)
+
+const (
+	rioFile   = "testdata/hsimple.rio"
+	rootH1    = "../hbook/rootcnv/testdata/gauss-h1.root"
+	rootH2    = "../hbook/rootcnv/testdata/gauss-h2.root"
+	rootGraph = "../rootio/testdata/graphs.root"
+	rootTree  = "../rootio/testdata/simple.root"
+)
+
+func TestFileOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pawgo-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	short := filepath.Join(dir, "short")
+	err = ioutil.WriteFile(short, []byte("ro"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		fname string
+		root  bool
+		keys  []string
+		err   bool
+	}{
+		{
+			fname: rioFile,
+			keys:  []string{"h1", "h2"},
+		},
+		{
+			fname: rootH1,
+			root:  true,
+			keys:  []string{"h1d", "h1f", "h1d-var", "h1f-var"},
+		},
+		{
+			fname: rootGraph,
+			root:  true,
+			keys:  []string{"tg", "tge", "tgae"},
+		},
+		{
+			fname: short,
+			err:   true,
+		},
+		{
+			fname: filepath.Join(dir, "no-such-file"),
+			err:   true,
+		},
+	} {
+		t.Run(filepath.Base(test.fname), func(t *testing.T) {
+			var r rfile
+			err := r.open(test.fname)
+			if test.err {
+				if err == nil {
+					r.close()
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.close()
+
+			if got := r.root != nil; got != test.root {
+				t.Fatalf("got ROOT file=%v. want %v", got, test.root)
+			}
+			if got := r.keys(); !reflect.DeepEqual(got, test.keys) {
+				t.Fatalf("got keys %q. want %q", got, test.keys)
+			}
+		})
+	}
+}
+
+func TestFileGet(t *testing.T) {
+	for _, test := range []struct {
+		fname string
+		name  string
+		want  reflect.Type
+		err   string
+	}{
+		{
+			// hsimple.rio was written by github.com/go-hep/hbook.
+			fname: rioFile,
+			name:  "h1",
+			err:   `no histogram [h1] in file [id=f name=testdata/hsimple.rio] (type="*github.com/go-hep/hbook.H1D")`,
+		},
+		{
+			fname: rioFile,
+			name:  "nope",
+			err:   `no histogram [nope] in file [id=f name=testdata/hsimple.rio] (type="")`,
+		},
+		{
+			fname: rootH1,
+			name:  "h1d",
+			want:  reflect.TypeOf((*hbook.H1D)(nil)),
+		},
+		{
+			fname: rootH1,
+			name:  "h1f-var",
+			want:  reflect.TypeOf((*hbook.H1D)(nil)),
+		},
+		{
+			fname: rootH2,
+			name:  "h2f",
+			want:  reflect.TypeOf((*hbook.H2D)(nil)),
+		},
+		{
+			fname: rootGraph,
+			name:  "tgae",
+			want:  reflect.TypeOf((*hbook.S2D)(nil)),
+		},
+		{
+			fname: rootH1,
+			name:  "nope",
+			err:   "no record [nope] in file [id=f name=" + rootH1 + "]",
+		},
+		{
+			fname: rootH1,
+			name:  "h1d/h1f",
+			err:   "record [h1d] in file [id=f name=" + rootH1 + "] is not a directory",
+		},
+		{
+			fname: rootTree,
+			name:  "tree",
+			err:   `could not convert [tree] from file [id=f name=` + rootTree + `]: unhandled ROOT type "TTree"`,
+		},
+	} {
+		t.Run(filepath.Base(test.fname)+"/"+test.name, func(t *testing.T) {
+			r := rfile{id: "f"}
+			err := r.open(test.fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.close()
+
+			h, err := r.get(test.name)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v. want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := reflect.TypeOf(h); got != test.want {
+				t.Fatalf("got type %v. want %v", got, test.want)
+			}
+			if h.Name() != test.name {
+				t.Fatalf("got name %q. want %q", h.Name(), test.name)
+			}
+		})
+	}
+}
+
+func TestLsDir(t *testing.T) {
+	for _, test := range []struct {
+		fname string
+		want  []string
+	}{
+		{
+			fname: rootH2,
+			want: []string{
+				` 	- h2f	(type="TH2F")`,
+				` 	- h2d	(type="TH2D")`,
+				` 	- h2f-var	(type="TH2F")`,
+				` 	- h2d-var	(type="TH2D")`,
+			},
+		},
+		{
+			fname: rootTree,
+			want: []string{
+				` 	- tree	(type="TTree")`,
+			},
+		},
+	} {
+		t.Run(filepath.Base(test.fname), func(t *testing.T) {
+			var r rfile
+			err := r.open(test.fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.close()
+
+			var buf bytes.Buffer
+			lsDir(&buf, r.root, "")
+			got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
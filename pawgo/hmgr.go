@@ -6,23 +6,29 @@ package main
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 
+	"go-hep.org/x/hep/fit"
 	"go-hep.org/x/hep/hbook"
 	"go-hep.org/x/hep/hplot"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotutil"
 )
 
 type histMgr struct {
-	h1ds map[string]*hbook.H1D
+	hists map[string]hbook.Object
 }
 
 func newHistMgr() *histMgr {
 	return &histMgr{
-		h1ds: make(map[string]*hbook.H1D),
+		hists: make(map[string]hbook.Object),
 	}
 }
 
-func (mgr *histMgr) openH1D(fmgr *fileMgr, hid, path string) error {
+func (mgr *histMgr) open(fmgr *fileMgr, hid, path string) error {
 	var err error
 	const prefix = "/file/id/"
 	if !strings.HasPrefix(path, prefix) {
@@ -49,49 +55,320 @@ func (mgr *histMgr) openH1D(fmgr *fileMgr, hid, path string) error {
 		return fmt.Errorf("unknown file-id [%s]", fid)
 	}
 
-	hname := toks[1]
+	// e.g. /file/id/f/dir/h1 for a histogram in a ROOT directory.
+	hname := strings.Join(toks[1:], "/")
 
-	var h1d hbook.H1D
-	err = r.read(hname, &h1d)
+	h, err := r.get(hname)
 	if err != nil {
 		return err
 	}
 
-	mgr.h1ds[hid] = &h1d
+	mgr.hists[hid] = h
 	return err
 }
 
-func (mgr *histMgr) plotH1D(wmgr *winMgr, hid string) error {
-	var err error
-	h, ok := mgr.h1ds[hid]
+func (mgr *histMgr) get(hid string) (hbook.Object, error) {
+	h, ok := mgr.hists[hid]
 	if !ok {
-		return fmt.Errorf("unknown H1D [id=%s]", hid)
+		return nil, fmt.Errorf("unknown histogram [id=%s]", hid)
 	}
+	return h, nil
+}
 
-	fmt.Printf("== h1d: name=%q\nentries=%d\nmean=%+8.3f\nRMS= %+8.3f\n",
-		h.Name(), h.Entries(), h.XMean(), h.XRMS(),
-	)
+func (mgr *histMgr) ids() []string {
+	ids := make([]string, 0, len(mgr.hists))
+	for id := range mgr.hists {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// plot plots the given histograms.
+// 1-dim values (H1D, P1D, S2D) are overlaid on the same plot.
+// A H2D can only be plotted alone.
+func (mgr *histMgr) plot(wmgr *winMgr, hids ...string) error {
+	var err error
+	objs := make([]hbook.Object, len(hids))
+	for i, hid := range hids {
+		objs[i], err = mgr.get(hid)
+		if err != nil {
+			return err
+		}
+	}
+
+	if h, ok := objs[0].(*hbook.H2D); ok {
+		if len(objs) > 1 {
+			return fmt.Errorf("can not overlay a H2D with other values")
+		}
+		fmt.Printf("== h2d: name=%q\nentries=%d\nxmean=%+8.3f\nymean=%+8.3f\nxRMS= %+8.3f\nyRMS= %+8.3f\n",
+			h.Name(), h.Entries(), h.XMean(), h.YMean(), h.XRMS(), h.YRMS(),
+		)
+		hh := hplot.NewH2D(h, nil)
+		p, err := hplot.NewColorBarPlot(hh.ColorBar())
+		if err != nil {
+			return err
+		}
+		p.Plot.Title.Text = h.Name()
+		p.Plot.X.Label.Text = "x"
+		p.Plot.Y.Label.Text = "y"
+		p.Plot.Add(hh)
+		return wmgr.newPlot(p)
+	}
 
 	p, err := hplot.New()
 	if err != nil {
 		return err
 	}
-	p.Title.Text = h.Name()
+	p.Title.Text = objs[0].Name()
 	p.X.Label.Text = "x"
 	p.Y.Label.Text = "y"
 
-	hh, err := hplot.NewH1D(h)
+	for i, obj := range objs {
+		var pl plot.Plotter
+		switch h := obj.(type) {
+		case *hbook.H1D:
+			fmt.Printf("== h1d: name=%q\nentries=%d\nmean=%+8.3f\nRMS= %+8.3f\n",
+				h.Name(), h.Entries(), h.XMean(), h.XRMS(),
+			)
+			hh, err := hplot.NewH1D(h)
+			if err != nil {
+				return err
+			}
+			if len(objs) == 1 {
+				hh.Infos.Style = hplot.HInfoSummary
+			} else {
+				hh.FillColor = nil
+				hh.LineStyle.Color = plotutil.Color(i)
+			}
+			pl = hh
+		case *hbook.P1D:
+			fmt.Printf("== p1d: name=%q\nentries=%d\nmean=%+8.3f\nRMS= %+8.3f\n",
+				h.Name(), h.Entries(), h.XMean(), h.XRMS(),
+			)
+			pl = newS2D(hbook.NewS2DFromP1D(h), i)
+		case *hbook.S2D:
+			fmt.Printf("== s2d: name=%q\npoints=%d\n", h.Name(), h.Len())
+			pl = newS2D(h, i)
+		case *hbook.H2D:
+			return fmt.Errorf("can not overlay a H2D with other values")
+		default:
+			return fmt.Errorf("unhandled value type %T [id=%s]", h, hids[i])
+		}
+		p.Add(pl)
+		if len(objs) > 1 {
+			p.Legend.Add(hids[i], pl.(plot.Thumbnailer))
+		}
+	}
+	p.Legend.Top = true
+
+	return wmgr.newPlot(p)
+}
+
+// newS2D returns a scatter plotter for the i-th plotted value.
+// Points with an undefined position are removed.
+func newS2D(s *hbook.S2D, i int) *hplot.S2D {
+	pts := make([]hbook.Point2D, 0, s.Len())
+	for _, pt := range s.Points() {
+		if math.IsNaN(pt.X) || math.IsNaN(pt.Y) {
+			continue
+		}
+		pts = append(pts, pt)
+	}
+	o := hplot.NewS2D(hbook.NewS2D(pts...), hplot.WithXErrBars|hplot.WithYErrBars)
+	o.GlyphStyle.Color = plotutil.Color(i)
+	o.GlyphStyle.Shape = plotutil.Shape(i)
+	return o
+}
+
+// fit fits the H1D hid with the function named fct.
+// ps are the initial values of the parameters of the function.
+// If ps is empty, initial values are guessed from the histogram.
+func (mgr *histMgr) fit(wmgr *winMgr, hid, fct string, ps []float64) error {
+	obj, err := mgr.get(hid)
 	if err != nil {
 		return err
 	}
-	hh.Infos.Style = hplot.HInfoSummary
+	h, ok := obj.(*hbook.H1D)
+	if !ok {
+		return fmt.Errorf("can only fit H1D values (got %T)", obj)
+	}
 
-	p.Add(hh)
+	f, err := fitFunc(h, fct, ps)
+	if err != nil {
+		return err
+	}
 
-	err = wmgr.newPlot(p)
+	res, err := fit.H1D(h, f, nil, nil)
 	if err != nil {
 		return err
 	}
 
-	return err
+	fmt.Printf("== fit: name=%q func=%q\n", h.Name(), fct)
+	for i, v := range res.X {
+		fmt.Printf("p%d= %+e\n", i, v)
+	}
+
+	fp, err := hplot.NewFitPlot(h, f, res, hplot.Pulls)
+	if err != nil {
+		return err
+	}
+	fp.Main.Title.Text = h.Name()
+	fp.Sub.X.Label.Text = "x"
+	return wmgr.newPlot(fp)
+}
+
+// fitFunc returns the fit function named name, with initial parameters ps.
+//
+// Known functions are:
+//  - gaus: p0*exp(-0.5*((x-p1)/p2)^2)
+//  - expo: exp(p0+p1*x)
+//  - polN: p0+p1*x+...+pN*x^N
+func fitFunc(h *hbook.H1D, name string, ps []float64) (fit.Func1D, error) {
+	var f fit.Func1D
+	switch {
+	case name == "gaus":
+		f.F = func(x float64, ps []float64) float64 {
+			v := (x - ps[1]) / ps[2]
+			return ps[0] * math.Exp(-0.5*v*v)
+		}
+		f.Ps = []float64{hmax(h), h.XMean(), h.XStdDev()}
+	case name == "expo":
+		f.F = func(x float64, ps []float64) float64 {
+			return math.Exp(ps[0] + ps[1]*x)
+		}
+		f.Ps = []float64{0, 0}
+	case strings.HasPrefix(name, "pol"):
+		n, err := strconv.Atoi(name[len("pol"):])
+		if err != nil || n < 0 {
+			return f, fmt.Errorf("invalid polynomial function %q", name)
+		}
+		f.F = func(x float64, ps []float64) float64 {
+			v := 0.0
+			for i := len(ps) - 1; i >= 0; i-- {
+				v = v*x + ps[i]
+			}
+			return v
+		}
+		f.Ps = make([]float64, n+1)
+		f.Ps[0] = h.SumW() / float64(h.Len())
+	default:
+		return f, fmt.Errorf("unknown fit function %q", name)
+	}
+
+	if len(ps) > 0 {
+		if len(ps) != len(f.Ps) {
+			return f, fmt.Errorf("invalid number of parameters for %q (got=%d, want=%d)", name, len(ps), len(f.Ps))
+		}
+		copy(f.Ps, ps)
+	}
+	return f, nil
+}
+
+func hmax(h *hbook.H1D) float64 {
+	max := 0.0
+	for _, bin := range h.Binning().Bins() {
+		max = math.Max(max, bin.SumW())
+	}
+	return max
+}
+
+// op stores into dst the result of the operation op on the arguments args.
+//
+// Known operations are:
+//  - add h1 h2: bin-by-bin sum of the H1D h1 and h2
+//  - sub h1 h2: bin-by-bin difference of the H1D h1 and h2
+//  - div h1 h2: ratio of the H1D h1 and h2, as a S2D
+//  - scale h1 f: H1D h1 scaled by the factor f
+func (mgr *histMgr) op(dst, op string, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%s: need 2 arguments (got=%d)", op, len(args))
+	}
+
+	h1, err := mgr.getH1D(args[0])
+	if err != nil {
+		return err
+	}
+
+	var o hbook.Object
+	switch op {
+	case "add", "sub", "div":
+		h2, err := mgr.getH1D(args[1])
+		if err != nil {
+			return err
+		}
+		switch op {
+		case "add":
+			o, err = hbook.AddH1D(h1, h2)
+		case "sub":
+			o, err = hbook.SubH1D(h1, h2)
+		case "div":
+			o, err = hbook.DivideH1D(h1, h2)
+		}
+		if err != nil {
+			return err
+		}
+	case "scale":
+		f, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid scale factor %q: %v", args[1], err)
+		}
+		h, err := cloneH1D(h1)
+		if err != nil {
+			return err
+		}
+		h.Scale(f)
+		o = h
+	default:
+		return fmt.Errorf("unknown operation %q", op)
+	}
+
+	o.Annotation()["name"] = dst
+	mgr.hists[dst] = o
+	return nil
+}
+
+func (mgr *histMgr) getH1D(hid string) (*hbook.H1D, error) {
+	obj, err := mgr.get(hid)
+	if err != nil {
+		return nil, err
+	}
+	h, ok := obj.(*hbook.H1D)
+	if !ok {
+		return nil, fmt.Errorf("histogram [id=%s] is not a H1D (%T)", hid, obj)
+	}
+	return h, nil
+}
+
+// cloneH1D returns a deep copy of h.
+func cloneH1D(h *hbook.H1D) (*hbook.H1D, error) {
+	raw, err := h.MarshalYODA()
+	if err != nil {
+		return nil, err
+	}
+	var o hbook.H1D
+	err = o.UnmarshalYODA(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// save writes the given histograms to the file fid, created with /file/create.
+func (mgr *histMgr) save(fmgr *fileMgr, fid string, hids ...string) error {
+	w, ok := fmgr.wfds[fid]
+	if !ok {
+		return fmt.Errorf("unknown output file-id [%s]", fid)
+	}
+	for _, hid := range hids {
+		h, err := mgr.get(hid)
+		if err != nil {
+			return err
+		}
+		err = w.write(hid, h)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
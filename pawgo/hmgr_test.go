@@ -0,0 +1,326 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-hep.org/x/hep/hbook"
+	"go-hep.org/x/hep/hbook/yodacnv"
+)
+
+// newTestHists returns a Cmd with the H1D a and b (4 bins in [0, 4)),
+// c (2 bins in [0, 4)), g (a gaussian of amplitude 100, mean 0.5 and
+// sigma 1) and e (exp(4-x)), the H2D h2 and the S2D s.
+func newTestHists() *Cmd {
+	c, _ := newTestCmd()
+
+	a := hbook.NewH1D(4, 0, 4)
+	a.Annotation()["name"] = "h-a"
+	a.Fill(0.5, 1)
+	a.Fill(1.5, 2)
+
+	b := hbook.NewH1D(4, 0, 4)
+	b.Annotation()["name"] = "h-b"
+	b.Fill(0.5, 3)
+	b.Fill(1.5, 1)
+
+	g := hbook.NewH1D(40, -4, 4)
+	e := hbook.NewH1D(40, 0, 4)
+	for i := 0; i < 40; i++ {
+		x := -4 + 0.2*(float64(i)+0.5)
+		v := (x - 0.5) / 1
+		g.Fill(x, 100*math.Exp(-0.5*v*v))
+
+		x = 0.1 * (float64(i) + 0.5)
+		e.Fill(x, math.Exp(4-x))
+	}
+
+	h2 := hbook.NewH2D(2, 0, 2, 2, 0, 2)
+	h2.Fill(0.5, 0.5, 1)
+
+	c.hmgr.hists = map[string]hbook.Object{
+		"a":  a,
+		"b":  b,
+		"c":  hbook.NewH1D(2, 0, 4),
+		"g":  g,
+		"e":  e,
+		"h2": h2,
+		"s":  hbook.NewS2D(hbook.Point2D{X: 1, Y: 2}),
+	}
+	return c
+}
+
+func sumWs(h *hbook.H1D) []float64 {
+	var o []float64
+	for _, bin := range h.Binning().Bins() {
+		o = append(o, bin.SumW())
+	}
+	return o
+}
+
+func TestHistOp(t *testing.T) {
+	for _, test := range []struct {
+		cmd  string
+		want []float64 // bin contents of the H1D result
+		npts int       // number of points of the S2D result
+		err  string
+	}{
+		{cmd: "/hist/op r add a b", want: []float64{4, 3, 0, 0}},
+		{cmd: "/hist/op r sub a b", want: []float64{-2, 1, 0, 0}},
+		{cmd: "/hist/op r scale a 2.5", want: []float64{2.5, 5, 0, 0}},
+		{cmd: "/hist/op r scale a -1", want: []float64{-1, -2, 0, 0}},
+		{cmd: "/hist/op r div a b", npts: 4},
+		{cmd: "/hist/op r", err: "/hist/op: need a histo-id and an operation (got=[r])"},
+		{cmd: "/hist/op r add a", err: "add: need 2 arguments (got=1)"},
+		{cmd: "/hist/op r mul a b", err: `unknown operation "mul"`},
+		{cmd: "/hist/op r add a nope", err: "unknown histogram [id=nope]"},
+		{cmd: "/hist/op r add a h2", err: "histogram [id=h2] is not a H1D (*hbook.H2D)"},
+		{cmd: "/hist/op r scale s 2", err: "histogram [id=s] is not a H1D (*hbook.S2D)"},
+		{cmd: "/hist/op r scale a x", err: `invalid scale factor "x": strconv.ParseFloat: parsing "x": invalid syntax`},
+		{cmd: "/hist/op r add a c", err: "hbook: x binnings are not equivalent in h-a and "},
+	} {
+		t.Run(test.cmd, func(t *testing.T) {
+			c := newTestHists()
+			err := c.exec(test.cmd)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v. want %q", err, test.err)
+				}
+				if _, ok := c.hmgr.hists["r"]; ok {
+					t.Fatalf("result stored despite the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := c.hmgr.get("r")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Name() != "r" {
+				t.Fatalf("got name %q. want %q", r.Name(), "r")
+			}
+			switch r := r.(type) {
+			case *hbook.H1D:
+				if got := sumWs(r); !reflect.DeepEqual(got, test.want) {
+					t.Fatalf("got %v. want %v", got, test.want)
+				}
+			case *hbook.S2D:
+				if r.Len() != test.npts {
+					t.Fatalf("got %d points. want %d", r.Len(), test.npts)
+				}
+			default:
+				t.Fatalf("invalid result type %T", r)
+			}
+
+			// the operands are left untouched.
+			a := c.hmgr.hists["a"].(*hbook.H1D)
+			if got, want := sumWs(a), []float64{1, 2, 0, 0}; !reflect.DeepEqual(got, want) {
+				t.Fatalf("operand modified: got %v. want %v", got, want)
+			}
+			if a.Name() != "h-a" {
+				t.Fatalf("operand renamed to %q", a.Name())
+			}
+		})
+	}
+}
+
+func TestHistFit(t *testing.T) {
+	for _, test := range []struct {
+		cmd string
+		err string
+	}{
+		{cmd: "/hist/fit g gaus"},
+		{cmd: "/hist/fit g gaus 90 0 2"},
+		{cmd: "/hist/fit e expo"},
+		{cmd: "/hist/fit e expo 3 -0.5"},
+		{cmd: "/hist/fit a pol1"},
+		{cmd: "/hist/fit a pol2 1 0 0"},
+		{cmd: "/hist/fit g", err: "/hist/fit: need a histo-id and a function name (got=[g])"},
+		{cmd: "/hist/fit g gaus 1 x 2", err: `/hist/fit: invalid parameter "x": strconv.ParseFloat: parsing "x": invalid syntax`},
+		{cmd: "/hist/fit g gaus 1 2", err: `invalid number of parameters for "gaus" (got=2, want=3)`},
+		{cmd: "/hist/fit g lorentz", err: `unknown fit function "lorentz"`},
+		{cmd: "/hist/fit g polx", err: `invalid polynomial function "polx"`},
+		{cmd: "/hist/fit nope gaus", err: "unknown histogram [id=nope]"},
+		{cmd: "/hist/fit h2 gaus", err: "can only fit H1D values (got *hbook.H2D)"},
+	} {
+		t.Run(test.cmd, func(t *testing.T) {
+			c := newTestHists()
+			err := c.exec(test.cmd)
+			switch {
+			case test.err != "":
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v. want %q", err, test.err)
+				}
+			case err != nil:
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestFitFunc(t *testing.T) {
+	h := hbook.NewH1D(4, 0, 4)
+	h.Fill(1.5, 8)
+
+	for _, test := range []struct {
+		name string
+		ps   []float64
+		x    float64
+		want float64
+		init []float64
+	}{
+		{name: "gaus", ps: []float64{2, 1, 0.5}, x: 2, want: 2 * math.Exp(-2)},
+		{name: "gaus", ps: []float64{8, 1.5, 1}, x: 1.5, want: 8},
+		{name: "expo", ps: []float64{1, -2}, x: 0.5, want: 1},
+		{name: "expo", x: 2, want: 1, init: []float64{0, 0}},
+		{name: "pol0", ps: []float64{3}, x: 10, want: 3},
+		{name: "pol2", ps: []float64{1, 2, 3}, x: 2, want: 17},
+		{name: "pol1", x: 2, want: 2, init: []float64{2, 0}},
+	} {
+		f, err := fitFunc(h, test.name, test.ps)
+		if err != nil {
+			t.Errorf("%s%v: %v", test.name, test.ps, err)
+			continue
+		}
+		if test.init != nil && !reflect.DeepEqual(f.Ps, test.init) {
+			t.Errorf("%s: got initial parameters %v. want %v", test.name, f.Ps, test.init)
+		}
+		if got := f.F(test.x, f.Ps); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("%s%v: f(%v)=%v. want %v", test.name, f.Ps, test.x, got, test.want)
+		}
+	}
+}
+
+func TestHistPlot(t *testing.T) {
+	for _, test := range []struct {
+		cmd string
+		err string
+	}{
+		{cmd: "/hist/plot a"},
+		{cmd: "/hist/plot a b g"},
+		{cmd: "/hist/plot a s"},
+		{cmd: "/hist/plot h2"},
+		{cmd: "/hist/plot", err: "/hist/plot: need a histo-id to plot"},
+		{cmd: "/hist/plot nope", err: "unknown histogram [id=nope]"},
+		{cmd: "/hist/plot h2 a", err: "can not overlay a H2D with other values"},
+		{cmd: "/hist/plot a h2", err: "can not overlay a H2D with other values"},
+	} {
+		t.Run(test.cmd, func(t *testing.T) {
+			c := newTestHists()
+			err := c.exec(test.cmd)
+			switch {
+			case test.err != "":
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v. want %q", err, test.err)
+				}
+			case err != nil:
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestHistSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pawgo-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, test := range []struct {
+		fname string
+		hids  []string
+		err   string
+	}{
+		{fname: "out.yoda", hids: []string{"a", "h2", "s"}},
+		{fname: "out.rio", hids: []string{"a", "h2", "s"}},
+		{fname: "nope.yoda", hids: []string{"a", "nope"}, err: "unknown histogram [id=nope]"},
+	} {
+		t.Run(test.fname, func(t *testing.T) {
+			c := newTestHists()
+			defer c.fmgr.Close()
+
+			fname := filepath.Join(dir, test.fname)
+			err := c.exec("/file/create o " + fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = c.exec("/hist/save o " + strings.Join(test.hids, " "))
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v. want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = c.exec("/file/close o")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the saved histograms keep their name.
+			if got := c.hmgr.hists["a"].Name(); got != "h-a" {
+				t.Fatalf("histogram renamed to %q", got)
+			}
+
+			var names []string
+			switch filepath.Ext(fname) {
+			case ".yoda":
+				f, err := os.Open(fname)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+				objs, err := yodacnv.Read(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, o := range objs {
+					names = append(names, o.Name())
+				}
+			default:
+				err = c.exec("/file/open f " + fname)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r := c.fmgr.rfds["f"]
+				names = r.keys()
+
+				err = c.exec("/hist/open x /file/id/f/a")
+				if err != nil {
+					t.Fatal(err)
+				}
+				x, err := c.hmgr.getH1D("x")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, want := sumWs(x), []float64{1, 2, 0, 0}; !reflect.DeepEqual(got, want) {
+					t.Fatalf("got %v. want %v", got, want)
+				}
+			}
+			if !reflect.DeepEqual(names, test.hids) {
+				t.Fatalf("got %q. want %q", names, test.hids)
+			}
+		})
+	}
+
+	c := newTestHists()
+	err = c.exec("/hist/save o a")
+	if err == nil || err.Error() != "unknown output file-id [o]" {
+		t.Fatalf("got error %v. want an unknown file error", err)
+	}
+}
@@ -0,0 +1,451 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A macro is a named sequence of statements, defined in a .kumac-style
+// file between a "macro name" line and a "return" line.
+// A file without any "macro" line defines a single unnamed macro.
+//
+// Statements are either PAW-Go commands or control blocks:
+//
+//	do i = 1, 10 [, step]      for v in a b c      if [i] > 2 then
+//	  ...                        ...                 ...
+//	enddo                      endfor              elseif [i] = 1 then
+//	                                                 ...
+//	                                               else
+//	                                                 ...
+//	                                               endif
+//
+// "exitm" exits the current macro.
+//
+// Before a statement is executed, the variables it references are
+// substituted:
+//   - [1], [2], ... by the arguments of the macro,
+//   - [#] by the number of arguments of the macro,
+//   - [*] by all the arguments of the macro,
+//   - [name] by the value of the loop variable or of the variable
+//     name, defined with /set.
+//
+// References to unknown variables are left untouched.
+type macro struct {
+	name  string
+	stmts []stmt
+}
+
+// frame holds the arguments and the loop variables of a running macro.
+type frame struct {
+	args []string
+	vars map[string]string
+}
+
+func newFrame(args []string) *frame {
+	return &frame{args: args, vars: make(map[string]string)}
+}
+
+type stmt interface {
+	run(c *Cmd, f *frame) error
+}
+
+// errExitMacro is returned by the exitm statement.
+var errExitMacro = errors.New("paw: exit macro")
+
+var reVar = regexp.MustCompile(`\[[^\[\] ]+\]`)
+
+// subst substitutes the variables referenced in line.
+func (c *Cmd) subst(f *frame, line string) string {
+	return reVar.ReplaceAllStringFunc(line, func(v string) string {
+		name := v[1 : len(v)-1]
+		switch name {
+		case "#":
+			return strconv.Itoa(len(f.args))
+		case "*":
+			return strings.Join(f.args, " ")
+		}
+		if i, err := strconv.Atoi(name); err == nil {
+			if i > 0 && i <= len(f.args) {
+				return f.args[i-1]
+			}
+			return v
+		}
+		if val, ok := f.vars[name]; ok {
+			return val
+		}
+		if val, ok := c.vars[name]; ok {
+			return val
+		}
+		return v
+	})
+}
+
+// runMacro runs the statements of the macro m with the given arguments.
+func (c *Cmd) runMacro(m *macro, args []string) error {
+	err := runStmts(c, newFrame(args), m.stmts)
+	if err == errExitMacro {
+		err = nil
+	}
+	return err
+}
+
+func runStmts(c *Cmd, f *frame, stmts []stmt) error {
+	for _, s := range stmts {
+		err := s.run(c, f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cmdStmt is a PAW-Go command.
+type cmdStmt struct {
+	line string
+}
+
+func (s *cmdStmt) run(c *Cmd, f *frame) error {
+	line := c.subst(f, s.line)
+	fmt.Printf("# %s\n", line)
+	err := c.exec(line)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("error executing %q: %v", line, err)
+	}
+	return err
+}
+
+type exitStmt struct{}
+
+func (exitStmt) run(c *Cmd, f *frame) error { return errExitMacro }
+
+// doStmt is a "do i = beg, end [, step]" loop.
+type doStmt struct {
+	hdr  string
+	body []stmt
+}
+
+func (s *doStmt) run(c *Cmd, f *frame) error {
+	hdr := c.subst(f, s.hdr)
+	i := strings.Index(hdr, "=")
+	if i < 0 {
+		return fmt.Errorf("paw: invalid do loop %q", hdr)
+	}
+	name := strings.TrimSpace(hdr[:i])
+	toks := strings.Split(hdr[i+1:], ",")
+	if name == "" || len(toks) < 2 || len(toks) > 3 {
+		return fmt.Errorf("paw: invalid do loop %q", hdr)
+	}
+	vs := []float64{0, 0, 1}
+	for j, tok := range toks {
+		v, err := strconv.ParseFloat(strings.TrimSpace(tok), 64)
+		if err != nil {
+			return fmt.Errorf("paw: invalid do loop %q: %v", hdr, err)
+		}
+		vs[j] = v
+	}
+	beg, end, step := vs[0], vs[1], vs[2]
+	if step == 0 {
+		return fmt.Errorf("paw: invalid do loop %q: null step", hdr)
+	}
+
+	for v := beg; (step > 0 && v <= end) || (step < 0 && v >= end); v += step {
+		f.vars[name] = strconv.FormatFloat(v, 'g', -1, 64)
+		err := runStmts(c, f, s.body)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forStmt is a "for v in a b c" loop.
+type forStmt struct {
+	hdr  string
+	body []stmt
+}
+
+func (s *forStmt) run(c *Cmd, f *frame) error {
+	toks := strings.Fields(c.subst(f, s.hdr))
+	if len(toks) < 2 || toks[1] != "in" {
+		return fmt.Errorf("paw: invalid for loop %q", s.hdr)
+	}
+	name := toks[0]
+	for _, v := range toks[2:] {
+		f.vars[name] = v
+		err := runStmts(c, f, s.body)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ifStmt is an "if cond then ... [elseif cond then ...] [else ...] endif" block.
+type ifStmt struct {
+	conds  []string
+	bodies [][]stmt // bodies[len(conds)], if any, is the else block.
+}
+
+func (s *ifStmt) run(c *Cmd, f *frame) error {
+	for i, cond := range s.conds {
+		ok, err := evalCond(c.subst(f, cond))
+		if err != nil {
+			return err
+		}
+		if ok {
+			return runStmts(c, f, s.bodies[i])
+		}
+	}
+	if len(s.bodies) > len(s.conds) {
+		return runStmts(c, f, s.bodies[len(s.conds)])
+	}
+	return nil
+}
+
+// evalCond evaluates a "lhs op rhs" condition.
+// Operands are compared as numbers if both are numbers, as strings otherwise.
+func evalCond(cond string) (bool, error) {
+	toks := strings.Fields(cond)
+	if len(toks) != 3 {
+		return false, fmt.Errorf("paw: invalid condition %q", cond)
+	}
+	lhs, op, rhs := toks[0], strings.ToLower(toks[1]), toks[2]
+
+	cmp := strings.Compare(lhs, rhs)
+	x, errx := strconv.ParseFloat(lhs, 64)
+	y, erry := strconv.ParseFloat(rhs, 64)
+	if errx == nil && erry == nil {
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = +1
+		default:
+			cmp = 0
+		}
+	}
+
+	switch op {
+	case "=", "==", ".eq.":
+		return cmp == 0, nil
+	case "<>", "!=", ".ne.":
+		return cmp != 0, nil
+	case "<", ".lt.":
+		return cmp < 0, nil
+	case "<=", ".le.":
+		return cmp <= 0, nil
+	case ">", ".gt.":
+		return cmp > 0, nil
+	case ">=", ".ge.":
+		return cmp >= 0, nil
+	}
+	return false, fmt.Errorf("paw: invalid operator %q in condition %q", toks[1], cond)
+}
+
+// parseMacros parses the macros defined in r.
+func parseMacros(r io.Reader) ([]*macro, error) {
+	var lines []string
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		lines = append(lines, scan.Text())
+	}
+	err := scan.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{lines: lines}
+	return p.parse()
+}
+
+// findMacro returns the macro named name, or the first macro if name is empty.
+func findMacro(ms []*macro, name string) (*macro, error) {
+	if len(ms) == 0 {
+		return nil, fmt.Errorf("paw: no macro defined")
+	}
+	if name == "" {
+		return ms[0], nil
+	}
+	for _, m := range ms {
+		if m.name == name {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("paw: no macro named %q", name)
+}
+
+type parser struct {
+	lines []string
+	pos   int
+}
+
+// keyword returns the lower-cased first word of line and the rest of line.
+func keyword(line string) (string, string) {
+	toks := strings.SplitN(line, " ", 2)
+	kw := strings.ToLower(toks[0])
+	rest := ""
+	if len(toks) > 1 {
+		rest = strings.TrimSpace(toks[1])
+	}
+	return kw, rest
+}
+
+func (p *parser) next() (string, bool) {
+	for p.pos < len(p.lines) {
+		line := strings.TrimSpace(p.lines[p.pos])
+		p.pos++
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		return line, true
+	}
+	return "", false
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("paw: line %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parse() ([]*macro, error) {
+	var (
+		ms  []*macro
+		top = &macro{}
+	)
+	for {
+		line, ok := p.next()
+		if !ok {
+			break
+		}
+		kw, rest := keyword(line)
+		if kw != "macro" {
+			p.pos--
+			// statements outside of a macro block, until the next one.
+			stmts, _, err := p.block("macro")
+			if err != nil {
+				return nil, err
+			}
+			top.stmts = append(top.stmts, stmts...)
+			continue
+		}
+		if rest == "" {
+			return nil, p.errorf("missing macro name")
+		}
+		stmts, end, err := p.block("return")
+		if err != nil {
+			return nil, err
+		}
+		if end != "return" {
+			return nil, p.errorf("missing return for macro %q", rest)
+		}
+		ms = append(ms, &macro{name: strings.Fields(rest)[0], stmts: stmts})
+	}
+
+	if len(top.stmts) > 0 || len(ms) == 0 {
+		ms = append([]*macro{top}, ms...)
+	}
+	return ms, nil
+}
+
+// block parses statements until one of the given end keywords (or the end
+// of the input) and returns the statements and the end line.
+func (p *parser) block(ends ...string) ([]stmt, string, error) {
+	var stmts []stmt
+	for {
+		line, ok := p.next()
+		if !ok {
+			return stmts, "", nil
+		}
+		kw, rest := keyword(line)
+		for _, end := range ends {
+			if kw == end {
+				if end == "macro" {
+					p.pos--
+				}
+				return stmts, line, nil
+			}
+		}
+
+		switch kw {
+		case "do":
+			body, end, err := p.block("enddo")
+			if err != nil {
+				return nil, "", err
+			}
+			if end == "" {
+				return nil, "", p.errorf("missing enddo")
+			}
+			stmts = append(stmts, &doStmt{hdr: rest, body: body})
+
+		case "for":
+			body, end, err := p.block("endfor")
+			if err != nil {
+				return nil, "", err
+			}
+			if end == "" {
+				return nil, "", p.errorf("missing endfor")
+			}
+			stmts = append(stmts, &forStmt{hdr: rest, body: body})
+
+		case "if":
+			s, err := p.ifBlock(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			stmts = append(stmts, s)
+
+		case "exitm":
+			stmts = append(stmts, exitStmt{})
+
+		case "enddo", "endfor", "endif", "else", "elseif", "return":
+			return nil, "", p.errorf("unexpected %q", kw)
+
+		default:
+			stmts = append(stmts, &cmdStmt{line: line})
+		}
+	}
+}
+
+func (p *parser) ifBlock(cond string) (*ifStmt, error) {
+	s := &ifStmt{}
+	for {
+		if !strings.HasSuffix(strings.ToLower(cond), " then") {
+			return nil, p.errorf("missing then in if statement")
+		}
+		s.conds = append(s.conds, strings.TrimSpace(cond[:len(cond)-len(" then")]))
+		body, end, err := p.block("elseif", "else", "endif")
+		if err != nil {
+			return nil, err
+		}
+		s.bodies = append(s.bodies, body)
+
+		kw, rest := keyword(end)
+		switch kw {
+		case "endif":
+			return s, nil
+		case "elseif":
+			cond = rest
+			continue
+		case "else":
+			body, end, err := p.block("endif")
+			if err != nil {
+				return nil, err
+			}
+			if end == "" {
+				return nil, p.errorf("missing endif")
+			}
+			s.bodies = append(s.bodies, body)
+			return s, nil
+		default:
+			return nil, p.errorf("missing endif")
+		}
+	}
+}
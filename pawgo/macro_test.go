@@ -0,0 +1,381 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// cmdRec records the arguments of its invocations.
+type cmdRec struct {
+	lines []string
+}
+
+func (cmd *cmdRec) Name() string                  { return "/rec" }
+func (cmd *cmdRec) Help(w io.Writer)              {}
+func (cmd *cmdRec) Complete(line string) []string { return nil }
+
+func (cmd *cmdRec) Run(args []string) error {
+	cmd.lines = append(cmd.lines, strings.Join(args, " "))
+	return nil
+}
+
+// newTestCmd returns a Cmd with the file, histogram, n-tuple and macro
+// commands and a /rec command, without a terminal nor a screen.
+func newTestCmd() (*Cmd, *cmdRec) {
+	rec := &cmdRec{}
+	c := &Cmd{
+		msg:  log.New(ioutil.Discard, "paw: ", 0),
+		wmgr: newWinMgr(nil),
+		fmgr: newFileMgr(),
+		hmgr: newHistMgr(),
+		nmgr: newNtupMgr(),
+		vars: make(map[string]string),
+		top:  newFrame(nil),
+	}
+	c.cmds = map[string]Cmdr{
+		"/rec": rec,
+
+		"/file/open":   &cmdFileOpen{c},
+		"/file/close":  &cmdFileClose{c},
+		"/file/create": &cmdFileCreate{c},
+		"/file/ls":     &cmdFileList{c},
+
+		"/hist/open": &cmdHistOpen{c},
+		"/hist/plot": &cmdHistPlot{c},
+		"/hist/fit":  &cmdHistFit{c},
+		"/hist/op":   &cmdHistOp{c},
+		"/hist/save": &cmdHistSave{c},
+
+		"/ntuple/open": &cmdNtupleOpen{c},
+		"/ntuple/plot": &cmdNtuplePlot{c},
+
+		"/exec":    &cmdExec{c},
+		"/set":     &cmdSet{c},
+		"/echo":    &cmdEcho{c},
+		"/history": &cmdHistory{c},
+	}
+	return c, rec
+}
+
+// runSource runs the first macro defined in src.
+func runSource(c *Cmd, src string, args ...string) error {
+	ms, err := parseMacros(strings.NewReader(src))
+	if err != nil {
+		return err
+	}
+	m, err := findMacro(ms, "")
+	if err != nil {
+		return err
+	}
+	return c.runMacro(m, args)
+}
+
+func TestSubst(t *testing.T) {
+	c, _ := newTestCmd()
+	c.vars["name"] = "global"
+	c.vars["v"] = "global-v"
+	f := newFrame([]string{"a", "b c"})
+	f.vars["v"] = "local-v"
+
+	for _, test := range []struct {
+		line string
+		want string
+	}{
+		{"/rec no variables", "/rec no variables"},
+		{"/rec [1] [2]", "/rec a b c"},
+		{"/rec [#]", "/rec 2"},
+		{"/rec [*]", "/rec a b c"},
+		{"/rec [3] [0]", "/rec [3] [0]"},
+		{"/rec [name]", "/rec global"},
+		{"/rec [v]", "/rec local-v"},
+		{"/rec h[1]x", "/rec hax"},
+		{"/rec [unknown] [a b]", "/rec [unknown] [a b]"},
+		{"/rec [[1]]", "/rec [a]"},
+	} {
+		if got := c.subst(f, test.line); got != test.want {
+			t.Errorf("subst(%q): got %q. want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		src   string
+		names []string
+		err   string
+	}{
+		{
+			src:   "/rec a\n/rec b\n",
+			names: []string{""},
+		},
+		{
+			src:   "",
+			names: []string{""},
+		},
+		{
+			src:   "# comment\nmacro m1\n/rec a\nreturn\n\nMACRO m2 arg\nreturn\n",
+			names: []string{"m1", "m2"},
+		},
+		{
+			src:   "/rec top\nmacro m1\nreturn\n",
+			names: []string{"", "m1"},
+		},
+		{
+			src: "macro\nreturn\n",
+			err: "paw: line 1: missing macro name",
+		},
+		{
+			src: "macro m1\n/rec a\n",
+			err: `paw: line 2: missing return for macro "m1"`,
+		},
+		{
+			src: "do i = 1, 2\n/rec [i]\n",
+			err: "paw: line 2: missing enddo",
+		},
+		{
+			src: "for v in a b\n/rec [v]\n",
+			err: "paw: line 2: missing endfor",
+		},
+		{
+			src: "if 1 = 1\n/rec a\nendif\n",
+			err: "paw: line 1: missing then in if statement",
+		},
+		{
+			src: "if 1 = 1 then\n/rec a\nelse\n/rec b\n",
+			err: "paw: line 4: missing endif",
+		},
+		{
+			src: "/rec a\nenddo\n",
+			err: `paw: line 2: unexpected "enddo"`,
+		},
+	} {
+		ms, err := parseMacros(strings.NewReader(test.src))
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%q: got error %v. want %q", test.src, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.src, err)
+			continue
+		}
+		var names []string
+		for _, m := range ms {
+			names = append(names, m.name)
+		}
+		if !reflect.DeepEqual(names, test.names) {
+			t.Errorf("%q: got macros %q. want %q", test.src, names, test.names)
+		}
+	}
+}
+
+func TestRunStmts(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		src  string
+		args []string
+		want []string
+		err  string
+	}{
+		{
+			name: "args",
+			src:  "/rec [#]: [*]\n/rec [2] [1]\n",
+			args: []string{"x", "y"},
+			want: []string{"2: x y", "y x"},
+		},
+		{
+			name: "do",
+			src:  "do i = 1, [1]\n/rec h[i]\nenddo\n",
+			args: []string{"3"},
+			want: []string{"h1", "h2", "h3"},
+		},
+		{
+			name: "do-step",
+			src:  "do i = 1, 0, -0.5\n/rec [i]\nenddo\n",
+			want: []string{"1", "0.5", "0"},
+		},
+		{
+			name: "do-empty",
+			src:  "do i = 2, 1\n/rec [i]\nenddo\n/rec done\n",
+			want: []string{"done"},
+		},
+		{
+			name: "for",
+			src:  "for v in [*]\n/rec [v]\nendfor\n",
+			args: []string{"a", "b"},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "nested",
+			src:  "for v in a b\ndo i = 1, 2\n/rec [v][i]\nenddo\nendfor\n",
+			want: []string{"a1", "a2", "b1", "b2"},
+		},
+		{
+			name: "if",
+			src: `do i = 1, 4
+if [i] = 1 then
+/rec one
+elseif [i] .lt. 3 then
+/rec two
+elseif [i] <> 4 then
+/rec three
+else
+/rec [i]
+endif
+enddo
+`,
+			want: []string{"one", "two", "three", "4"},
+		},
+		{
+			name: "if-strings",
+			src:  "if [1] = abc then\n/rec eq\nendif\nif [1] < abd then\n/rec lt\nendif\n",
+			args: []string{"abc"},
+			want: []string{"eq", "lt"},
+		},
+		{
+			name: "exitm",
+			src:  "do i = 1, 10\nif [i] > 2 then\nexitm\nendif\n/rec [i]\nenddo\n/rec unreachable\n",
+			want: []string{"1", "2"},
+		},
+		{
+			name: "set",
+			src:  "/set x a b\n/rec [x]\n/set x\n/rec [x]\n",
+			want: []string{"a b", "[x]"},
+		},
+		{
+			name: "empty-line",
+			src:  "/set cmd \"\"\n[cmd]\n/rec done\n",
+			want: []string{"done"},
+		},
+		{
+			name: "unknown-command",
+			src:  "/rec a\n/nope\n/rec b\n",
+			want: []string{"a"},
+			err:  `error executing "/nope": unknown command "/nope"`,
+		},
+		{
+			name: "invalid-do",
+			src:  "do i = 1\n/rec [i]\nenddo\n",
+			err:  `paw: invalid do loop "i = 1"`,
+		},
+		{
+			name: "invalid-cond",
+			src:  "if [1] ~ 1 then\n/rec a\nendif\n",
+			args: []string{"1"},
+			err:  `paw: invalid operator "~" in condition "1 ~ 1"`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, rec := newTestCmd()
+			err := runSource(c, test.src, test.args...)
+			switch {
+			case test.err != "":
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v. want %q", err, test.err)
+				}
+			case err != nil:
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rec.lines, test.want) {
+				t.Fatalf("got %q. want %q", rec.lines, test.want)
+			}
+		})
+	}
+}
+
+func TestExec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pawgo-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, src := range map[string]string{
+		"outer.kumac": "/rec outer [1]\n/exec " + filepath.Join(dir, "inner") + "#twice [1] z\n/rec outer done\n",
+		"inner.kumac": "macro once\n/rec once\nreturn\nmacro twice\ndo i = 1, 2\n/rec inner [*] [i]\nenddo\nexitm\n/rec unreachable\nreturn\n",
+		"loop.kumac":  "/exec " + filepath.Join(dir, "loop") + "\n",
+	} {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, rec := newTestCmd()
+	err = c.exec("/exec " + filepath.Join(dir, "outer.kumac") + " y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"outer y", "inner y z 1", "inner y z 2", "outer done"}
+	if !reflect.DeepEqual(rec.lines, want) {
+		t.Fatalf("got %q. want %q", rec.lines, want)
+	}
+
+	err = c.exec("/exec " + filepath.Join(dir, "inner.kumac") + "#nope")
+	if err == nil || !strings.HasSuffix(err.Error(), `paw: no macro named "nope"`) {
+		t.Fatalf("got error %v. want a missing macro error", err)
+	}
+
+	err = c.exec("/exec " + filepath.Join(dir, "loop"))
+	if err == nil || !strings.Contains(err.Error(), "/exec: too many nested macros") {
+		t.Fatalf("got error %v. want a nesting error", err)
+	}
+	if c.depth != 0 {
+		t.Fatalf("got depth=%d after /exec. want 0", c.depth)
+	}
+}
+
+func TestHistorySave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pawgo-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, rec := newTestCmd()
+	c.hist = []string{
+		"/set x 2",
+		"do i = 1, [x]",
+		"/rec [i]",
+		"enddo",
+	}
+
+	fname := filepath.Join(dir, "hist.kumac")
+	err = c.exec("/history save " + fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := ioutil.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(raw), strings.Join(c.hist, "\n")+"\n"; got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// the saved history is a macro replaying the session.
+	err = c.exec("/exec " + fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1", "2"}; !reflect.DeepEqual(rec.lines, want) {
+		t.Fatalf("got %q. want %q", rec.lines, want)
+	}
+
+	err = c.exec("/history load " + fname)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"go-hep.org/x/hep/hbook/ntup"
+	"go-hep.org/x/hep/hbook/ntup/ntcsv"
+)
+
+type ntupMgr struct {
+	nts map[string]*ntup.Ntuple
+}
+
+func newNtupMgr() *ntupMgr {
+	return &ntupMgr{
+		nts: make(map[string]*ntup.Ntuple),
+	}
+}
+
+// open opens the CSV file fname as the n-tuple nid.
+// The names of the columns are read from the header of the file if
+// header is true, and are "var1", "var2", ... otherwise.
+func (mgr *ntupMgr) open(nid, fname string, header bool) error {
+	if _, dup := mgr.nts[nid]; dup {
+		return fmt.Errorf("paw: n-tuple [id=%s] already open", nid)
+	}
+
+	var opts []ntcsv.Option
+	if header {
+		opts = append(opts, ntcsv.Header())
+	}
+	nt, err := ntcsv.Open(fname, opts...)
+	if err != nil {
+		return err
+	}
+
+	mgr.nts[nid] = nt
+	return nil
+}
+
+// plot plots the distribution of the variables vars of the n-tuple nid,
+// for the rows passing the cut.
+// vars is either a single variable (e.g. "x"), filling a H1D, or two
+// variables separated by a colon (e.g. "x:y"), filling a H2D.
+// The resulting histogram is registered with hmgr as hid.
+func (mgr *ntupMgr) plot(wmgr *winMgr, hmgr *histMgr, nid, hid, vars, cut string) error {
+	nt, ok := mgr.nts[nid]
+	if !ok {
+		return fmt.Errorf("unknown n-tuple [id=%s]", nid)
+	}
+
+	q := strings.Replace(vars, ":", ", ", -1)
+	if cut != "" {
+		q += " where " + cut
+	}
+
+	switch strings.Count(vars, ":") {
+	case 0:
+		h, err := nt.ScanH1D(q, nil)
+		if err != nil {
+			return err
+		}
+		h.Annotation()["name"] = vars
+		hmgr.hists[hid] = h
+	case 1:
+		h, err := nt.ScanH2D(q, nil)
+		if err != nil {
+			return err
+		}
+		h.Annotation()["name"] = vars
+		hmgr.hists[hid] = h
+	default:
+		return fmt.Errorf("invalid n-tuple variables %q", vars)
+	}
+
+	return hmgr.plot(wmgr, hid)
+}
+
+func (mgr *ntupMgr) Close() error {
+	var err error
+	for k, nt := range mgr.nts {
+		e := nt.DB().Close()
+		if e != nil {
+			fmt.Printf("error closing n-tuple [%s]: %v\n", k, e)
+			if err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
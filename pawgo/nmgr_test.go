@@ -0,0 +1,131 @@
+// Copyright 2017 The go-hep Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"go-hep.org/x/hep/hbook"
+)
+
+const ntFile = "testdata/data.csv" // 500 rows of (x,y), 252 with y>0
+
+func TestNtupleOpen(t *testing.T) {
+	for _, test := range []struct {
+		cmds []string
+		err  string
+	}{
+		{cmds: []string{"/ntuple/open nt " + ntFile + " header"}},
+		{cmds: []string{"/ntuple/open nt " + ntFile}},
+		{
+			cmds: []string{"/ntuple/open nt"},
+			err:  "/ntuple/open: need a n-tuple-id and a file name (got=[nt])",
+		},
+		{
+			cmds: []string{"/ntuple/open nt " + ntFile + " csv"},
+			err:  `/ntuple/open: invalid option "csv"`,
+		},
+		{
+			cmds: []string{
+				"/ntuple/open nt " + ntFile + " header",
+				"/ntuple/open nt " + ntFile + " header",
+			},
+			err: "paw: n-tuple [id=nt] already open",
+		},
+	} {
+		t.Run(test.cmds[len(test.cmds)-1], func(t *testing.T) {
+			c, _ := newTestCmd()
+			defer c.nmgr.Close()
+
+			var err error
+			for _, cmd := range test.cmds {
+				err = c.exec(cmd)
+				if err != nil {
+					break
+				}
+			}
+			switch {
+			case test.err != "":
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v. want %q", err, test.err)
+				}
+			case err != nil:
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestNtuplePlot(t *testing.T) {
+	for _, test := range []struct {
+		cmd     string
+		hid     string
+		h2d     bool
+		entries int64
+		err     string
+	}{
+		{cmd: "/ntuple/plot nt x", hid: "nt/x", entries: 500},
+		{cmd: "/ntuple/plot nt x y>0", hid: "nt/x", entries: 252},
+		{cmd: "/ntuple/plot nt x:y", hid: "nt/x:y", h2d: true, entries: 500},
+		{cmd: "/ntuple/plot nt x:y y > 0", hid: "nt/x:y", h2d: true, entries: 252},
+		{
+			cmd: "/ntuple/plot nt",
+			err: "/ntuple/plot: need a n-tuple-id and variables (got=[nt])",
+		},
+		{
+			cmd: "/ntuple/plot nope x",
+			err: "unknown n-tuple [id=nope]",
+		},
+		{
+			cmd: "/ntuple/plot nt x:y:x",
+			err: `invalid n-tuple variables "x:y:x"`,
+		},
+	} {
+		t.Run(test.cmd, func(t *testing.T) {
+			c, _ := newTestCmd()
+			defer c.nmgr.Close()
+
+			err := c.exec("/ntuple/open nt " + ntFile + " header")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = c.exec(test.cmd)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v. want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the histogram is registered for the /hist commands.
+			h, err := c.hmgr.get(test.hid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var entries int64
+			switch h := h.(type) {
+			case *hbook.H1D:
+				if test.h2d {
+					t.Fatalf("got a H1D. want a H2D")
+				}
+				entries = h.Entries()
+			case *hbook.H2D:
+				if !test.h2d {
+					t.Fatalf("got a H2D. want a H1D")
+				}
+				entries = h.Entries()
+			default:
+				t.Fatalf("invalid histogram type %T", h)
+			}
+			if entries != test.entries {
+				t.Fatalf("got %d entries. want %d", entries, test.entries)
+			}
+		})
+	}
+}
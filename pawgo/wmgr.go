@@ -11,6 +11,7 @@ import (
 	"go-hep.org/x/hep/hplot/vgshiny"
 	"gonum.org/v1/plot/vg"
 	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
 
 	"golang.org/x/exp/shiny/screen"
 )
@@ -30,7 +31,22 @@ func newWinMgr(scr screen.Screen) *winMgr {
 	}
 }
 
-func (wmgr *winMgr) newPlot(p *hplot.Plot) error {
+// drawer is a plot that can be drawn on a canvas, such as a hplot.Plot,
+// a hplot.FitPlot or a hplot.ColorBarPlot.
+type drawer interface {
+	Draw(c draw.Canvas)
+}
+
+// newPlot displays the plot p in a new window.
+// Windows displaying a hplot.Plot can be zoomed and panned.
+// Without a screen (e.g. in tests), p is drawn on an image which is
+// discarded.
+func (wmgr *winMgr) newPlot(p drawer) error {
+	if wmgr.scr == nil {
+		p.Draw(draw.New(vgimg.New(xmax, ymax)))
+		return nil
+	}
+
 	cnv, err := vgshiny.New(wmgr.scr, xmax, ymax)
 	if err != nil {
 		return err
@@ -38,7 +54,12 @@ func (wmgr *winMgr) newPlot(p *hplot.Plot) error {
 	p.Draw(draw.New(cnv))
 	cnv.Paint()
 	go func() {
-		cnv.Run(nil)
+		switch p := p.(type) {
+		case *hplot.Plot:
+			p.Explore(cnv)
+		default:
+			cnv.Run(nil)
+		}
 		cnv.Release()
 	}()
 
@@ -254,14 +254,20 @@ func (b *tbranch) loadBasket(entry int64) error {
 		return err
 	}
 
-	b.baskets = append(b.baskets, Basket{})
-	b.basket = &b.baskets[len(b.baskets)-1]
+	if ib == len(b.baskets) {
+		b.baskets = append(b.baskets, Basket{})
+		b.basket = &b.baskets[ib]
+	} else {
+		// baskets were skipped (e.g. after a SeekEntry): do not cache
+		// this one at the wrong index.
+		b.basket = &Basket{}
+	}
 	err = b.basket.UnmarshalROOT(NewRBuffer(buf, nil, 0))
 	if err != nil {
 		return err
 	}
 	b.basket.key.f = f
-	b.firstEntry = entry
+	b.firstEntry = b.basketEntry[ib]
 
 	if len(b.basketBuf) < int(b.basket.key.objlen) {
 		b.basketBuf = make([]byte, b.basket.key.objlen)
@@ -313,7 +319,11 @@ func (b *tbranch) findBasketIndex(entry int64) int {
 			return i
 	*/
 
-	for i := b.readbasket; i < len(b.basketEntry); i++ {
+	beg := b.readbasket
+	if entry < b.firstbasket {
+		beg = 0
+	}
+	for i := beg; i < len(b.basketEntry); i++ {
 		v := b.basketEntry[i]
 		if v > entry && v > 0 {
 			return i - 1
@@ -425,6 +425,60 @@ func TestTreeScannerVars(t *testing.T) {
 	}
 }
 
+func TestTreeScannerSeekEntry(t *testing.T) {
+	f, err := Open("testdata/small-flat-tree.root")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer f.Close()
+
+	obj, err := f.Get("tree")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree := obj.(Tree)
+
+	sc, err := NewTreeScannerVars(tree, ScanVar{Name: "Int32"}, ScanVar{Name: "SliceInt64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+
+	for _, i := range []int64{42, 43, 99, 10, 0, 57} {
+		err = sc.SeekEntry(i)
+		if err != nil {
+			t.Fatalf("seek %d: %v", i, err)
+		}
+		if !sc.Next() {
+			t.Fatalf("seek %d: no entry: %v", i, sc.Err())
+		}
+		var (
+			i32 int32
+			sli []int64
+		)
+		err = sc.Scan(&i32, &sli)
+		if err != nil {
+			t.Fatalf("seek %d: %v", i, err)
+		}
+		if got := sc.Entry(); got != i {
+			t.Fatalf("seek %d: got entry %d", i, got)
+		}
+		if int64(i32) != i {
+			t.Errorf("seek %d: got Int32=%d", i, i32)
+		}
+		if len(sli) != int(i%10) {
+			t.Errorf("seek %d: got len(SliceInt64)=%d. want %d", i, len(sli), i%10)
+		}
+		for _, v := range sli {
+			if v != i {
+				t.Errorf("seek %d: got SliceInt64=%v", i, sli)
+				break
+			}
+		}
+	}
+}
+
 func TestScannerVarsMultipleTimes(t *testing.T) {
 	f, err := Open("testdata/mc_105986.ZZ.root")
 	if err != nil {